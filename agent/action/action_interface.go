@@ -2,10 +2,25 @@ package action
 
 type ProtocolVersion int
 
+// ConcurrencyGroup names state shared by actions that must not be
+// modified by more than one asynchronous task at a time.
+// Tasks of actions in the same group are queued and run in order.
+// Asynchronous actions that do not declare any group are put in
+// ConcurrencyGroupDefault and therefore still run one at a time.
+type ConcurrencyGroup string
+
+const (
+	ConcurrencyGroupJobs     ConcurrencyGroup = "jobs"
+	ConcurrencyGroupDisks    ConcurrencyGroup = "disks"
+	ConcurrencyGroupPackages ConcurrencyGroup = "packages"
+	ConcurrencyGroupDefault  ConcurrencyGroup = "default"
+)
+
 type Action interface {
 	IsAsynchronous(ProtocolVersion) bool
 	IsPersistent() bool
 	IsLoggable() bool
+	ConcurrencyGroups() []ConcurrencyGroup
 
 	// Action should implement Run
 	// Arguments should be the list of arguments the payload will include
@@ -18,6 +33,9 @@ type Action interface {
 	// Run(...) (interface{}, error)
 	//
 	// See Runner for more details
+	//
+	// Persistent actions whose Run accepts a boshtask.Checkpoint are resumed
+	// after agent restart by running them again, otherwise Resume is called.
 
 	Resume() (interface{}, error)
 	Cancel() error
@@ -44,6 +44,10 @@ func (a AddPersistentDiskAction) IsLoggable() bool {
 	return true
 }
 
+func (a AddPersistentDiskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupDisks}
+}
+
 func (a AddPersistentDiskAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
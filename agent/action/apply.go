@@ -7,6 +7,7 @@ import (
 
 	boshappl "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
@@ -47,33 +48,65 @@ func (a ApplyAction) IsAsynchronous(_ ProtocolVersion) bool {
 }
 
 func (a ApplyAction) IsPersistent() bool {
-	return false
+	return true
 }
 
 func (a ApplyAction) IsLoggable() bool {
 	return true
 }
 
-func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
+// Apply installs packages into the same bundle collection
+// that compilation cleans up
+func (a ApplyAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs, ConcurrencyGroupPackages}
+}
+
+const (
+	applyStepResolveNetworks = "resolve_dynamic_networks"
+	applyStepApply           = "apply_jobs_and_packages"
+)
+
+func (a ApplyAction) Run(progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint, desiredSpec boshas.V1ApplySpec) (string, error) {
 	settings := a.settingsService.GetSettings()
 
-	resolvedDesiredSpec, err := a.specService.PopulateDHCPNetworks(desiredSpec, settings)
+	progress(boshtask.Progress{Stage: "Resolving dynamic networks"})
+
+	// Resumed apply must use networks resolved before agent restart
+	// since the same spec might already be partially applied
+	var resolvedDesiredSpec boshas.V1ApplySpec
+
+	err := boshtask.RunStep(checkpoint, applyStepResolveNetworks, &resolvedDesiredSpec, func() error {
+		var err error
+		resolvedDesiredSpec, err = a.specService.PopulateDHCPNetworks(desiredSpec, settings)
+		return err
+	})
 	if err != nil {
 		return "", bosherr.WrapError(err, "Resolving dynamic networks")
 	}
 
 	if desiredSpec.ConfigurationHash != "" {
-		currentSpec, err := a.specService.Get()
-		if err != nil {
-			return "", bosherr.WrapError(err, "Getting current spec")
-		}
+		progress(boshtask.Progress{Stage: "Applying jobs and packages"})
 
-		err = a.applier.Apply(currentSpec, resolvedDesiredSpec)
+		err = boshtask.RunStep(checkpoint, applyStepApply, nil, func() error {
+			currentSpec, err := a.specService.Get()
+			if err != nil {
+				return bosherr.WrapError(err, "Getting current spec")
+			}
+
+			err = a.applier.Apply(currentSpec, resolvedDesiredSpec)
+			if err != nil {
+				return bosherr.WrapError(err, "Applying")
+			}
+
+			return nil
+		})
 		if err != nil {
-			return "", bosherr.WrapError(err, "Applying")
+			return "", err
 		}
 	}
 
+	progress(boshtask.Progress{Stage: "Persisting apply spec"})
+
 	err = a.specService.Set(resolvedDesiredSpec)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Persisting apply spec")
@@ -133,5 +166,5 @@ func (a ApplyAction) Resume() (interface{}, error) {
 }
 
 func (a ApplyAction) Cancel() error {
-	return errors.New("not supported")
+	return a.applier.Cancel()
 }
@@ -11,6 +11,8 @@ import (
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeappl "github.com/cloudfoundry/bosh-agent/agent/applier/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
 	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
@@ -38,11 +40,27 @@ var _ = Describe("ApplyAction", func() {
 	})
 
 	AssertActionIsAsynchronous(action)
-	AssertActionIsNotPersistent(action)
+	AssertActionIsPersistent(action)
 	AssertActionIsLoggable(action)
-	AssertActionIsNotCancelable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs, ConcurrencyGroupPackages)
 	AssertActionIsNotResumable(action)
 
+	Describe("Cancel", func() {
+		It("cancels apply in progress", func() {
+			err := action.Cancel()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applier.Canceled).To(BeTrue())
+		})
+
+		It("returns error if applier fails to cancel", func() {
+			applier.CancelErr = errors.New("fake-cancel-err")
+
+			err := action.Cancel()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-cancel-err"))
+		})
+	})
+
 	Describe("Run", func() {
 		settings := boshsettings.Settings{AgentID: "fake-agent-id"}
 
@@ -50,6 +68,90 @@ var _ = Describe("ApplyAction", func() {
 			settingsService.Settings = settings
 		})
 
+		It("reports progress of each apply stage", func() {
+			specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+
+			var stages []string
+			progress := func(p boshtask.Progress) { stages = append(stages, p.Stage) }
+
+			_, err := action.Run(progress, boshtask.NoopCheckpoint, boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stages).To(Equal([]string{
+				"Resolving dynamic networks",
+				"Applying jobs and packages",
+				"Persisting apply spec",
+			}))
+		})
+
+		Context("when resumed after agent restart", func() {
+			var (
+				taskManager *faketask.FakeManager
+				checkpoint  boshtask.Checkpoint
+			)
+
+			BeforeEach(func() {
+				taskManager = faketask.NewFakeManager()
+				err := taskManager.AddInfo(boshtask.Info{TaskID: "fake-task-id"})
+				Expect(err).ToNot(HaveOccurred())
+
+				checkpoint = boshtask.NewCheckpoint(taskManager, "fake-task-id", nil)
+
+				specService.Spec = boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
+				specService.PopulateDHCPNetworksResultSpec = boshas.V1ApplySpec{ConfigurationHash: "fake-populated-config-hash"}
+			})
+
+			It("records completed steps", func() {
+				_, err := action.Run(boshtask.NoopProgressFunc, checkpoint, boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"})
+				Expect(err).ToNot(HaveOccurred())
+
+				taskInfos, err := taskManager.GetInfos()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(taskInfos[0].Checkpoints).To(HaveKey("resolve_dynamic_networks"))
+				Expect(taskInfos[0].Checkpoints).To(HaveKey("apply_jobs_and_packages"))
+			})
+
+			It("uses previously resolved networks and does not apply jobs and packages again", func() {
+				applier.ApplyError = errors.New("fake-apply-error")
+
+				_, err := action.Run(boshtask.NoopProgressFunc, checkpoint, boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"})
+				Expect(err).To(HaveOccurred())
+
+				taskInfos, err := taskManager.GetInfos()
+				Expect(err).ToNot(HaveOccurred())
+
+				specService.PopulateDHCPNetworksResultSpec = boshas.V1ApplySpec{ConfigurationHash: "fake-other-populated-config-hash"}
+				applier.ApplyError = nil
+
+				resumedCheckpoint := boshtask.NewCheckpoint(taskManager, "fake-task-id", taskInfos[0].Checkpoints)
+
+				value, err := action.Run(boshtask.NoopProgressFunc, resumedCheckpoint, boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal("applied"))
+
+				Expect(applier.ApplyDesiredApplySpec).To(Equal(boshas.V1ApplySpec{ConfigurationHash: "fake-populated-config-hash"}))
+				Expect(specService.Spec).To(Equal(boshas.V1ApplySpec{ConfigurationHash: "fake-populated-config-hash"}))
+
+				applier.Applied = false
+
+				taskInfos, err = taskManager.GetInfos()
+				Expect(err).ToNot(HaveOccurred())
+				resumedCheckpoint = boshtask.NewCheckpoint(taskManager, "fake-task-id", taskInfos[0].Checkpoints)
+
+				_, err = action.Run(boshtask.NoopProgressFunc, resumedCheckpoint, boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(applier.Applied).To(BeFalse())
+			})
+
+			It("returns error if step cannot be recorded", func() {
+				taskManager.AddCheckpointErr = errors.New("fake-add-checkpoint-err")
+
+				_, err := action.Run(boshtask.NoopProgressFunc, checkpoint, boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-add-checkpoint-err"))
+				Expect(applier.Applied).To(BeFalse())
+			})
+		})
+
 		Context("when desired spec has configuration hash", func() {
 			currentApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
 			desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}
@@ -63,7 +165,7 @@ var _ = Describe("ApplyAction", func() {
 				})
 
 				It("populates dynamic networks in desired spec", func() {
-					_, err := action.Run(desiredApplySpec)
+					_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(specService.PopulateDHCPNetworksSpec).To(Equal(desiredApplySpec))
 					Expect(specService.PopulateDHCPNetworksSettings).To(Equal(settings))
@@ -75,7 +177,7 @@ var _ = Describe("ApplyAction", func() {
 					})
 
 					It("runs applier with populated desired spec", func() {
-						_, err := action.Run(desiredApplySpec)
+						_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(applier.Applied).To(BeTrue())
 						Expect(applier.ApplyCurrentApplySpec).To(Equal(currentApplySpec))
@@ -85,7 +187,7 @@ var _ = Describe("ApplyAction", func() {
 					Context("when applier succeeds applying desired spec", func() {
 						Context("when saving desires spec as current spec succeeds", func() {
 							It("returns 'applied' after setting populated desired spec as current spec", func() {
-								value, err := action.Run(desiredApplySpec)
+								value, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 								Expect(err).ToNot(HaveOccurred())
 								Expect(value).To(Equal("applied"))
 
@@ -100,7 +202,7 @@ var _ = Describe("ApplyAction", func() {
 								})
 
 								It("returns 'applied' and writes the id, instance name, deployment name, and az to files in the instance directory", func() {
-									value, err := action.Run(desiredApplySpec)
+									value, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 									Expect(err).ToNot(HaveOccurred())
 									Expect(value).To(Equal("applied"))
 
@@ -129,7 +231,7 @@ var _ = Describe("ApplyAction", func() {
 							It("returns error because agent was not able to remember that is converged to desired spec", func() {
 								specService.SetErr = errors.New("fake-set-error")
 
-								_, err := action.Run(desiredApplySpec)
+								_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 								Expect(err).To(HaveOccurred())
 								Expect(err.Error()).To(ContainSubstring("fake-set-error"))
 							})
@@ -142,13 +244,13 @@ var _ = Describe("ApplyAction", func() {
 						})
 
 						It("returns error", func() {
-							_, err := action.Run(desiredApplySpec)
+							_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 							Expect(err).To(HaveOccurred())
 							Expect(err.Error()).To(ContainSubstring("fake-apply-error"))
 						})
 
 						It("does not save desired spec as current spec", func() {
-							_, err := action.Run(desiredApplySpec)
+							_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 							Expect(err).To(HaveOccurred())
 							Expect(specService.Spec).To(Equal(currentApplySpec))
 						})
@@ -161,19 +263,19 @@ var _ = Describe("ApplyAction", func() {
 					})
 
 					It("returns error", func() {
-						_, err := action.Run(desiredApplySpec)
+						_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("fake-populate-dynamic-networks-err"))
 					})
 
 					It("does not apply desired spec as current spec", func() {
-						_, err := action.Run(desiredApplySpec)
+						_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).To(HaveOccurred())
 						Expect(applier.Applied).To(BeFalse())
 					})
 
 					It("does not save desired spec as current spec", func() {
-						_, err := action.Run(desiredApplySpec)
+						_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).To(HaveOccurred())
 						Expect(specService.Spec).To(Equal(currentApplySpec))
 					})
@@ -187,19 +289,19 @@ var _ = Describe("ApplyAction", func() {
 				})
 
 				It("returns error and does not apply desired spec", func() {
-					_, err := action.Run(desiredApplySpec)
+					_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-get-error"))
 				})
 
 				It("does not run applier with desired spec", func() {
-					_, err := action.Run(desiredApplySpec)
+					_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 					Expect(err).To(HaveOccurred())
 					Expect(applier.Applied).To(BeFalse())
 				})
 
 				It("does not save desired spec as current spec", func() {
-					_, err := action.Run(desiredApplySpec)
+					_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 					Expect(err).To(HaveOccurred())
 					Expect(specService.Spec).To(Equal(currentApplySpec))
 				})
@@ -220,7 +322,7 @@ var _ = Describe("ApplyAction", func() {
 			}
 
 			It("populates dynamic networks in desired spec", func() {
-				_, err := action.Run(desiredApplySpec)
+				_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(specService.PopulateDHCPNetworksSpec).To(Equal(desiredApplySpec))
 				Expect(specService.PopulateDHCPNetworksSettings).To(Equal(settings))
@@ -233,7 +335,7 @@ var _ = Describe("ApplyAction", func() {
 
 				Context("when saving desires spec as current spec succeeds", func() {
 					It("returns 'applied' after setting desired spec as current spec", func() {
-						value, err := action.Run(desiredApplySpec)
+						value, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(value).To(Equal("applied"))
 
@@ -241,7 +343,7 @@ var _ = Describe("ApplyAction", func() {
 					})
 
 					It("does not try to apply desired spec since it does not have jobs and packages", func() {
-						_, err := action.Run(desiredApplySpec)
+						_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(applier.Applied).To(BeFalse())
 					})
@@ -253,13 +355,13 @@ var _ = Describe("ApplyAction", func() {
 					})
 
 					It("returns error because agent was not able to remember that is converged to desired spec", func() {
-						_, err := action.Run(desiredApplySpec)
+						_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(ContainSubstring("fake-set-error"))
 					})
 
 					It("does not try to apply desired spec since it does not have jobs and packages", func() {
-						_, err := action.Run(desiredApplySpec)
+						_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 						Expect(err).To(HaveOccurred())
 						Expect(applier.Applied).To(BeFalse())
 					})
@@ -272,19 +374,19 @@ var _ = Describe("ApplyAction", func() {
 				})
 
 				It("returns error", func() {
-					_, err := action.Run(desiredApplySpec)
+					_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-populate-dynamic-networks-err"))
 				})
 
 				It("does not apply desired spec as current spec", func() {
-					_, err := action.Run(desiredApplySpec)
+					_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 					Expect(err).To(HaveOccurred())
 					Expect(applier.Applied).To(BeFalse())
 				})
 
 				It("does not save desired spec as current spec", func() {
-					_, err := action.Run(desiredApplySpec)
+					_, err := action.Run(boshtask.NoopProgressFunc, boshtask.NoopCheckpoint, desiredApplySpec)
 					Expect(err).To(HaveOccurred())
 					Expect(specService.Spec).ToNot(Equal(desiredApplySpec))
 				})
@@ -0,0 +1,230 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const batchActionLogTag = "BatchAction"
+
+var errBatchCanceled = bosherr.Error("Batch was cancelled by user request")
+
+type BatchStepState string
+
+const (
+	BatchStepStateDone    BatchStepState = "done"
+	BatchStepStateFailed  BatchStepState = "failed"
+	BatchStepStateSkipped BatchStepState = "skipped"
+)
+
+type BatchStep struct {
+	Method    string          `json:"method"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type BatchOptions struct {
+	// Skip remaining steps after a step fails
+	StopOnFailure bool `json:"stop_on_failure"`
+}
+
+type BatchStepResult struct {
+	Method string         `json:"method"`
+	State  BatchStepState `json:"state"`
+	Value  interface{}    `json:"value,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+type runningBatchStep struct {
+	action Action
+	lock   sync.Mutex
+}
+
+type BatchAction struct {
+	actionFactory Factory
+	actionRunner  Runner
+	logger        boshlog.Logger
+
+	cancel      *boshtask.RunCancel
+	runningStep *runningBatchStep
+}
+
+func NewBatch(actionFactory Factory, actionRunner Runner, logger boshlog.Logger) (action BatchAction) {
+	action.actionFactory = actionFactory
+	action.actionRunner = actionRunner
+	action.logger = logger
+
+	action.cancel = &boshtask.RunCancel{}
+	action.runningStep = &runningBatchStep{}
+	return
+}
+
+func (a BatchAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a BatchAction) IsPersistent() bool {
+	return false
+}
+
+// Steps with arguments that must not be logged are rejected by Run
+func (a BatchAction) IsLoggable() bool {
+	return true
+}
+
+// Steps of a batch are not known until it runs
+// so it excludes every action that could be one of them
+func (a BatchAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{
+		ConcurrencyGroupJobs,
+		ConcurrencyGroupDisks,
+		ConcurrencyGroupPackages,
+		ConcurrencyGroupDefault,
+	}
+}
+
+func (a BatchAction) Run(
+	protocolVersion ProtocolVersion,
+	progress boshtask.ProgressFunc,
+	steps []BatchStep,
+	options BatchOptions,
+) ([]BatchStepResult, error) {
+	cancelCh := a.cancel.Start()
+	defer a.cancel.Finish(cancelCh)
+
+	actions, err := a.createActions(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchStepResult, len(steps))
+	failed := false
+
+	for i, step := range steps {
+		if boshtask.IsCanceled(cancelCh) {
+			return nil, errBatchCanceled
+		}
+
+		results[i].Method = step.Method
+
+		if failed && options.StopOnFailure {
+			results[i].State = BatchStepStateSkipped
+			continue
+		}
+
+		progress(boshtask.Progress{
+			Stage:   fmt.Sprintf("Running %s", step.Method),
+			Current: int64(i),
+			Total:   int64(len(steps)),
+		})
+
+		value, err := a.runStep(actions[i], step, protocolVersion, progress)
+		if err != nil {
+			a.logger.Error(batchActionLogTag, "Running step %d (%s) failed: %s", i+1, step.Method, err.Error())
+			results[i].State = BatchStepStateFailed
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+
+		results[i].State = BatchStepStateDone
+		results[i].Value = value
+	}
+
+	if boshtask.IsCanceled(cancelCh) {
+		return nil, errBatchCanceled
+	}
+
+	return results, nil
+}
+
+func (a BatchAction) createActions(steps []BatchStep) ([]Action, error) {
+	if len(steps) == 0 {
+		return nil, bosherr.Error("Batch must include at least one step")
+	}
+
+	var actions []Action
+
+	// Validate all steps before running any of them
+	for i, step := range steps {
+		if step.Method == "batch" {
+			return nil, bosherr.Errorf("Step %d: Batches cannot be nested", i+1)
+		}
+
+		action, err := a.actionFactory.Create(step.Method)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Step %d", i+1)
+		}
+
+		if !action.IsLoggable() {
+			return nil, bosherr.Errorf("Step %d: Action %s cannot be run in a batch", i+1, step.Method)
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+func (a BatchAction) runStep(
+	action Action,
+	step BatchStep,
+	protocolVersion ProtocolVersion,
+	progress boshtask.ProgressFunc,
+) (interface{}, error) {
+	arguments := step.Arguments
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("[]")
+	}
+
+	payload, err := json.Marshal(map[string]json.RawMessage{"arguments": arguments})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling step payload")
+	}
+
+	a.runningStep.lock.Lock()
+	a.runningStep.action = action
+	a.runningStep.lock.Unlock()
+
+	defer func() {
+		a.runningStep.lock.Lock()
+		a.runningStep.action = nil
+		a.runningStep.lock.Unlock()
+	}()
+
+	stepProgress := func(stepProgress boshtask.Progress) {
+		stepProgress.Stage = fmt.Sprintf("%s: %s", step.Method, stepProgress.Stage)
+		progress(stepProgress)
+	}
+
+	// Steps run one after another within the batch task
+	// regardless of whether their actions are asynchronous
+	return a.actionRunner.Run(action, payload, protocolVersion, stepProgress, nil)
+}
+
+func (a BatchAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a BatchAction) Cancel() error {
+	// Cancelling when no batch is running is a no-op
+	a.cancel.Cancel()
+
+	a.runningStep.lock.Lock()
+	defer a.runningStep.lock.Unlock()
+
+	if a.runningStep.action != nil {
+		err := a.runningStep.action.Cancel()
+		if err != nil {
+			// Remaining steps are still skipped once running step finishes
+			a.logger.Warn(batchActionLogTag, "Running step cannot be cancelled: %s", err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	fakeaction "github.com/cloudfoundry/bosh-agent/agent/action/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("BatchAction", func() {
+	var (
+		actionFactory *fakeaction.FakeFactory
+		actionRunner  *fakeaction.FakeRunner
+		stopAction    *fakeaction.TestAction
+		drainAction   *fakeaction.TestAction
+		runPayloads   []string
+		action        BatchAction
+	)
+
+	BeforeEach(func() {
+		actionFactory = fakeaction.NewFakeFactory()
+		actionRunner = &fakeaction.FakeRunner{}
+
+		stopAction = &fakeaction.TestAction{Loggable: true}
+		drainAction = &fakeaction.TestAction{Loggable: true}
+		actionFactory.RegisterAction("stop", stopAction)
+		actionFactory.RegisterAction("drain", drainAction)
+
+		runPayloads = nil
+		actionRunner.RunStub = func(action Action, payload []byte) (interface{}, error) {
+			runPayloads = append(runPayloads, string(payload))
+			if action == stopAction {
+				return "stopped", nil
+			}
+			return 0, nil
+		}
+
+		action = NewBatch(actionFactory, actionRunner, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+
+	It("is in every concurrency group since its steps are not known in advance", func() {
+		Expect(action.ConcurrencyGroups()).To(ConsistOf(
+			ConcurrencyGroupJobs,
+			ConcurrencyGroupDisks,
+			ConcurrencyGroupPackages,
+			ConcurrencyGroupDefault,
+		))
+	})
+
+	Describe("Run", func() {
+		steps := []BatchStep{
+			{Method: "stop"},
+			{Method: "drain", Arguments: []byte(`["shutdown",{}]`)},
+		}
+
+		It("runs each step in order with its arguments and returns their results", func() {
+			results, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, steps, BatchOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runPayloads).To(Equal([]string{
+				`{"arguments":[]}`,
+				`{"arguments":["shutdown",{}]}`,
+			}))
+			Expect(actionRunner.RunProtocolVersion).To(Equal(ProtocolVersion(4)))
+
+			Expect(results).To(Equal([]BatchStepResult{
+				{Method: "stop", State: BatchStepStateDone, Value: "stopped"},
+				{Method: "drain", State: BatchStepStateDone, Value: 0},
+			}))
+		})
+
+		It("reports progress of each step", func() {
+			var reported []boshtask.Progress
+			progress := func(p boshtask.Progress) { reported = append(reported, p) }
+
+			_, err := action.Run(ProtocolVersion(4), progress, steps, BatchOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(reported).To(Equal([]boshtask.Progress{
+				{Stage: "Running stop", Current: 0, Total: 2},
+				{Stage: "Running drain", Current: 1, Total: 2},
+			}))
+		})
+
+		It("prefixes progress reported by step with its method", func() {
+			var reported []boshtask.Progress
+			progress := func(p boshtask.Progress) { reported = append(reported, p) }
+
+			actionRunner.RunStub = func(_ Action, _ []byte) (interface{}, error) {
+				actionRunner.RunProgress(boshtask.Progress{Stage: "Waiting"})
+				return nil, nil
+			}
+
+			_, err := action.Run(ProtocolVersion(4), progress, steps[:1], BatchOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reported).To(ContainElement(boshtask.Progress{Stage: "stop: Waiting"}))
+		})
+
+		Context("when step fails", func() {
+			BeforeEach(func() {
+				actionRunner.RunStub = func(action Action, payload []byte) (interface{}, error) {
+					runPayloads = append(runPayloads, string(payload))
+					if action == stopAction {
+						return nil, errors.New("fake-stop-error")
+					}
+					return 0, nil
+				}
+			})
+
+			It("runs remaining steps and reports the failure", func() {
+				results, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, steps, BatchOptions{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(results).To(Equal([]BatchStepResult{
+					{Method: "stop", State: BatchStepStateFailed, Error: "fake-stop-error"},
+					{Method: "drain", State: BatchStepStateDone, Value: 0},
+				}))
+			})
+
+			It("skips remaining steps when asked to stop on failure", func() {
+				results, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, steps, BatchOptions{StopOnFailure: true})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(runPayloads).To(HaveLen(1))
+				Expect(results).To(Equal([]BatchStepResult{
+					{Method: "stop", State: BatchStepStateFailed, Error: "fake-stop-error"},
+					{Method: "drain", State: BatchStepStateSkipped},
+				}))
+			})
+		})
+
+		It("returns error without running any step when batch is empty", func() {
+			_, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, []BatchStep{}, BatchOptions{})
+			Expect(err).To(MatchError("Batch must include at least one step"))
+			Expect(runPayloads).To(BeEmpty())
+		})
+
+		It("returns error without running any step when a step has unknown method", func() {
+			_, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, append(steps, BatchStep{Method: "fake-unknown"}), BatchOptions{})
+			Expect(err).To(MatchError("Step 3: Action not found"))
+			Expect(runPayloads).To(BeEmpty())
+		})
+
+		It("returns error when batches are nested", func() {
+			_, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, []BatchStep{{Method: "batch"}}, BatchOptions{})
+			Expect(err).To(MatchError("Step 1: Batches cannot be nested"))
+		})
+
+		It("returns error when step action is not loggable since batch payload is logged", func() {
+			actionFactory.RegisterAction("upload_blob", &fakeaction.TestAction{Loggable: false})
+
+			_, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, []BatchStep{{Method: "upload_blob"}}, BatchOptions{})
+			Expect(err).To(MatchError("Step 1: Action upload_blob cannot be run in a batch"))
+		})
+	})
+
+	Describe("Cancel", func() {
+		It("cancels running step and skips remaining steps", func() {
+			steps := []BatchStep{{Method: "stop"}, {Method: "drain"}}
+
+			actionRunner.RunStub = func(_ Action, _ []byte) (interface{}, error) {
+				Expect(action.Cancel()).To(Succeed())
+				return nil, errors.New("fake-canceled-error")
+			}
+
+			_, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, steps, BatchOptions{})
+			Expect(err).To(MatchError("Batch was cancelled by user request"))
+
+			Expect(stopAction.Canceled).To(BeTrue())
+			Expect(drainAction.Canceled).To(BeFalse())
+		})
+
+		It("succeeds even if running step cannot be cancelled", func() {
+			stopAction.CancelErr = errors.New("not supported")
+
+			actionRunner.RunStub = func(_ Action, _ []byte) (interface{}, error) {
+				Expect(action.Cancel()).To(Succeed())
+				return "stopped", nil
+			}
+
+			_, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, []BatchStep{{Method: "stop"}}, BatchOptions{})
+			Expect(err).To(MatchError("Batch was cancelled by user request"))
+		})
+
+		It("does not affect subsequent batches", func() {
+			actionRunner.RunStub = func(_ Action, _ []byte) (interface{}, error) {
+				Expect(action.Cancel()).To(Succeed())
+				return "stopped", nil
+			}
+
+			_, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, []BatchStep{{Method: "stop"}}, BatchOptions{})
+			Expect(err).To(MatchError("Batch was cancelled by user request"))
+
+			actionRunner.RunStub = nil
+
+			_, err = action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, []BatchStep{{Method: "stop"}}, BatchOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("ignores cancel requests made while no batch is running", func() {
+			Expect(action.Cancel()).To(Succeed())
+
+			results, err := action.Run(ProtocolVersion(4), boshtask.NoopProgressFunc, []BatchStep{{Method: "stop"}}, BatchOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].State).To(Equal(BatchStepStateDone))
+		})
+	})
+})
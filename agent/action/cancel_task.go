@@ -28,6 +28,10 @@ func (a CancelTaskAction) IsLoggable() bool {
 	return true
 }
 
+func (a CancelTaskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a CancelTaskAction) Run(taskID string) (string, error) {
 	task, found := a.taskService.FindTaskWithID(taskID)
 	if !found {
@@ -5,6 +5,7 @@ import (
 
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
@@ -23,14 +24,27 @@ func (a CompilePackageAction) IsAsynchronous(_ ProtocolVersion) bool {
 }
 
 func (a CompilePackageAction) IsPersistent() bool {
-	return false
+	return true
 }
 
 func (a CompilePackageAction) IsLoggable() bool {
 	return true
 }
 
-func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) (val map[string]interface{}, err error) {
+// Compilation installs dependencies into and then empties
+// the bundle collection of packages shared with apply
+func (a CompilePackageAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupPackages}
+}
+
+const compilePackageStepCompile = "compile"
+
+type compiledPackage struct {
+	BlobID string `json:"blobstore_id"`
+	Digest string `json:"sha1"`
+}
+
+func (a CompilePackageAction) Run(progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint, blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) (val map[string]interface{}, err error) {
 	pkg := boshcomp.Package{
 		BlobstoreID: blobID,
 		Name:        name,
@@ -51,15 +65,27 @@ func (a CompilePackageAction) Run(blobID string, multiDigest boshcrypto.Multiple
 		})
 	}
 
-	uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
+	// Compiled package that was already uploaded before agent
+	// restart is returned instead of compiling package again
+	var compiled compiledPackage
+
+	err = boshtask.RunStep(checkpoint, compilePackageStepCompile, &compiled, func() error {
+		uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps, progress)
+		if err != nil {
+			return err
+		}
+
+		compiled = compiledPackage{BlobID: uploadedBlobID, Digest: uploadedDigest.String()}
+		return nil
+	})
 	if err != nil {
 		err = bosherr.WrapErrorf(err, "Compiling package %s", pkg.Name)
 		return
 	}
 
 	result := map[string]string{
-		"blobstore_id": uploadedBlobID,
-		"sha1":         uploadedDigest.String(),
+		"blobstore_id": compiled.BlobID,
+		"sha1":         compiled.Digest,
 	}
 
 	val = map[string]interface{}{
@@ -73,5 +99,5 @@ func (a CompilePackageAction) Resume() (interface{}, error) {
 }
 
 func (a CompilePackageAction) Cancel() error {
-	return errors.New("not supported")
+	return a.compiler.Cancel()
 }
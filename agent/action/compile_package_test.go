@@ -11,10 +11,14 @@ import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	fakecomp "github.com/cloudfoundry/bosh-agent/agent/compiler/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 )
 
-func getCompileActionArguments() (blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) {
+func getCompileActionArguments() (progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint, blobID string, multiDigest boshcrypto.MultipleDigest, name, version string, deps boshcomp.Dependencies) {
+	progress = boshtask.NoopProgressFunc
+	checkpoint = boshtask.NoopCheckpoint
 	blobID = "fake-blobstore-id"
 	multiDigest = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
 	name = "fake-package-name"
@@ -48,12 +52,28 @@ var _ = Describe("CompilePackageAction", func() {
 	})
 
 	AssertActionIsAsynchronous(action)
-	AssertActionIsNotPersistent(action)
+	AssertActionIsPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupPackages)
 
-	AssertActionIsNotCancelable(action)
 	AssertActionIsNotResumable(action)
 
+	Describe("Cancel", func() {
+		It("cancels compilation in progress", func() {
+			err := action.Cancel()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(compiler.Canceled).To(BeTrue())
+		})
+
+		It("returns error if compiler fails to cancel", func() {
+			compiler.CancelErr = errors.New("fake-cancel-err")
+
+			err := action.Cancel()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-cancel-err"))
+		})
+	})
+
 	Describe("Run", func() {
 		It("can unmarshal deps arguments", func() {
 			depsJSON := `{"foo": {
@@ -121,6 +141,20 @@ var _ = Describe("CompilePackageAction", func() {
 			Expect(compiler.CompileDeps).To(ConsistOf(expectedDeps))
 		})
 
+		It("reports compilation progress", func() {
+			compiler.CompileDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some checksum")
+			compiler.CompileProgress = []boshtask.Progress{{Stage: "fake-stage", Current: 1, Total: 2}}
+
+			var reported []boshtask.Progress
+			progress := func(p boshtask.Progress) { reported = append(reported, p) }
+
+			_, checkpoint, blobID, multiDigest, name, version, deps := getCompileActionArguments()
+
+			_, err := action.Run(progress, checkpoint, blobID, multiDigest, name, version, deps)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reported).To(Equal([]boshtask.Progress{{Stage: "fake-stage", Current: 1, Total: 2}}))
+		})
+
 		It("returns error when compile fails", func() {
 			compiler.CompileErr = errors.New("fake-compile-error")
 
@@ -128,5 +162,65 @@ var _ = Describe("CompilePackageAction", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
 		})
+
+		Context("when resumed after agent restart", func() {
+			var (
+				taskManager *faketask.FakeManager
+				checkpoint  boshtask.Checkpoint
+			)
+
+			BeforeEach(func() {
+				taskManager = faketask.NewFakeManager()
+				err := taskManager.AddInfo(boshtask.Info{TaskID: "fake-task-id"})
+				Expect(err).ToNot(HaveOccurred())
+
+				checkpoint = boshtask.NewCheckpoint(taskManager, "fake-task-id", nil)
+
+				compiler.CompileBlobID = "my-blob-id"
+				compiler.CompileDigest = boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "some checksum")
+			})
+
+			It("returns previously uploaded compiled package without compiling again", func() {
+				progress, _, blobID, multiDigest, name, version, deps := getCompileActionArguments()
+
+				_, err := action.Run(progress, checkpoint, blobID, multiDigest, name, version, deps)
+				Expect(err).ToNot(HaveOccurred())
+
+				taskInfos, err := taskManager.GetInfos()
+				Expect(err).ToNot(HaveOccurred())
+
+				compiler.CompileBlobID = "fake-other-blob-id"
+				compiler.CompilePkg = boshcomp.Package{}
+
+				resumedCheckpoint := boshtask.NewCheckpoint(taskManager, "fake-task-id", taskInfos[0].Checkpoints)
+
+				value, err := action.Run(progress, resumedCheckpoint, blobID, multiDigest, name, version, deps)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal(map[string]interface{}{
+					"result": map[string]string{
+						"blobstore_id": "my-blob-id",
+						"sha1":         "some checksum",
+					},
+				}))
+
+				Expect(compiler.CompilePkg).To(Equal(boshcomp.Package{}))
+			})
+
+			It("compiles package again if compilation did not finish", func() {
+				compiler.CompileErr = errors.New("fake-compile-error")
+
+				progress, _, blobID, multiDigest, name, version, deps := getCompileActionArguments()
+
+				_, err := action.Run(progress, checkpoint, blobID, multiDigest, name, version, deps)
+				Expect(err).To(HaveOccurred())
+
+				compiler.CompileErr = nil
+
+				value, err := action.Run(progress, checkpoint, blobID, multiDigest, name, version, deps)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(HaveKey("result"))
+				Expect(compiler.CompilePkg.Name).To(Equal("fake-package-name"))
+			})
+		})
 	})
 })
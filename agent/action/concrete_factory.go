@@ -1,6 +1,8 @@
 package action
 
 import (
+	"code.cloudfoundry.org/clock"
+
 	boshappl "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore"
@@ -32,6 +34,7 @@ func NewFactory(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
 	jobScriptProvider boshscript.JobScriptProvider,
+	timeService clock.Clock,
 	logger boshlog.Logger,
 ) (factory Factory) {
 	compressor := platform.GetCompressor()
@@ -40,7 +43,7 @@ func NewFactory(
 	vitalsService := platform.GetVitalsService()
 	certManager := platform.GetCertManager()
 
-	factory = concreteFactory{
+	f := concreteFactory{
 		availableActions: map[string]Action{
 			// API
 			"ping": NewPing(),
@@ -49,22 +52,27 @@ func NewFactory(
 			// Task management
 			"get_task":    NewGetTask(taskService),
 			"cancel_task": NewCancelTask(taskService),
+			"list_tasks":  NewListTasks(taskService),
 
 			// VM admin
 			"ssh":             NewSSH(settingsService, platform, dirProvider, logger),
-			"fetch_logs":      NewFetchLogs(compressor, copier, blobstore, dirProvider),
+			"fetch_logs":      NewFetchLogs(compressor, copier, blobstore, platform.GetFs(), dirProvider, logger),
+			"tail_logs":       NewTailLogs(platform.GetFs(), dirProvider, timeService, logger),
 			"update_settings": NewUpdateSettings(settingsService, platform, certManager, logger),
 			"shutdown":        NewShutdown(platform),
 
 			// Job management
-			"prepare":    NewPrepare(applier),
-			"apply":      NewApply(applier, specService, settingsService, dirProvider, platform.GetFs()),
-			"start":      NewStart(jobSupervisor, applier, specService),
-			"stop":       NewStop(jobSupervisor),
-			"drain":      NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger),
-			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService),
-			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
-			"run_script": NewRunScript(jobScriptProvider, specService, logger),
+			"prepare":     NewPrepare(applier),
+			"apply":       NewApply(applier, specService, settingsService, dirProvider, platform.GetFs()),
+			"start":       NewStart(jobSupervisor, applier, specService),
+			"stop":        NewStop(jobSupervisor),
+			"start_job":   NewStartJob(jobSupervisor, specService, jobScriptProvider, timeService, logger),
+			"stop_job":    NewStopJob(jobSupervisor, specService, jobScriptProvider, timeService, logger),
+			"restart_job": NewRestartJob(jobSupervisor, specService, jobScriptProvider, timeService, logger),
+			"drain":       NewDrain(notifier, specService, jobScriptProvider, jobSupervisor, logger),
+			"get_state":   NewGetState(settingsService, specService, jobSupervisor, vitalsService),
+			"run_errand":  NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
+			"run_script":  NewRunScript(jobScriptProvider, specService, logger),
 
 			// Compilation
 			"compile_package": NewCompilePackage(compiler),
@@ -87,6 +95,11 @@ func NewFactory(
 			"sync_dns": NewSyncDNS(blobstore, settingsService, platform, logger),
 		},
 	}
+
+	// Batch runs other actions so it is given the factory itself
+	f.availableActions["batch"] = NewBatch(f, NewRunner(), logger)
+
+	factory = f
 	return
 }
 
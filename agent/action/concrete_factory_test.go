@@ -1,6 +1,10 @@
 package action_test
 
 import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -75,6 +79,7 @@ var _ = Describe("concreteFactory", func() {
 			jobSupervisor,
 			specService,
 			jobScriptProvider,
+			fakeclock.NewFakeClock(time.Now()),
 			logger,
 		)
 	})
@@ -107,7 +112,15 @@ var _ = Describe("concreteFactory", func() {
 	It("fetch_logs", func() {
 		action, err := factory.Create("fetch_logs")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewFetchLogs(platform.GetCompressor(), platform.GetCopier(), blobstore, platform.GetDirProvider())))
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(FetchLogsAction{}))
+	})
+
+	It("tail_logs", func() {
+		action, err := factory.Create("tail_logs")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(TailLogsAction{}))
 	})
 
 	It("get_task", func() {
@@ -122,6 +135,12 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewCancelTask(taskService)))
 	})
 
+	It("list_tasks", func() {
+		action, err := factory.Create("list_tasks")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewListTasks(taskService)))
+	})
+
 	It("get_state", func() {
 		action, err := factory.Create("get_state")
 		Expect(err).ToNot(HaveOccurred())
@@ -176,6 +195,24 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewStop(jobSupervisor)))
 	})
 
+	It("start_job", func() {
+		action, err := factory.Create("start_job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(BeAssignableToTypeOf(StartJobAction{}))
+	})
+
+	It("stop_job", func() {
+		action, err := factory.Create("stop_job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(BeAssignableToTypeOf(StopJobAction{}))
+	})
+
+	It("restart_job", func() {
+		action, err := factory.Create("restart_job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(BeAssignableToTypeOf(RestartJobAction{}))
+	})
+
 	It("remove_persistent_disk", func() {
 		action, err := factory.Create("remove_persistent_disk")
 		Expect(err).ToNot(HaveOccurred())
@@ -232,6 +269,13 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewSyncDNS(blobstore, settingsService, platform, logger)))
 	})
 
+	It("batch", func() {
+		action, err := factory.Create("batch")
+		Expect(err).ToNot(HaveOccurred())
+		// Cannot do equality check since channel is used in initializer
+		Expect(action).To(BeAssignableToTypeOf(BatchAction{}))
+	})
+
 	It("upload_blob", func() {
 		action, err := factory.Create("upload_blob")
 		Expect(err).ToNot(HaveOccurred())
@@ -32,6 +32,10 @@ func (a DeleteARPEntriesAction) IsLoggable() bool {
 	return true
 }
 
+func (a DeleteARPEntriesAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a DeleteARPEntriesAction) Run(args DeleteARPEntriesActionArgs) (interface{}, error) {
 	addresses := args.Ips
 	for _, address := range addresses {
@@ -62,6 +62,10 @@ func (a DrainAction) IsLoggable() bool {
 	return true
 }
 
+func (a DrainAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs}
+}
+
 func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (int, error) {
 	currentSpec, err := a.specService.Get()
 	if err != nil {
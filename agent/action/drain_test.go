@@ -53,6 +53,7 @@ var _ = Describe("DrainAction", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs)
 
 	AssertActionIsNotResumable(action)
 
@@ -48,6 +48,8 @@ type TestAction struct {
 	Persistent   bool
 	Loggable     bool
 
+	Groups []boshaction.ConcurrencyGroup
+
 	ResumeValue interface{}
 	ResumeErr   error
 	Resumed     bool
@@ -71,6 +73,10 @@ func (a *TestAction) IsLoggable() bool {
 	return a.Loggable
 }
 
+func (a *TestAction) ConcurrencyGroups() []boshaction.ConcurrencyGroup {
+	return a.Groups
+}
+
 func (a *TestAction) Run(payload []byte) (interface{}, error) {
 	return nil, nil
 }
@@ -2,30 +2,45 @@ package fakes
 
 import (
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 )
 
 type FakeRunner struct {
 	RunAction          boshaction.Action
 	RunPayload         []byte
 	RunProtocolVersion boshaction.ProtocolVersion
+	RunProgress        boshtask.ProgressFunc
+	RunCheckpoint      boshtask.Checkpoint
 	RunValue           interface{}
 	RunErr             error
+	RunStub            func(boshaction.Action, []byte) (interface{}, error)
 
-	ResumeAction  boshaction.Action
-	ResumePayload []byte
-	ResumeValue   interface{}
-	ResumeErr     error
+	ResumeAction          boshaction.Action
+	ResumePayload         []byte
+	ResumeProtocolVersion boshaction.ProtocolVersion
+	ResumeProgress        boshtask.ProgressFunc
+	ResumeCheckpoint      boshtask.Checkpoint
+	ResumeValue           interface{}
+	ResumeErr             error
 }
 
-func (runner *FakeRunner) Run(action boshaction.Action, payload []byte, version boshaction.ProtocolVersion) (interface{}, error) {
+func (runner *FakeRunner) Run(action boshaction.Action, payload []byte, version boshaction.ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint) (interface{}, error) {
 	runner.RunAction = action
 	runner.RunPayload = payload
 	runner.RunProtocolVersion = version
+	runner.RunProgress = progress
+	runner.RunCheckpoint = checkpoint
+	if runner.RunStub != nil {
+		return runner.RunStub(action, payload)
+	}
 	return runner.RunValue, runner.RunErr
 }
 
-func (runner *FakeRunner) Resume(action boshaction.Action, payload []byte) (interface{}, error) {
+func (runner *FakeRunner) Resume(action boshaction.Action, payload []byte, version boshaction.ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint) (interface{}, error) {
 	runner.ResumeAction = action
 	runner.ResumePayload = payload
+	runner.ResumeProtocolVersion = version
+	runner.ResumeProgress = progress
+	runner.ResumeCheckpoint = checkpoint
 	return runner.ResumeValue, runner.ResumeErr
 }
@@ -1,31 +1,114 @@
 package action
 
 import (
+	"bufio"
+	"encoding/json"
 	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	boshagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+var errFetchLogsCanceled = bosherr.Error("Fetching logs was cancelled by user request")
+
+const (
+	// Manifest is also included in the tarball next to the logs
+	fetchLogsManifestFileName = "fetch_logs_manifest.json"
+
+	fetchLogsSkippedCompressed     = "compressed"
+	fetchLogsSkippedModifiedBefore = "modified before window"
+	fetchLogsSkippedModifiedAfter  = "modified after window"
+	fetchLogsSkippedTotalSize      = "maximum total size reached"
+)
+
+// Entries written by platform.AuditLogger are tagged with vcap.agent in syslog
+var agentAuditEntryPattern = regexp.MustCompile(`\svcap\.agent(\[\d+\])?:`)
+
+// Extensions of rotated logs that were already compressed
+var fetchLogsCompressedExtensions = []string{".gz", ".tgz", ".bz2", ".xz", ".zst", ".zip"}
+
+// FetchLogsOptions limit which logs are fetched and how much of them.
+// Zero values do not limit anything.
+type FetchLogsOptions struct {
+	ModifiedAfter  time.Time `json:"modified_after"`
+	ModifiedBefore time.Time `json:"modified_before"`
+
+	// Only the end of larger files is kept
+	MaxFileBytes int64 `json:"max_file_bytes"`
+
+	// Most recently modified files are fetched first
+	// and remaining ones are skipped once limit is reached
+	MaxTotalBytes int64 `json:"max_total_bytes"`
+
+	ExcludeCompressed bool `json:"exclude_compressed"`
+}
+
+type FetchLogsResult struct {
+	BlobstoreID string `json:"blobstore_id"`
+	SHA1        string `json:"sha1"`
+
+	// Only set when fetch options were given
+	Manifest *FetchLogsManifest `json:"manifest,omitempty"`
+}
+
+// FetchLogsManifest describes logs that were not fetched in full
+type FetchLogsManifest struct {
+	IncludedFiles int   `json:"included_files"`
+	IncludedBytes int64 `json:"included_bytes"`
+
+	Truncated []FetchLogsManifestEntry `json:"truncated"`
+	Skipped   []FetchLogsManifestEntry `json:"skipped"`
+}
+
+type FetchLogsManifestEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+
+	IncludedBytes int64  `json:"included_bytes,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
 type FetchLogsAction struct {
 	compressor  boshcmd.Compressor
 	copier      boshcmd.Copier
 	blobstore   boshblob.DigestBlobstore
+	fs          boshsys.FileSystem
 	settingsDir boshdirs.Provider
+	logger      boshlog.Logger
+
+	cancel *boshtask.RunCancel
 }
 
 func NewFetchLogs(
 	compressor boshcmd.Compressor,
 	copier boshcmd.Copier,
 	blobstore boshblob.DigestBlobstore,
+	fs boshsys.FileSystem,
 	settingsDir boshdirs.Provider,
+	logger boshlog.Logger,
 ) (action FetchLogsAction) {
 	action.compressor = compressor
 	action.copier = copier
 	action.blobstore = blobstore
+	action.fs = fs
 	action.settingsDir = settingsDir
+	action.logger = logger
+
+	// Shared by copies of the action since its methods have value receivers
+	action.cancel = &boshtask.RunCancel{}
 	return
 }
 
@@ -41,32 +124,58 @@ func (a FetchLogsAction) IsLoggable() bool {
 	return true
 }
 
-func (a FetchLogsAction) Run(logType string, filters []string) (value map[string]string, err error) {
-	var logsDir string
+func (a FetchLogsAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
+// Run fetches logs of given type matching filters.
+// Options are optional so that older directors can keep calling it with two arguments.
+func (a FetchLogsAction) Run(progress boshtask.ProgressFunc, logType string, filters []string, options ...FetchLogsOptions) (value FetchLogsResult, err error) {
+	cancelCh := a.cancel.Start()
+	defer a.cancel.Finish(cancelCh)
 
-	switch logType {
-	case "job":
-		if len(filters) == 0 {
-			filters = []string{"**/*"}
+	sources, err := a.logSources(logType, filters)
+	if err != nil {
+		return
+	}
+
+	progress(boshtask.Progress{Stage: "Copying logs"})
+
+	var tmpDir string
+
+	if len(options) > 0 || len(sources) > 1 || sources[0].entryPattern != nil {
+		var fetchOptions FetchLogsOptions
+		if len(options) > 0 {
+			fetchOptions = options[0]
 		}
-		logsDir = a.settingsDir.LogsDir()
-	case "agent":
-		if len(filters) == 0 {
-			filters = []string{"**/*"}
+
+		var manifest FetchLogsManifest
+
+		tmpDir, manifest, err = a.selectiveCopyToTemp(sources, fetchOptions)
+		if err != nil {
+			err = bosherr.WrapError(err, "Copying selected files to temp directory")
+			return
+		}
+
+		if len(options) > 0 {
+			value.Manifest = &manifest
+		}
+	} else {
+		tmpDir, err = a.copier.FilteredCopyToTemp(sources[0].dir, sources[0].filters)
+		if err != nil {
+			err = bosherr.WrapError(err, "Copying filtered files to temp directory")
+			return
 		}
-		logsDir = a.settingsDir.AgentLogsDir()
-	default:
-		err = bosherr.Error("Invalid log type")
-		return
 	}
 
-	tmpDir, err := a.copier.FilteredCopyToTemp(logsDir, filters)
-	if err != nil {
-		err = bosherr.WrapError(err, "Copying filtered files to temp directory")
+	defer a.copier.CleanUp(tmpDir)
+
+	if boshtask.IsCanceled(cancelCh) {
+		err = errFetchLogsCanceled
 		return
 	}
 
-	defer a.copier.CleanUp(tmpDir)
+	progress(boshtask.Progress{Stage: "Compressing logs"})
 
 	tarball, err := a.compressor.CompressFilesInDir(tmpDir)
 	if err != nil {
@@ -78,20 +187,370 @@ func (a FetchLogsAction) Run(logType string, filters []string) (value map[string
 		_ = a.compressor.CleanUp(tarball)
 	}()
 
-	blobID, multidigestSha, err := a.blobstore.Create(tarball)
+	if boshtask.IsCanceled(cancelCh) {
+		err = errFetchLogsCanceled
+		return
+	}
+
+	progress(boshtask.Progress{Stage: "Uploading logs"})
+
+	blobID, multidigestSha, err := boshagentblob.CreateCancelable(a.blobstore, tarball, cancelCh, a.logger)
 	if err != nil {
 		err = bosherr.WrapError(err, "Create file on blobstore")
 		return
 	}
 
-	value = map[string]string{"blobstore_id": blobID, "sha1": multidigestSha.String()}
+	value.BlobstoreID = blobID
+	value.SHA1 = multidigestSha.String()
 	return
 }
 
+// fetchLogsSource is a directory with logs of one type.
+// Logs are placed under prefix in the tarball.
+type fetchLogsSource struct {
+	dir     string
+	filters []string
+	prefix  string
+
+	// Only lines matching pattern are fetched when it is set
+	entryPattern *regexp.Regexp
+}
+
+// logSources returns where logs of given type are found; filters default to all files
+// except for system, audit and monit logs which are mixed with other files in their directories.
+// Audit logs are the agent's own audit events (e.g. requests it denied) which it sends to syslog,
+// so they are extracted from uncompressed syslog files; auditd records are not included.
+func (a FetchLogsAction) logSources(logType string, filters []string) ([]fetchLogsSource, error) {
+	withDefault := func(defaultFilters ...string) []string {
+		if len(filters) == 0 {
+			return defaultFilters
+		}
+		return filters
+	}
+
+	switch logType {
+	case "job":
+		return []fetchLogsSource{{dir: a.settingsDir.LogsDir(), filters: withDefault("**/*")}}, nil
+	case "agent":
+		return []fetchLogsSource{{dir: a.settingsDir.AgentLogsDir(), filters: withDefault("**/*")}}, nil
+	case "system":
+		return []fetchLogsSource{{dir: a.settingsDir.SystemLogsDir(), filters: withDefault("syslog*", "auth.log*", "kern.log*")}}, nil
+	case "audit":
+		return []fetchLogsSource{{
+			dir:          a.settingsDir.SystemLogsDir(),
+			filters:      withDefault("syslog*"),
+			entryPattern: agentAuditEntryPattern,
+		}}, nil
+	case "monit":
+		return []fetchLogsSource{{dir: a.settingsDir.MonitDir(), filters: withDefault("monit.log*")}}, nil
+	case "all":
+		var sources []fetchLogsSource
+
+		for _, bundledType := range []string{"job", "agent", "system", "audit", "monit"} {
+			typeSources, err := a.logSources(bundledType, filters)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, source := range typeSources {
+				source.prefix = bundledType
+				sources = append(sources, source)
+			}
+		}
+
+		return sources, nil
+	default:
+		return nil, bosherr.Error("Invalid log type")
+	}
+}
+
+type fetchLogsCandidate struct {
+	path    string
+	relPath string
+	info    os.FileInfo
+	size    int64
+
+	entryPattern *regexp.Regexp
+}
+
+// selectiveCopyToTemp copies files matching filters like Copier.FilteredCopyToTemp
+// but from several sources and leaves out or truncates files according to options
+func (a FetchLogsAction) selectiveCopyToTemp(sources []fetchLogsSource, options FetchLogsOptions) (string, FetchLogsManifest, error) {
+	manifest := FetchLogsManifest{
+		Truncated: []FetchLogsManifestEntry{},
+		Skipped:   []FetchLogsManifestEntry{},
+	}
+
+	candidates, err := a.findCandidates(sources)
+	if err != nil {
+		return "", manifest, err
+	}
+
+	tmpDir, err := a.fs.TempDir("bosh-agent-fetch-logs")
+	if err != nil {
+		return "", manifest, bosherr.WrapError(err, "Creating temporary directory")
+	}
+
+	var entriesDir string
+
+	defer func() {
+		if entriesDir != "" {
+			_ = a.fs.RemoveAll(entriesDir)
+		}
+	}()
+
+	for _, candidate := range candidates {
+		if candidate.entryPattern != nil {
+			if entriesDir == "" {
+				entriesDir, err = a.fs.TempDir("bosh-agent-fetch-logs-entries")
+				if err != nil {
+					a.copier.CleanUp(tmpDir)
+					return "", manifest, bosherr.WrapError(err, "Creating temporary directory for log entries")
+				}
+			}
+
+			candidate, err = a.extractEntries(candidate, entriesDir)
+			if err != nil {
+				a.copier.CleanUp(tmpDir)
+				return "", manifest, err
+			}
+
+			// Files without matching entries are left out
+			if candidate.size == 0 {
+				continue
+			}
+		}
+
+		size := candidate.size
+		entry := FetchLogsManifestEntry{Path: candidate.relPath, Size: size}
+
+		if reason := a.skipReason(candidate, options); reason != "" {
+			entry.Reason = reason
+			manifest.Skipped = append(manifest.Skipped, entry)
+			continue
+		}
+
+		includedBytes := size
+		if options.MaxFileBytes > 0 && size > options.MaxFileBytes {
+			includedBytes = options.MaxFileBytes
+		}
+
+		if options.MaxTotalBytes > 0 && manifest.IncludedBytes+includedBytes > options.MaxTotalBytes {
+			entry.Reason = fetchLogsSkippedTotalSize
+			manifest.Skipped = append(manifest.Skipped, entry)
+			continue
+		}
+
+		err = a.copyTail(candidate.path, filepath.Join(tmpDir, candidate.relPath), size-includedBytes, includedBytes)
+		if err != nil {
+			a.copier.CleanUp(tmpDir)
+			return "", manifest, err
+		}
+
+		if includedBytes < size {
+			entry.IncludedBytes = includedBytes
+			manifest.Truncated = append(manifest.Truncated, entry)
+		}
+
+		manifest.IncludedFiles++
+		manifest.IncludedBytes += includedBytes
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		a.copier.CleanUp(tmpDir)
+		return "", manifest, bosherr.WrapError(err, "Marshalling manifest")
+	}
+
+	err = a.fs.WriteFile(filepath.Join(tmpDir, fetchLogsManifestFileName), manifestBytes)
+	if err != nil {
+		a.copier.CleanUp(tmpDir)
+		return "", manifest, bosherr.WrapError(err, "Writing manifest")
+	}
+
+	return tmpDir, manifest, nil
+}
+
+// findCandidates returns regular files matching filters, most recently modified first.
+// Filters matching directories match all files in them.
+func (a FetchLogsAction) findCandidates(sources []fetchLogsSource) ([]fetchLogsCandidate, error) {
+	found := map[string]fetchLogsCandidate{}
+
+	for _, source := range sources {
+		for _, filter := range source.filters {
+			pattern := filepath.Join(source.dir, filter)
+
+			if info, err := a.fs.Stat(pattern); err == nil && info.IsDir() {
+				pattern = filepath.Join(pattern, "**", "*")
+			}
+
+			matches, err := a.fs.RecursiveGlob(pattern)
+			if err != nil {
+				return nil, bosherr.WrapError(err, "Finding files matching filters")
+			}
+
+			for _, match := range matches {
+				relPath, err := filepath.Rel(source.dir, match)
+				if err != nil || strings.HasPrefix(relPath, "..") {
+					continue
+				}
+
+				info, err := a.fs.Stat(match)
+				if err != nil {
+					return nil, bosherr.WrapErrorf(err, "Getting file info for '%s'", match)
+				}
+
+				relPath = filepath.Join(source.prefix, relPath)
+
+				if info.Mode().IsRegular() {
+					found[relPath] = fetchLogsCandidate{
+						path:         match,
+						relPath:      relPath,
+						info:         info,
+						size:         info.Size(),
+						entryPattern: source.entryPattern,
+					}
+				}
+			}
+		}
+	}
+
+	candidates := make([]fetchLogsCandidate, 0, len(found))
+	for _, candidate := range found {
+		candidates = append(candidates, candidate)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		iModTime, jModTime := candidates[i].info.ModTime(), candidates[j].info.ModTime()
+		if !iModTime.Equal(jModTime) {
+			return iModTime.After(jModTime)
+		}
+		return candidates[i].relPath < candidates[j].relPath
+	})
+
+	return candidates, nil
+}
+
+func (a FetchLogsAction) skipReason(candidate fetchLogsCandidate, options FetchLogsOptions) string {
+	if options.ExcludeCompressed {
+		ext := strings.ToLower(filepath.Ext(candidate.path))
+		for _, compressedExt := range fetchLogsCompressedExtensions {
+			if ext == compressedExt {
+				return fetchLogsSkippedCompressed
+			}
+		}
+	}
+
+	modTime := candidate.info.ModTime()
+
+	if !options.ModifiedAfter.IsZero() && modTime.Before(options.ModifiedAfter) {
+		return fetchLogsSkippedModifiedBefore
+	}
+
+	if !options.ModifiedBefore.IsZero() && modTime.After(options.ModifiedBefore) {
+		return fetchLogsSkippedModifiedAfter
+	}
+
+	return ""
+}
+
+// extractEntries copies lines of candidate matching its entry pattern into dir
+// and returns candidate that refers to the copy
+func (a FetchLogsAction) extractEntries(candidate fetchLogsCandidate, dir string) (fetchLogsCandidate, error) {
+	srcFile, err := a.fs.OpenFile(candidate.path, os.O_RDONLY, 0)
+	if err != nil {
+		return candidate, bosherr.WrapErrorf(err, "Opening '%s'", candidate.path)
+	}
+
+	defer func() {
+		_ = srcFile.Close()
+	}()
+
+	dst := filepath.Join(dir, candidate.relPath)
+
+	err = a.fs.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if err != nil {
+		return candidate, bosherr.WrapErrorf(err, "Making directory for entries of '%s'", candidate.path)
+	}
+
+	dstFile, err := a.fs.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0644))
+	if err != nil {
+		return candidate, bosherr.WrapErrorf(err, "Creating '%s'", dst)
+	}
+
+	defer func() {
+		_ = dstFile.Close()
+	}()
+
+	// Logs may grow while being read so only what was there when they were selected is read
+	reader := bufio.NewReader(io.NewSectionReader(srcFile, 0, candidate.size))
+
+	var extracted int64
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+
+		if candidate.entryPattern.Match(line) {
+			n, err := dstFile.Write(line)
+			if err != nil {
+				return candidate, bosherr.WrapErrorf(err, "Writing entries of '%s'", candidate.path)
+			}
+			extracted += int64(n)
+		}
+
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			return candidate, bosherr.WrapErrorf(readErr, "Reading '%s'", candidate.path)
+		}
+	}
+
+	candidate.path = dst
+	candidate.size = extracted
+
+	return candidate, nil
+}
+
+// copyTail copies length bytes of src starting at offset.
+// Logs may grow while being copied so only what was there when they were selected is copied.
+func (a FetchLogsAction) copyTail(src, dst string, offset, length int64) error {
+	err := a.fs.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Making destination directory for '%s'", src)
+	}
+
+	srcFile, err := a.fs.OpenFile(src, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening '%s'", src)
+	}
+
+	defer func() {
+		_ = srcFile.Close()
+	}()
+
+	dstFile, err := a.fs.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0644))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating '%s'", dst)
+	}
+
+	defer func() {
+		_ = dstFile.Close()
+	}()
+
+	_, err = io.Copy(dstFile, io.NewSectionReader(srcFile, offset, length))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying '%s'", src)
+	}
+
+	return nil
+}
+
 func (a FetchLogsAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
 func (a FetchLogsAction) Cancel() error {
-	return errors.New("not supported")
+	// Cancelling when no logs are being fetched is a no-op
+	a.cancel.Cancel()
+	return nil
 }
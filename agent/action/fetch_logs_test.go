@@ -1,17 +1,26 @@
 package action_test
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
 
 var _ = Describe("FetchLogsAction", func() {
@@ -28,7 +37,7 @@ var _ = Describe("FetchLogsAction", func() {
 		blobstore = &fakeblobstore.FakeDigestBlobstore{}
 		dirProvider = boshdirs.NewProvider("/fake/dir")
 		copier = fakecmd.NewFakeCopier()
-		action = NewFetchLogs(compressor, copier, blobstore, dirProvider)
+		action = NewFetchLogs(compressor, copier, blobstore, fakesys.NewFakeFileSystem(), dirProvider, boshlog.NewLogger(boshlog.LevelNone))
 	})
 
 	AssertActionIsAsynchronous(action)
@@ -36,7 +45,6 @@ var _ = Describe("FetchLogsAction", func() {
 	AssertActionIsLoggable(action)
 
 	AssertActionIsNotResumable(action)
-	AssertActionIsNotCancelable(action)
 
 	Describe("Run", func() {
 		testLogs := func(logType string, filters []string, expectedFilters []string) {
@@ -48,7 +56,7 @@ var _ = Describe("FetchLogsAction", func() {
 				return "my-blob-id", multidigestSha, nil
 			}
 
-			logs, err := action.Run(logType, filters)
+			logs, err := action.Run(boshtask.NoopProgressFunc, logType, filters)
 			Expect(err).ToNot(HaveOccurred())
 
 			var expectedPath string
@@ -57,6 +65,10 @@ var _ = Describe("FetchLogsAction", func() {
 				expectedPath = filepath.Join("/fake", "dir", "sys", "log")
 			case "agent":
 				expectedPath = filepath.Join("/fake", "dir", "bosh", "log")
+			case "system":
+				expectedPath = filepath.Join("/fake", "dir", "data", "root_log")
+			case "monit":
+				expectedPath = filepath.Join("/fake", "dir", "monit")
 			}
 
 			Expect(copier.FilteredCopyToTempDir).To(boshassert.MatchPath(expectedPath))
@@ -71,7 +83,7 @@ var _ = Describe("FetchLogsAction", func() {
 		}
 
 		It("logs errs if given invalid log type", func() {
-			_, err := action.Run("other-logs", []string{})
+			_, err := action.Run(boshtask.NoopProgressFunc, "other-logs", []string{})
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -99,6 +111,299 @@ var _ = Describe("FetchLogsAction", func() {
 			testLogs("job", filters, expectedFilters)
 		})
 
+		It("system logs without filters", func() {
+			testLogs("system", []string{}, []string{"syslog*", "auth.log*", "kern.log*"})
+		})
+
+		It("system logs with filters", func() {
+			testLogs("system", []string{"dpkg.log"}, []string{"dpkg.log"})
+		})
+
+		It("monit logs without filters", func() {
+			testLogs("monit", []string{}, []string{"monit.log*"})
+		})
+
+		It("reports progress of each stage", func() {
+			var stages []string
+			progress := func(p boshtask.Progress) { stages = append(stages, p.Stage) }
+
+			_, err := action.Run(progress, "job", []string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stages).To(Equal([]string{"Copying logs", "Compressing logs", "Uploading logs"}))
+		})
+
+		Context("when cancelled", func() {
+			cancelAtStage := func(stage string) boshtask.ProgressFunc {
+				return func(p boshtask.Progress) {
+					if p.Stage == stage {
+						Expect(action.Cancel()).To(Succeed())
+					}
+				}
+			}
+
+			It("stops after copying logs and cleans up copied logs", func() {
+				copier.FilteredCopyToTempTempDir = "/fake-temp-dir"
+
+				_, err := action.Run(cancelAtStage("Copying logs"), "job", []string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Fetching logs was cancelled by user request"))
+
+				Expect(compressor.CompressFilesInDirDir).To(BeEmpty())
+				Expect(copier.CleanUpTempDir).To(Equal("/fake-temp-dir"))
+				Expect(blobstore.CreateCallCount()).To(Equal(0))
+			})
+
+			It("stops after compressing logs and cleans up the tarball", func() {
+				compressor.CompressFilesInDirTarballPath = "/fake-compressed-logs.tar"
+
+				_, err := action.Run(cancelAtStage("Compressing logs"), "job", []string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Fetching logs was cancelled by user request"))
+
+				Expect(compressor.CleanUpTarballPath).To(Equal("/fake-compressed-logs.tar"))
+				Expect(blobstore.CreateCallCount()).To(Equal(0))
+			})
+
+			It("aborts upload and deletes the blob once it is uploaded", func() {
+				finishUploadCh := make(chan struct{})
+				blobstore.CreateStub = func(string) (string, boshcrypto.MultipleDigest, error) {
+					Expect(action.Cancel()).To(Succeed())
+					<-finishUploadCh
+					return "my-blob-id", boshcrypto.MultipleDigest{}, nil
+				}
+
+				_, err := action.Run(boshtask.NoopProgressFunc, "job", []string{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Upload was cancelled by user request"))
+
+				close(finishUploadCh)
+				Eventually(blobstore.DeleteCallCount).Should(Equal(1))
+				Expect(blobstore.DeleteArgsForCall(0)).To(Equal("my-blob-id"))
+			})
+
+			It("ignores cancel requests made while no logs are being fetched", func() {
+				Expect(action.Cancel()).To(Succeed())
+
+				_, err := action.Run(boshtask.NoopProgressFunc, "job", []string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = action.Run(cancelAtStage("Compressing logs"), "job", []string{})
+				Expect(err).To(HaveOccurred())
+
+				_, err = action.Run(boshtask.NoopProgressFunc, "job", []string{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("when options are given or all logs are bundled", func() {
+			var (
+				baseDir string
+				now     time.Time
+
+				copiedLogs map[string]string
+				manifest   FetchLogsManifest
+			)
+
+			BeforeEach(func() {
+				var err error
+				baseDir, err = ioutil.TempDir("", "fetch-logs")
+				Expect(err).ToNot(HaveOccurred())
+
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				dirProvider = boshdirs.NewProvider(baseDir)
+				action = NewFetchLogs(compressor, copier, blobstore, boshsys.NewOsFileSystem(logger), dirProvider, logger)
+
+				now = time.Now().Truncate(time.Second)
+
+				writeLog := func(relPath string, content string, modTime time.Time) {
+					path := filepath.Join(dirProvider.LogsDir(), relPath)
+					Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+					Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+					Expect(os.Chtimes(path, modTime, modTime)).To(Succeed())
+				}
+
+				writeLog("job/current.log", "0123456789", now)
+				writeLog("job/previous.log", "abcdef", now.Add(-time.Hour))
+				writeLog("job/older.log.1.gz", "compressed", now.Add(-2*time.Hour))
+				writeLog("job/oldest.log", "oldest", now.Add(-24*time.Hour))
+
+				// Copied logs are cleaned up right after compressing them
+				copiedLogs = map[string]string{}
+				compressor.CompressFilesInDirCallBack = func() {
+					dir := compressor.CompressFilesInDirDir
+					err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+						Expect(err).ToNot(HaveOccurred())
+						if !info.IsDir() {
+							contents, err := ioutil.ReadFile(path)
+							Expect(err).ToNot(HaveOccurred())
+							relPath, err := filepath.Rel(dir, path)
+							Expect(err).ToNot(HaveOccurred())
+							copiedLogs[filepath.ToSlash(relPath)] = string(contents)
+						}
+						return nil
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(json.Unmarshal([]byte(copiedLogs["fetch_logs_manifest.json"]), &manifest)).To(Succeed())
+				}
+			})
+
+			AfterEach(func() {
+				_ = os.RemoveAll(baseDir)
+				_ = os.RemoveAll(compressor.CompressFilesInDirDir)
+			})
+
+			It("fetches every matching log when options do not limit anything", func() {
+				result, err := action.Run(boshtask.NoopProgressFunc, "job", []string{}, FetchLogsOptions{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(copiedLogs).To(HaveLen(5))
+				Expect(copiedLogs["job/current.log"]).To(Equal("0123456789"))
+
+				Expect(*result.Manifest).To(Equal(FetchLogsManifest{
+					IncludedFiles: 4,
+					IncludedBytes: 32,
+					Truncated:     []FetchLogsManifestEntry{},
+					Skipped:       []FetchLogsManifestEntry{},
+				}))
+				Expect(manifest).To(Equal(*result.Manifest))
+
+				Expect(copier.CleanUpTempDir).To(Equal(compressor.CompressFilesInDirDir))
+			})
+
+			It("skips logs modified outside of window", func() {
+				result, err := action.Run(boshtask.NoopProgressFunc, "job", []string{"job"}, FetchLogsOptions{
+					ModifiedAfter:  now.Add(-3 * time.Hour),
+					ModifiedBefore: now.Add(-time.Minute),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(copiedLogs).To(Equal(map[string]string{
+					"job/previous.log":         "abcdef",
+					"job/older.log.1.gz":       "compressed",
+					"fetch_logs_manifest.json": copiedLogs["fetch_logs_manifest.json"],
+				}))
+				Expect(result.Manifest.Skipped).To(Equal([]FetchLogsManifestEntry{
+					{Path: "job/current.log", Size: 10, Reason: "modified after window"},
+					{Path: "job/oldest.log", Size: 6, Reason: "modified before window"},
+				}))
+			})
+
+			It("keeps only the end of logs larger than maximum file size", func() {
+				result, err := action.Run(boshtask.NoopProgressFunc, "job", []string{"**/*.log"}, FetchLogsOptions{MaxFileBytes: 6})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(copiedLogs["job/current.log"]).To(Equal("456789"))
+				Expect(copiedLogs["job/previous.log"]).To(Equal("abcdef"))
+
+				Expect(result.Manifest.Truncated).To(Equal([]FetchLogsManifestEntry{
+					{Path: "job/current.log", Size: 10, IncludedBytes: 6},
+				}))
+			})
+
+			It("skips compressed logs", func() {
+				result, err := action.Run(boshtask.NoopProgressFunc, "job", []string{}, FetchLogsOptions{ExcludeCompressed: true})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(copiedLogs).ToNot(HaveKey("job/older.log.1.gz"))
+				Expect(result.Manifest.Skipped).To(Equal([]FetchLogsManifestEntry{
+					{Path: "job/older.log.1.gz", Size: 10, Reason: "compressed"},
+				}))
+			})
+
+			It("fetches most recently modified logs until maximum total size is reached", func() {
+				result, err := action.Run(boshtask.NoopProgressFunc, "job", []string{}, FetchLogsOptions{MaxTotalBytes: 22})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(copiedLogs).To(HaveKey("job/current.log"))
+				Expect(copiedLogs).To(HaveKey("job/previous.log"))
+				Expect(copiedLogs).To(HaveKey("job/oldest.log"))
+
+				Expect(result.Manifest.IncludedFiles).To(Equal(3))
+				Expect(result.Manifest.IncludedBytes).To(Equal(int64(22)))
+				Expect(result.Manifest.Skipped).To(Equal([]FetchLogsManifestEntry{
+					{Path: "job/older.log.1.gz", Size: 10, Reason: "maximum total size reached"},
+				}))
+			})
+
+			It("bundles all log types under separate directories", func() {
+				writeLogTo := func(path string, content string) {
+					Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+					Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+				}
+
+				writeLogTo(filepath.Join(dirProvider.AgentLogsDir(), "current"), "agent")
+				writeLogTo(filepath.Join(dirProvider.SystemLogsDir(), "syslog"), "syslog\nOct 18 10:00:00 fake-host vcap.agent[42]: audit\n")
+				writeLogTo(filepath.Join(dirProvider.SystemLogsDir(), "kern.log.1"), "kern")
+				writeLogTo(filepath.Join(dirProvider.SystemLogsDir(), "dpkg.log"), "dpkg")
+				writeLogTo(filepath.Join(dirProvider.MonitDir(), "monit.log"), "monit")
+				writeLogTo(filepath.Join(dirProvider.MonitDir(), "job", "fake-job.monitrc"), "monitrc")
+
+				result, err := action.Run(boshtask.NoopProgressFunc, "all", []string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				delete(copiedLogs, "fetch_logs_manifest.json")
+				Expect(copiedLogs).To(Equal(map[string]string{
+					"job/job/current.log":    "0123456789",
+					"job/job/previous.log":   "abcdef",
+					"job/job/older.log.1.gz": "compressed",
+					"job/job/oldest.log":     "oldest",
+					"agent/current":          "agent",
+					"system/syslog":          "syslog\nOct 18 10:00:00 fake-host vcap.agent[42]: audit\n",
+					"system/kern.log.1":      "kern",
+					"audit/syslog":           "Oct 18 10:00:00 fake-host vcap.agent[42]: audit\n",
+					"monit/monit.log":        "monit",
+				}))
+
+				Expect(result.Manifest).To(BeNil())
+			})
+
+			It("fetches agent audit entries from syslog", func() {
+				writeSyslog := func(name string, lines ...string) {
+					path := filepath.Join(dirProvider.SystemLogsDir(), name)
+					Expect(os.MkdirAll(filepath.Dir(path), os.ModePerm)).To(Succeed())
+					Expect(ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)).To(Succeed())
+				}
+
+				writeSyslog("syslog",
+					"Oct 18 10:00:00 fake-host vcap.agent[42]: CEF:0|CloudFoundry|BOSH|1|agent_api|ssh|7|duser=monitoring",
+					"Oct 18 10:00:01 fake-host kernel: not from agent",
+					"Oct 18 10:00:02 fake-host vcap.agent: CEF:0|CloudFoundry|BOSH|1|agent_api|apply|1|duser=director",
+				)
+				writeSyslog("syslog.1", "Oct 17 10:00:00 fake-host cron[7]: not from agent")
+				writeSyslog("audit/audit.log", "type=SYSCALL msg=audit(1.0:1): auditd record")
+
+				_, err := action.Run(boshtask.NoopProgressFunc, "audit", []string{})
+				Expect(err).ToNot(HaveOccurred())
+
+				delete(copiedLogs, "fetch_logs_manifest.json")
+				Expect(copiedLogs).To(Equal(map[string]string{
+					"syslog": "Oct 18 10:00:00 fake-host vcap.agent[42]: CEF:0|CloudFoundry|BOSH|1|agent_api|ssh|7|duser=monitoring\n" +
+						"Oct 18 10:00:02 fake-host vcap.agent: CEF:0|CloudFoundry|BOSH|1|agent_api|apply|1|duser=director",
+				}))
+			})
+
+			It("includes manifest in the result", func() {
+				multidigestSha := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
+				blobstore.CreateReturns("my-blob-id", multidigestSha, nil)
+
+				result, err := action.Run(boshtask.NoopProgressFunc, "job", []string{"job/current.log"}, FetchLogsOptions{MaxFileBytes: 1})
+				Expect(err).ToNot(HaveOccurred())
+
+				resultJSON, err := json.Marshal(result)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resultJSON).To(MatchJSON(`{
+					"blobstore_id": "my-blob-id",
+					"sha1": "fake-sha1",
+					"manifest": {
+						"included_files": 1,
+						"included_bytes": 1,
+						"truncated": [{"path": "job/current.log", "size": 10, "included_bytes": 1}],
+						"skipped": []
+					}
+				}`))
+			})
+		})
+
 		It("cleans up compressed package after uploading it to blobstore", func() {
 			var beforeCleanUpTarballPath, afterCleanUpTarballPath string
 
@@ -110,7 +415,7 @@ var _ = Describe("FetchLogsAction", func() {
 				return "my-blob-id", boshcrypto.MultipleDigest{}, nil
 			}
 
-			_, err := action.Run("job", []string{})
+			_, err := action.Run(boshtask.NoopProgressFunc, "job", []string{})
 			Expect(err).ToNot(HaveOccurred())
 
 			// Logs are not cleaned up before blobstore upload
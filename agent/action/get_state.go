@@ -42,6 +42,10 @@ func (a GetStateAction) IsLoggable() bool {
 	return true
 }
 
+func (a GetStateAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 type GetStateV1ApplySpec struct {
 	boshas.V1ApplySpec
 
@@ -7,6 +7,9 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
+// Clients using older protocol versions only understand task id and running state
+const getTaskProgressProtocolVersion ProtocolVersion = 4
+
 type GetTaskAction struct {
 	taskService boshtask.Service
 }
@@ -28,17 +31,18 @@ func (a GetTaskAction) IsLoggable() bool {
 	return true
 }
 
-func (a GetTaskAction) Run(taskID string) (interface{}, error) {
+func (a GetTaskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
+func (a GetTaskAction) Run(protocolVersion ProtocolVersion, taskID string) (interface{}, error) {
 	task, found := a.taskService.FindTaskWithID(taskID)
 	if !found {
 		return nil, bosherr.Errorf("Task with id %s could not be found", taskID)
 	}
 
-	if task.State == boshtask.StateRunning {
-		return boshtask.StateValue{
-			AgentTaskID: task.ID,
-			State:       task.State,
-		}, nil
+	if task.State == boshtask.StateRunning || task.State == boshtask.StateQueued {
+		return NewTaskStateValue(task, protocolVersion), nil
 	}
 
 	if task.Error != nil {
@@ -48,6 +52,23 @@ func (a GetTaskAction) Run(taskID string) (interface{}, error) {
 	return task.Value, nil
 }
 
+// NewTaskStateValue describes unfinished task to a client using given protocol version
+func NewTaskStateValue(task boshtask.Task, protocolVersion ProtocolVersion) boshtask.StateValue {
+	stateValue := boshtask.StateValue{
+		AgentTaskID: task.ID,
+		State:       task.State,
+	}
+
+	if protocolVersion >= getTaskProgressProtocolVersion {
+		stateValue.Progress = task.Progress
+	} else if task.State == boshtask.StateQueued {
+		// Older clients consider any other state to be final
+		stateValue.State = boshtask.StateRunning
+	}
+
+	return stateValue
+}
+
 func (a GetTaskAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -36,7 +36,7 @@ var _ = Describe("GetTask", func() {
 			State: boshtask.StateRunning,
 		}
 
-		taskValue, err := action.Run("fake-task-id")
+		taskValue, err := action.Run(ProtocolVersion(3), "fake-task-id")
 		Expect(err).ToNot(HaveOccurred())
 
 		// Check JSON key casing
@@ -44,6 +44,62 @@ var _ = Describe("GetTask", func() {
 			`{"agent_task_id":"fake-task-id","state":"running"}`)
 	})
 
+	Context("when task has reported progress", func() {
+		BeforeEach(func() {
+			taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+				ID:    "fake-task-id",
+				State: boshtask.StateRunning,
+				Progress: &boshtask.Progress{
+					Stage:   "Uploading compiled package",
+					Current: 120,
+					Total:   400,
+					Unit:    boshtask.ProgressUnitBytes,
+				},
+			}
+		})
+
+		It("returns progress of a running task for protocol version 4 and above", func() {
+			taskValue, err := action.Run(ProtocolVersion(4), "fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"running","progress":{"stage":"Uploading compiled package","current":120,"total":400,"unit":"bytes"}}`)
+		})
+
+		It("does not return progress for older protocol versions", func() {
+			taskValue, err := action.Run(ProtocolVersion(3), "fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"running"}`)
+		})
+	})
+
+	Context("when task is queued", func() {
+		BeforeEach(func() {
+			taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+				ID:    "fake-task-id",
+				State: boshtask.StateQueued,
+			}
+		})
+
+		It("returns queued state for protocol version 4 and above", func() {
+			taskValue, err := action.Run(ProtocolVersion(4), "fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"queued"}`)
+		})
+
+		It("returns running state for older protocol versions since they consider other states final", func() {
+			taskValue, err := action.Run(ProtocolVersion(3), "fake-task-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONString(GinkgoT(), taskValue,
+				`{"agent_task_id":"fake-task-id","state":"running"}`)
+		})
+	})
+
 	It("returns a failed task", func() {
 		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
 			ID:    "fake-task-id",
@@ -51,7 +107,7 @@ var _ = Describe("GetTask", func() {
 			Error: errors.New("fake-task-error"),
 		}
 
-		taskValue, err := action.Run("fake-task-id")
+		taskValue, err := action.Run(ProtocolVersion(3), "fake-task-id")
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(Equal("Task fake-task-id result: fake-task-error"))
 		Expect(taskValue).To(BeNil())
@@ -64,7 +120,7 @@ var _ = Describe("GetTask", func() {
 			Value: "some-task-value",
 		}
 
-		taskValue, err := action.Run("fake-task-id")
+		taskValue, err := action.Run(ProtocolVersion(3), "fake-task-id")
 		Expect(err).ToNot(HaveOccurred())
 		Expect(taskValue).To(Equal("some-task-value"))
 	})
@@ -72,7 +128,7 @@ var _ = Describe("GetTask", func() {
 	It("returns error when task is not found", func() {
 		taskService.StartedTasks = map[string]boshtask.Task{}
 
-		_, err := action.Run("fake-task-id")
+		_, err := action.Run(ProtocolVersion(3), "fake-task-id")
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(Equal("Task with id fake-task-id could not be found"))
 	})
@@ -24,6 +24,10 @@ func (a InfoAction) IsLoggable() bool {
 	return true
 }
 
+func (a InfoAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a InfoAction) Run() (InfoResponse, error) {
 	return InfoResponse{APIVersion: 1}, nil
 }
@@ -0,0 +1,249 @@
+package action
+
+import (
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	"github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	jobControlDefaultTimeout = 5 * time.Minute
+	jobControlPollInterval   = 1 * time.Second
+)
+
+// JobControlOptions are accepted by start_job, stop_job and restart_job
+type JobControlOptions struct {
+	// Wait until processes are running (or stopped)
+	Wait bool `json:"wait"`
+
+	// How long to wait, 5 minutes by default
+	TimeoutSeconds int `json:"timeout_seconds"`
+
+	// Stop processes without running job's drain script
+	SkipDrain bool `json:"skip_drain"`
+}
+
+func (o JobControlOptions) timeout() time.Duration {
+	if o.TimeoutSeconds > 0 {
+		return time.Duration(o.TimeoutSeconds) * time.Second
+	}
+	return jobControlDefaultTimeout
+}
+
+// controlledJob is a set of services to act on. Job is set when
+// name refers to a whole job or to a process that belongs to a job.
+type controlledJob struct {
+	job      *models.Job
+	services []string
+
+	// Set when services are all services of job
+	wholeJob bool
+}
+
+// jobControl acts on services of a single job instead of all jobs
+type jobControl struct {
+	jobSupervisor     boshjobsuper.JobSupervisor
+	jobScriptProvider boshscript.JobScriptProvider
+	timeService       clock.Clock
+
+	logTag string
+	logger boshlog.Logger
+}
+
+func newJobControl(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	jobScriptProvider boshscript.JobScriptProvider,
+	timeService clock.Clock,
+	logTag string,
+	logger boshlog.Logger,
+) jobControl {
+	return jobControl{
+		jobSupervisor:     jobSupervisor,
+		jobScriptProvider: jobScriptProvider,
+		timeService:       timeService,
+
+		logTag: logTag,
+		logger: logger,
+	}
+}
+
+// resolve takes either job name from current spec or process name from get_state
+func (c jobControl) resolve(spec boshas.V1ApplySpec, name string) (controlledJob, error) {
+	jobs := spec.Jobs()
+
+	for i, job := range jobs {
+		if job.Name != name {
+			continue
+		}
+
+		services, err := c.jobSupervisor.JobServices(job.Name)
+		if err != nil {
+			return controlledJob{}, bosherr.WrapErrorf(err, "Getting services of job '%s'", name)
+		}
+
+		if len(services) == 0 {
+			return controlledJob{}, bosherr.Errorf("Job '%s' has no processes", name)
+		}
+
+		return controlledJob{job: &jobs[i], services: services, wholeJob: true}, nil
+	}
+
+	processes, err := c.jobSupervisor.Processes()
+	if err != nil {
+		return controlledJob{}, bosherr.WrapError(err, "Getting processes")
+	}
+
+	for _, process := range processes {
+		if process.Name != name {
+			continue
+		}
+
+		target := controlledJob{services: []string{name}}
+
+		for i, job := range jobs {
+			services, err := c.jobSupervisor.JobServices(job.Name)
+			if err != nil {
+				return controlledJob{}, bosherr.WrapErrorf(err, "Getting services of job '%s'", job.Name)
+			}
+
+			if containsString(services, name) {
+				target.job = &jobs[i]
+				target.wholeJob = len(services) == 1
+				break
+			}
+		}
+
+		return target, nil
+	}
+
+	return controlledJob{}, bosherr.Errorf("Job or process '%s' could not be found", name)
+}
+
+func (c jobControl) start(target controlledJob, options JobControlOptions) error {
+	c.logger.Debug(c.logTag, "Starting services %v", target.services)
+
+	err := c.jobSupervisor.StartServices(target.services)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting services")
+	}
+
+	if options.Wait {
+		return c.waitFor(target.services, "running", options.timeout(), func(state string) bool {
+			return state == "running"
+		})
+	}
+
+	return nil
+}
+
+// stop runs drain script of the job before stopping its services.
+// Drain script prepares the whole job for shutdown so it is not run
+// when only some processes of a job are stopped.
+// Services are unmonitored first so that processes stopped by the drain
+// script are not restarted by the job supervisor.
+func (c jobControl) stop(target controlledJob, params boshdrain.ScriptParams, options JobControlOptions) error {
+	if target.wholeJob && !options.SkipDrain {
+		err := c.jobSupervisor.UnmonitorServices(target.services)
+		if err != nil {
+			return bosherr.WrapError(err, "Unmonitoring services")
+		}
+
+		script := c.jobScriptProvider.NewDrainScript(target.job.BundleName(), params)
+		if script.Exists() {
+			c.logger.Debug(c.logTag, "Draining job %s", target.job.Name)
+
+			err = script.Run()
+			if err != nil {
+				// Job keeps running so it is handed back to job supervisor
+				startErr := c.jobSupervisor.StartServices(target.services)
+				if startErr != nil {
+					c.logger.Error(c.logTag, "Monitoring services %v again after failed drain: %s", target.services, startErr.Error())
+				}
+
+				return bosherr.WrapErrorf(err, "Draining job '%s'", target.job.Name)
+			}
+		}
+	}
+
+	c.logger.Debug(c.logTag, "Stopping services %v", target.services)
+
+	err := c.jobSupervisor.StopServices(target.services)
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping services")
+	}
+
+	if options.Wait {
+		return c.waitForStopped(target, options)
+	}
+
+	return nil
+}
+
+func (c jobControl) waitForStopped(target controlledJob, options JobControlOptions) error {
+	return c.waitFor(target.services, "stopped", options.timeout(), func(state string) bool {
+		return state != "running" && state != "starting"
+	})
+}
+
+// waitFor polls processes until all services reach expected state;
+// services missing from processes are considered to have empty state
+func (c jobControl) waitFor(services []string, description string, timeout time.Duration, reached func(string) bool) error {
+	timer := c.timeService.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		processes, err := c.jobSupervisor.Processes()
+		if err != nil {
+			return bosherr.WrapError(err, "Getting processes")
+		}
+
+		states := map[string]string{}
+		for _, process := range processes {
+			states[process.Name] = process.State
+		}
+
+		var pending []string
+		for _, service := range services {
+			if !reached(states[service]) {
+				pending = append(pending, service)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		c.logger.Debug(c.logTag, "Waiting for services %v to be %s", pending, description)
+
+		select {
+		case <-timer.C():
+			return bosherr.Errorf("Timed out waiting for services '%s' to be %s after %s", strings.Join(pending, ", "), description, timeout)
+		case <-c.timeService.After(jobControlPollInterval):
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func firstJobControlOptions(options []JobControlOptions) JobControlOptions {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return JobControlOptions{}
+}
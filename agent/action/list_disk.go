@@ -42,6 +42,10 @@ func (a ListDiskAction) IsLoggable() bool {
 	return true
 }
 
+func (a ListDiskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a ListDiskAction) Run() (interface{}, error) {
 	err := a.settingsService.LoadSettings()
 	if err != nil {
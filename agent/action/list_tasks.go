@@ -0,0 +1,54 @@
+package action
+
+import (
+	"errors"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type ListTasksAction struct {
+	taskService boshtask.Service
+}
+
+func NewListTasks(taskService boshtask.Service) (listTasks ListTasksAction) {
+	listTasks.taskService = taskService
+	return
+}
+
+func (a ListTasksAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return false
+}
+
+func (a ListTasksAction) IsPersistent() bool {
+	return false
+}
+
+func (a ListTasksAction) IsLoggable() bool {
+	return true
+}
+
+func (a ListTasksAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
+func (a ListTasksAction) Run() ([]boshtask.HistoryEntry, error) {
+	entries, err := a.taskService.ListTasks()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing tasks")
+	}
+
+	if entries == nil {
+		entries = []boshtask.HistoryEntry{}
+	}
+
+	return entries, nil
+}
+
+func (a ListTasksAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ListTasksAction) Cancel() error {
+	return errors.New("not supported")
+}
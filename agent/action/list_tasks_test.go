@@ -0,0 +1,78 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+)
+
+var _ = Describe("ListTasks", func() {
+	var (
+		taskService *faketask.FakeService
+		action      ListTasksAction
+	)
+
+	BeforeEach(func() {
+		taskService = faketask.NewFakeService()
+		action = NewListTasks(taskService)
+	})
+
+	AssertActionIsNotAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("returns tasks from task service", func() {
+		startedAt := time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+		finishedAt := startedAt.Add(time.Minute)
+
+		taskService.ListTasksEntries = []boshtask.HistoryEntry{
+			{
+				TaskID:    "fake-running-task-id",
+				Method:    "apply",
+				State:     boshtask.StateRunning,
+				StartedAt: startedAt,
+			},
+			{
+				TaskID:     "fake-failed-task-id",
+				Method:     "compile_package",
+				State:      boshtask.StateFailed,
+				StartedAt:  startedAt,
+				FinishedAt: &finishedAt,
+				Error:      "fake-task-error",
+			},
+		}
+
+		value, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		// Check JSON key casing
+		boshassert.MatchesJSONString(GinkgoT(), value, `[`+
+			`{"agent_task_id":"fake-running-task-id","method":"apply","state":"running","started_at":"2017-03-01T10:00:00Z"},`+
+			`{"agent_task_id":"fake-failed-task-id","method":"compile_package","state":"failed","started_at":"2017-03-01T10:00:00Z","finished_at":"2017-03-01T10:01:00Z","error":"fake-task-error"}`+
+			`]`)
+	})
+
+	It("returns empty list when there are no tasks", func() {
+		value, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+		boshassert.MatchesJSONString(GinkgoT(), value, `[]`)
+	})
+
+	It("returns error when listing tasks fails", func() {
+		taskService.ListTasksErr = errors.New("fake-list-error")
+
+		_, err := action.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Listing tasks: fake-list-error"))
+	})
+})
@@ -34,6 +34,11 @@ func (a MigrateDiskAction) IsLoggable() bool {
 	return true
 }
 
+// Jobs write to the persistent disk so it is not changed while any job action runs
+func (a MigrateDiskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupDisks, ConcurrencyGroupJobs}
+}
+
 func (a MigrateDiskAction) Run() (value interface{}, err error) {
 	err = a.platform.MigratePersistentDisk(a.dirProvider.StoreDir(), a.dirProvider.StoreMigrationDir())
 	if err != nil {
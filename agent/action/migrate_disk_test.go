@@ -25,6 +25,7 @@ var _ = Describe("Testing with Ginkgo", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupDisks, ConcurrencyGroupJobs)
 
 	AssertActionIsNotResumable(action)
 	AssertActionIsNotCancelable(action)
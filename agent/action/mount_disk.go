@@ -3,6 +3,7 @@ package action
 import (
 	"errors"
 
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -40,29 +41,45 @@ func (a MountDiskAction) IsAsynchronous(_ ProtocolVersion) bool {
 }
 
 func (a MountDiskAction) IsPersistent() bool {
-	return false
+	return true
 }
 
 func (a MountDiskAction) IsLoggable() bool {
 	return true
 }
 
-func (a MountDiskAction) Run(diskCid string) (interface{}, error) {
-	err := a.settingsService.LoadSettings()
-	if err != nil {
-		return nil, bosherr.WrapError(err, "Refreshing the settings")
-	}
+// Jobs write to the persistent disk so it is not changed while any job action runs
+func (a MountDiskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupDisks, ConcurrencyGroupJobs}
+}
 
-	diskSettings, err := a.settingsService.GetPersistentDiskSettings(diskCid)
-	if err != nil {
-		return nil, bosherr.WrapError(err, "Reading persistent disk settings")
-	}
+const mountDiskStepMount = "mount_persistent_disk"
 
-	mountPoint := a.dirProvider.StoreDir()
+func (a MountDiskAction) Run(checkpoint boshtask.Checkpoint, diskCid string) (interface{}, error) {
+	// Mounting is idempotent but settings might have changed since
+	// the disk was mounted so resumed task does not mount it again
+	err := boshtask.RunStep(checkpoint, mountDiskStepMount, nil, func() error {
+		err := a.settingsService.LoadSettings()
+		if err != nil {
+			return bosherr.WrapError(err, "Refreshing the settings")
+		}
+
+		diskSettings, err := a.settingsService.GetPersistentDiskSettings(diskCid)
+		if err != nil {
+			return bosherr.WrapError(err, "Reading persistent disk settings")
+		}
+
+		mountPoint := a.dirProvider.StoreDir()
+
+		err = a.diskMounter.MountPersistentDisk(diskSettings, mountPoint)
+		if err != nil {
+			return bosherr.WrapError(err, "Mounting persistent disk")
+		}
 
-	err = a.diskMounter.MountPersistentDisk(diskSettings, mountPoint)
+		return nil
+	})
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Mounting persistent disk")
+		return nil, err
 	}
 
 	return map[string]string{}, nil
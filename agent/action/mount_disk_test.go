@@ -7,6 +7,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -32,8 +34,9 @@ var _ = Describe("MountDiskAction", func() {
 	})
 
 	AssertActionIsAsynchronous(action)
-	AssertActionIsNotPersistent(action)
+	AssertActionIsPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupDisks, ConcurrencyGroupJobs)
 
 	AssertActionIsNotResumable(action)
 	AssertActionIsNotCancelable(action)
@@ -54,7 +57,7 @@ var _ = Describe("MountDiskAction", func() {
 
 					Context("when mounting succeeds", func() {
 						It("returns without an error after mounting store directory", func() {
-							result, err := action.Run("fake-disk-cid")
+							result, err := action.Run(boshtask.NoopCheckpoint, "fake-disk-cid")
 							Expect(err).NotTo(HaveOccurred())
 							Expect(result).To(Equal(map[string]string{}))
 
@@ -70,7 +73,7 @@ var _ = Describe("MountDiskAction", func() {
 						})
 
 						It("does not save disk hint", func() {
-							result, err := action.Run("fake-disk-cid")
+							result, err := action.Run(boshtask.NoopCheckpoint, "fake-disk-cid")
 							Expect(err).NotTo(HaveOccurred())
 							Expect(result).To(Equal(map[string]string{}))
 							Expect(settingsService.SavePersistentDiskSettingsCallCount).To(Equal(0))
@@ -83,7 +86,7 @@ var _ = Describe("MountDiskAction", func() {
 						})
 
 						It("returns error after trying to mount store directory", func() {
-							_, err := action.Run("fake-disk-cid")
+							_, err := action.Run(boshtask.NoopCheckpoint, "fake-disk-cid")
 							Expect(err).To(HaveOccurred())
 							Expect(err.Error()).To(ContainSubstring("fake-mount-persistent-disk-err"))
 							Expect(settingsService.SavePersistentDiskSettingsCallCount).To(Equal(0))
@@ -97,7 +100,7 @@ var _ = Describe("MountDiskAction", func() {
 					})
 
 					It("returns error", func() {
-						_, err := action.Run("fake-unknown-disk-cid")
+						_, err := action.Run(boshtask.NoopCheckpoint, "fake-unknown-disk-cid")
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(Equal("Reading persistent disk settings: Persistent disk with volume id 'fake-unknown-disk-cid' could not be found"))
 					})
@@ -105,11 +108,56 @@ var _ = Describe("MountDiskAction", func() {
 			})
 		})
 
+		Context("when resumed after agent restart", func() {
+			var taskManager *faketask.FakeManager
+
+			BeforeEach(func() {
+				taskManager = faketask.NewFakeManager()
+				err := taskManager.AddInfo(boshtask.Info{TaskID: "fake-task-id"})
+				Expect(err).ToNot(HaveOccurred())
+
+				settingsService.PersistentDiskSettings = map[string]boshsettings.DiskSettings{
+					"fake-disk-cid": {ID: "fake-disk-cid", Path: "fake-device-path"},
+				}
+			})
+
+			It("does not mount disk again if it was already mounted", func() {
+				checkpoint := boshtask.NewCheckpoint(taskManager, "fake-task-id", nil)
+
+				_, err := action.Run(checkpoint, "fake-disk-cid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(platform.MountPersistentDiskCallCount()).To(Equal(1))
+
+				taskInfos, err := taskManager.GetInfos()
+				Expect(err).ToNot(HaveOccurred())
+				resumedCheckpoint := boshtask.NewCheckpoint(taskManager, "fake-task-id", taskInfos[0].Checkpoints)
+
+				result, err := action.Run(resumedCheckpoint, "fake-disk-cid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(map[string]string{}))
+				Expect(platform.MountPersistentDiskCallCount()).To(Equal(1))
+			})
+
+			It("mounts disk again if mounting did not finish", func() {
+				platform.MountPersistentDiskReturns(errors.New("fake-mount-persistent-disk-err"))
+				checkpoint := boshtask.NewCheckpoint(taskManager, "fake-task-id", nil)
+
+				_, err := action.Run(checkpoint, "fake-disk-cid")
+				Expect(err).To(HaveOccurred())
+
+				platform.MountPersistentDiskReturns(nil)
+
+				_, err = action.Run(checkpoint, "fake-disk-cid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(platform.MountPersistentDiskCallCount()).To(Equal(2))
+			})
+		})
+
 		Context("when settings cannot be loaded", func() {
 			It("returns error", func() {
 				settingsService.LoadSettingsError = errors.New("fake-load-settings-err")
 
-				_, err := action.Run("fake-disk-cid")
+				_, err := action.Run(boshtask.NoopCheckpoint, "fake-disk-cid")
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-load-settings-err"))
 			})
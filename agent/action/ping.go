@@ -22,6 +22,10 @@ func (a PingAction) IsLoggable() bool {
 	return true
 }
 
+func (a PingAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a PingAction) Run() (string, error) {
 	return "pong", nil
 }
@@ -29,6 +29,10 @@ func (a PrepareAction) IsLoggable() bool {
 	return true
 }
 
+func (a PrepareAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs, ConcurrencyGroupPackages}
+}
+
 func (a PrepareAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
 	err := a.applier.Prepare(desiredSpec)
 	if err != nil {
@@ -35,6 +35,10 @@ func (a PrepareConfigureNetworksAction) IsLoggable() bool {
 	return true
 }
 
+func (a PrepareConfigureNetworksAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a PrepareConfigureNetworksAction) Run() (string, error) {
 	err := a.settingsService.InvalidateSettings()
 	if err != nil {
@@ -25,6 +25,7 @@ var _ = Describe("PrepareAction", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs, ConcurrencyGroupPackages)
 
 	AssertActionIsNotResumable(action)
 	AssertActionIsNotCancelable(action)
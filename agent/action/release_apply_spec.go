@@ -29,6 +29,10 @@ func (a ReleaseApplySpecAction) IsLoggable() bool {
 	return true
 }
 
+func (a ReleaseApplySpecAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a ReleaseApplySpecAction) Run() (value interface{}, err error) {
 	fs := a.platform.GetFs()
 	specBytes, err := fs.ReadFile("/var/vcap/micro/apply_spec.json")
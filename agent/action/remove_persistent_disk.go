@@ -42,6 +42,10 @@ func (a RemovePersistentDiskAction) IsLoggable() bool {
 	return true
 }
 
+func (a RemovePersistentDiskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupDisks}
+}
+
 func (a RemovePersistentDiskAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
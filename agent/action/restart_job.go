@@ -0,0 +1,92 @@
+package action
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// RestartJobAction drains, stops and starts again processes
+// of a single job or a single process.
+// Job is only drained when all of its processes are restarted.
+type RestartJobAction struct {
+	specService boshas.V1Service
+	control     jobControl
+}
+
+func NewRestartJob(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V1Service,
+	jobScriptProvider boshscript.JobScriptProvider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) RestartJobAction {
+	return RestartJobAction{
+		specService: specService,
+		control:     newJobControl(jobSupervisor, jobScriptProvider, timeService, "RestartJob Action", logger),
+	}
+}
+
+func (a RestartJobAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a RestartJobAction) IsPersistent() bool {
+	return false
+}
+
+func (a RestartJobAction) IsLoggable() bool {
+	return true
+}
+
+func (a RestartJobAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs}
+}
+
+func (a RestartJobAction) Run(name string, options ...JobControlOptions) (string, error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current spec")
+	}
+
+	target, err := a.control.resolve(currentSpec, name)
+	if err != nil {
+		return "", err
+	}
+
+	opts := firstJobControlOptions(options)
+
+	// Job is not being updated so drain script sees the same spec twice
+	params := boshdrain.NewShutdownParams(currentSpec, &currentSpec)
+
+	// Processes are always stopped completely before starting them again
+	stopOpts := opts
+	stopOpts.Wait = true
+
+	err = a.control.stop(target, params, stopOpts)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Stopping '%s'", name)
+	}
+
+	err = a.control.start(target, opts)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Starting '%s'", name)
+	}
+
+	return "restarted", nil
+}
+
+func (a RestartJobAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RestartJobAction) Cancel() error {
+	return errors.New("not supported")
+}
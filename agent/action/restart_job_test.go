@@ -0,0 +1,87 @@
+package action_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	"github.com/cloudfoundry/bosh-agent/agent/script/scriptfakes"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("RestartJobAction", func() {
+	var (
+		jobSupervisor     *fakejobsuper.FakeJobSupervisor
+		specService       *fakeas.FakeV1Service
+		jobScriptProvider *scriptfakes.FakeJobScriptProvider
+		drainScript       *scriptfakes.FakeCancellableScript
+		timeService       *fakeclock.FakeClock
+		action            RestartJobAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		specService = fakeas.NewFakeV1Service()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		drainScript = &scriptfakes.FakeCancellableScript{}
+		drainScript.ExistsReturns(true)
+		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		jobScriptProvider.NewDrainScriptReturns(drainScript)
+
+		action = NewRestartJob(jobSupervisor, specService, jobScriptProvider, timeService, logger)
+
+		specService.Spec = jobControlSpec("fake-job")
+		jobSupervisor.JobServicesByJob = map[string][]string{
+			"fake-job": {"fake-job"},
+		}
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{Name: "fake-job", State: "unknown"},
+		}
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("drains and stops services before starting them again", func() {
+		value, err := action.Run("fake-job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("restarted"))
+
+		Expect(drainScript.RunCallCount()).To(Equal(1))
+		Expect(jobSupervisor.StoppedServices).To(Equal([][]string{{"fake-job"}}))
+		Expect(jobSupervisor.StartedServices).To(Equal([][]string{{"fake-job"}}))
+
+		_, params := jobScriptProvider.NewDrainScriptArgsForCall(0)
+		Expect(params).To(Equal(boshdrain.NewShutdownParams(specService.Spec, &specService.Spec)))
+	})
+
+	It("does not start services until they are stopped", func() {
+		jobSupervisor.ProcessesStatus[0].State = "running"
+
+		errCh := make(chan error)
+		go func() {
+			_, err := action.Run("fake-job", JobControlOptions{TimeoutSeconds: 5})
+			errCh <- err
+		}()
+
+		Eventually(timeService.WatcherCount).Should(Equal(2))
+		timeService.Increment(5 * time.Second)
+
+		Eventually(errCh).Should(Receive(MatchError(ContainSubstring("to be stopped"))))
+		Expect(jobSupervisor.StartedServices).To(BeEmpty())
+	})
+})
@@ -53,6 +53,10 @@ func (a RunErrandAction) IsLoggable() bool {
 	return true
 }
 
+func (a RunErrandAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs}
+}
+
 type ErrandResult struct {
 	Stdout     string `json:"stdout"`
 	Stderr     string `json:"stderr"`
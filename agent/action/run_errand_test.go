@@ -43,6 +43,7 @@ var _ = Describe("RunErrand", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs)
 
 	AssertActionIsNotResumable(action)
 
@@ -32,6 +32,7 @@ var _ = Describe("RunScript", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs)
 
 	AssertActionIsNotResumable(action)
 	AssertActionIsNotCancelable(action)
@@ -5,21 +5,24 @@ import (
 	"encoding/json"
 	"reflect"
 
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type Runner interface {
-	Run(action Action, payload []byte, protocolVersion ProtocolVersion) (value interface{}, err error)
-	Resume(action Action, payload []byte) (value interface{}, err error)
+	Run(action Action, payload []byte, protocolVersion ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint) (value interface{}, err error)
+	Resume(action Action, payload []byte, protocolVersion ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint) (value interface{}, err error)
 }
 
+var checkpointType = reflect.TypeOf((*boshtask.Checkpoint)(nil)).Elem()
+
 func NewRunner() Runner {
 	return concreteRunner{}
 }
 
 type concreteRunner struct{}
 
-func (r concreteRunner) Run(action Action, payloadBytes []byte, protocolVersion ProtocolVersion) (value interface{}, err error) {
+func (r concreteRunner) Run(action Action, payloadBytes []byte, protocolVersion ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint) (value interface{}, err error) {
 	payloadArgs, err := r.extractJSONArguments(payloadBytes)
 	if err != nil {
 		err = bosherr.WrapError(err, "Extracting json arguments")
@@ -39,7 +42,15 @@ func (r concreteRunner) Run(action Action, payloadBytes []byte, protocolVersion
 		return
 	}
 
-	methodArgs, err := r.extractMethodArgs(runMethodType, protocolVersion, payloadArgs)
+	if progress == nil {
+		progress = boshtask.NoopProgressFunc
+	}
+
+	if checkpoint == nil {
+		checkpoint = boshtask.NoopCheckpoint
+	}
+
+	methodArgs, err := r.extractMethodArgs(runMethodType, protocolVersion, progress, checkpoint, payloadArgs)
 	if err != nil {
 		err = bosherr.WrapError(err, "Extracting method arguments from payload")
 		return
@@ -49,10 +60,32 @@ func (r concreteRunner) Run(action Action, payloadBytes []byte, protocolVersion
 	return r.extractReturns(values)
 }
 
-func (r concreteRunner) Resume(action Action, payloadBytes []byte) (value interface{}, err error) {
+func (r concreteRunner) Resume(action Action, payloadBytes []byte, protocolVersion ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint) (value interface{}, err error) {
+	// Actions that record checkpoints are resumed by running them again
+	// with the original payload since they skip already completed steps
+	if r.acceptsCheckpoint(action) {
+		return r.Run(action, payloadBytes, protocolVersion, progress, checkpoint)
+	}
+
 	return action.Resume()
 }
 
+func (r concreteRunner) acceptsCheckpoint(action Action) bool {
+	runMethodValue := reflect.ValueOf(action).MethodByName("Run")
+	if runMethodValue.Kind() != reflect.Func {
+		return false
+	}
+
+	runMethodType := runMethodValue.Type()
+	for i := 0; i < runMethodType.NumIn(); i++ {
+		if runMethodType.In(i) == checkpointType {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r concreteRunner) extractJSONArguments(payloadBytes []byte) (args []interface{}, err error) {
 	type payloadType struct {
 		Arguments []interface{} `json:"arguments"`
@@ -88,7 +121,7 @@ func (r concreteRunner) invalidReturnTypes(methodType reflect.Type) (valid bool)
 	return
 }
 
-func (r concreteRunner) extractMethodArgs(runMethodType reflect.Type, protocolVersion ProtocolVersion, args []interface{}) (methodArgs []reflect.Value, err error) {
+func (r concreteRunner) extractMethodArgs(runMethodType reflect.Type, protocolVersion ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint, args []interface{}) (methodArgs []reflect.Value, err error) {
 	numberOfArgs := runMethodType.NumIn()
 	numberOfReqArgs := numberOfArgs
 
@@ -108,6 +141,21 @@ func (r concreteRunner) extractMethodArgs(runMethodType reflect.Type, protocolVe
 		}
 	}
 
+	// Progress func and checkpoint are given in that order
+	// after protocol version to actions that ask for them
+	injectedArgs := []reflect.Value{
+		reflect.ValueOf(progress),
+		reflect.ValueOf(&checkpoint).Elem(),
+	}
+
+	for _, injectedArg := range injectedArgs {
+		if numberOfArgs > argsOffset && runMethodType.In(argsOffset) == injectedArg.Type() {
+			methodArgs = append(methodArgs, injectedArg)
+			numberOfReqArgs--
+			argsOffset++
+		}
+	}
+
 	if len(args) < numberOfReqArgs {
 		err = bosherr.Errorf("Not enough arguments, expected %d, got %d", numberOfReqArgs, len(args))
 		return
@@ -10,6 +10,8 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 	fakeaction "github.com/cloudfoundry/bosh-agent/agent/action/fakes"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 )
 
 type valueType struct {
@@ -57,6 +59,10 @@ func (a *actionWithTypes) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithTypes) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithTypes) Run(arg argumentWithTypes) (valueType, error) {
 	a.Arg = arg
 	return a.Value, a.Err
@@ -89,6 +95,10 @@ func (a *actionWithSingleStringArgument) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithSingleStringArgument) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithSingleStringArgument) Run(arg string) (valueType, error) {
 	a.Arg = arg
 	return a.Value, a.Err
@@ -124,6 +134,10 @@ func (a *actionWithGoodRunMethod) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithGoodRunMethod) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithGoodRunMethod) Run(subAction string, someID int, extraArgs argsType, sliceArgs []string) (valueType, error) {
 	a.SubAction = subAction
 	a.SomeID = someID
@@ -160,6 +174,10 @@ func (a *actionWithOptionalRunArgument) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithOptionalRunArgument) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithOptionalRunArgument) Run(subAction string, optionalArgs ...argsType) (valueType, error) {
 	a.SubAction = subAction
 	a.OptionalArgs = optionalArgs
@@ -188,6 +206,10 @@ func (a *actionWithoutRunMethod) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithoutRunMethod) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithoutRunMethod) Resume() (interface{}, error) {
 	return nil, nil
 }
@@ -210,6 +232,10 @@ func (a *actionWithOneRunReturnValue) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithOneRunReturnValue) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithOneRunReturnValue) Run() error {
 	return nil
 }
@@ -236,6 +262,10 @@ func (a *actionWithSecondReturnValueNotError) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithSecondReturnValueNotError) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithSecondReturnValueNotError) Run() (interface{}, string) {
 	return nil, ""
 }
@@ -265,6 +295,10 @@ func (a *actionWithProtocolVersion) IsLoggable() bool {
 	return true
 }
 
+func (a *actionWithProtocolVersion) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a *actionWithProtocolVersion) Run(protocolVersion ProtocolVersion, subAction string) (valueType, error) {
 	a.ProtocolVersion = protocolVersion
 	a.SubAction = subAction
@@ -280,6 +314,86 @@ func (a *actionWithProtocolVersion) Cancel() error {
 	return nil
 }
 
+type actionWithProgress struct {
+	ProtocolVersion ProtocolVersion
+	SubAction       string
+}
+
+func (a *actionWithProgress) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a *actionWithProgress) IsPersistent() bool {
+	return false
+}
+
+func (a *actionWithProgress) IsLoggable() bool {
+	return true
+}
+
+func (a *actionWithProgress) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
+func (a *actionWithProgress) Run(protocolVersion ProtocolVersion, progress boshtask.ProgressFunc, subAction string) (valueType, error) {
+	a.ProtocolVersion = protocolVersion
+	a.SubAction = subAction
+
+	progress(boshtask.Progress{Stage: "fake-stage", Current: 1, Total: 2})
+
+	return valueType{}, nil
+}
+
+func (a *actionWithProgress) Resume() (interface{}, error) {
+	return nil, nil
+}
+
+func (a *actionWithProgress) Cancel() error {
+	return nil
+}
+
+type actionWithCheckpoint struct {
+	ProtocolVersion ProtocolVersion
+	Checkpoint      boshtask.Checkpoint
+	SubAction       string
+	Runs            int
+}
+
+func (a *actionWithCheckpoint) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a *actionWithCheckpoint) IsPersistent() bool {
+	return true
+}
+
+func (a *actionWithCheckpoint) IsLoggable() bool {
+	return true
+}
+
+func (a *actionWithCheckpoint) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
+func (a *actionWithCheckpoint) Run(protocolVersion ProtocolVersion, progress boshtask.ProgressFunc, checkpoint boshtask.Checkpoint, subAction string) (valueType, error) {
+	a.ProtocolVersion = protocolVersion
+	a.Checkpoint = checkpoint
+	a.SubAction = subAction
+	a.Runs++
+
+	progress(boshtask.Progress{Stage: "fake-stage"})
+
+	return valueType{ID: a.Runs}, nil
+}
+
+func (a *actionWithCheckpoint) Resume() (interface{}, error) {
+	return nil, errors.New("should not be called")
+}
+
+func (a *actionWithCheckpoint) Cancel() error {
+	return nil
+}
+
 var _ = Describe("concreteRunner", func() {
 	It("runner run parses the payload", func() {
 		runner := NewRunner()
@@ -298,7 +412,7 @@ var _ = Describe("concreteRunner", func() {
 				]
 			}`
 
-		value, err := runner.Run(action, []byte(payload), 0)
+		value, err := runner.Run(action, []byte(payload), 0, nil, nil)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(Equal("fake-run-error"))
 
@@ -319,7 +433,7 @@ var _ = Describe("concreteRunner", func() {
 		action := &actionWithGoodRunMethod{Value: expectedValue}
 		payload := `{"arguments":["setup"]}`
 
-		_, err := runner.Run(action, []byte(payload), 0)
+		_, err := runner.Run(action, []byte(payload), 0, nil, nil)
 		Expect(err).To(HaveOccurred())
 	})
 
@@ -331,7 +445,7 @@ var _ = Describe("concreteRunner", func() {
 		action := &actionWithSingleStringArgument{Value: expectedValue}
 		payload := `{"arguments":["setup", "additional extra argument", "another extra argument"]}`
 
-		_, err := runner.Run(action, []byte(payload), 0)
+		_, err := runner.Run(action, []byte(payload), 0, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
 	})
 
@@ -343,7 +457,7 @@ var _ = Describe("concreteRunner", func() {
 		action := &actionWithGoodRunMethod{Value: expectedValue}
 		payload := `{"arguments":[123, "setup", {"user":"rob","pwd":"rob123","id":12}]}`
 
-		_, err := runner.Run(action, []byte(payload), 0)
+		_, err := runner.Run(action, []byte(payload), 0, nil, nil)
 		Expect(err).To(HaveOccurred())
 	})
 
@@ -364,7 +478,7 @@ var _ = Describe("concreteRunner", func() {
 					"bool_type":false
 				}]
 			}`
-		_, err := runner.Run(action, []byte(payload), 0)
+		_, err := runner.Run(action, []byte(payload), 0, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(action.Arg.IntType).To(Equal(int(-1024000)))
@@ -386,7 +500,7 @@ var _ = Describe("concreteRunner", func() {
 		action := &actionWithOptionalRunArgument{Value: expectedValue, Err: expectedErr}
 		payload := `{"arguments":["setup", {"user":"rob","pwd":"rob123","id":12}, {"user":"bob","pwd":"bob123","id":13}]}`
 
-		value, err := runner.Run(action, []byte(payload), 0)
+		value, err := runner.Run(action, []byte(payload), 0, nil, nil)
 
 		Expect(value).To(Equal(expectedValue))
 		Expect(err).To(Equal(expectedErr))
@@ -403,7 +517,7 @@ var _ = Describe("concreteRunner", func() {
 		action := &actionWithOptionalRunArgument{}
 		payload := `{"arguments":["setup"]}`
 
-		runner.Run(action, []byte(payload), 0)
+		runner.Run(action, []byte(payload), 0, nil, nil)
 
 		Expect(action.SubAction).To(Equal("setup"))
 		Expect(action.OptionalArgs).To(Equal([]argsType{}))
@@ -411,23 +525,39 @@ var _ = Describe("concreteRunner", func() {
 
 	It("runner run errs when action does not implement run", func() {
 		runner := NewRunner()
-		_, err := runner.Run(&actionWithoutRunMethod{}, []byte(`{"arguments":[]}`), 0)
+		_, err := runner.Run(&actionWithoutRunMethod{}, []byte(`{"arguments":[]}`), 0, nil, nil)
 		Expect(err).To(HaveOccurred())
 	})
 
 	It("runner run errs when actions run does not return two values", func() {
 		runner := NewRunner()
-		_, err := runner.Run(&actionWithOneRunReturnValue{}, []byte(`{"arguments":[]}`), 0)
+		_, err := runner.Run(&actionWithOneRunReturnValue{}, []byte(`{"arguments":[]}`), 0, nil, nil)
 		Expect(err).To(HaveOccurred())
 	})
 
 	It("runner run errs when actions run second return type is not error", func() {
 		runner := NewRunner()
-		_, err := runner.Run(&actionWithSecondReturnValueNotError{}, []byte(`{"arguments":[]}`), 0)
+		_, err := runner.Run(&actionWithSecondReturnValueNotError{}, []byte(`{"arguments":[]}`), 0, nil, nil)
 		Expect(err).To(HaveOccurred())
 	})
 
 	Describe("Resume", func() {
+		It("runs action again with its payload when action accepts checkpoint", func() {
+			runner := NewRunner()
+
+			action := &actionWithCheckpoint{}
+			payload := `{"arguments":["setup"]}`
+			checkpoint := boshtask.NewCheckpoint(faketask.NewFakeManager(), "fake-task-id", nil)
+
+			value, err := runner.Resume(action, []byte(payload), 2, nil, checkpoint)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(valueType{ID: 1}))
+
+			Expect(action.ProtocolVersion).To(Equal(ProtocolVersion(2)))
+			Expect(action.Checkpoint).To(Equal(checkpoint))
+			Expect(action.SubAction).To(Equal("setup"))
+		})
+
 		It("calls Resume() on action", func() {
 			runner := NewRunner()
 			testAction := &fakeaction.TestAction{
@@ -435,7 +565,7 @@ var _ = Describe("concreteRunner", func() {
 				ResumeValue: "fake-action-resume-value",
 			}
 
-			value, err := runner.Resume(testAction, []byte{})
+			value, err := runner.Resume(testAction, []byte{}, 0, nil, nil)
 			Expect(value).To(Equal("fake-action-resume-value"))
 			Expect(err.Error()).To(Equal("fake-action-error"))
 
@@ -449,7 +579,7 @@ var _ = Describe("concreteRunner", func() {
 		action := &actionWithProtocolVersion{}
 		payload := `{"arguments":["setup"]}`
 
-		_, err := runner.Run(action, []byte(payload), 1)
+		_, err := runner.Run(action, []byte(payload), 1, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(action.ProtocolVersion).To(Equal(ProtocolVersion(1)))
@@ -462,10 +592,68 @@ var _ = Describe("concreteRunner", func() {
 		action := &actionWithProtocolVersion{}
 		payload := `{"protocol":98,"arguments":["setup"]}`
 
-		_, err := runner.Run(action, []byte(payload), 1)
+		_, err := runner.Run(action, []byte(payload), 1, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(action.ProtocolVersion).To(Equal(ProtocolVersion(1)))
 		Expect(action.SubAction).To(Equal("setup"))
 	})
+
+	It("passes progress func to run method after protocol version", func() {
+		runner := NewRunner()
+
+		action := &actionWithProgress{}
+		payload := `{"arguments":["setup"]}`
+
+		var reported []boshtask.Progress
+		progress := func(p boshtask.Progress) { reported = append(reported, p) }
+
+		_, err := runner.Run(action, []byte(payload), 1, progress, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(action.ProtocolVersion).To(Equal(ProtocolVersion(1)))
+		Expect(action.SubAction).To(Equal("setup"))
+		Expect(reported).To(Equal([]boshtask.Progress{{Stage: "fake-stage", Current: 1, Total: 2}}))
+	})
+
+	It("passes no-op progress func to run method when progress is not tracked", func() {
+		runner := NewRunner()
+
+		action := &actionWithProgress{}
+		payload := `{"arguments":["setup"]}`
+
+		_, err := runner.Run(action, []byte(payload), 1, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action.SubAction).To(Equal("setup"))
+	})
+
+	It("passes checkpoint to run method after progress func", func() {
+		runner := NewRunner()
+
+		action := &actionWithCheckpoint{}
+		payload := `{"arguments":["setup"]}`
+
+		var reported []boshtask.Progress
+		progress := func(p boshtask.Progress) { reported = append(reported, p) }
+		checkpoint := boshtask.NewCheckpoint(faketask.NewFakeManager(), "fake-task-id", nil)
+
+		_, err := runner.Run(action, []byte(payload), 1, progress, checkpoint)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(action.ProtocolVersion).To(Equal(ProtocolVersion(1)))
+		Expect(action.Checkpoint).To(Equal(checkpoint))
+		Expect(action.SubAction).To(Equal("setup"))
+		Expect(reported).To(Equal([]boshtask.Progress{{Stage: "fake-stage"}}))
+	})
+
+	It("passes no-op checkpoint to run method when task is not persisted", func() {
+		runner := NewRunner()
+
+		action := &actionWithCheckpoint{}
+		payload := `{"arguments":["setup"]}`
+
+		_, err := runner.Run(action, []byte(payload), 1, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action.Checkpoint).To(Equal(boshtask.NoopCheckpoint))
+	})
 })
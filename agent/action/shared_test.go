@@ -1,6 +1,8 @@
 package action_test
 
 import (
+	"fmt"
+
 	. "github.com/cloudfoundry/bosh-agent/agent/action"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -54,6 +56,12 @@ func AssertActionIsNotLoggable(action Action) {
 	})
 }
 
+func AssertActionIsInConcurrencyGroups(action Action, groups ...ConcurrencyGroup) {
+	It(fmt.Sprintf("is in %v concurrency groups", groups), func() {
+		Expect(action.ConcurrencyGroups()).To(Equal(groups))
+	})
+}
+
 func AssertActionIsNotCancelable(action Action) {
 	It("cannot be cancelled", func() {
 		err := action.Cancel()
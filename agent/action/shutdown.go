@@ -28,6 +28,10 @@ func (a ShutdownAction) IsLoggable() bool {
 	return true
 }
 
+func (a ShutdownAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a ShutdownAction) Run() (string, error) {
 	a.platform.Shutdown()
 	return "", nil
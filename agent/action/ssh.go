@@ -43,6 +43,10 @@ func (a SSHAction) IsLoggable() bool {
 	return true
 }
 
+func (a SSHAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 type SSHParams struct {
 	UserRegex string `json:"user_regex"`
 	User      string
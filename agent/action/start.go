@@ -36,6 +36,10 @@ func (a StartAction) IsLoggable() bool {
 	return true
 }
 
+func (a StartAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a StartAction) Run() (value string, err error) {
 	desiredApplySpec, err := a.specService.Get()
 	if err != nil {
@@ -0,0 +1,75 @@
+package action
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// StartJobAction starts processes of a single job or a single process
+type StartJobAction struct {
+	specService boshas.V1Service
+	control     jobControl
+}
+
+func NewStartJob(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V1Service,
+	jobScriptProvider boshscript.JobScriptProvider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) StartJobAction {
+	return StartJobAction{
+		specService: specService,
+		control:     newJobControl(jobSupervisor, jobScriptProvider, timeService, "StartJob Action", logger),
+	}
+}
+
+func (a StartJobAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a StartJobAction) IsPersistent() bool {
+	return false
+}
+
+func (a StartJobAction) IsLoggable() bool {
+	return true
+}
+
+func (a StartJobAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs}
+}
+
+func (a StartJobAction) Run(name string, options ...JobControlOptions) (string, error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current spec")
+	}
+
+	target, err := a.control.resolve(currentSpec, name)
+	if err != nil {
+		return "", err
+	}
+
+	err = a.control.start(target, firstJobControlOptions(options))
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Starting '%s'", name)
+	}
+
+	return "started", nil
+}
+
+func (a StartJobAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a StartJobAction) Cancel() error {
+	return errors.New("not supported")
+}
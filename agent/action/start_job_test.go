@@ -0,0 +1,129 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	"github.com/cloudfoundry/bosh-agent/agent/script/scriptfakes"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+func jobControlSpec(jobNames ...string) boshas.V1ApplySpec {
+	spec := boshas.V1ApplySpec{
+		RenderedTemplatesArchiveSpec: &boshas.RenderedTemplatesArchiveSpec{},
+	}
+	for _, name := range jobNames {
+		spec.JobSpec.JobTemplateSpecs = append(spec.JobSpec.JobTemplateSpecs, boshas.JobTemplateSpec{Name: name})
+	}
+	return spec
+}
+
+var _ = Describe("StartJobAction", func() {
+	var (
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		specService   *fakeas.FakeV1Service
+		timeService   *fakeclock.FakeClock
+		action        StartJobAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		specService = fakeas.NewFakeV1Service()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		action = NewStartJob(jobSupervisor, specService, &scriptfakes.FakeJobScriptProvider{}, timeService, logger)
+
+		specService.Spec = jobControlSpec("fake-job", "other-job")
+		jobSupervisor.JobServicesByJob = map[string][]string{
+			"fake-job":  {"fake-job", "fake-job_extra"},
+			"other-job": {"other-job"},
+		}
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{Name: "fake-job", State: "running"},
+			{Name: "fake-job_extra", State: "running"},
+			{Name: "other-job", State: "running"},
+		}
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("starts all services of the job", func() {
+		value, err := action.Run("fake-job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("started"))
+		Expect(jobSupervisor.StartedServices).To(Equal([][]string{{"fake-job", "fake-job_extra"}}))
+	})
+
+	It("starts single process when process name is given", func() {
+		value, err := action.Run("fake-job_extra")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("started"))
+		Expect(jobSupervisor.StartedServices).To(Equal([][]string{{"fake-job_extra"}}))
+	})
+
+	It("returns error when neither job nor process is found", func() {
+		_, err := action.Run("unknown")
+		Expect(err).To(MatchError("Job or process 'unknown' could not be found"))
+		Expect(jobSupervisor.StartedServices).To(BeEmpty())
+	})
+
+	It("returns error when job has no services", func() {
+		jobSupervisor.JobServicesByJob = map[string][]string{}
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(MatchError("Job 'fake-job' has no processes"))
+	})
+
+	It("returns error when getting current spec fails", func() {
+		specService.GetErr = errors.New("fake-spec-err")
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(MatchError(ContainSubstring("fake-spec-err")))
+	})
+
+	It("returns error when starting services fails", func() {
+		jobSupervisor.StartServicesErr = errors.New("fake-start-err")
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(MatchError(ContainSubstring("fake-start-err")))
+	})
+
+	Context("when waiting is requested", func() {
+		It("returns once all services are running", func() {
+			value, err := action.Run("fake-job", JobControlOptions{Wait: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("started"))
+		})
+
+		It("returns error when services are not running before timeout", func() {
+			jobSupervisor.ProcessesStatus[1].State = "starting"
+
+			errCh := make(chan error)
+			go func() {
+				_, err := action.Run("fake-job", JobControlOptions{Wait: true, TimeoutSeconds: 10})
+				errCh <- err
+			}()
+
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(10 * time.Second)
+
+			Eventually(errCh).Should(Receive(MatchError(ContainSubstring(
+				"Timed out waiting for services 'fake-job_extra' to be running after 10s"))))
+		})
+	})
+})
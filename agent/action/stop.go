@@ -30,6 +30,10 @@ func (a StopAction) IsLoggable() bool {
 	return true
 }
 
+func (a StopAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs}
+}
+
 func (a StopAction) Run(protocolVersion ProtocolVersion) (value string, err error) {
 	if protocolVersion > 2 {
 		err = a.jobSupervisor.StopAndWait()
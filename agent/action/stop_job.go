@@ -0,0 +1,79 @@
+package action
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/clock"
+
+	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
+	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// StopJobAction drains and stops processes of a single job or stops a single process.
+// Job is only drained when all of its processes are stopped.
+type StopJobAction struct {
+	specService boshas.V1Service
+	control     jobControl
+}
+
+func NewStopJob(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V1Service,
+	jobScriptProvider boshscript.JobScriptProvider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) StopJobAction {
+	return StopJobAction{
+		specService: specService,
+		control:     newJobControl(jobSupervisor, jobScriptProvider, timeService, "StopJob Action", logger),
+	}
+}
+
+func (a StopJobAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a StopJobAction) IsPersistent() bool {
+	return false
+}
+
+func (a StopJobAction) IsLoggable() bool {
+	return true
+}
+
+func (a StopJobAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupJobs}
+}
+
+func (a StopJobAction) Run(name string, options ...JobControlOptions) (string, error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting current spec")
+	}
+
+	target, err := a.control.resolve(currentSpec, name)
+	if err != nil {
+		return "", err
+	}
+
+	params := boshdrain.NewShutdownParams(currentSpec, nil)
+
+	err = a.control.stop(target, params, firstJobControlOptions(options))
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Stopping '%s'", name)
+	}
+
+	return "stopped", nil
+}
+
+func (a StopJobAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a StopJobAction) Cancel() error {
+	return errors.New("not supported")
+}
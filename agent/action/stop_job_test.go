@@ -0,0 +1,166 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
+	boshdrain "github.com/cloudfoundry/bosh-agent/agent/script/drain"
+	"github.com/cloudfoundry/bosh-agent/agent/script/scriptfakes"
+	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("StopJobAction", func() {
+	var (
+		jobSupervisor     *fakejobsuper.FakeJobSupervisor
+		specService       *fakeas.FakeV1Service
+		jobScriptProvider *scriptfakes.FakeJobScriptProvider
+		drainScript       *scriptfakes.FakeCancellableScript
+		timeService       *fakeclock.FakeClock
+		action            StopJobAction
+	)
+
+	BeforeEach(func() {
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		specService = fakeas.NewFakeV1Service()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		drainScript = &scriptfakes.FakeCancellableScript{}
+		drainScript.ExistsReturns(true)
+		jobScriptProvider = &scriptfakes.FakeJobScriptProvider{}
+		jobScriptProvider.NewDrainScriptReturns(drainScript)
+
+		action = NewStopJob(jobSupervisor, specService, jobScriptProvider, timeService, logger)
+
+		specService.Spec = jobControlSpec("fake-job", "other-job")
+		jobSupervisor.JobServicesByJob = map[string][]string{
+			"fake-job":  {"fake-job", "fake-job_extra"},
+			"other-job": {"other-job"},
+		}
+		jobSupervisor.ProcessesStatus = []boshjobsuper.Process{
+			{Name: "fake-job", State: "unknown"},
+			{Name: "fake-job_extra", State: "unknown"},
+			{Name: "other-job", State: "running"},
+		}
+	})
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs)
+
+	AssertActionIsNotResumable(action)
+	AssertActionIsNotCancelable(action)
+
+	It("unmonitors services, drains and stops the job", func() {
+		drainScript.RunStub = func() error {
+			Expect(jobSupervisor.UnmonitoredServices).To(Equal([][]string{{"fake-job", "fake-job_extra"}}))
+			Expect(jobSupervisor.StoppedServices).To(BeEmpty())
+			return nil
+		}
+
+		value, err := action.Run("fake-job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("stopped"))
+
+		Expect(drainScript.RunCallCount()).To(Equal(1))
+		Expect(jobSupervisor.StoppedServices).To(Equal([][]string{{"fake-job", "fake-job_extra"}}))
+
+		jobName, params := jobScriptProvider.NewDrainScriptArgsForCall(0)
+		Expect(jobName).To(Equal("fake-job"))
+		Expect(params).To(Equal(boshdrain.NewShutdownParams(specService.Spec, nil)))
+	})
+
+	It("stops only the process without draining its job when name of one of several processes of job is given", func() {
+		value, err := action.Run("fake-job_extra")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(value).To(Equal("stopped"))
+
+		Expect(jobScriptProvider.NewDrainScriptCallCount()).To(Equal(0))
+		Expect(jobSupervisor.UnmonitoredServices).To(BeEmpty())
+		Expect(jobSupervisor.StoppedServices).To(Equal([][]string{{"fake-job_extra"}}))
+	})
+
+	It("drains job owning the process when process is the only process of job", func() {
+		jobSupervisor.JobServicesByJob["other-job"] = []string{"other-process"}
+		jobSupervisor.ProcessesStatus = append(jobSupervisor.ProcessesStatus, boshjobsuper.Process{Name: "other-process"})
+
+		_, err := action.Run("other-process")
+		Expect(err).ToNot(HaveOccurred())
+
+		jobName, _ := jobScriptProvider.NewDrainScriptArgsForCall(0)
+		Expect(jobName).To(Equal("other-job"))
+		Expect(jobSupervisor.StoppedServices).To(Equal([][]string{{"other-process"}}))
+	})
+
+	It("does not run drain script when it does not exist", func() {
+		drainScript.ExistsReturns(false)
+
+		_, err := action.Run("fake-job")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drainScript.RunCallCount()).To(Equal(0))
+		Expect(jobSupervisor.StoppedServices).To(HaveLen(1))
+	})
+
+	It("does not drain when skipping drain is requested", func() {
+		_, err := action.Run("fake-job", JobControlOptions{SkipDrain: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(jobScriptProvider.NewDrainScriptCallCount()).To(Equal(0))
+		Expect(jobSupervisor.UnmonitoredServices).To(BeEmpty())
+		Expect(jobSupervisor.StoppedServices).To(HaveLen(1))
+	})
+
+	It("does not stop services and monitors them again when drain script fails", func() {
+		drainScript.RunReturns(errors.New("fake-drain-err"))
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(MatchError(ContainSubstring("fake-drain-err")))
+		Expect(jobSupervisor.StoppedServices).To(BeEmpty())
+		Expect(jobSupervisor.StartedServices).To(Equal([][]string{{"fake-job", "fake-job_extra"}}))
+	})
+
+	It("returns drain error when monitoring services again fails", func() {
+		drainScript.RunReturns(errors.New("fake-drain-err"))
+		jobSupervisor.StartServicesErr = errors.New("fake-start-err")
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(MatchError(ContainSubstring("fake-drain-err")))
+	})
+
+	It("returns error when stopping services fails", func() {
+		jobSupervisor.StopServicesErr = errors.New("fake-stop-err")
+
+		_, err := action.Run("fake-job")
+		Expect(err).To(MatchError(ContainSubstring("fake-stop-err")))
+	})
+
+	Context("when waiting is requested", func() {
+		It("returns once all services are no longer running", func() {
+			value, err := action.Run("fake-job", JobControlOptions{Wait: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("stopped"))
+		})
+
+		It("returns error when services are still running after timeout", func() {
+			errCh := make(chan error)
+			go func() {
+				_, err := action.Run("other-job", JobControlOptions{Wait: true, TimeoutSeconds: 5})
+				errCh <- err
+			}()
+
+			Eventually(timeService.WatcherCount).Should(Equal(2))
+			timeService.Increment(5 * time.Second)
+
+			Eventually(errCh).Should(Receive(MatchError(ContainSubstring(
+				"Timed out waiting for services 'other-job' to be stopped after 5s"))))
+		})
+	})
+})
@@ -22,6 +22,7 @@ var _ = Describe("Stop", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupJobs)
 
 	AssertActionIsNotResumable(action)
 	AssertActionIsNotCancelable(action)
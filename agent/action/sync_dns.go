@@ -52,6 +52,10 @@ func (a SyncDNS) IsLoggable() bool {
 	return true
 }
 
+func (a SyncDNS) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a SyncDNS) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
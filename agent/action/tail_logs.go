@@ -0,0 +1,409 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	tailLogsDefaultLines = 200
+
+	// Responses have to fit into a single message bus message
+	tailLogsMaxBytes = 512 * 1024
+
+	tailLogsMaxFollow      = 10 * time.Minute
+	tailLogsFollowInterval = time.Second
+)
+
+var errTailLogsCanceled = bosherr.Error("Tailing logs was cancelled by user request")
+
+// TailLogsOptions limits how much of each log file is returned.
+// Last 200 lines are returned when neither lines nor bytes are given.
+type TailLogsOptions struct {
+	Filters []string `json:"filters"`
+	Lines   int      `json:"lines"`
+	Bytes   int64    `json:"bytes"`
+
+	// Keep returning lines appended to logs for this long
+	FollowSeconds int `json:"follow_seconds"`
+}
+
+type TailLogsResult struct {
+	Files  []TailedLogFile  `json:"files"`
+	Chunks []TailedLogChunk `json:"chunks,omitempty"`
+}
+
+type TailedLogFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+
+	// Set when beginning of the file was left out
+	Truncated bool `json:"truncated"`
+}
+
+// TailedLogChunk is content appended to a log while following it.
+// Chunks are also reported in task progress so that clients polling
+// get_task with task ID can show them before task finishes;
+// gaps in sequence numbers mean older chunks were dropped.
+type TailedLogChunk struct {
+	Sequence int    `json:"sequence"`
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+}
+
+type TailLogsAction struct {
+	fs          boshsys.FileSystem
+	settingsDir boshdirs.Provider
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	cancel *boshtask.RunCancel
+}
+
+func NewTailLogs(
+	fs boshsys.FileSystem,
+	settingsDir boshdirs.Provider,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) (action TailLogsAction) {
+	action.fs = fs
+	action.settingsDir = settingsDir
+	action.timeService = timeService
+	action.logger = logger
+
+	action.cancel = &boshtask.RunCancel{}
+	return
+}
+
+func (a TailLogsAction) IsAsynchronous(_ ProtocolVersion) bool {
+	return true
+}
+
+func (a TailLogsAction) IsPersistent() bool {
+	return false
+}
+
+func (a TailLogsAction) IsLoggable() bool {
+	return true
+}
+
+func (a TailLogsAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
+func (a TailLogsAction) Run(progress boshtask.ProgressFunc, logType string, jobName string, options TailLogsOptions) (TailLogsResult, error) {
+	var result TailLogsResult
+
+	cancelCh := a.cancel.Start()
+	defer a.cancel.Finish(cancelCh)
+
+	logsDir, err := a.logsDir(logType, jobName)
+	if err != nil {
+		return result, err
+	}
+
+	if options.Lines < 0 || options.Bytes < 0 {
+		return result, bosherr.Error("Lines and bytes must not be negative")
+	}
+
+	if options.FollowSeconds < 0 || time.Duration(options.FollowSeconds)*time.Second > tailLogsMaxFollow {
+		return result, bosherr.Errorf("Follow duration must be between 0 and %d seconds", int(tailLogsMaxFollow.Seconds()))
+	}
+
+	if options.Lines == 0 && options.Bytes == 0 {
+		options.Lines = tailLogsDefaultLines
+	}
+
+	filters := options.Filters
+	if len(filters) == 0 {
+		filters = []string{"**/*"}
+	}
+
+	paths, err := a.findLogs(logsDir, filters)
+	if err != nil {
+		return result, err
+	}
+
+	progress(boshtask.Progress{Stage: "Reading logs"})
+
+	// Offsets where following continues
+	offsets := map[string]int64{}
+	remainingBytes := int64(tailLogsMaxBytes)
+
+	result.Files = []TailedLogFile{}
+
+	for _, path := range paths {
+		maxBytes := remainingBytes
+		if options.Bytes > 0 && options.Bytes < maxBytes {
+			maxBytes = options.Bytes
+		}
+
+		content, size, truncated, err := a.readLog(path, 0, maxBytes)
+		if err != nil {
+			return result, err
+		}
+
+		offsets[path] = size
+
+		if options.Lines > 0 {
+			if start := lastLinesStart(content, options.Lines); start > 0 {
+				content = content[start:]
+				truncated = true
+			}
+		}
+
+		remainingBytes -= int64(len(content))
+
+		result.Files = append(result.Files, TailedLogFile{
+			Path:      a.relativePath(logsDir, path),
+			Content:   string(content),
+			Truncated: truncated,
+		})
+	}
+
+	if options.FollowSeconds == 0 {
+		return result, nil
+	}
+
+	result.Chunks, err = a.follow(progress, logsDir, filters, offsets, time.Duration(options.FollowSeconds)*time.Second, cancelCh)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (a TailLogsAction) follow(
+	progress boshtask.ProgressFunc,
+	logsDir string,
+	filters []string,
+	offsets map[string]int64,
+	duration time.Duration,
+	cancelCh <-chan struct{},
+) ([]TailedLogChunk, error) {
+	chunks := []TailedLogChunk{}
+	chunksBytes := 0
+	sequence := 0
+
+	deadline := a.timeService.Now().Add(duration)
+
+	progress(boshtask.Progress{Stage: "Following logs", Data: json.RawMessage(`[]`)})
+
+	for {
+		wait := deadline.Sub(a.timeService.Now())
+		if wait <= 0 {
+			return chunks, nil
+		}
+
+		if wait > tailLogsFollowInterval {
+			wait = tailLogsFollowInterval
+		}
+
+		select {
+		case <-cancelCh:
+			return chunks, errTailLogsCanceled
+		case <-a.timeService.After(wait):
+		}
+
+		paths, err := a.findLogs(logsDir, filters)
+		if err != nil {
+			return chunks, err
+		}
+
+		appended := false
+
+		for _, path := range paths {
+			// Logs created while following are read from the beginning
+			offset := offsets[path]
+
+			content, size, _, err := a.readLog(path, offset, tailLogsMaxBytes)
+			if err != nil {
+				return chunks, err
+			}
+
+			offsets[path] = size
+
+			if len(content) == 0 {
+				continue
+			}
+
+			sequence++
+			chunks = append(chunks, TailedLogChunk{
+				Sequence: sequence,
+				Path:     a.relativePath(logsDir, path),
+				Content:  string(content),
+			})
+			chunksBytes += len(content)
+			appended = true
+		}
+
+		// Keep at least the latest chunk even if it is too large by itself
+		for chunksBytes > tailLogsMaxBytes && len(chunks) > 1 {
+			chunksBytes -= len(chunks[0].Content)
+			chunks = chunks[1:]
+		}
+
+		if appended {
+			data, err := json.Marshal(chunks)
+			if err != nil {
+				return chunks, bosherr.WrapError(err, "Marshalling log chunks")
+			}
+
+			progress(boshtask.Progress{Stage: "Following logs", Current: int64(sequence), Data: data})
+		}
+	}
+}
+
+func (a TailLogsAction) logsDir(logType string, jobName string) (string, error) {
+	switch logType {
+	case "job":
+		if jobName == "" {
+			return a.settingsDir.LogsDir(), nil
+		}
+
+		if jobName != filepath.Base(jobName) || jobName == "." || jobName == ".." {
+			return "", bosherr.Errorf("Invalid job name '%s'", jobName)
+		}
+
+		return filepath.Join(a.settingsDir.LogsDir(), jobName), nil
+
+	case "agent":
+		if jobName != "" {
+			return "", bosherr.Error("Job name cannot be given for agent logs")
+		}
+
+		return a.settingsDir.AgentLogsDir(), nil
+
+	default:
+		return "", bosherr.Error("Invalid log type")
+	}
+}
+
+// findLogs returns sorted paths of files in logs dir matching any filter
+func (a TailLogsAction) findLogs(logsDir string, filters []string) ([]string, error) {
+	found := map[string]struct{}{}
+
+	for _, filter := range filters {
+		matches, err := a.fs.RecursiveGlob(filepath.Join(logsDir, filter))
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Finding logs matching '%s'", filter)
+		}
+
+		for _, match := range matches {
+			// Filters such as '../*' must not reach outside of logs dir
+			if !strings.HasPrefix(match, logsDir+string(filepath.Separator)) {
+				continue
+			}
+
+			info, err := a.fs.Stat(match)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			found[match] = struct{}{}
+		}
+	}
+
+	paths := make([]string, 0, len(found))
+	for path := range found {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// readLog returns up to maxBytes of content from the end of the file that follows offset
+// and current size of the file. Log is read from the beginning if it shrank since
+// it was likely rotated.
+func (a TailLogsAction) readLog(path string, offset int64, maxBytes int64) ([]byte, int64, bool, error) {
+	file, err := a.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, 0, false, bosherr.WrapErrorf(err, "Opening log %s", path)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, false, bosherr.WrapErrorf(err, "Checking size of log %s", path)
+	}
+
+	size := info.Size()
+
+	if size < offset {
+		offset = 0
+	}
+
+	truncated := false
+	if size-offset > maxBytes {
+		offset = size - maxBytes
+		truncated = true
+	}
+
+	content := make([]byte, size-offset)
+
+	n, err := file.ReadAt(content, offset)
+	if err != nil && err != io.EOF {
+		return nil, 0, false, bosherr.WrapErrorf(err, "Reading log %s", path)
+	}
+
+	return content[:n], offset + int64(n), truncated, nil
+}
+
+func (a TailLogsAction) relativePath(logsDir string, path string) string {
+	relPath, err := filepath.Rel(logsDir, path)
+	if err != nil {
+		return path
+	}
+
+	return filepath.ToSlash(relPath)
+}
+
+// lastLinesStart returns index at which last given number of lines start
+func lastLinesStart(content []byte, lines int) int {
+	end := len(content)
+	if end > 0 && content[end-1] == '\n' {
+		end--
+	}
+
+	count := 0
+
+	for i := end - 1; i >= 0; i-- {
+		if content[i] == '\n' {
+			count++
+			if count == lines {
+				return i + 1
+			}
+		}
+	}
+
+	return 0
+}
+
+func (a TailLogsAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a TailLogsAction) Cancel() error {
+	// Cancelling when no logs are being tailed is a no-op
+	a.cancel.Cancel()
+	return nil
+}
@@ -0,0 +1,278 @@
+package action_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("TailLogsAction", func() {
+	var (
+		baseDir     string
+		dirProvider boshdirs.Provider
+		timeService *fakeclock.FakeClock
+		action      TailLogsAction
+	)
+
+	BeforeEach(func() {
+		var err error
+		baseDir, err = ioutil.TempDir("", "tail-logs")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		dirProvider = boshdirs.NewProvider(baseDir)
+		timeService = fakeclock.NewFakeClock(time.Now())
+		action = NewTailLogs(boshsys.NewOsFileSystem(logger), dirProvider, timeService, logger)
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(baseDir)
+	})
+
+	writeLog := func(path string, content string) {
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	appendLog := func(path string, content string) {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		_, err = file.WriteString(content)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	numberedLines := func(from, to int) string {
+		var lines []string
+		for i := from; i <= to; i++ {
+			lines = append(lines, fmt.Sprintf("line %d", i))
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	AssertActionIsAsynchronous(action)
+	AssertActionIsNotPersistent(action)
+	AssertActionIsLoggable(action)
+
+	AssertActionIsNotResumable(action)
+
+	Describe("Run", func() {
+		BeforeEach(func() {
+			writeLog(filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-job.stdout.log"), "out 1\nout 2\nout 3\n")
+			writeLog(filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-job.stderr.log"), "err 1\nerr 2\nerr 3\n")
+			writeLog(filepath.Join(dirProvider.LogsDir(), "other-job", "other-job.stderr.log"), "other err\n")
+			writeLog(filepath.Join(dirProvider.AgentLogsDir(), "current"), "agent 1\nagent 2\n")
+		})
+
+		It("returns last lines of job logs matching filters", func() {
+			result, err := action.Run(boshtask.NoopProgressFunc, "job", "fake-job", TailLogsOptions{
+				Filters: []string{"*.stderr.log"},
+				Lines:   2,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Files).To(Equal([]TailedLogFile{
+				{Path: "fake-job.stderr.log", Content: "err 2\nerr 3\n", Truncated: true},
+			}))
+			Expect(result.Chunks).To(BeEmpty())
+		})
+
+		It("returns all job logs when job name and filters are not given", func() {
+			result, err := action.Run(boshtask.NoopProgressFunc, "job", "", TailLogsOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Files).To(Equal([]TailedLogFile{
+				{Path: "fake-job/fake-job.stderr.log", Content: "err 1\nerr 2\nerr 3\n"},
+				{Path: "fake-job/fake-job.stdout.log", Content: "out 1\nout 2\nout 3\n"},
+				{Path: "other-job/other-job.stderr.log", Content: "other err\n"},
+			}))
+		})
+
+		It("returns last 200 lines by default", func() {
+			writeLog(filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-job.stdout.log"), numberedLines(1, 300))
+
+			result, err := action.Run(boshtask.NoopProgressFunc, "job", "fake-job", TailLogsOptions{Filters: []string{"*.stdout.log"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Files[0].Content).To(Equal(numberedLines(101, 300)))
+			Expect(result.Files[0].Truncated).To(BeTrue())
+		})
+
+		It("returns at most given number of bytes from the end of each log", func() {
+			result, err := action.Run(boshtask.NoopProgressFunc, "job", "fake-job", TailLogsOptions{Bytes: 4})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Files).To(Equal([]TailedLogFile{
+				{Path: "fake-job.stderr.log", Content: "r 3\n", Truncated: true},
+				{Path: "fake-job.stdout.log", Content: "t 3\n", Truncated: true},
+			}))
+		})
+
+		It("returns agent logs", func() {
+			result, err := action.Run(boshtask.NoopProgressFunc, "agent", "", TailLogsOptions{Lines: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result.Files).To(Equal([]TailedLogFile{
+				{Path: "current", Content: "agent 2\n", Truncated: true},
+			}))
+		})
+
+		It("does not return files outside of logs directory", func() {
+			result, err := action.Run(boshtask.NoopProgressFunc, "job", "fake-job", TailLogsOptions{Filters: []string{"../other-job/*"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Files).To(BeEmpty())
+
+			_, err = action.Run(boshtask.NoopProgressFunc, "job", "..", TailLogsOptions{})
+			Expect(err).To(MatchError("Invalid job name '..'"))
+		})
+
+		It("returns error for invalid arguments", func() {
+			_, err := action.Run(boshtask.NoopProgressFunc, "other-logs", "", TailLogsOptions{})
+			Expect(err).To(MatchError("Invalid log type"))
+
+			_, err = action.Run(boshtask.NoopProgressFunc, "agent", "fake-job", TailLogsOptions{})
+			Expect(err).To(MatchError("Job name cannot be given for agent logs"))
+
+			_, err = action.Run(boshtask.NoopProgressFunc, "job", "fake-job", TailLogsOptions{Lines: -1})
+			Expect(err).To(MatchError("Lines and bytes must not be negative"))
+
+			_, err = action.Run(boshtask.NoopProgressFunc, "job", "fake-job", TailLogsOptions{FollowSeconds: 601})
+			Expect(err).To(MatchError("Follow duration must be between 0 and 600 seconds"))
+		})
+
+		Context("when following logs", func() {
+			var (
+				progressLock sync.Mutex
+				progresses   []boshtask.Progress
+				progressFunc boshtask.ProgressFunc
+			)
+
+			BeforeEach(func() {
+				progresses = nil
+				progressFunc = func(progress boshtask.Progress) {
+					progressLock.Lock()
+					defer progressLock.Unlock()
+					progresses = append(progresses, progress)
+				}
+			})
+
+			lastProgress := func() boshtask.Progress {
+				progressLock.Lock()
+				defer progressLock.Unlock()
+				return progresses[len(progresses)-1]
+			}
+
+			run := func(options TailLogsOptions) (chan TailLogsResult, chan error) {
+				resultCh := make(chan TailLogsResult, 1)
+				errCh := make(chan error, 1)
+
+				go func() {
+					defer GinkgoRecover()
+					result, err := action.Run(progressFunc, "job", "fake-job", options)
+					resultCh <- result
+					errCh <- err
+				}()
+
+				return resultCh, errCh
+			}
+
+			It("reports appended content in task progress and returns it when done", func() {
+				resultCh, errCh := run(TailLogsOptions{Filters: []string{"*.stdout.log"}, Lines: 1, FollowSeconds: 2})
+
+				timeService.WaitForWatcherAndIncrement(time.Second)
+				appendLog(filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-job.stdout.log"), "out 4\n")
+
+				timeService.WaitForWatcherAndIncrement(time.Second)
+
+				var result TailLogsResult
+				Eventually(resultCh).Should(Receive(&result))
+				Expect(<-errCh).ToNot(HaveOccurred())
+
+				Expect(result.Files).To(Equal([]TailedLogFile{
+					{Path: "fake-job.stdout.log", Content: "out 3\n", Truncated: true},
+				}))
+				Expect(result.Chunks).To(Equal([]TailedLogChunk{
+					{Sequence: 1, Path: "fake-job.stdout.log", Content: "out 4\n"},
+				}))
+
+				progress := lastProgress()
+				Expect(progress.Stage).To(Equal("Following logs"))
+				Expect(progress.Current).To(Equal(int64(1)))
+
+				var chunks []TailedLogChunk
+				Expect(json.Unmarshal(progress.Data, &chunks)).To(Succeed())
+				Expect(chunks).To(Equal(result.Chunks))
+			})
+
+			It("reads logs created while following from the beginning", func() {
+				resultCh, _ := run(TailLogsOptions{FollowSeconds: 1})
+
+				Eventually(timeService.WatcherCount).Should(Equal(1))
+				writeLog(filepath.Join(dirProvider.LogsDir(), "fake-job", "new.log"), "new 1\n")
+				timeService.Increment(time.Second)
+
+				var result TailLogsResult
+				Eventually(resultCh).Should(Receive(&result))
+				Expect(result.Chunks).To(Equal([]TailedLogChunk{
+					{Sequence: 1, Path: "new.log", Content: "new 1\n"},
+				}))
+			})
+
+			It("reads rotated logs from the beginning", func() {
+				resultCh, _ := run(TailLogsOptions{Filters: []string{"*.stdout.log"}, FollowSeconds: 1})
+
+				Eventually(timeService.WatcherCount).Should(Equal(1))
+				writeLog(filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-job.stdout.log"), "rotated\n")
+				timeService.Increment(time.Second)
+
+				var result TailLogsResult
+				Eventually(resultCh).Should(Receive(&result))
+				Expect(result.Chunks).To(Equal([]TailedLogChunk{
+					{Sequence: 1, Path: "fake-job.stdout.log", Content: "rotated\n"},
+				}))
+			})
+
+			It("stops following when cancelled", func() {
+				_, errCh := run(TailLogsOptions{FollowSeconds: 60})
+
+				Eventually(timeService.WatcherCount).Should(Equal(1))
+				Expect(action.Cancel()).To(Succeed())
+
+				Eventually(errCh).Should(Receive(MatchError("Tailing logs was cancelled by user request")))
+			})
+
+			It("ignores cancel requests made while no logs are being tailed", func() {
+				_, err := action.Run(progressFunc, "job", "fake-job", TailLogsOptions{Lines: 1})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(action.Cancel()).To(Succeed())
+
+				_, errCh := run(TailLogsOptions{FollowSeconds: 1})
+
+				Eventually(timeService.WatcherCount).Should(Equal(1))
+				timeService.Increment(time.Second)
+
+				Eventually(errCh).Should(Receive(BeNil()))
+			})
+		})
+	})
+})
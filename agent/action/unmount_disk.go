@@ -35,6 +35,11 @@ func (a UnmountDiskAction) IsLoggable() bool {
 	return true
 }
 
+// Jobs write to the persistent disk so it is not changed while any job action runs
+func (a UnmountDiskAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupDisks, ConcurrencyGroupJobs}
+}
+
 func (a UnmountDiskAction) Run(diskID string) (value interface{}, err error) {
 	diskSettings, err := a.settingsService.GetPersistentDiskSettings(diskID)
 	if err != nil {
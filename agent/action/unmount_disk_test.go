@@ -67,6 +67,7 @@ var _ = Describe("UnmountDiskAction", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupDisks, ConcurrencyGroupJobs)
 
 	AssertActionIsNotResumable(action)
 	AssertActionIsNotCancelable(action)
@@ -40,6 +40,11 @@ func (a UpdateSettingsAction) IsLoggable() bool {
 	return true
 }
 
+// Settings include persistent disk associations used by disk actions
+func (a UpdateSettingsAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return []ConcurrencyGroup{ConcurrencyGroupDisks}
+}
+
 func (a UpdateSettingsAction) Run(newUpdateSettings boshsettings.UpdateSettings) (string, error) {
 	err := a.settingsService.LoadSettings()
 	if err != nil {
@@ -46,6 +46,7 @@ var _ = Describe("UpdateSettings", func() {
 	AssertActionIsAsynchronous(action)
 	AssertActionIsNotPersistent(action)
 	AssertActionIsLoggable(action)
+	AssertActionIsInConcurrencyGroups(action, ConcurrencyGroupDisks)
 
 	AssertActionIsNotResumable(action)
 	AssertActionIsNotCancelable(action)
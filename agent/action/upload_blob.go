@@ -37,6 +37,10 @@ func (a UploadBlobAction) IsLoggable() bool {
 	return false
 }
 
+func (a UploadBlobAction) ConcurrencyGroups() []ConcurrencyGroup {
+	return nil
+}
+
 func (a UploadBlobAction) Run(content UploadBlobSpec) (string, error) {
 
 	decodedPayload, err := base64.StdEncoding.DecodeString(content.Payload)
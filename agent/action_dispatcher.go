@@ -21,6 +21,7 @@ type concreteActionDispatcher struct {
 	taskManager   boshtask.Manager
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
+	requestCache  *RequestCache
 }
 
 func NewActionDispatcher(
@@ -29,6 +30,7 @@ func NewActionDispatcher(
 	taskManager boshtask.Manager,
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
+	requestCache *RequestCache,
 ) (dispatcher ActionDispatcher) {
 	return concreteActionDispatcher{
 		logger:        logger,
@@ -36,6 +38,7 @@ func NewActionDispatcher(
 		taskManager:   taskManager,
 		actionFactory: actionFactory,
 		actionRunner:  actionRunner,
+		requestCache:  requestCache,
 	}
 }
 
@@ -62,23 +65,58 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 
 		taskID := taskInfo.TaskID
 		payload := taskInfo.Payload
+		protocolVersion := boshaction.ProtocolVersion(taskInfo.ProtocolVersion)
+
+		// Steps completed before agent restart are skipped by resumed task
+		checkpoint := boshtask.NewCheckpoint(dispatcher.taskManager, taskID, taskInfo.Checkpoints)
+
+		reportProgress := func(progress boshtask.Progress) {
+			dispatcher.taskService.UpdateProgress(taskID, progress)
+		}
 
 		task := dispatcher.taskService.CreateTaskWithID(
 			taskID,
-			func() (interface{}, error) { return dispatcher.actionRunner.Resume(action, payload) },
+			func() (interface{}, error) {
+				return dispatcher.actionRunner.Resume(action, payload, protocolVersion, reportProgress, checkpoint)
+			},
 			func(_ boshtask.Task) error { return action.Cancel() },
 			dispatcher.removeInfo,
 		)
+		task.Method = taskInfo.Method
+		task.ConcurrencyGroups = concurrencyGroups(action)
 
 		dispatcher.taskService.StartTask(task)
 	}
 }
 
 func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) boshhandler.Response {
+	if req.IdempotencyKey == "" {
+		resp, _, _ := dispatcher.dispatch(req)
+		return resp
+	}
+
+	// Requests retried by API consumers (e.g. after a timeout)
+	// should not execute the same action again
+	cached, found := dispatcher.requestCache.Begin(req.IdempotencyKey, req.Method)
+	if found {
+		return dispatcher.dispatchCachedRequest(req, cached)
+	}
+
+	resp, taskID, succeeded := dispatcher.dispatch(req)
+	if succeeded {
+		dispatcher.requestCache.Complete(req.IdempotencyKey, taskID, resp)
+	} else {
+		dispatcher.requestCache.Release(req.IdempotencyKey, resp)
+	}
+
+	return resp
+}
+
+func (dispatcher concreteActionDispatcher) dispatch(req boshhandler.Request) (boshhandler.Response, string, bool) {
 	action, err := dispatcher.actionFactory.Create(req.Method)
 	if err != nil {
 		dispatcher.logger.Error(actionDispatcherLogTag, "Unknown action %s", req.Method)
-		return boshhandler.NewExceptionResponse(bosherr.Errorf("unknown message %s", req.Method))
+		return boshhandler.NewExceptionResponse(bosherr.Errorf("unknown message %s", req.Method)), "", false
 	}
 
 	dispatcher.logger.Info(actionDispatcherLogTag, "Received request with action %s", req.Method)
@@ -93,17 +131,46 @@ func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) bos
 	return dispatcher.dispatchSynchronousAction(action, req)
 }
 
+func (dispatcher concreteActionDispatcher) dispatchCachedRequest(
+	req boshhandler.Request,
+	cached CachedRequest,
+) boshhandler.Response {
+	if cached.Method != req.Method {
+		err := bosherr.Errorf("Idempotency key '%s' was already used for %s", req.IdempotencyKey, cached.Method)
+		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+		return boshhandler.NewExceptionResponse(err)
+	}
+
+	dispatcher.logger.Info(actionDispatcherLogTag,
+		"Received duplicate request with action %s and idempotency key '%s'", req.Method, req.IdempotencyKey)
+
+	if cached.TaskID != "" {
+		task, found := dispatcher.taskService.FindTaskWithID(cached.TaskID)
+		if found && (task.State == boshtask.StateRunning || task.State == boshtask.StateQueued) {
+			return boshhandler.NewValueResponse(
+				boshaction.NewTaskStateValue(task, boshaction.ProtocolVersion(req.ProtocolVersion)))
+		}
+	}
+
+	return cached.Response
+}
+
 func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
-) boshhandler.Response {
+) (boshhandler.Response, string, bool) {
 	dispatcher.logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
 
 	var task boshtask.Task
+	var checkpoint boshtask.Checkpoint
 	var err error
 
+	reportProgress := func(progress boshtask.Progress) {
+		dispatcher.taskService.UpdateProgress(task.ID, progress)
+	}
+
 	runTask := func() (interface{}, error) {
-		return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+		return dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion), reportProgress, checkpoint)
 	}
 
 	cancelTask := func(_ boshtask.Task) error { return action.Cancel() }
@@ -117,52 +184,76 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err)
+			return boshhandler.NewExceptionResponse(err), "", false
 		}
 
 		taskInfo := boshtask.Info{
-			TaskID:  task.ID,
-			Method:  req.Method,
-			Payload: req.GetPayload(),
+			TaskID:          task.ID,
+			Method:          req.Method,
+			Payload:         req.GetPayload(),
+			ProtocolVersion: int(req.ProtocolVersion),
 		}
 
 		err = dispatcher.taskManager.AddInfo(taskInfo)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err)
+			return boshhandler.NewExceptionResponse(err), "", false
 		}
+
+		checkpoint = boshtask.NewCheckpoint(dispatcher.taskManager, task.ID, nil)
 	} else {
 		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, nil)
 		if err != nil {
 			err = bosherr.WrapErrorf(err, "Create Task Failed %s", req.Method)
 			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err)
+			return boshhandler.NewExceptionResponse(err), "", false
 		}
 	}
 
+	task.Method = req.Method
+	task.ConcurrencyGroups = concurrencyGroups(action)
+
 	dispatcher.taskService.StartTask(task)
 
-	return boshhandler.NewValueResponse(boshtask.StateValue{
-		AgentTaskID: task.ID,
-		State:       task.State,
-	})
+	// Task service decides whether task runs right away or is queued
+	if startedTask, found := dispatcher.taskService.FindTaskWithID(task.ID); found {
+		task = startedTask
+	}
+
+	return boshhandler.NewValueResponse(
+		boshaction.NewTaskStateValue(task, boshaction.ProtocolVersion(req.ProtocolVersion))), task.ID, true
 }
 
 func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
-) boshhandler.Response {
+) (boshhandler.Response, string, bool) {
 	dispatcher.logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
 
-	value, err := dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion))
+	value, err := dispatcher.actionRunner.Run(action, req.GetPayload(), boshaction.ProtocolVersion(req.ProtocolVersion), nil, nil)
 	if err != nil {
 		err = bosherr.WrapErrorf(err, "Action Failed %s", req.Method)
 		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-		return boshhandler.NewExceptionResponse(err)
+		return boshhandler.NewExceptionResponse(err), "", false
 	}
 
-	return boshhandler.NewValueResponse(value)
+	return boshhandler.NewValueResponse(value), "", true
+}
+
+// concurrencyGroups puts asynchronous actions that do not declare any group
+// into the default group so that they keep running one at a time
+func concurrencyGroups(action boshaction.Action) []string {
+	actionGroups := action.ConcurrencyGroups()
+	if len(actionGroups) == 0 {
+		actionGroups = []boshaction.ConcurrencyGroup{boshaction.ConcurrencyGroupDefault}
+	}
+
+	var groups []string
+	for _, group := range actionGroups {
+		groups = append(groups, string(group))
+	}
+	return groups
 }
 
 func (dispatcher concreteActionDispatcher) removeInfo(task boshtask.Task) {
@@ -4,18 +4,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "github.com/cloudfoundry/bosh-agent/agent"
 	"github.com/cloudfoundry/bosh-agent/agent/action"
 	fakeaction "github.com/cloudfoundry/bosh-agent/agent/action/fakes"
+	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
 	"github.com/cloudfoundry/bosh-agent/logger/fakes"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
 	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 func init() {
@@ -26,6 +37,7 @@ func init() {
 			taskManager   *faketask.FakeManager
 			actionFactory *fakeaction.FakeFactory
 			actionRunner  *fakeaction.FakeRunner
+			timeService   *fakeclock.FakeClock
 			dispatcher    ActionDispatcher
 		)
 
@@ -35,7 +47,9 @@ func init() {
 			taskManager = faketask.NewFakeManager()
 			actionFactory = fakeaction.NewFakeFactory()
 			actionRunner = &fakeaction.FakeRunner{}
-			dispatcher = NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner)
+			timeService = fakeclock.NewFakeClock(time.Now())
+			requestCache := NewRequestCache(10*time.Minute, timeService)
+			dispatcher = NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner, requestCache)
 		})
 
 		It("responds with exception when the method is unknown", func() {
@@ -181,6 +195,103 @@ func init() {
 			})
 		})
 
+		Context("when compile_package is dispatched while another compilation is running", func() {
+			var (
+				compiler *exclusiveCompiler
+				req      boshhandler.Request
+			)
+
+			BeforeEach(func() {
+				compiler = &exclusiveCompiler{releaseCh: make(chan struct{})}
+				compilePackageFactory := actionsByMethod{"compile_package": action.NewCompilePackage(compiler)}
+
+				taskService := boshtask.NewAsyncTaskService(
+					boshuuid.NewGenerator(),
+					faketask.NewFakeHistory(),
+					timeService,
+					boshlog.NewLogger(boshlog.LevelNone),
+				)
+				requestCache := NewRequestCache(10*time.Minute, timeService)
+				dispatcher = NewActionDispatcher(logger, taskService, taskManager, compilePackageFactory, action.NewRunner(), requestCache)
+
+				payload := `{"arguments":["fake-blob-id","da39a3ee5e6b4b0d3255bfef95601890afd80709","fake-name","fake-version",{}]}`
+				req = boshhandler.NewRequest("fake-reply", "compile_package", []byte(payload), 4)
+			})
+
+			It("queues it until the running compilation finishes", func() {
+				dispatcher.Dispatch(req)
+				Eventually(compiler.StartedCount).Should(Equal(1))
+
+				respJSON, err := json.Marshal(dispatcher.Dispatch(req))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(respJSON)).To(ContainSubstring(`"state":"queued"`))
+
+				Consistently(compiler.StartedCount).Should(Equal(1))
+
+				close(compiler.releaseCh)
+				Eventually(compiler.FinishedCount).Should(Equal(2))
+				Expect(compiler.MaxRunningCount()).To(Equal(1))
+			})
+
+			It("reports queued task as running to clients older than protocol 4 since they consider any other state to be final", func() {
+				dispatcher.Dispatch(req)
+				Eventually(compiler.StartedCount).Should(Equal(1))
+
+				req.ProtocolVersion = 3
+				resp := dispatcher.Dispatch(req)
+				close(compiler.releaseCh)
+
+				respJSON, err := json.Marshal(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(respJSON)).To(ContainSubstring(`"state":"running"`))
+				Eventually(compiler.FinishedCount).Should(Equal(2))
+			})
+		})
+
+		Context("when unmount_disk is dispatched while jobs are being stopped", func() {
+			var (
+				jobSupervisor *blockingJobSupervisor
+				platform      *platformfakes.FakePlatform
+			)
+
+			BeforeEach(func() {
+				jobSupervisor = &blockingJobSupervisor{
+					FakeJobSupervisor: fakejobsuper.NewFakeJobSupervisor(),
+					releaseCh:         make(chan struct{}),
+				}
+				platform = &platformfakes.FakePlatform{}
+
+				factory := actionsByMethod{
+					"stop":         action.NewStop(jobSupervisor),
+					"unmount_disk": action.NewUnmountDisk(&fakesettings.FakeSettingsService{}, platform),
+				}
+
+				taskService := boshtask.NewAsyncTaskService(
+					boshuuid.NewGenerator(),
+					faketask.NewFakeHistory(),
+					timeService,
+					boshlog.NewLogger(boshlog.LevelNone),
+				)
+				requestCache := NewRequestCache(10*time.Minute, timeService)
+				dispatcher = NewActionDispatcher(logger, taskService, taskManager, factory, action.NewRunner(), requestCache)
+			})
+
+			It("queues it until jobs are stopped so that persistent disk is not unmounted while jobs use it", func() {
+				dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "stop", []byte(`{"arguments":[]}`), 4))
+				Eventually(jobSupervisor.StopCalled).Should(BeTrue())
+
+				resp := dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "unmount_disk", []byte(`{"arguments":["fake-disk-cid"]}`), 4))
+				respJSON, err := json.Marshal(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(respJSON)).To(ContainSubstring(`"state":"queued"`))
+
+				Consistently(platform.UnmountPersistentDiskCallCount).Should(Equal(0))
+
+				close(jobSupervisor.releaseCh)
+				Eventually(platform.UnmountPersistentDiskCallCount).Should(Equal(1))
+			})
+		})
+
 		Context("when action is asynchronous", func() {
 			var (
 				req    boshhandler.Request
@@ -230,6 +341,33 @@ func init() {
 					Expect(taskService.StartedTasks["fake-generated-task-id"]).ToNot(BeNil())
 				})
 
+				It("records request method on the task", func() {
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks["fake-generated-task-id"].Method).To(Equal(req.Method))
+				})
+
+				It("records concurrency groups of the action on the task so that it is queued behind conflicting tasks", func() {
+					action.Groups = append(action.Groups, "jobs")
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks["fake-generated-task-id"].ConcurrencyGroups).To(Equal([]string{"jobs"}))
+				})
+
+				It("records default concurrency group on the task when action does not declare any", func() {
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks["fake-generated-task-id"].ConcurrencyGroups).To(Equal([]string{"default"}))
+				})
+
+				It("records progress reported by the action on the task", func() {
+					dispatcher.Dispatch(req)
+
+					_, err := taskService.StartedTasks["fake-generated-task-id"].Func()
+					Expect(err).ToNot(HaveOccurred())
+
+					actionRunner.RunProgress(boshtask.Progress{Stage: "fake-stage", Current: 3, Total: 7})
+					Expect(taskService.StartedTasks["fake-generated-task-id"].Progress).To(Equal(
+						&boshtask.Progress{Stage: "fake-stage", Current: 3, Total: 7}))
+				})
+
 				It("returns create task error", func() {
 					taskService.CreateTaskErr = errors.New("fake-create-task-error")
 					resp := dispatcher.Dispatch(req)
@@ -265,6 +403,14 @@ func init() {
 
 				ItAllowsToCancelTask()
 
+				It("does not give checkpoint to the task since it is not resumed", func() {
+					dispatcher.Dispatch(req)
+
+					_, err := taskService.StartedTasks["fake-generated-task-id"].Func()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(actionRunner.RunCheckpoint).To(BeNil())
+				})
+
 				It("does not add task to task manager since it should not be resumed if agent is restarted", func() {
 					dispatcher.Dispatch(req)
 					taskInfos, _ := taskManager.GetInfos()
@@ -341,6 +487,27 @@ func init() {
 					}))
 				})
 
+				It("records protocol version with the task so that it can be resumed with it", func() {
+					req = boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 3)
+
+					dispatcher.Dispatch(req)
+					taskInfos, _ := taskManager.GetInfos()
+					Expect(taskInfos[0].ProtocolVersion).To(Equal(3))
+				})
+
+				It("gives the task checkpoint that records completed steps with the task", func() {
+					dispatcher.Dispatch(req)
+
+					_, err := taskService.StartedTasks["fake-generated-task-id"].Func()
+					Expect(err).ToNot(HaveOccurred())
+
+					err = actionRunner.RunCheckpoint.Complete("fake-step", "fake-result")
+					Expect(err).ToNot(HaveOccurred())
+
+					taskInfos, _ := taskManager.GetInfos()
+					Expect(string(taskInfos[0].Checkpoints["fake-step"])).To(Equal(`"fake-result"`))
+				})
+
 				It("removes task from task manager after task finishes", func() {
 					dispatcher.Dispatch(req)
 					taskService.StartedTasks["fake-generated-task-id"].EndFunc(boshtask.Task{ID: "fake-generated-task-id"})
@@ -361,6 +528,143 @@ func init() {
 			})
 		})
 
+		Context("when request has idempotency key", func() {
+			var (
+				req    boshhandler.Request
+				action *fakeaction.TestAction
+			)
+
+			BeforeEach(func() {
+				req = boshhandler.NewRequest("fake-reply", "fake-action", []byte("fake-payload"), 0)
+				req.IdempotencyKey = "fake-idempotency-key"
+				action = &fakeaction.TestAction{}
+				actionFactory.RegisterAction("fake-action", action)
+			})
+
+			Context("when action is synchronous", func() {
+				It("returns previous response without running action again", func() {
+					actionRunner.RunValue = "fake-value"
+					resp := dispatcher.Dispatch(req)
+					Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+
+					actionRunner.RunValue = "fake-other-value"
+					actionRunner.RunPayload = nil
+					resp = dispatcher.Dispatch(req)
+					Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+					Expect(actionRunner.RunPayload).To(BeNil())
+				})
+
+				It("runs action again after idempotency window expires", func() {
+					actionRunner.RunValue = "fake-value"
+					dispatcher.Dispatch(req)
+
+					timeService.Increment(10 * time.Minute)
+
+					actionRunner.RunValue = "fake-other-value"
+					resp := dispatcher.Dispatch(req)
+					Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-other-value")))
+				})
+
+				It("runs action again if previous request failed so that it could be retried", func() {
+					actionRunner.RunErr = errors.New("fake-run-error")
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"exception":{"message":"Action Failed fake-action: fake-run-error"}}`)
+
+					actionRunner.RunErr = nil
+					actionRunner.RunValue = "fake-value"
+					resp = dispatcher.Dispatch(req)
+					Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+				})
+
+				It("runs action for requests with different idempotency keys", func() {
+					actionRunner.RunValue = "fake-value"
+					dispatcher.Dispatch(req)
+
+					req.IdempotencyKey = "fake-other-idempotency-key"
+					actionRunner.RunValue = "fake-other-value"
+					resp := dispatcher.Dispatch(req)
+					Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-other-value")))
+				})
+
+				It("runs action for every request without idempotency key", func() {
+					req.IdempotencyKey = ""
+
+					actionRunner.RunValue = "fake-value"
+					dispatcher.Dispatch(req)
+
+					actionRunner.RunValue = "fake-other-value"
+					resp := dispatcher.Dispatch(req)
+					Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-other-value")))
+				})
+
+				It("responds with exception when idempotency key was used for a different method", func() {
+					dispatcher.Dispatch(req)
+
+					actionFactory.RegisterAction("fake-other-action", &fakeaction.TestAction{})
+					req.Method = "fake-other-action"
+
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"exception":{"message":"Idempotency key 'fake-idempotency-key' was already used for fake-action"}}`)
+				})
+			})
+
+			Context("when action is asynchronous", func() {
+				BeforeEach(func() {
+					action.Asynchronous = true
+				})
+
+				It("responds with current state of previously created task without creating another one", func() {
+					req.ProtocolVersion = 4
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(HaveLen(1))
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					task.State = boshtask.StateQueued
+					taskService.StartedTasks["fake-generated-task-id"] = task
+
+					taskService.CreateTaskErr = errors.New("fake-create-task-error")
+
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"value":{"agent_task_id":"fake-generated-task-id","state":"queued"}}`)
+				})
+
+				It("responds with previous response if task has finished so that its result is fetched with get_task", func() {
+					dispatcher.Dispatch(req)
+
+					task := taskService.StartedTasks["fake-generated-task-id"]
+					task.State = boshtask.StateDone
+					taskService.StartedTasks["fake-generated-task-id"] = task
+
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"value":{"agent_task_id":"fake-generated-task-id","state":"running"}}`)
+				})
+
+				It("responds with previous response if task is no longer known", func() {
+					dispatcher.Dispatch(req)
+					delete(taskService.StartedTasks, "fake-generated-task-id")
+
+					resp := dispatcher.Dispatch(req)
+					boshassert.MatchesJSONString(GinkgoT(), resp,
+						`{"value":{"agent_task_id":"fake-generated-task-id","state":"running"}}`)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+				})
+
+				It("creates task again if previous task could not be created", func() {
+					taskService.CreateTaskErr = errors.New("fake-create-task-error")
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(BeEmpty())
+
+					taskService.CreateTaskErr = nil
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks).To(HaveLen(1))
+				})
+			})
+		})
+
 		Describe("ResumePreviouslyDispatchedTasks", func() {
 			var firstAction, secondAction *fakeaction.TestAction
 
@@ -389,6 +693,8 @@ func init() {
 
 				dispatcher.ResumePreviouslyDispatchedTasks()
 				Expect(len(taskService.StartedTasks)).To(Equal(2))
+				Expect(taskService.StartedTasks["fake-task-id-1"].Method).To(Equal("fake-action-1"))
+				Expect(taskService.StartedTasks["fake-task-id-2"].Method).To(Equal("fake-action-2"))
 
 				{ // Check that first task executes first action
 					actionRunner.ResumeValue = "fake-resume-value-1"
@@ -409,6 +715,46 @@ func init() {
 				}
 			})
 
+			It("resumes task with its protocol version and previously completed steps", func() {
+				err := taskManager.AddInfo(boshtask.Info{
+					TaskID:          "fake-task-id-1",
+					Method:          "fake-action-1",
+					Payload:         []byte("fake-task-payload-1"),
+					ProtocolVersion: 3,
+					Checkpoints:     map[string]json.RawMessage{"fake-step": json.RawMessage(`"fake-result"`)},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				actionFactory.RegisterAction("fake-action-1", firstAction)
+				actionFactory.RegisterAction("fake-action-2", secondAction)
+
+				dispatcher.ResumePreviouslyDispatchedTasks()
+
+				_, err = taskService.StartedTasks["fake-task-id-1"].Func()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(actionRunner.ResumeProtocolVersion).To(Equal(action.ProtocolVersion(3)))
+
+				var result string
+				completed, err := actionRunner.ResumeCheckpoint.Get("fake-step", &result)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(completed).To(BeTrue())
+				Expect(result).To(Equal("fake-result"))
+			})
+
+			It("records progress reported by resumed task", func() {
+				actionFactory.RegisterAction("fake-action-1", firstAction)
+				actionFactory.RegisterAction("fake-action-2", secondAction)
+
+				dispatcher.ResumePreviouslyDispatchedTasks()
+
+				_, err := taskService.StartedTasks["fake-task-id-1"].Func()
+				Expect(err).ToNot(HaveOccurred())
+
+				actionRunner.ResumeProgress(boshtask.Progress{Stage: "fake-stage"})
+				Expect(taskService.StartedTasks["fake-task-id-1"].Progress).To(Equal(&boshtask.Progress{Stage: "fake-stage"}))
+			})
+
 			It("removes tasks from task manager after each task finishes", func() {
 				actionFactory.RegisterAction("fake-action-1", firstAction)
 				actionFactory.RegisterAction("fake-action-2", secondAction)
@@ -515,3 +861,88 @@ func init() {
 		})
 	})
 }
+
+type actionsByMethod map[string]action.Action
+
+func (f actionsByMethod) Create(method string) (action.Action, error) {
+	a, found := f[method]
+	if !found {
+		return nil, fmt.Errorf("unknown action %s", method)
+	}
+	return a, nil
+}
+
+// blockingJobSupervisor blocks stopping jobs until released
+type blockingJobSupervisor struct {
+	*fakejobsuper.FakeJobSupervisor
+	releaseCh chan struct{}
+
+	lock       sync.Mutex
+	stopCalled bool
+}
+
+func (s *blockingJobSupervisor) StopAndWait() error {
+	s.lock.Lock()
+	s.stopCalled = true
+	s.lock.Unlock()
+
+	<-s.releaseCh
+	return nil
+}
+
+func (s *blockingJobSupervisor) StopCalled() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stopCalled
+}
+
+// exclusiveCompiler blocks compilations until released
+// and records how many of them ran at the same time
+type exclusiveCompiler struct {
+	releaseCh chan struct{}
+
+	lock       sync.Mutex
+	started    int
+	finished   int
+	running    int
+	maxRunning int
+}
+
+func (c *exclusiveCompiler) Compile(boshcomp.Package, []boshmodels.Package, boshtask.ProgressFunc) (string, boshcrypto.Digest, error) {
+	c.lock.Lock()
+	c.started++
+	c.running++
+	if c.running > c.maxRunning {
+		c.maxRunning = c.running
+	}
+	c.lock.Unlock()
+
+	<-c.releaseCh
+
+	c.lock.Lock()
+	c.running--
+	c.finished++
+	c.lock.Unlock()
+
+	return "fake-compiled-blob-id", boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"), nil
+}
+
+func (c *exclusiveCompiler) Cancel() error { return nil }
+
+func (c *exclusiveCompiler) StartedCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.started
+}
+
+func (c *exclusiveCompiler) FinishedCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.finished
+}
+
+func (c *exclusiveCompiler) MaxRunningCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.maxRunning
+}
@@ -18,6 +18,10 @@ import (
 
 const (
 	agentLogTag = "agent"
+
+	// Summaries of held back alerts are sent at most this much
+	// after rate limit window of alert pipeline ends
+	alertFlushInterval = 10 * time.Second
 )
 
 //go:generate counterfeiter . CanRebooter
@@ -27,17 +31,19 @@ type CanRebooter interface {
 }
 
 type Agent struct {
-	logger            boshlog.Logger
-	mbusHandler       boshhandler.Handler
-	platform          boshplatform.Platform
-	actionDispatcher  ActionDispatcher
-	heartbeatInterval time.Duration
-	jobSupervisor     boshjobsuper.JobSupervisor
-	specService       boshas.V1Service
-	settingsService   boshsettings.Service
-	uuidGenerator     boshuuid.Generator
-	timeService       clock.Clock
-	canRebooter       CanRebooter
+	logger             boshlog.Logger
+	mbusHandler        boshhandler.Handler
+	platform           boshplatform.Platform
+	actionDispatcher   ActionDispatcher
+	heartbeatInterval  time.Duration
+	jobSupervisor      boshjobsuper.JobSupervisor
+	alertPipeline      boshalert.Pipeline
+	thresholdEvaluator boshalert.ThresholdEvaluator
+	specService        boshas.V1Service
+	settingsService    boshsettings.Service
+	uuidGenerator      boshuuid.Generator
+	timeService        clock.Clock
+	canRebooter        CanRebooter
 }
 
 func New(
@@ -46,6 +52,8 @@ func New(
 	platform boshplatform.Platform,
 	actionDispatcher ActionDispatcher,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	alertPipeline boshalert.Pipeline,
+	thresholdEvaluator boshalert.ThresholdEvaluator,
 	specService boshas.V1Service,
 	heartbeatInterval time.Duration,
 	settingsService boshsettings.Service,
@@ -54,17 +62,19 @@ func New(
 	canRebooter CanRebooter,
 ) Agent {
 	return Agent{
-		logger:            logger,
-		mbusHandler:       mbusHandler,
-		platform:          platform,
-		actionDispatcher:  actionDispatcher,
-		heartbeatInterval: heartbeatInterval,
-		jobSupervisor:     jobSupervisor,
-		specService:       specService,
-		settingsService:   settingsService,
-		uuidGenerator:     uuidGenerator,
-		timeService:       timeService,
-		canRebooter:       canRebooter,
+		logger:             logger,
+		mbusHandler:        mbusHandler,
+		platform:           platform,
+		actionDispatcher:   actionDispatcher,
+		heartbeatInterval:  heartbeatInterval,
+		jobSupervisor:      jobSupervisor,
+		alertPipeline:      alertPipeline,
+		thresholdEvaluator: thresholdEvaluator,
+		specService:        specService,
+		settingsService:    settingsService,
+		uuidGenerator:      uuidGenerator,
+		timeService:        timeService,
+		canRebooter:        canRebooter,
 	}
 }
 
@@ -85,6 +95,8 @@ func (a Agent) Run() error {
 
 	go a.generateHeartbeats(errCh)
 
+	go a.flushAlerts(errCh)
+
 	go func() {
 		err := a.jobSupervisor.MonitorJobFailures(a.handleJobFailure(errCh))
 		if err != nil {
@@ -137,6 +149,14 @@ func (a Agent) sendAndRecordHeartbeat(errCh chan error) {
 	if err != nil {
 		err = bosherr.WrapError(err, "Sending heartbeat")
 		errCh <- err
+		return
+	}
+
+	for _, alert := range a.thresholdEvaluator.Evaluate(heartbeat.Vitals) {
+		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Sending vitals alert")
+		}
 	}
 }
 
@@ -184,11 +204,34 @@ func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler
 			errCh <- bosherr.WrapError(err, "Adapting monit alert")
 		}
 
-		err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
-		if err != nil {
-			errCh <- bosherr.WrapError(err, "Sending monit alert")
+		alerts := a.alertPipeline.Filter(monitAlert, alert)
+		if len(alerts) == 0 {
+			a.logger.Debug(agentLogTag, "Held back monit alert %s", monitAlert.ID)
+		}
+
+		for _, alert := range alerts {
+			err = a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+			if err != nil {
+				errCh <- bosherr.WrapError(err, "Sending monit alert")
+			}
 		}
 
 		return nil
 	}
 }
+
+func (a Agent) flushAlerts(errCh chan error) {
+	defer a.logger.HandlePanic("Agent Flush Alerts")
+
+	ticker := a.timeService.NewTicker(alertFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C() {
+		for _, alert := range a.alertPipeline.Flush() {
+			err := a.mbusHandler.Send(boshhandler.HealthMonitor, boshhandler.Alert, alert)
+			if err != nil {
+				errCh <- bosherr.WrapError(err, "Sending alert summary")
+			}
+		}
+	}
+}
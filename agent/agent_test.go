@@ -12,6 +12,7 @@ import (
 	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/cloudfoundry/bosh-agent/agent/agentfakes"
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/agent/alert/alertfakes"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	fakeas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec/fakes"
 	fakeagent "github.com/cloudfoundry/bosh-agent/agent/fakes"
@@ -34,6 +35,8 @@ func init() {
 			platform         *platformfakes.FakePlatform
 			actionDispatcher *fakeagent.FakeActionDispatcher
 			jobSupervisor    *fakejobsuper.FakeJobSupervisor
+			alertPipeline    *alertfakes.FakePipeline
+			evaluator        *alertfakes.FakeThresholdEvaluator
 			specService      *fakeas.FakeV1Service
 			settingsService  *fakesettings.FakeSettingsService
 			uuidGenerator    *fakeuuid.FakeGenerator
@@ -50,6 +53,11 @@ func init() {
 			platform = &platformfakes.FakePlatform{}
 			actionDispatcher = &fakeagent.FakeActionDispatcher{}
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+			alertPipeline = &alertfakes.FakePipeline{}
+			evaluator = &alertfakes.FakeThresholdEvaluator{}
+			alertPipeline.FilterStub = func(_ boshalert.MonitAlert, alert boshalert.Alert) []boshalert.Alert {
+				return []boshalert.Alert{alert}
+			}
 			specService = fakeas.NewFakeV1Service()
 			settingsService = &fakesettings.FakeSettingsService{}
 			uuidGenerator = &fakeuuid.FakeGenerator{}
@@ -66,6 +74,8 @@ func init() {
 				platform,
 				actionDispatcher,
 				jobSupervisor,
+				alertPipeline,
+				evaluator,
 				specService,
 				5*time.Millisecond,
 				settingsService,
@@ -145,6 +155,8 @@ func init() {
 						platform,
 						actionDispatcher,
 						jobSupervisor,
+						alertPipeline,
+						evaluator,
 						specService,
 						5*time.Hour,
 						settingsService,
@@ -196,6 +208,36 @@ func init() {
 					Expect(jobSupervisor.GetHealthRecorded()).To(BeNumerically(">=", 3))
 				})
 
+				It("sends alerts for vitals crossing thresholds after heartbeat", func() {
+					vitalsAlert := boshalert.Alert{ID: "fake-vitals-alert", Severity: boshalert.SeverityCritical}
+					evaluator.EvaluateReturns([]boshalert.Alert{vitalsAlert})
+
+					handler.SendCallback = func(input fakembus.SendInput) {
+						if input.Topic == boshhandler.Alert {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Sending vitals alert: stop"))
+
+					Expect(handler.SendInputs()[:2]).To(Equal([]fakembus.SendInput{
+						{
+							Target:  boshhandler.HealthMonitor,
+							Topic:   boshhandler.Heartbeat,
+							Message: expectedHb,
+						},
+						{
+							Target:  boshhandler.HealthMonitor,
+							Topic:   boshhandler.Alert,
+							Message: vitalsAlert,
+						},
+					}))
+
+					Expect(evaluator.EvaluateArgsForCall(0)).To(Equal(expectedHb.Vitals))
+				})
+
 				Context("when the agent may not be rebooted", func() {
 					BeforeEach(func() {
 						canRebooter.CanRebootReturns(false, nil)
@@ -282,6 +324,57 @@ func init() {
 					Topic:   boshhandler.Alert,
 					Message: expectedAlert,
 				}))
+
+				Expect(alertPipeline.FilterCallCount()).To(Equal(1))
+				filteredMonitAlert, filteredAlert := alertPipeline.FilterArgsForCall(0)
+				Expect(filteredMonitAlert).To(Equal(monitAlert))
+				Expect(filteredAlert).To(Equal(expectedAlert))
+			})
+
+			Context("when alert pipeline holds back alerts", func() {
+				var summary boshalert.Alert
+
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+
+					jobSupervisor.JobFailureAlert = &boshalert.MonitAlert{
+						ID:      "fake-monit-alert",
+						Service: "fake-service",
+						Event:   "does not exist",
+						Action:  "restart",
+					}
+
+					summary = boshalert.Alert{ID: "fake-monit-alert.summary", Summary: "fake-summary"}
+
+					alertPipeline.FilterReturns(nil)
+					alertPipeline.FlushReturns([]boshalert.Alert{summary})
+					alertPipeline.FilterStub = nil
+
+					handler.SendCallback = func(input fakembus.SendInput) {
+						if input.Topic == boshhandler.Alert {
+							handler.SendErr = errors.New("stop")
+						}
+					}
+				})
+
+				It("periodically sends summaries of held back alerts", func() {
+					errCh := make(chan error)
+					go func() { errCh <- agent.Run() }()
+
+					Eventually(timeService.WatcherCount).Should(BeNumerically(">=", 1))
+					Consistently(handler.SendInputs).ShouldNot(ContainElement(
+						WithTransform(func(input fakembus.SendInput) boshhandler.Topic { return input.Topic }, Equal(boshhandler.Alert)),
+					))
+
+					timeService.Increment(10 * time.Second)
+
+					Eventually(errCh).Should(Receive(MatchError(ContainSubstring("Sending alert summary: stop"))))
+					Expect(handler.SendInputs()).To(ContainElement(fakembus.SendInput{
+						Target:  boshhandler.HealthMonitor,
+						Topic:   boshhandler.Alert,
+						Message: summary,
+					}))
+				})
 			})
 		})
 	})
@@ -0,0 +1,176 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package alertfakes
+
+import (
+	sync "sync"
+
+	alert "github.com/cloudfoundry/bosh-agent/agent/alert"
+)
+
+type FakePipeline struct {
+	FilterStub        func(alert.MonitAlert, alert.Alert) []alert.Alert
+	filterMutex       sync.RWMutex
+	filterArgsForCall []struct {
+		arg1 alert.MonitAlert
+		arg2 alert.Alert
+	}
+	filterReturns struct {
+		result1 []alert.Alert
+	}
+	filterReturnsOnCall map[int]struct {
+		result1 []alert.Alert
+	}
+	FlushStub        func() []alert.Alert
+	flushMutex       sync.RWMutex
+	flushArgsForCall []struct {
+	}
+	flushReturns struct {
+		result1 []alert.Alert
+	}
+	flushReturnsOnCall map[int]struct {
+		result1 []alert.Alert
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakePipeline) Filter(arg1 alert.MonitAlert, arg2 alert.Alert) []alert.Alert {
+	fake.filterMutex.Lock()
+	ret, specificReturn := fake.filterReturnsOnCall[len(fake.filterArgsForCall)]
+	fake.filterArgsForCall = append(fake.filterArgsForCall, struct {
+		arg1 alert.MonitAlert
+		arg2 alert.Alert
+	}{arg1, arg2})
+	fake.recordInvocation("Filter", []interface{}{arg1, arg2})
+	fake.filterMutex.Unlock()
+	if fake.FilterStub != nil {
+		return fake.FilterStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.filterReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakePipeline) FilterCallCount() int {
+	fake.filterMutex.RLock()
+	defer fake.filterMutex.RUnlock()
+	return len(fake.filterArgsForCall)
+}
+
+func (fake *FakePipeline) FilterCalls(stub func(alert.MonitAlert, alert.Alert) []alert.Alert) {
+	fake.filterMutex.Lock()
+	defer fake.filterMutex.Unlock()
+	fake.FilterStub = stub
+}
+
+func (fake *FakePipeline) FilterArgsForCall(i int) (alert.MonitAlert, alert.Alert) {
+	fake.filterMutex.RLock()
+	defer fake.filterMutex.RUnlock()
+	argsForCall := fake.filterArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakePipeline) FilterReturns(result1 []alert.Alert) {
+	fake.filterMutex.Lock()
+	defer fake.filterMutex.Unlock()
+	fake.FilterStub = nil
+	fake.filterReturns = struct {
+		result1 []alert.Alert
+	}{result1}
+}
+
+func (fake *FakePipeline) FilterReturnsOnCall(i int, result1 []alert.Alert) {
+	fake.filterMutex.Lock()
+	defer fake.filterMutex.Unlock()
+	fake.FilterStub = nil
+	if fake.filterReturnsOnCall == nil {
+		fake.filterReturnsOnCall = make(map[int]struct {
+			result1 []alert.Alert
+		})
+	}
+	fake.filterReturnsOnCall[i] = struct {
+		result1 []alert.Alert
+	}{result1}
+}
+
+func (fake *FakePipeline) Flush() []alert.Alert {
+	fake.flushMutex.Lock()
+	ret, specificReturn := fake.flushReturnsOnCall[len(fake.flushArgsForCall)]
+	fake.flushArgsForCall = append(fake.flushArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Flush", []interface{}{})
+	fake.flushMutex.Unlock()
+	if fake.FlushStub != nil {
+		return fake.FlushStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.flushReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakePipeline) FlushCallCount() int {
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	return len(fake.flushArgsForCall)
+}
+
+func (fake *FakePipeline) FlushCalls(stub func() []alert.Alert) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = stub
+}
+
+func (fake *FakePipeline) FlushReturns(result1 []alert.Alert) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = nil
+	fake.flushReturns = struct {
+		result1 []alert.Alert
+	}{result1}
+}
+
+func (fake *FakePipeline) FlushReturnsOnCall(i int, result1 []alert.Alert) {
+	fake.flushMutex.Lock()
+	defer fake.flushMutex.Unlock()
+	fake.FlushStub = nil
+	if fake.flushReturnsOnCall == nil {
+		fake.flushReturnsOnCall = make(map[int]struct {
+			result1 []alert.Alert
+		})
+	}
+	fake.flushReturnsOnCall[i] = struct {
+		result1 []alert.Alert
+	}{result1}
+}
+
+func (fake *FakePipeline) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.filterMutex.RLock()
+	defer fake.filterMutex.RUnlock()
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakePipeline) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ alert.Pipeline = new(FakePipeline)
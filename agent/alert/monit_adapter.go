@@ -47,18 +47,21 @@ func (m *monitAdapter) Alert() (Alert, error) {
 }
 
 func (m *monitAdapter) title() string {
-	settings := m.settingsService.GetSettings()
+	return title(m.settingsService, m.monitAlert.Service, m.monitAlert.Event, m.monitAlert.Action)
+}
+
+// title identifies VM by its IPs since alerts do not carry instance names
+func title(settingsService boshsettings.Service, service, event, action string) string {
+	settings := settingsService.GetSettings()
 
 	ips := settings.Networks.IPs()
 	sort.Strings(ips)
 
-	service := m.monitAlert.Service
-
 	if len(ips) > 0 {
 		service = fmt.Sprintf("%s (%s)", service, strings.Join(ips, ", "))
 	}
 
-	return fmt.Sprintf("%s - %s - %s", service, m.monitAlert.Event, m.monitAlert.Action)
+	return fmt.Sprintf("%s - %s - %s", service, event, action)
 }
 
 func (m *monitAdapter) createdAt() int64 {
@@ -112,6 +115,8 @@ var eventToSeverity = map[string]SeverityLevel{
 	"heartbeat succeeded":          SeverityIgnored,
 	"heartbeat changed":            SeverityWarning,
 	"heartbeat not changed":        SeverityIgnored,
+	"health check failed":          SeverityAlert,
+	"health check recovered":       SeverityWarning,
 	"icmp failed":                  SeverityCritical,
 	"icmp succeeded":               SeverityIgnored,
 	"icmp changed":                 SeverityWarning,
@@ -120,6 +125,7 @@ var eventToSeverity = map[string]SeverityLevel{
 	"monit instance succeeded":     SeverityIgnored,
 	"monit instance changed":       SeverityIgnored,
 	"monit instance not changed":   SeverityIgnored,
+	"oom killed":                   SeverityCritical,
 	"invalid type":                 SeverityError,
 	"type succeeded":               SeverityIgnored,
 	"type changed":                 SeverityWarning,
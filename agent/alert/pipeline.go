@@ -0,0 +1,327 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+)
+
+const (
+	DefaultDedupWindow     = 1 * time.Minute
+	DefaultRateLimit       = 10
+	DefaultRateLimitWindow = 10 * time.Minute
+	DefaultFlapRestarts    = 5
+	DefaultFlapWindow      = 5 * time.Minute
+)
+
+// PipelineOptions are read from agent config;
+// zero values are replaced with defaults
+type PipelineOptions struct {
+	// Alerts with the same title as an alert sent
+	// within this many seconds are held back
+	DedupWindowSeconds int
+
+	// At most RateLimit alerts of each service
+	// are sent within RateLimitWindowSeconds;
+	// held back alerts are summarized once per window
+	RateLimit              int
+	RateLimitWindowSeconds int
+
+	// Service is flapping when it is (re)started FlapRestarts times
+	// within FlapWindowSeconds; its alerts are held back until it
+	// has not been restarted for FlapWindowSeconds
+	FlapRestarts      int
+	FlapWindowSeconds int
+}
+
+func (o PipelineOptions) DedupWindow() time.Duration {
+	return secondsOrDefault(o.DedupWindowSeconds, DefaultDedupWindow)
+}
+
+func (o PipelineOptions) RateLimitWindow() time.Duration {
+	return secondsOrDefault(o.RateLimitWindowSeconds, DefaultRateLimitWindow)
+}
+
+func (o PipelineOptions) FlapWindow() time.Duration {
+	return secondsOrDefault(o.FlapWindowSeconds, DefaultFlapWindow)
+}
+
+func (o PipelineOptions) rateLimit() int {
+	if o.RateLimit > 0 {
+		return o.RateLimit
+	}
+	return DefaultRateLimit
+}
+
+func (o PipelineOptions) flapRestarts() int {
+	if o.FlapRestarts > 0 {
+		return o.FlapRestarts
+	}
+	return DefaultFlapRestarts
+}
+
+func secondsOrDefault(seconds int, defaultDuration time.Duration) time.Duration {
+	if seconds <= 0 {
+		return defaultDuration
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+//go:generate counterfeiter . Pipeline
+
+// Pipeline decides which alerts reach health monitor so that
+// a crash looping job does not flood it with similar alerts.
+type Pipeline interface {
+	// Filter returns alerts to send for adapted monit alert;
+	// there are none when alert is held back.
+	Filter(monitAlert MonitAlert, alert Alert) []Alert
+
+	// Flush returns summaries of alerts held back
+	// in rate limit windows that have ended
+	Flush() []Alert
+}
+
+type pipeline struct {
+	options     PipelineOptions
+	timeService clock.Clock
+
+	lock     sync.Mutex
+	services map[string]*serviceAlerts
+}
+
+type serviceAlerts struct {
+	lastSent map[string]time.Time
+
+	windowStart  time.Time
+	sentInWindow int
+
+	restarts []time.Time
+	flapping bool
+
+	held heldAlerts
+}
+
+type heldAlerts struct {
+	since time.Time
+	last  Alert
+
+	duplicates  int
+	rateLimited int
+	flapping    int
+	restarts    int
+
+	// Most severe level of held back alerts
+	severity SeverityLevel
+}
+
+func NewPipeline(options PipelineOptions, timeService clock.Clock) Pipeline {
+	return &pipeline{
+		options:     options,
+		timeService: timeService,
+		services:    map[string]*serviceAlerts{},
+	}
+}
+
+func (p *pipeline) Filter(monitAlert MonitAlert, alert Alert) []Alert {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.timeService.Now()
+	service := p.service(monitAlert.Service)
+
+	restart := isRestart(monitAlert)
+
+	if restart && p.recordRestart(service, now) {
+		service.hold(alert, restart, now, &service.held.flapping)
+		return []Alert{p.flappingAlert(monitAlert.Service, alert, now)}
+	}
+
+	if service.flapping {
+		service.hold(alert, restart, now, &service.held.flapping)
+		return nil
+	}
+
+	if sentAt, found := service.lastSent[alert.Title]; found && now.Sub(sentAt) < p.options.DedupWindow() {
+		service.hold(alert, restart, now, &service.held.duplicates)
+		return nil
+	}
+
+	if now.Sub(service.windowStart) >= p.options.RateLimitWindow() {
+		service.windowStart = now
+		service.sentInWindow = 0
+	}
+
+	if service.sentInWindow >= p.options.rateLimit() {
+		service.hold(alert, restart, now, &service.held.rateLimited)
+		return nil
+	}
+
+	service.sentInWindow++
+	service.lastSent[alert.Title] = now
+
+	return []Alert{alert}
+}
+
+func (p *pipeline) Flush() []Alert {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.timeService.Now()
+
+	var names []string
+	for name := range p.services {
+		names = append(names, name)
+	}
+
+	// Summaries are returned in a stable order
+	sort.Strings(names)
+
+	var summaries []Alert
+
+	for _, name := range names {
+		service := p.services[name]
+
+		p.pruneRestarts(service, now)
+
+		if service.flapping && len(service.restarts) == 0 {
+			service.flapping = false
+		}
+
+		held := service.held
+
+		if held.count() > 0 && now.Sub(held.since) >= p.options.RateLimitWindow() {
+			summaries = append(summaries, p.summaryAlert(name, held, now))
+			service.held = heldAlerts{}
+		}
+
+		for title, sentAt := range service.lastSent {
+			if now.Sub(sentAt) >= p.options.DedupWindow() {
+				delete(service.lastSent, title)
+			}
+		}
+	}
+
+	return summaries
+}
+
+func (p *pipeline) service(name string) *serviceAlerts {
+	service, found := p.services[name]
+	if !found {
+		service = &serviceAlerts{lastSent: map[string]time.Time{}}
+		p.services[name] = service
+	}
+
+	return service
+}
+
+// recordRestart returns true when service starts flapping
+func (p *pipeline) recordRestart(service *serviceAlerts, now time.Time) bool {
+	service.restarts = append(service.restarts, now)
+	p.pruneRestarts(service, now)
+
+	if service.flapping || len(service.restarts) < p.options.flapRestarts() {
+		return false
+	}
+
+	service.flapping = true
+
+	return true
+}
+
+func (p *pipeline) pruneRestarts(service *serviceAlerts, now time.Time) {
+	var recent []time.Time
+
+	for _, restartedAt := range service.restarts {
+		if now.Sub(restartedAt) < p.options.FlapWindow() {
+			recent = append(recent, restartedAt)
+		}
+	}
+
+	service.restarts = recent
+}
+
+func (p *pipeline) flappingAlert(service string, alert Alert, now time.Time) Alert {
+	return Alert{
+		ID:       alert.ID + ".flapping",
+		Severity: alert.Severity,
+		Title:    alert.Title,
+		Summary: fmt.Sprintf(
+			"Service %s was restarted %d times in %s; its alerts are summarized until it stops flapping",
+			service, p.options.flapRestarts(), p.options.FlapWindow(),
+		),
+		CreatedAt: now.Unix(),
+	}
+}
+
+func (p *pipeline) summaryAlert(service string, held heldAlerts, now time.Time) Alert {
+	var counts []string
+
+	for _, count := range []struct {
+		n    int
+		what string
+	}{
+		{held.duplicates, "duplicate"},
+		{held.rateLimited, "over rate limit"},
+		{held.flapping, "while flapping"},
+	} {
+		if count.n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", count.n, count.what))
+		}
+	}
+
+	summary := fmt.Sprintf(
+		"%d alerts of service %s held back in last %s (%s)",
+		held.count(), service, now.Sub(held.since), strings.Join(counts, ", "),
+	)
+
+	if held.restarts > 0 {
+		summary += fmt.Sprintf(", %d restarts", held.restarts)
+	}
+
+	if len(held.last.Summary) > 0 {
+		summary += "; last: " + held.last.Summary
+	}
+
+	return Alert{
+		ID:        held.last.ID + ".summary",
+		Severity:  held.severity,
+		Title:     held.last.Title,
+		Summary:   summary,
+		CreatedAt: now.Unix(),
+	}
+}
+
+func (s *serviceAlerts) hold(alert Alert, restart bool, now time.Time, counter *int) {
+	if s.held.count() == 0 {
+		s.held.since = now
+		s.held.severity = alert.Severity
+	}
+
+	*counter++
+
+	if restart {
+		s.held.restarts++
+	}
+
+	s.held.last = alert
+
+	// Lower levels are more severe
+	if alert.Severity < s.held.severity {
+		s.held.severity = alert.Severity
+	}
+}
+
+func (h heldAlerts) count() int {
+	return h.duplicates + h.rateLimited + h.flapping
+}
+
+// Monit and native job supervisors (re)start failed processes
+// with "restart" action while Windows one uses "start"
+func isRestart(monitAlert MonitAlert) bool {
+	action := strings.ToLower(monitAlert.Action)
+	return action == "restart" || action == "start"
+}
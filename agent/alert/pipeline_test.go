@@ -0,0 +1,192 @@
+package alert_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+)
+
+var _ = Describe("pipeline", func() {
+	var (
+		timeService *fakeclock.FakeClock
+		pipeline    Pipeline
+		alertCount  int
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Now())
+		alertCount = 0
+
+		pipeline = NewPipeline(PipelineOptions{
+			DedupWindowSeconds:     60,
+			RateLimit:              3,
+			RateLimitWindowSeconds: 600,
+			FlapRestarts:           3,
+			FlapWindowSeconds:      300,
+		}, timeService)
+	})
+
+	// filter passes a new alert of service through pipeline
+	filter := func(service, event, action string, severity SeverityLevel) []Alert {
+		alertCount++
+
+		return pipeline.Filter(
+			MonitAlert{Service: service, Event: event, Action: action},
+			Alert{
+				ID:       fmt.Sprintf("fake-alert-%d", alertCount),
+				Severity: severity,
+				Title:    fmt.Sprintf("%s - %s - %s", service, event, action),
+				Summary:  fmt.Sprintf("fake-summary-%d", alertCount),
+			},
+		)
+	}
+
+	It("sends alerts that are not held back as is", func() {
+		Expect(filter("fake-service", "does not exist", "alert", SeverityCritical)).To(Equal([]Alert{
+			{
+				ID:       "fake-alert-1",
+				Severity: SeverityCritical,
+				Title:    "fake-service - does not exist - alert",
+				Summary:  "fake-summary-1",
+			},
+		}))
+	})
+
+	Describe("deduplication", func() {
+		It("holds back alerts with the same title within dedup window", func() {
+			Expect(filter("fake-service", "does not exist", "alert", SeverityCritical)).To(HaveLen(1))
+
+			timeService.Increment(59 * time.Second)
+			Expect(filter("fake-service", "does not exist", "alert", SeverityCritical)).To(BeEmpty())
+			Expect(filter("fake-service", "pid failed", "alert", SeverityCritical)).To(HaveLen(1))
+			Expect(filter("other-service", "does not exist", "alert", SeverityCritical)).To(HaveLen(1))
+
+			timeService.Increment(1 * time.Second)
+			Expect(filter("fake-service", "does not exist", "alert", SeverityCritical)).To(HaveLen(1))
+		})
+	})
+
+	Describe("rate limiting", func() {
+		It("holds back alerts of service over rate limit until rate limit window ends", func() {
+			for i := 0; i < 3; i++ {
+				Expect(filter("fake-service", fmt.Sprintf("event-%d", i), "alert", SeverityError)).To(HaveLen(1))
+			}
+
+			Expect(filter("fake-service", "event-3", "alert", SeverityError)).To(BeEmpty())
+			Expect(filter("other-service", "event-3", "alert", SeverityError)).To(HaveLen(1))
+
+			timeService.Increment(10 * time.Minute)
+			Expect(filter("fake-service", "event-4", "alert", SeverityError)).To(HaveLen(1))
+		})
+	})
+
+	Describe("flap detection", func() {
+		It("sends one flapping alert and holds back alerts until service stops restarting", func() {
+			Expect(filter("fake-service", "does not exist", "restart", SeverityCritical)).To(HaveLen(1))
+
+			timeService.Increment(1 * time.Minute)
+			Expect(filter("fake-service", "pid failed", "restart", SeverityCritical)).To(HaveLen(1))
+
+			timeService.Increment(1 * time.Minute)
+			Expect(filter("fake-service", "execution failed", "restart", SeverityAlert)).To(Equal([]Alert{
+				{
+					ID:        "fake-alert-3.flapping",
+					Severity:  SeverityAlert,
+					Title:     "fake-service - execution failed - restart",
+					Summary:   "Service fake-service was restarted 3 times in 5m0s; its alerts are summarized until it stops flapping",
+					CreatedAt: timeService.Now().Unix(),
+				},
+			}))
+
+			timeService.Increment(1 * time.Minute)
+			Expect(filter("fake-service", "checksum failed", "alert", SeverityCritical)).To(BeEmpty())
+			Expect(filter("fake-service", "does not exist", "restart", SeverityCritical)).To(BeEmpty())
+
+			timeService.Increment(5 * time.Minute)
+			pipeline.Flush()
+			Expect(filter("fake-service", "checksum failed", "alert", SeverityCritical)).To(HaveLen(1))
+		})
+
+		It("treats start action as restart", func() {
+			Expect(filter("fake-service", "exited", "Start", SeverityCritical)).To(HaveLen(1))
+			Expect(filter("fake-service", "exited", "Start", SeverityCritical)).To(BeEmpty())
+
+			flapping := filter("fake-service", "exited", "Start", SeverityCritical)
+			Expect(flapping).To(HaveLen(1))
+			Expect(flapping[0].ID).To(Equal("fake-alert-3.flapping"))
+		})
+
+		It("does not consider restarts older than flap window", func() {
+			Expect(filter("fake-service", "event-1", "restart", SeverityCritical)).To(HaveLen(1))
+			Expect(filter("fake-service", "event-2", "restart", SeverityCritical)).To(HaveLen(1))
+
+			timeService.Increment(5 * time.Minute)
+			Expect(filter("fake-service", "event-3", "restart", SeverityCritical)).To(HaveLen(1))
+		})
+	})
+
+	Describe("Flush", func() {
+		It("returns nothing when no alerts were held back", func() {
+			filter("fake-service", "does not exist", "alert", SeverityCritical)
+
+			timeService.Increment(10 * time.Minute)
+			Expect(pipeline.Flush()).To(BeEmpty())
+		})
+
+		It("summarizes held back alerts of each service once rate limit window ends", func() {
+			filter("fake-service", "does not exist", "restart", SeverityWarning)
+			filter("fake-service", "does not exist", "restart", SeverityWarning)
+			filter("fake-service", "does not exist", "restart", SeverityWarning)
+			filter("fake-service", "checksum failed", "alert", SeverityCritical)
+
+			timeService.Increment(1 * time.Minute)
+			filter("other-service", "a", "alert", SeverityError)
+			filter("other-service", "b", "alert", SeverityError)
+			filter("other-service", "c", "alert", SeverityError)
+			filter("other-service", "a", "alert", SeverityError)
+			filter("other-service", "d", "alert", SeverityError)
+
+			timeService.Increment(9*time.Minute - time.Second)
+			Expect(pipeline.Flush()).To(BeEmpty())
+
+			timeService.Increment(1 * time.Second)
+			Expect(pipeline.Flush()).To(Equal([]Alert{
+				{
+					ID:        "fake-alert-4.summary",
+					Severity:  SeverityCritical,
+					Title:     "fake-service - checksum failed - alert",
+					Summary:   "3 alerts of service fake-service held back in last 10m0s (1 duplicate, 2 while flapping), 2 restarts; last: fake-summary-4",
+					CreatedAt: timeService.Now().Unix(),
+				},
+			}))
+
+			timeService.Increment(1 * time.Minute)
+			Expect(pipeline.Flush()).To(Equal([]Alert{
+				{
+					ID:        "fake-alert-9.summary",
+					Severity:  SeverityError,
+					Title:     "other-service - d - alert",
+					Summary:   "2 alerts of service other-service held back in last 10m0s (1 duplicate, 1 over rate limit); last: fake-summary-9",
+					CreatedAt: timeService.Now().Unix(),
+				},
+			}))
+
+			timeService.Increment(10 * time.Minute)
+			Expect(pipeline.Flush()).To(BeEmpty())
+		})
+	})
+
+	Describe("PipelineOptions", func() {
+		It("uses defaults for unset options", func() {
+			options := PipelineOptions{}
+			Expect(options.DedupWindow()).To(Equal(DefaultDedupWindow))
+			Expect(options.RateLimitWindow()).To(Equal(DefaultRateLimitWindow))
+			Expect(options.FlapWindow()).To(Equal(DefaultFlapWindow))
+		})
+	})
+})
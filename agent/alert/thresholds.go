@@ -0,0 +1,248 @@
+package alert
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"code.cloudfoundry.org/clock"
+
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+)
+
+const (
+	DefaultDiskWarningPercent    = 80
+	DefaultDiskCriticalPercent   = 90
+	DefaultMemoryWarningPercent  = 80
+	DefaultMemoryCriticalPercent = 95
+	DefaultSwapWarningPercent    = 50
+	DefaultSwapCriticalPercent   = 80
+	DefaultHysteresisPercent     = 5
+)
+
+// Threshold levels are percentages of disk, memory or swap in use;
+// zero levels are replaced with defaults and levels above 100 never trigger
+type Threshold struct {
+	WarningPercent  float64
+	CriticalPercent float64
+}
+
+// ThresholdOptions are read from agent config. Disk thresholds
+// apply to both space and inodes of the disk.
+type ThresholdOptions struct {
+	SystemDisk     Threshold
+	EphemeralDisk  Threshold
+	PersistentDisk Threshold
+	Memory         Threshold
+	Swap           Threshold
+
+	// Usage has to drop this many percentage points below a level
+	// before it is considered below it again so that usage hovering
+	// around a level does not produce alert after alert
+	HysteresisPercent float64
+}
+
+func (o ThresholdOptions) hysteresis() float64 {
+	if o.HysteresisPercent > 0 {
+		return o.HysteresisPercent
+	}
+	return DefaultHysteresisPercent
+}
+
+func (t Threshold) withDefaults(warning, critical float64) Threshold {
+	if t.WarningPercent <= 0 {
+		t.WarningPercent = warning
+	}
+	if t.CriticalPercent <= 0 {
+		t.CriticalPercent = critical
+	}
+	return t
+}
+
+//go:generate counterfeiter . ThresholdEvaluator
+
+// ThresholdEvaluator lets the agent alert about vitals on its own
+// so that e.g. persistent disk filling up is noticed even when
+// health monitor has no rule for it.
+type ThresholdEvaluator interface {
+	// Evaluate returns alerts for usages that crossed a level
+	// since previous evaluation in either direction
+	Evaluate(vitals boshvitals.Vitals) []Alert
+}
+
+type thresholdLevel int
+
+const (
+	levelNormal thresholdLevel = iota
+	levelWarning
+	levelCritical
+)
+
+func (l thresholdLevel) String() string {
+	switch l {
+	case levelWarning:
+		return "warning"
+	case levelCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+type usage struct {
+	service   string
+	what      string
+	percent   string
+	threshold Threshold
+}
+
+type thresholdEvaluator struct {
+	options         ThresholdOptions
+	settingsService boshsettings.Service
+	timeService     clock.Clock
+
+	lock       sync.Mutex
+	levels     map[string]thresholdLevel
+	alertCount int
+}
+
+func NewThresholdEvaluator(
+	options ThresholdOptions,
+	settingsService boshsettings.Service,
+	timeService clock.Clock,
+) ThresholdEvaluator {
+	return &thresholdEvaluator{
+		options:         options,
+		settingsService: settingsService,
+		timeService:     timeService,
+		levels:          map[string]thresholdLevel{},
+	}
+}
+
+func (e *thresholdEvaluator) Evaluate(vitals boshvitals.Vitals) []Alert {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var alerts []Alert
+
+	for _, usage := range e.usages(vitals) {
+		key := usage.service + " " + usage.what
+
+		percent, err := strconv.ParseFloat(usage.percent, 64)
+		if err != nil {
+			// Disks that are not mounted (e.g. persistent disk) have no vitals;
+			// they start from normal level once they are mounted again
+			delete(e.levels, key)
+			continue
+		}
+
+		previous := e.levels[key]
+		current := e.level(previous, percent, usage.threshold)
+
+		e.levels[key] = current
+
+		if current != previous {
+			alerts = append(alerts, e.alert(usage, percent, previous, current))
+		}
+	}
+
+	return alerts
+}
+
+func (e *thresholdEvaluator) usages(vitals boshvitals.Vitals) []usage {
+	disks := []struct {
+		name      string
+		service   string
+		threshold Threshold
+	}{
+		{"system", "system_disk", e.options.SystemDisk},
+		{"ephemeral", "ephemeral_disk", e.options.EphemeralDisk},
+		{"persistent", "persistent_disk", e.options.PersistentDisk},
+	}
+
+	var usages []usage
+
+	for _, disk := range disks {
+		diskVitals := vitals.Disk[disk.name]
+		threshold := disk.threshold.withDefaults(DefaultDiskWarningPercent, DefaultDiskCriticalPercent)
+
+		usages = append(usages,
+			usage{disk.service, "usage", diskVitals.Percent, threshold},
+			usage{disk.service, "inode usage", diskVitals.InodePercent, threshold},
+		)
+	}
+
+	return append(usages,
+		usage{"memory", "usage", vitals.Mem.Percent, e.options.Memory.withDefaults(DefaultMemoryWarningPercent, DefaultMemoryCriticalPercent)},
+		usage{"swap", "usage", vitals.Swap.Percent, e.options.Swap.withDefaults(DefaultSwapWarningPercent, DefaultSwapCriticalPercent)},
+	)
+}
+
+// level rises as soon as usage reaches a level but only falls
+// once usage is below the level by more than hysteresis
+func (e *thresholdEvaluator) level(previous thresholdLevel, percent float64, threshold Threshold) thresholdLevel {
+	current := levelNormal
+
+	if percent >= threshold.CriticalPercent {
+		current = levelCritical
+	} else if percent >= threshold.WarningPercent {
+		current = levelWarning
+	}
+
+	level := previous
+	for level > current && percent < threshold.percent(level)-e.options.hysteresis() {
+		level--
+	}
+
+	if current > level {
+		return current
+	}
+
+	return level
+}
+
+func (t Threshold) percent(level thresholdLevel) float64 {
+	if level == levelCritical {
+		return t.CriticalPercent
+	}
+	return t.WarningPercent
+}
+
+func (e *thresholdEvaluator) alert(usage usage, percent float64, previous, current thresholdLevel) Alert {
+	e.alertCount++
+
+	now := e.timeService.Now()
+
+	var event, summary string
+	severity := SeverityWarning
+
+	if current > previous {
+		event = fmt.Sprintf("%s above %s", usage.what, current)
+		summary = fmt.Sprintf("%s %s is %s%% which is above %s level of %s%%",
+			usage.service, usage.what, formatPercent(percent), current, formatPercent(usage.threshold.percent(current)))
+
+		if current == levelCritical {
+			severity = SeverityCritical
+		}
+	} else {
+		// Lowest level that usage fell below
+		crossed := current + 1
+
+		event = fmt.Sprintf("%s below %s", usage.what, crossed)
+		summary = fmt.Sprintf("%s %s is %s%% which is back below %s level of %s%%",
+			usage.service, usage.what, formatPercent(percent), crossed, formatPercent(usage.threshold.percent(crossed)))
+	}
+
+	return Alert{
+		ID:        fmt.Sprintf("%d.%d@%s", now.Unix(), e.alertCount, usage.service),
+		Severity:  severity,
+		Title:     title(e.settingsService, usage.service, event, "alert"),
+		Summary:   summary,
+		CreatedAt: now.Unix(),
+	}
+}
+
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', -1, 64)
+}
@@ -0,0 +1,163 @@
+package alert_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
+	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
+	fakesettings "github.com/cloudfoundry/bosh-agent/settings/fakes"
+)
+
+var _ = Describe("thresholdEvaluator", func() {
+	var (
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *fakeclock.FakeClock
+		options         ThresholdOptions
+		evaluator       ThresholdEvaluator
+	)
+
+	BeforeEach(func() {
+		settingsService = &fakesettings.FakeSettingsService{}
+		settingsService.Settings.Networks = boshsettings.Networks{
+			"fake-net": boshsettings.Network{IP: "10.0.0.1"},
+		}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		options = ThresholdOptions{
+			PersistentDisk: Threshold{WarningPercent: 70, CriticalPercent: 85},
+		}
+	})
+
+	JustBeforeEach(func() {
+		evaluator = NewThresholdEvaluator(options, settingsService, timeService)
+	})
+
+	persistentDisk := func(percent, inodePercent int) boshvitals.Vitals {
+		return boshvitals.Vitals{
+			Disk: boshvitals.DiskVitals{
+				"system":     {Percent: "10", InodePercent: "10"},
+				"ephemeral":  {Percent: "10", InodePercent: "10"},
+				"persistent": {Percent: fmt.Sprintf("%d", percent), InodePercent: fmt.Sprintf("%d", inodePercent)},
+			},
+			Mem:  boshvitals.MemoryVitals{Percent: "10"},
+			Swap: boshvitals.MemoryVitals{Percent: "0"},
+		}
+	}
+
+	titles := func(alerts []Alert) []string {
+		var titles []string
+		for _, alert := range alerts {
+			titles = append(titles, alert.Title)
+		}
+		return titles
+	}
+
+	It("returns no alerts when all usages are below warning levels", func() {
+		Expect(evaluator.Evaluate(persistentDisk(69, 10))).To(BeEmpty())
+	})
+
+	It("alerts when usage reaches warning and critical levels", func() {
+		Expect(evaluator.Evaluate(persistentDisk(70, 10))).To(Equal([]Alert{
+			{
+				ID:        fmt.Sprintf("%d.1@persistent_disk", timeService.Now().Unix()),
+				Severity:  SeverityWarning,
+				Title:     "persistent_disk (10.0.0.1) - usage above warning - alert",
+				Summary:   "persistent_disk usage is 70% which is above warning level of 70%",
+				CreatedAt: timeService.Now().Unix(),
+			},
+		}))
+
+		Expect(evaluator.Evaluate(persistentDisk(75, 10))).To(BeEmpty())
+
+		alerts := evaluator.Evaluate(persistentDisk(90, 10))
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Severity).To(Equal(SeverityCritical))
+		Expect(alerts[0].Title).To(Equal("persistent_disk (10.0.0.1) - usage above critical - alert"))
+		Expect(alerts[0].Summary).To(Equal("persistent_disk usage is 90% which is above critical level of 85%"))
+		Expect(alerts[0].ID).To(HaveSuffix(".2@persistent_disk"))
+	})
+
+	It("evaluates inode usage separately from space usage", func() {
+		Expect(titles(evaluator.Evaluate(persistentDisk(10, 86)))).To(Equal([]string{
+			"persistent_disk (10.0.0.1) - inode usage above critical - alert",
+		}))
+	})
+
+	It("only considers usage below a level once it drops below it by more than hysteresis", func() {
+		evaluator.Evaluate(persistentDisk(90, 10))
+
+		Expect(evaluator.Evaluate(persistentDisk(80, 10))).To(BeEmpty())
+
+		alerts := evaluator.Evaluate(persistentDisk(79, 10))
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Severity).To(Equal(SeverityWarning))
+		Expect(alerts[0].Title).To(Equal("persistent_disk (10.0.0.1) - usage below critical - alert"))
+		Expect(alerts[0].Summary).To(Equal("persistent_disk usage is 79% which is back below critical level of 85%"))
+
+		Expect(evaluator.Evaluate(persistentDisk(84, 10))).To(BeEmpty())
+		Expect(evaluator.Evaluate(persistentDisk(65, 10))).To(BeEmpty())
+
+		Expect(titles(evaluator.Evaluate(persistentDisk(64, 10)))).To(Equal([]string{
+			"persistent_disk (10.0.0.1) - usage below warning - alert",
+		}))
+	})
+
+	It("reports only lowest level crossed when usage drops by several levels", func() {
+		evaluator.Evaluate(persistentDisk(90, 10))
+
+		alerts := evaluator.Evaluate(persistentDisk(20, 10))
+		Expect(alerts).To(HaveLen(1))
+		Expect(alerts[0].Summary).To(Equal("persistent_disk usage is 20% which is back below warning level of 70%"))
+	})
+
+	It("starts from normal level when disk is mounted again", func() {
+		evaluator.Evaluate(persistentDisk(90, 10))
+
+		vitals := persistentDisk(0, 0)
+		delete(vitals.Disk, "persistent")
+		Expect(evaluator.Evaluate(vitals)).To(BeEmpty())
+
+		Expect(titles(evaluator.Evaluate(persistentDisk(90, 10)))).To(Equal([]string{
+			"persistent_disk (10.0.0.1) - usage above critical - alert",
+		}))
+	})
+
+	Context("when levels are not configured", func() {
+		BeforeEach(func() {
+			options = ThresholdOptions{}
+		})
+
+		It("uses default levels for disks, memory and swap", func() {
+			vitals := boshvitals.Vitals{
+				Disk: boshvitals.DiskVitals{
+					"system":    {Percent: "80", InodePercent: "79"},
+					"ephemeral": {Percent: "90", InodePercent: "10"},
+				},
+				Mem:  boshvitals.MemoryVitals{Percent: "95"},
+				Swap: boshvitals.MemoryVitals{Percent: "50"},
+			}
+
+			Expect(titles(evaluator.Evaluate(vitals))).To(Equal([]string{
+				"system_disk (10.0.0.1) - usage above warning - alert",
+				"ephemeral_disk (10.0.0.1) - usage above critical - alert",
+				"memory (10.0.0.1) - usage above critical - alert",
+				"swap (10.0.0.1) - usage above warning - alert",
+			}))
+		})
+	})
+
+	Context("when level is above 100", func() {
+		BeforeEach(func() {
+			options.PersistentDisk = Threshold{WarningPercent: 101, CriticalPercent: 101}
+		})
+
+		It("never alerts", func() {
+			Expect(evaluator.Evaluate(persistentDisk(100, 100))).To(BeEmpty())
+		})
+	})
+})
@@ -8,4 +8,7 @@ type Applier interface {
 	Prepare(desiredApplySpec boshas.ApplySpec) error
 	ConfigureJobs(desiredApplySpec boshas.ApplySpec) error
 	Apply(currentApplySpec, desiredApplySpec boshas.ApplySpec) error
+
+	// Cancel aborts in-progress Apply and restores jobs and packages of current apply spec
+	Cancel() error
 }
@@ -2,16 +2,31 @@ package applyspec
 
 import (
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshhealth "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 )
 
 type JobTemplateSpec struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
+
+	// Resource limits enforced on processes of the job
+	Limits *boshcgroup.Limits `json:"limits,omitempty"`
+
+	// Checks run by the agent in addition to process supervision
+	HealthChecks []boshhealth.Check `json:"health_checks,omitempty"`
 }
 
 func (s *JobTemplateSpec) AsJob() models.Job {
-	return models.Job{
-		Name:    s.Name,
-		Version: s.Version,
+	job := models.Job{
+		Name:         s.Name,
+		Version:      s.Version,
+		HealthChecks: s.HealthChecks,
 	}
+
+	if s.Limits != nil {
+		job.Limits = *s.Limits
+	}
+
+	return job
 }
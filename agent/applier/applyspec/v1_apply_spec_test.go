@@ -8,6 +8,8 @@ import (
 
 	. "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	models "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshhealth "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	"github.com/cloudfoundry/bosh-utils/crypto"
 )
 
@@ -27,8 +29,8 @@ var _ = Describe("V1ApplySpec", func() {
 					"sha1": "sha1:routersha1;sha256:routersha256",
 					"blobstore_id": "router-blob-id-1",
 					"templates": [
-						{"name": "template 1", "version": "0.1"},
-						{"name": "template 2", "version": "0.2"}
+						{"name": "template 1", "version": "0.1", "health_checks": [{"type": "http", "port": 8080, "path": "/healthz", "interval_seconds": 5}]},
+						{"name": "template 2", "version": "0.2", "limits": {"memory_mb": 512, "cpu_percent": 50}}
 					]
 				},
 				"packages": {
@@ -87,8 +89,8 @@ var _ = Describe("V1ApplySpec", func() {
 					Template: "router template",
 					Version:  "1.0",
 					JobTemplateSpecs: []JobTemplateSpec{
-						{Name: "template 1", Version: "0.1"},
-						{Name: "template 2", Version: "0.2"},
+						{Name: "template 1", Version: "0.1", HealthChecks: []boshhealth.Check{{Type: "http", Port: 8080, Path: "/healthz", IntervalSeconds: 5}}},
+						{Name: "template 2", Version: "0.2", Limits: &boshcgroup.Limits{MemoryMB: 512, CPUPercent: 50}},
 					},
 				},
 				PackageSpecs: map[string]PackageSpec{
@@ -187,12 +189,14 @@ var _ = Describe("V1ApplySpec", func() {
 					Version: "fake-job-legacy-version",
 					JobTemplateSpecs: []JobTemplateSpec{
 						{
-							Name:    "fake-job1-name",
-							Version: "fake-job1-version",
+							Name:         "fake-job1-name",
+							Version:      "fake-job1-version",
+							HealthChecks: []boshhealth.Check{{Type: "tcp", Port: 8080}},
 						},
 						{
 							Name:    "fake-job2-name",
 							Version: "fake-job2-version",
+							Limits:  &boshcgroup.Limits{Pids: 100},
 						},
 					},
 				},
@@ -252,7 +256,8 @@ var _ = Describe("V1ApplySpec", func() {
 						BlobstoreID:   "fake-rendered-templates-archive-blobstore-id",
 						PathInArchive: "fake-job1-name",
 					},
-					Packages: actualJobs[0].Packages, // tested above
+					Packages:     actualJobs[0].Packages, // tested above
+					HealthChecks: []boshhealth.Check{{Type: "tcp", Port: 8080}},
 				},
 				{
 					Name:    "fake-job2-name",
@@ -263,6 +268,7 @@ var _ = Describe("V1ApplySpec", func() {
 						PathInArchive: "fake-job2-name",
 					},
 					Packages: actualJobs[1].Packages, // tested above
+					Limits:   boshcgroup.Limits{Pids: 100},
 				},
 			}))
 		})
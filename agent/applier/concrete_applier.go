@@ -4,6 +4,7 @@ import (
 	as "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/jobs"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -18,8 +19,12 @@ type concreteApplier struct {
 	jobSupervisor     boshjobsuper.JobSupervisor
 	dirProvider       boshdirs.Provider
 	settings          boshsettings.Settings
+
+	cancel boshtask.RunCancel
 }
 
+var errApplyCanceled = bosherr.Error("Apply was cancelled by user request")
+
 func NewConcreteApplier(
 	jobApplier jobs.Applier,
 	packageApplier packages.Applier,
@@ -74,14 +79,27 @@ func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
 	return nil
 }
 
-func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec) error {
-	err := a.jobSupervisor.RemoveAllJobs()
+func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec) (err error) {
+	cancelCh := a.cancel.Start()
+	defer a.cancel.Finish(cancelCh)
+
+	defer func() {
+		if err == errApplyCanceled {
+			err = a.rollBack(currentApplySpec)
+		}
+	}()
+
+	err = a.jobSupervisor.RemoveAllJobs()
 	if err != nil {
 		return bosherr.WrapError(err, "Removing all jobs")
 	}
 
 	jobs := desiredApplySpec.Jobs()
 	for _, job := range jobs {
+		if boshtask.IsCanceled(cancelCh) {
+			return errApplyCanceled
+		}
+
 		err = a.jobApplier.Apply(job)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Applying job %s", job.Name)
@@ -94,12 +112,20 @@ func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec)
 	}
 
 	for _, pkg := range desiredApplySpec.Packages() {
+		if boshtask.IsCanceled(cancelCh) {
+			return errApplyCanceled
+		}
+
 		err = a.packageApplier.Apply(pkg)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Applying package %s", pkg.Name)
 		}
 	}
 
+	if boshtask.IsCanceled(cancelCh) {
+		return errApplyCanceled
+	}
+
 	err = a.packageApplier.KeepOnly(append(currentApplySpec.Packages(), desiredApplySpec.Packages()...))
 	if err != nil {
 		return bosherr.WrapError(err, "Keeping only needed packages")
@@ -113,6 +139,12 @@ func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec)
 	return a.setUpLogrotate(desiredApplySpec)
 }
 
+func (a *concreteApplier) Cancel() error {
+	// Cancelling while nothing is being applied is a no-op
+	a.cancel.Cancel()
+	return nil
+}
+
 func (a *concreteApplier) ConfigureJobs(desiredApplySpec as.ApplySpec) error {
 
 	jobs := desiredApplySpec.Jobs()
@@ -145,3 +177,38 @@ func (a *concreteApplier) setUpLogrotate(applySpec as.ApplySpec) error {
 
 	return nil
 }
+
+// rollBack re-enables jobs and packages from current apply spec and removes
+// bundles that were only installed for the cancelled desired apply spec
+func (a *concreteApplier) rollBack(currentApplySpec as.ApplySpec) error {
+	for _, job := range currentApplySpec.Jobs() {
+		err := a.jobApplier.Apply(job)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Rolling back cancelled apply: Applying job %s", job.Name)
+		}
+	}
+
+	err := a.jobApplier.KeepOnly(currentApplySpec.Jobs())
+	if err != nil {
+		return bosherr.WrapError(err, "Rolling back cancelled apply: Keeping only current jobs")
+	}
+
+	for _, pkg := range currentApplySpec.Packages() {
+		err = a.packageApplier.Apply(pkg)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Rolling back cancelled apply: Applying package %s", pkg.Name)
+		}
+	}
+
+	err = a.packageApplier.KeepOnly(currentApplySpec.Packages())
+	if err != nil {
+		return bosherr.WrapError(err, "Rolling back cancelled apply: Keeping only current packages")
+	}
+
+	err = a.jobSupervisor.Reload()
+	if err != nil {
+		return bosherr.WrapError(err, "Rolling back cancelled apply: Reloading jobSupervisor")
+	}
+
+	return errApplyCanceled
+}
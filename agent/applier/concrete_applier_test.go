@@ -334,6 +334,90 @@ func init() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-set-up-logrotate-error"))
 			})
+
+			Context("when apply is cancelled", func() {
+				var (
+					currentJob, desiredJob1, desiredJob2 models.Job
+					currentPkg, desiredPkg               models.Package
+					currentSpec, desiredSpec             *fakeas.FakeApplySpec
+				)
+
+				BeforeEach(func() {
+					currentJob = buildJob()
+					desiredJob1 = buildJob()
+					desiredJob2 = buildJob()
+					currentPkg = buildPackage()
+					desiredPkg = buildPackage()
+
+					currentSpec = &fakeas.FakeApplySpec{
+						JobResults:     []models.Job{currentJob},
+						PackageResults: []models.Package{currentPkg},
+					}
+					desiredSpec = &fakeas.FakeApplySpec{
+						JobResults:     []models.Job{desiredJob1, desiredJob2},
+						PackageResults: []models.Package{desiredPkg},
+					}
+
+					jobApplier.ApplyStub = func(job models.Job) error {
+						if job.Name == desiredJob1.Name {
+							return applier.Cancel()
+						}
+						return nil
+					}
+				})
+
+				It("stops applying remaining jobs and packages", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Apply was cancelled by user request"))
+
+					Expect(jobApplier.ApplyArgsForCall(0)).To(Equal(desiredJob1))
+					Expect(packageApplier.AppliedPackages).ToNot(ContainElement(desiredPkg))
+					Expect(logRotateDelegate.SetupLogrotateArgs).To(Equal(SetupLogrotateArgs{}))
+				})
+
+				It("restores jobs and packages from current spec and removes desired ones", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+
+					Expect(jobApplier.ApplyCallCount()).To(Equal(2))
+					Expect(jobApplier.ApplyArgsForCall(1)).To(Equal(currentJob))
+					Expect(jobApplier.KeepOnlyCallCount()).To(Equal(1))
+					Expect(jobApplier.KeepOnlyArgsForCall(0)).To(Equal([]models.Job{currentJob}))
+
+					Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{currentPkg}))
+					Expect(packageApplier.KeptOnlyPackages).To(Equal([]models.Package{currentPkg}))
+
+					Expect(jobSupervisor.Reloaded).To(BeTrue())
+				})
+
+				It("returns error if restoring current spec fails", func() {
+					jobApplier.KeepOnlyReturns(errors.New("fake-keep-only-error"))
+
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Rolling back cancelled apply"))
+					Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
+				})
+
+				It("does not affect subsequent applies", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+
+					jobApplier.ApplyStub = nil
+
+					err = applier.Apply(currentSpec, desiredSpec)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("ignores cancel requests made while no apply is running", func() {
+					jobApplier.ApplyStub = nil
+					Expect(applier.Cancel()).To(Succeed())
+
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
 		})
 	})
 }
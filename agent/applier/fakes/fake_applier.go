@@ -19,6 +19,9 @@ type FakeApplier struct {
 	ConfiguredDesiredApplySpec boshas.ApplySpec
 	ConfiguredJobs             []models.Job
 	ConfiguredError            error
+
+	Canceled  bool
+	CancelErr error
 }
 
 func NewFakeApplier() *FakeApplier {
@@ -43,3 +46,8 @@ func (s *FakeApplier) Apply(currentApplySpec, desiredApplySpec boshas.ApplySpec)
 	s.ApplyDesiredApplySpec = desiredApplySpec
 	return s.ApplyError
 }
+
+func (s *FakeApplier) Cancel() error {
+	s.Canceled = true
+	return s.CancelErr
+}
@@ -11,6 +11,8 @@ import (
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshhealth "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -23,9 +25,11 @@ type FixPermissionsFunc func(boshsys.FileSystem, string, string, string) error
 
 type renderedJobApplier struct {
 	blobstore              boshblob.DigestBlobstore
+	cgroupManager          boshcgroup.Manager
 	dirProvider            directories.Provider
 	fixPermissions         FixPermissionsFunc
 	fs                     boshsys.FileSystem
+	healthChecker          boshhealth.Checker
 	jobSupervisor          boshjobsuper.JobSupervisor
 	jobsBc                 boshbc.BundleCollection
 	logger                 boshlog.Logger
@@ -36,6 +40,8 @@ func NewRenderedJobApplier(
 	dirProvider directories.Provider,
 	jobsBc boshbc.BundleCollection,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	cgroupManager boshcgroup.Manager,
+	healthChecker boshhealth.Checker,
 	packageApplierProvider packages.ApplierProvider,
 	blobstore boshblob.DigestBlobstore,
 	fixPermissions FixPermissionsFunc,
@@ -44,9 +50,11 @@ func NewRenderedJobApplier(
 ) Applier {
 	return &renderedJobApplier{
 		blobstore:              blobstore,
+		cgroupManager:          cgroupManager,
 		dirProvider:            dirProvider,
 		fixPermissions:         fixPermissions,
 		fs:                     fs,
+		healthChecker:          healthChecker,
 		jobSupervisor:          jobSupervisor,
 		jobsBc:                 jobsBc,
 		logger:                 logger,
@@ -167,6 +175,19 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 		return
 	}
 
+	// Cgroup has to exist before job supervisor starts job processes
+	err = s.configureCgroup(job)
+	if err != nil {
+		err = bosherr.WrapError(err, "Configuring job cgroup")
+		return
+	}
+
+	err = s.healthChecker.Configure(job.Name, job.HealthChecks)
+	if err != nil {
+		err = bosherr.WrapError(err, "Configuring job health checks")
+		return
+	}
+
 	monitFilePath := path.Join(jobDir, "monit")
 	if s.fs.FileExists(monitFilePath) {
 		err = s.jobSupervisor.AddJob(job.Name, jobIndex, monitFilePath)
@@ -196,9 +217,55 @@ func (s *renderedJobApplier) Configure(job models.Job, jobIndex int) (err error)
 	return nil
 }
 
+// configureCgroup lifts limits of jobs that no longer declare them
+// since their cgroups can only be removed once their processes exit
+func (s *renderedJobApplier) configureCgroup(job models.Job) error {
+	if job.Limits.IsEmpty() {
+		cgroupJobNames, err := s.cgroupManager.Jobs()
+		if err != nil {
+			return err
+		}
+
+		hasCgroup := false
+		for _, jobName := range cgroupJobNames {
+			hasCgroup = hasCgroup || jobName == job.Name
+		}
+
+		if !hasCgroup {
+			return nil
+		}
+	}
+
+	return s.cgroupManager.Configure(job.Name, job.Limits)
+}
+
 func (s *renderedJobApplier) KeepOnly(jobs []models.Job) error {
 	s.logger.Debug(logTag, "Keeping only jobs %v", jobs)
 
+	var limitedJobNames []string
+	for _, job := range jobs {
+		if !job.Limits.IsEmpty() {
+			limitedJobNames = append(limitedJobNames, job.Name)
+		}
+	}
+
+	// Cgroups of jobs whose processes are still running cannot be removed;
+	// they are tried again on the next apply
+	err := s.cgroupManager.KeepOnly(limitedJobNames)
+	if err != nil {
+		s.logger.Warn(logTag, "Failed to remove cgroups of jobs: %s", err.Error())
+	}
+
+	var jobNames []string
+	for _, job := range jobs {
+		jobNames = append(jobNames, job.Name)
+	}
+
+	err = s.healthChecker.KeepOnly(jobNames)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing health checks of jobs")
+	}
+
 	installedBundles, err := s.jobsBc.List()
 	if err != nil {
 		return bosherr.WrapError(err, "Retrieving installed bundles")
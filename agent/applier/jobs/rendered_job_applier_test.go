@@ -13,6 +13,8 @@ import (
 	"github.com/cloudfoundry/bosh-agent/settings/directories"
 
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
+	boshhealth "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -21,6 +23,8 @@ import (
 	fakebc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection/fakes"
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
 	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck/healthcheckfakes"
+	"github.com/cloudfoundry/bosh-agent/platform/cgroup/cgroupfakes"
 	fakeblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
@@ -85,6 +89,8 @@ func init() {
 			fs                     *fakesys.FakeFileSystem
 			applier                Applier
 			fixPermissions         *fakeFixer
+			cgroupManager          *cgroupfakes.FakeManager
+			healthChecker          *healthcheckfakes.FakeChecker
 		)
 
 		BeforeEach(func() {
@@ -96,11 +102,15 @@ func init() {
 			logger := boshlog.NewLogger(boshlog.LevelNone)
 			dirProvider := directories.NewProvider("/fakebasedir")
 			fixPermissions = &fakeFixer{}
+			cgroupManager = &cgroupfakes.FakeManager{}
+			healthChecker = &healthcheckfakes.FakeChecker{}
 
 			applier = NewRenderedJobApplier(
 				dirProvider,
 				jobsBc,
 				jobSupervisor,
+				cgroupManager,
+				healthChecker,
 				packageApplierProvider,
 				blobstore,
 				fixPermissions.Fix,
@@ -452,6 +462,72 @@ func init() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(jobSupervisor.AddJobArgs)).To(Equal(0))
 			})
+
+			It("configures cgroup of job with limits", func() {
+				job, _ := buildJob(jobsBc)
+				job.Limits = boshcgroup.Limits{MemoryMB: 512}
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cgroupManager.ConfigureCallCount()).To(Equal(1))
+				jobName, limits := cgroupManager.ConfigureArgsForCall(0)
+				Expect(jobName).To(Equal(job.Name))
+				Expect(limits).To(Equal(boshcgroup.Limits{MemoryMB: 512}))
+			})
+
+			It("lifts limits of job that no longer declares them", func() {
+				job, _ := buildJob(jobsBc)
+				cgroupManager.JobsReturns([]string{job.Name}, nil)
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cgroupManager.ConfigureCallCount()).To(Equal(1))
+				_, limits := cgroupManager.ConfigureArgsForCall(0)
+				Expect(limits).To(Equal(boshcgroup.Limits{}))
+			})
+
+			It("does not create cgroup for job without limits", func() {
+				job, _ := buildJob(jobsBc)
+				cgroupManager.JobsReturns([]string{"other-job"}, nil)
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cgroupManager.ConfigureCallCount()).To(Equal(0))
+			})
+
+			It("returns error when configuring cgroup fails", func() {
+				job, _ := buildJob(jobsBc)
+				job.Limits = boshcgroup.Limits{MemoryMB: 512}
+				cgroupManager.ConfigureReturns(errors.New("fake-cgroup-error"))
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(MatchError(ContainSubstring("fake-cgroup-error")))
+				Expect(len(jobSupervisor.AddJobArgs)).To(Equal(0))
+			})
+
+			It("configures health checks of job", func() {
+				job, _ := buildJob(jobsBc)
+				job.HealthChecks = []boshhealth.Check{{Type: "tcp", Port: 8080}}
+
+				err := applier.Configure(job, 0)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(healthChecker.ConfigureCallCount()).To(Equal(1))
+				jobName, checks := healthChecker.ConfigureArgsForCall(0)
+				Expect(jobName).To(Equal(job.Name))
+				Expect(checks).To(Equal([]boshhealth.Check{{Type: "tcp", Port: 8080}}))
+			})
+
+			It("returns error when configuring health checks fails", func() {
+				job, _ := buildJob(jobsBc)
+				healthChecker.ConfigureReturns(errors.New("fake-health-check-error"))
+
+				err := applier.Configure(job, 0)
+				Expect(err).To(MatchError(ContainSubstring("fake-health-check-error")))
+				Expect(len(jobSupervisor.AddJobArgs)).To(Equal(0))
+			})
 		})
 
 		Describe("KeepOnly", func() {
@@ -472,6 +548,43 @@ func init() {
 				Expect(bundle4.ActionsCalled).To(Equal([]string{}))
 			})
 
+			It("removes cgroups of jobs that are not in keeponly list or have no limits", func() {
+				job1, _ := buildJob(jobsBc)
+				job2, _ := buildJob(jobsBc)
+				job2.Limits = boshcgroup.Limits{Pids: 10}
+
+				err := applier.KeepOnly([]models.Job{job1, job2})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cgroupManager.KeepOnlyCallCount()).To(Equal(1))
+				Expect(cgroupManager.KeepOnlyArgsForCall(0)).To(Equal([]string{job2.Name}))
+			})
+
+			It("does not return error when cgroups cannot be removed", func() {
+				cgroupManager.KeepOnlyReturns(errors.New("fake-cgroup-error"))
+
+				err := applier.KeepOnly([]models.Job{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("removes health checks of jobs that are not in keeponly list", func() {
+				job1, _ := buildJob(jobsBc)
+				job2, _ := buildJob(jobsBc)
+
+				err := applier.KeepOnly([]models.Job{job1, job2})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(healthChecker.KeepOnlyCallCount()).To(Equal(1))
+				Expect(healthChecker.KeepOnlyArgsForCall(0)).To(Equal([]string{job1.Name, job2.Name}))
+			})
+
+			It("returns error when health checks cannot be removed", func() {
+				healthChecker.KeepOnlyReturns(errors.New("fake-health-check-error"))
+
+				err := applier.KeepOnly([]models.Job{})
+				Expect(err).To(MatchError(ContainSubstring("fake-health-check-error")))
+			})
+
 			It("returns error when bundle collection fails to return list of installed bundles", func() {
 				jobsBc.ListErr = errors.New("fake-bc-list-error")
 
@@ -1,8 +1,11 @@
 package models
 
 import (
-	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"os"
+
+	boshhealth "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
 type Job struct {
@@ -13,6 +16,12 @@ type Job struct {
 	// Packages that this job depends on; however,
 	// currently it will contain packages from all jobs
 	Packages []Package
+
+	// Resource limits enforced on processes of the job
+	Limits boshcgroup.Limits
+
+	// Health checks run in addition to process supervision
+	HealthChecks []boshhealth.Check
 }
 
 func (s Job) BundleName() string {
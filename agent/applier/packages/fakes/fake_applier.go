@@ -19,6 +19,7 @@ type FakeApplier struct {
 	KeepOnlyErr      error
 	applyMutex       sync.Mutex
 	PrepareStub      func(pkg models.Package) error
+	ApplyStub        func(pkg models.Package) error
 }
 
 func NewFakeApplier() *FakeApplier {
@@ -41,6 +42,9 @@ func (s *FakeApplier) Prepare(pkg models.Package) error {
 func (s *FakeApplier) Apply(pkg models.Package) error {
 	s.ActionsCalled = append(s.ActionsCalled, "Apply")
 	s.AppliedPackages = append(s.AppliedPackages, pkg)
+	if s.ApplyStub != nil {
+		return s.ApplyStub(pkg)
+	}
 	return s.ApplyError
 }
 
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+
+	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const authorizingActionDispatcherLogTag = "Authorizing Action Dispatcher"
+
+// authorizingActionDispatcher only passes on requests
+// that caller is allowed to make according to the policy
+type authorizingActionDispatcher struct {
+	dispatcher  ActionDispatcher
+	policy      AuthorizationPolicy
+	auditLogger boshplatform.AuditLogger
+	logger      boshlog.Logger
+}
+
+func NewAuthorizingActionDispatcher(
+	dispatcher ActionDispatcher,
+	policy AuthorizationPolicy,
+	auditLogger boshplatform.AuditLogger,
+	logger boshlog.Logger,
+) ActionDispatcher {
+	return authorizingActionDispatcher{
+		dispatcher:  dispatcher,
+		policy:      policy,
+		auditLogger: auditLogger,
+		logger:      logger,
+	}
+}
+
+// Tasks were authorized when they were first dispatched
+func (d authorizingActionDispatcher) ResumePreviouslyDispatchedTasks() {
+	d.dispatcher.ResumePreviouslyDispatchedTasks()
+}
+
+func (d authorizingActionDispatcher) Dispatch(req boshhandler.Request) boshhandler.Response {
+	deniedMethod, denied := d.policy.deniedMethod(req)
+	if !denied {
+		return d.dispatcher.Dispatch(req)
+	}
+
+	caller := req.ClientName
+	if caller == "" {
+		caller = req.ReplyTo
+	}
+
+	err := bosherr.Errorf("Caller '%s' is not allowed to call %s", caller, deniedMethod)
+	d.logger.Error(authorizingActionDispatcherLogTag, err.Error())
+
+	cefString, cefErr := boshhandler.NewCommonEventFormat().ProduceDeniedRequestEventLog(caller, req.Method, err.Error())
+	if cefErr != nil {
+		d.logger.Error(authorizingActionDispatcherLogTag, cefErr.Error())
+	} else {
+		d.auditLogger.Err(cefString)
+	}
+
+	return boshhandler.NewExceptionResponse(err)
+}
+
+// deniedMethod returns method of request that caller is not allowed to call.
+// Steps of a batch are checked too since batch runs them as if they were
+// requested separately.
+func (p AuthorizationPolicy) deniedMethod(req boshhandler.Request) (string, bool) {
+	if len(p.Rules) == 0 {
+		return "", false
+	}
+
+	for _, rule := range p.Rules {
+		if rule.matches(req) {
+			return rule.deniedMethod(req)
+		}
+	}
+
+	return req.Method, true
+}
+
+func (r AuthorizationRule) matches(req boshhandler.Request) bool {
+	if r.ReplyToPrefix != "" && !strings.HasPrefix(req.ReplyTo, r.ReplyToPrefix) {
+		return false
+	}
+
+	if r.ClientName != "" && req.ClientName != r.ClientName {
+		return false
+	}
+
+	return true
+}
+
+func (r AuthorizationRule) deniedMethod(req boshhandler.Request) (string, bool) {
+	if !r.allows(req.Method) {
+		return req.Method, true
+	}
+
+	if req.Method != "batch" || r.allows("*") {
+		return "", false
+	}
+
+	steps, err := batchSteps(req)
+	if err != nil {
+		// Batch that cannot be checked is not run
+		return req.Method, true
+	}
+
+	for _, step := range steps {
+		if !r.allows(step.Method) {
+			return step.Method + " in batch", true
+		}
+	}
+
+	return "", false
+}
+
+func (r AuthorizationRule) allows(method string) bool {
+	for _, allowed := range r.Methods {
+		if allowed == "*" || allowed == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func batchSteps(req boshhandler.Request) ([]boshaction.BatchStep, error) {
+	var payload struct {
+		Arguments []json.RawMessage `json:"arguments"`
+	}
+
+	err := json.Unmarshal(req.GetPayload(), &payload)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling batch payload")
+	}
+
+	if len(payload.Arguments) == 0 {
+		return nil, bosherr.Error("Batch payload has no steps")
+	}
+
+	var steps []boshaction.BatchStep
+
+	err = json.Unmarshal(payload.Arguments[0], &steps)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling batch steps")
+	}
+
+	return steps, nil
+}
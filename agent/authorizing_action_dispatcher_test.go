@@ -0,0 +1,174 @@
+package agent_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent"
+	fakeagent "github.com/cloudfoundry/bosh-agent/agent/fakes"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
+	boshassert "github.com/cloudfoundry/bosh-utils/assert"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("authorizingActionDispatcher", func() {
+	var (
+		innerDispatcher *fakeagent.FakeActionDispatcher
+		auditLogger     *platformfakes.FakeAuditLogger
+		policy          AuthorizationPolicy
+		dispatcher      ActionDispatcher
+	)
+
+	BeforeEach(func() {
+		innerDispatcher = &fakeagent.FakeActionDispatcher{
+			DispatchResp: boshhandler.NewValueResponse("fake-value"),
+		}
+		auditLogger = &platformfakes.FakeAuditLogger{}
+
+		policy = AuthorizationPolicy{
+			Rules: []AuthorizationRule{
+				{ReplyToPrefix: "director.", Methods: []string{"*"}},
+				{ClientName: "monitoring", Methods: []string{"ping", "get_state"}},
+				{ReplyToPrefix: "hm.", ClientName: "health-monitor", Methods: []string{"get_state"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		dispatcher = NewAuthorizingActionDispatcher(innerDispatcher, policy, auditLogger, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	dispatch := func(replyTo, clientName, method string) boshhandler.Response {
+		req := boshhandler.NewRequest(replyTo, method, []byte{}, 0)
+		req.ClientName = clientName
+		return dispatcher.Dispatch(req)
+	}
+
+	It("dispatches any method for caller allowed to call all methods", func() {
+		resp := dispatch("director.fake-director-id", "", "ssh")
+		Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+		Expect(innerDispatcher.DispatchReq.Method).To(Equal("ssh"))
+	})
+
+	It("dispatches methods that caller identified by client name is allowed to call", func() {
+		resp := dispatch("fake-reply-to", "monitoring", "get_state")
+		Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+		Expect(innerDispatcher.DispatchReq.ClientName).To(Equal("monitoring"))
+	})
+
+	It("denies methods that caller is not allowed to call and records them in audit log", func() {
+		resp := dispatch("fake-reply-to", "monitoring", "ssh")
+		boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Caller 'monitoring' is not allowed to call ssh"}}`)
+
+		Expect(innerDispatcher.DispatchReq).To(Equal(boshhandler.Request{}))
+
+		Expect(auditLogger.ErrCallCount()).To(Equal(1))
+		Expect(auditLogger.ErrArgsForCall(0)).To(ContainSubstring("|agent_api|ssh|7|duser=monitoring"))
+	})
+
+	It("only applies rule when caller matches all of its criteria", func() {
+		resp := dispatch("hm.fake-id", "", "get_state")
+		boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Caller 'hm.fake-id' is not allowed to call get_state"}}`)
+
+		resp = dispatch("hm.fake-id", "health-monitor", "get_state")
+		Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+	})
+
+	It("applies the first rule that matches caller", func() {
+		policy.Rules = append([]AuthorizationRule{{ClientName: "monitoring", Methods: []string{"ping"}}}, policy.Rules...)
+		dispatcher = NewAuthorizingActionDispatcher(innerDispatcher, policy, auditLogger, boshlog.NewLogger(boshlog.LevelNone))
+
+		resp := dispatch("fake-reply-to", "monitoring", "get_state")
+		boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Caller 'monitoring' is not allowed to call get_state"}}`)
+	})
+
+	It("denies callers that match no rule", func() {
+		resp := dispatch("fake-reply-to", "", "ping")
+		boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Caller 'fake-reply-to' is not allowed to call ping"}}`)
+		Expect(auditLogger.ErrCallCount()).To(Equal(1))
+	})
+
+	It("lets rule without criteria match every caller", func() {
+		policy.Rules = append(policy.Rules, AuthorizationRule{Methods: []string{"ping"}})
+		dispatcher = NewAuthorizingActionDispatcher(innerDispatcher, policy, auditLogger, boshlog.NewLogger(boshlog.LevelNone))
+
+		resp := dispatch("fake-reply-to", "", "ping")
+		Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+	})
+
+	Context("when caller is allowed to call batch", func() {
+		dispatchBatch := func(replyTo string, payload string) boshhandler.Response {
+			req := boshhandler.NewRequest(replyTo, "batch", []byte(payload), 0)
+			return dispatcher.Dispatch(req)
+		}
+
+		BeforeEach(func() {
+			policy.Rules = append(policy.Rules, AuthorizationRule{
+				ReplyToPrefix: "operator.",
+				Methods:       []string{"batch", "stop", "drain"},
+			})
+		})
+
+		It("dispatches batch whose steps caller is allowed to call", func() {
+			resp := dispatchBatch("operator.fake-id", `{"arguments":[[{"method":"stop"},{"method":"drain","arguments":["shutdown"]}],{}]}`)
+			Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+			Expect(innerDispatcher.DispatchReq.Method).To(Equal("batch"))
+		})
+
+		It("denies batch with a step that caller is not allowed to call", func() {
+			resp := dispatchBatch("operator.fake-id", `{"arguments":[[{"method":"stop"},{"method":"apply","arguments":[{}]}],{}]}`)
+			boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Caller 'operator.fake-id' is not allowed to call apply in batch"}}`)
+
+			Expect(innerDispatcher.DispatchReq).To(Equal(boshhandler.Request{}))
+			Expect(auditLogger.ErrCallCount()).To(Equal(1))
+			Expect(auditLogger.ErrArgsForCall(0)).To(ContainSubstring("|agent_api|batch|7|"))
+		})
+
+		It("denies batch whose steps cannot be read", func() {
+			resp := dispatchBatch("operator.fake-id", `{"arguments":["not-steps"]}`)
+			boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Caller 'operator.fake-id' is not allowed to call batch"}}`)
+			Expect(innerDispatcher.DispatchReq).To(Equal(boshhandler.Request{}))
+		})
+
+		It("does not check steps for caller allowed to call all methods", func() {
+			resp := dispatchBatch("director.fake-director-id", `{"arguments":[[{"method":"apply","arguments":[{}]}],{}]}`)
+			Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+		})
+	})
+
+	Context("when policy has no rules", func() {
+		BeforeEach(func() {
+			policy = AuthorizationPolicy{}
+		})
+
+		It("dispatches any method for any caller", func() {
+			resp := dispatch("fake-reply-to", "", "ssh")
+			Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+			Expect(auditLogger.ErrCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("with local authorization policy", func() {
+		BeforeEach(func() {
+			policy = LocalAuthorizationPolicy()
+		})
+
+		It("dispatches read-only methods", func() {
+			for _, method := range []string{"get_state", "list_disk", "info", "ping", "get_task"} {
+				resp := dispatch("", "local", method)
+				Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+			}
+		})
+
+		It("denies methods that change the agent", func() {
+			resp := dispatch("", "local", "apply")
+			boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Caller 'local' is not allowed to call apply"}}`)
+		})
+	})
+
+	It("resumes previously dispatched tasks without checking policy", func() {
+		dispatcher.ResumePreviouslyDispatchedTasks()
+		Expect(innerDispatcher.ResumedPreviouslyDispatchedTasks).To(BeTrue())
+	})
+})
@@ -0,0 +1,56 @@
+package blobstore
+
+import (
+	utilblobstore "github.com/cloudfoundry/bosh-utils/blobstore"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const cancelableCreateLogTag = "cancelableCreate"
+
+type createResult struct {
+	blobID string
+	digest boshcrypto.MultipleDigest
+	err    error
+}
+
+// CreateCancelable uploads fileName to the blobstore and gives up waiting
+// for the upload as soon as cancelCh is signalled. Blobstore clients cannot be
+// interrupted mid-upload, so a blob that finishes uploading after cancellation
+// is deleted to avoid leaving orphaned blobs behind.
+func CreateCancelable(
+	blobstore utilblobstore.DigestBlobstore,
+	fileName string,
+	cancelCh <-chan struct{},
+	logger boshlog.Logger,
+) (string, boshcrypto.MultipleDigest, error) {
+	resultCh := make(chan createResult, 1)
+
+	go func() {
+		blobID, digest, err := blobstore.Create(fileName)
+		resultCh <- createResult{blobID: blobID, digest: digest, err: err}
+	}()
+
+	select {
+	case result := <-resultCh:
+		return result.blobID, result.digest, result.err
+
+	case <-cancelCh:
+		go func() {
+			result := <-resultCh
+			if result.err != nil {
+				return
+			}
+
+			logger.Debug(cancelableCreateLogTag, "Deleting blob '%s' uploaded after cancellation", result.blobID)
+
+			err := blobstore.Delete(result.blobID)
+			if err != nil {
+				logger.Error(cancelableCreateLogTag, "Failed to delete blob '%s': %s", result.blobID, err.Error())
+			}
+		}()
+
+		return "", boshcrypto.MultipleDigest{}, bosherr.Error("Upload was cancelled by user request")
+	}
+}
@@ -0,0 +1,83 @@
+package blobstore_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/bosh-agent/agent/blobstore"
+	fakeblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("CreateCancelable", func() {
+	var (
+		innerBlobstore *fakeblob.FakeDigestBlobstore
+		cancelCh       chan struct{}
+		logger         boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		innerBlobstore = &fakeblob.FakeDigestBlobstore{}
+		cancelCh = make(chan struct{})
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	It("returns the result of the upload when it is not cancelled", func() {
+		digest := boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "fake-sha1"))
+		innerBlobstore.CreateReturns("fake-blob-id", digest, nil)
+
+		blobID, createdDigest, err := blobstore.CreateCancelable(innerBlobstore, "/fake-file", cancelCh, logger)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("fake-blob-id"))
+		Expect(createdDigest).To(Equal(digest))
+
+		Expect(innerBlobstore.CreateArgsForCall(0)).To(Equal("/fake-file"))
+		Expect(innerBlobstore.DeleteCallCount()).To(Equal(0))
+	})
+
+	It("returns the error of the upload when it is not cancelled", func() {
+		innerBlobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
+
+		_, _, err := blobstore.CreateCancelable(innerBlobstore, "/fake-file", cancelCh, logger)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("fake-create-err"))
+	})
+
+	Context("when cancelled during upload", func() {
+		var finishUploadCh chan struct{}
+
+		BeforeEach(func() {
+			finishUploadCh = make(chan struct{})
+			innerBlobstore.CreateStub = func(string) (string, boshcrypto.MultipleDigest, error) {
+				<-finishUploadCh
+				return "fake-blob-id", boshcrypto.MultipleDigest{}, nil
+			}
+		})
+
+		It("returns without waiting for upload to finish", func() {
+			close(cancelCh)
+
+			_, _, err := blobstore.CreateCancelable(innerBlobstore, "/fake-file", cancelCh, logger)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Upload was cancelled by user request"))
+
+			close(finishUploadCh)
+		})
+
+		It("deletes blob once the abandoned upload finishes", func() {
+			close(cancelCh)
+
+			_, _, err := blobstore.CreateCancelable(innerBlobstore, "/fake-file", cancelCh, logger)
+			Expect(err).To(HaveOccurred())
+
+			Expect(innerBlobstore.DeleteCallCount()).To(Equal(0))
+			close(finishUploadCh)
+
+			Eventually(innerBlobstore.DeleteCallCount).Should(Equal(1))
+			Expect(innerBlobstore.DeleteArgsForCall(0)).To(Equal("fake-blob-id"))
+		})
+	})
+})
@@ -17,4 +17,8 @@ type CmdResult struct {
 
 type CmdRunner interface {
 	RunCommand(jobName, taskName string, cmd boshsys.Command) (*CmdResult, error)
+
+	// RunCancelableCommand terminates command's process group
+	// when a value is received from cancelCh before command exits
+	RunCancelableCommand(jobName, taskName string, cmd boshsys.Command, cancelCh <-chan struct{}) (*CmdResult, error)
 }
@@ -11,6 +11,8 @@ type FakeFileLoggingCmdRunner struct {
 	RunCommandTaskName string
 	RunCommandResult   *boshcmdrunner.CmdResult
 	RunCommandErr      error
+
+	RunCommandCancelCh <-chan struct{}
 }
 
 func NewFakeFileLoggingCmdRunner() *FakeFileLoggingCmdRunner {
@@ -23,3 +25,8 @@ func (f *FakeFileLoggingCmdRunner) RunCommand(jobName, taskName string, cmd bosh
 	f.RunCommands = append(f.RunCommands, cmd)
 	return f.RunCommandResult, f.RunCommandErr
 }
+
+func (f *FakeFileLoggingCmdRunner) RunCancelableCommand(jobName, taskName string, cmd boshsys.Command, cancelCh <-chan struct{}) (*boshcmdrunner.CmdResult, error) {
+	f.RunCommandCancelCh = cancelCh
+	return f.RunCommand(jobName, taskName, cmd)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 	"unicode/utf8"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -14,6 +15,8 @@ import (
 const (
 	fileOpenFlag int         = os.O_RDWR | os.O_CREATE | os.O_TRUNC
 	fileOpenPerm os.FileMode = os.FileMode(0640)
+
+	cancelKillGracePeriod = 10 * time.Second
 )
 
 type FileLoggingCmdRunner struct {
@@ -62,6 +65,62 @@ func NewFileLoggingCmdRunner(
 }
 
 func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd boshsys.Command) (*CmdResult, error) {
+	return f.runCommand(jobName, taskName, cmd, func(cmd boshsys.Command) (int, error) {
+		_, _, exitStatus, err := f.cmdRunner.RunComplexCommand(cmd)
+		return exitStatus, err
+	})
+}
+
+func (f FileLoggingCmdRunner) RunCancelableCommand(jobName string, taskName string, cmd boshsys.Command, cancelCh <-chan struct{}) (*CmdResult, error) {
+	var terminateErr error
+	isCanceled := false
+
+	result, err := f.runCommand(jobName, taskName, cmd, func(cmd boshsys.Command) (int, error) {
+		process, err := f.cmdRunner.RunComplexCommandAsync(cmd)
+		if err != nil {
+			return -1, err
+		}
+
+		var result boshsys.Result
+
+		// Can only wait once on a process but cancelling can happen multiple times
+		for processExitedCh := process.Wait(); processExitedCh != nil; {
+			select {
+			case result = <-processExitedCh:
+				processExitedCh = nil
+			case <-cancelCh:
+				// Stop selecting on cancelCh since it may be closed rather than sent to
+				cancelCh = nil
+				isCanceled = true
+
+				// Command is started in its own process group
+				// so that all of its children are terminated as well
+				terminateErr = process.TerminateNicely(cancelKillGracePeriod)
+				if terminateErr != nil {
+					return -1, terminateErr
+				}
+			}
+		}
+
+		return result.ExitStatus, result.Error
+	})
+
+	if isCanceled {
+		if terminateErr != nil {
+			return nil, bosherr.WrapError(terminateErr, "Terminating canceled command")
+		}
+
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Command was cancelled by user request")
+		}
+
+		return nil, bosherr.Error("Command was cancelled by user request")
+	}
+
+	return result, err
+}
+
+func (f FileLoggingCmdRunner) runCommand(jobName string, taskName string, cmd boshsys.Command, run func(boshsys.Command) (int, error)) (*CmdResult, error) {
 	logsDir := path.Join(f.baseDir, jobName)
 
 	err := f.fs.RemoveAll(logsDir)
@@ -98,7 +157,7 @@ func (f FileLoggingCmdRunner) RunCommand(jobName string, taskName string, cmd bo
 	cmd.Stderr = stderrFile
 
 	// Stdout/stderr are redirected to the files
-	_, _, exitStatus, runErr := f.cmdRunner.RunComplexCommand(cmd)
+	exitStatus, runErr := run(cmd)
 
 	stdout, isStdoutTruncated, err := f.getTruncatedOutput(stdoutFile, f.truncateLength)
 	if err != nil {
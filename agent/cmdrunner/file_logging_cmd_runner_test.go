@@ -3,6 +3,7 @@ package cmdrunner_test
 import (
 	"errors"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -293,4 +294,68 @@ var _ = Describe("FileLoggingCmdRunner", func() {
 			})
 		})
 	})
+
+	Describe("RunCancelableCommand", func() {
+		var (
+			process  *fakesys.FakeProcess
+			cancelCh chan struct{}
+		)
+
+		BeforeEach(func() {
+			process = &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{ExitStatus: 0},
+			}
+			cmdRunner.AddProcess("fake-cmd fake-args", process)
+
+			cancelCh = make(chan struct{}, 1)
+		})
+
+		It("runs given command asynchronously and returns its result", func() {
+			result, err := runner.RunCancelableCommand("fake-log-dir-name", "fake-log-file-name", cmd, cancelCh)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.ExitStatus).To(Equal(0))
+
+			Expect(cmdRunner.RunComplexCommands).To(HaveLen(1))
+			Expect(cmdRunner.RunComplexCommands[0].Stdout).ToNot(BeNil())
+			Expect(process.TerminatedNicely).To(BeFalse())
+		})
+
+		It("returns an error if command fails to start", func() {
+			process.StartErr = errors.New("fake-start-error")
+
+			_, err := runner.RunCancelableCommand("fake-log-dir-name", "fake-log-file-name", cmd, cancelCh)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Command exited with -1"))
+		})
+
+		Context("when command is canceled", func() {
+			BeforeEach(func() {
+				process.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{
+						ExitStatus: 143,
+						Error:      errors.New("fake-terminated-error"),
+					}
+				}
+				cancelCh <- struct{}{}
+			})
+
+			It("terminates command's process group", func() {
+				_, err := runner.RunCancelableCommand("fake-log-dir-name", "fake-log-file-name", cmd, cancelCh)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Command was cancelled by user request"))
+
+				Expect(process.TerminatedNicely).To(BeTrue())
+				Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+			})
+
+			It("returns an error if terminating fails", func() {
+				process.TerminatedNicelyCallBack = func(*fakesys.FakeProcess) {}
+				process.TerminateNicelyErr = errors.New("fake-terminate-error")
+
+				_, err := runner.RunCancelableCommand("fake-log-dir-name", "fake-log-file-name", cmd, cancelCh)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-terminate-error"))
+			})
+		})
+	})
 })
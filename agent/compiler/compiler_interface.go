@@ -2,11 +2,15 @@ package compiler
 
 import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 )
 
 type Compiler interface {
-	Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error)
+	Compile(pkg Package, deps []boshmodels.Package, progress boshtask.ProgressFunc) (blobID string, digest boshcrypto.Digest, err error)
+
+	// Cancel aborts in-progress compilation and rolls back installed packages
+	Cancel() error
 }
 
 type Package struct {
@@ -7,7 +7,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
+func (c *concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package, cancelCh <-chan struct{}) error {
 	command := boshsys.Command{
 		Name: "bash",
 		Args: []string{"-x", PackagingScriptName},
@@ -19,7 +19,7 @@ func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pk
 		},
 		WorkingDir: compilePath,
 	}
-	_, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
+	_, err := c.runner.RunCancelableCommand("compilation", PackagingScriptName, command, cancelCh)
 	if err != nil {
 		return bosherr.WrapError(err, "Running packaging script")
 	}
@@ -7,7 +7,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package) error {
+func (c *concreteCompiler) runPackagingCommand(compilePath, enablePath string, pkg Package, cancelCh <-chan struct{}) error {
 	command := boshsys.Command{
 		Name: "powershell",
 		Args: []string{"-command", fmt.Sprintf("iex (get-content -raw %s)", PackagingScriptName)},
@@ -20,7 +20,7 @@ func (c concreteCompiler) runPackagingCommand(compilePath, enablePath string, pk
 		WorkingDir: compilePath,
 	}
 
-	_, err := c.runner.RunCommand("compilation", PackagingScriptName, command)
+	_, err := c.runner.RunCancelableCommand("compilation", PackagingScriptName, command, cancelCh)
 	if err != nil {
 		return bosherr.WrapError(err, "Running packaging script")
 	}
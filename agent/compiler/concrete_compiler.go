@@ -8,15 +8,24 @@ import (
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	"github.com/cloudfoundry/bosh-agent/agent/applier/packages"
+	boshagentblob "github.com/cloudfoundry/bosh-agent/agent/blobstore"
 	boshcmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
-const PackagingScriptName = "packaging"
+const (
+	PackagingScriptName = "packaging"
+
+	concreteCompilerLogTag = "concreteCompiler"
+)
+
+var errCompilationCanceled = bosherr.Error("Compilation was cancelled by user request")
 
 type CompileDirProvider interface {
 	CompileDir() string
@@ -30,6 +39,9 @@ type concreteCompiler struct {
 	compileDirProvider CompileDirProvider
 	packageApplier     packages.Applier
 	packagesBc         boshbc.BundleCollection
+	logger             boshlog.Logger
+
+	cancel boshtask.RunCancel
 }
 
 func NewConcreteCompiler(
@@ -40,8 +52,9 @@ func NewConcreteCompiler(
 	compileDirProvider CompileDirProvider,
 	packageApplier packages.Applier,
 	packagesBc boshbc.BundleCollection,
+	logger boshlog.Logger,
 ) Compiler {
-	return concreteCompiler{
+	return &concreteCompiler{
 		compressor:         compressor,
 		blobstore:          blobstore,
 		fs:                 fs,
@@ -49,24 +62,48 @@ func NewConcreteCompiler(
 		compileDirProvider: compileDirProvider,
 		packageApplier:     packageApplier,
 		packagesBc:         packagesBc,
+		logger:             logger,
 	}
 }
 
-func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
+func (c *concreteCompiler) Compile(pkg Package, deps []boshmodels.Package, progress boshtask.ProgressFunc) (blobID string, digest boshcrypto.Digest, err error) {
+	cancelCh := c.cancel.Start()
+	defer c.cancel.Finish(cancelCh)
+
+	var compiledPkgBundle boshbc.Bundle
+
+	defer func() {
+		if err != nil && boshtask.IsCanceled(cancelCh) {
+			err = c.rollBack(compiledPkgBundle, err)
+		}
+	}()
+
 	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Removing packages")
 	}
 
-	for _, dep := range deps {
+	for i, dep := range deps {
+		if boshtask.IsCanceled(cancelCh) {
+			return "", nil, errCompilationCanceled
+		}
+
+		progress(boshtask.Progress{Stage: "Installing dependencies", Current: int64(i + 1), Total: int64(len(deps))})
+
 		err := c.packageApplier.Apply(dep)
 		if err != nil {
 			return "", nil, bosherr.WrapErrorf(err, "Installing dependent package: '%s'", dep.Name)
 		}
 	}
 
+	if boshtask.IsCanceled(cancelCh) {
+		return "", nil, errCompilationCanceled
+	}
+
 	compilePath := path.Join(c.compileDirProvider.CompileDir(), pkg.Name)
 
+	progress(boshtask.Progress{Stage: "Fetching package"})
+
 	err = c.fetchAndUncompress(pkg, compilePath)
 	if err != nil {
 		return "", nil, bosherr.WrapErrorf(err, "Fetching package %s", pkg.Name)
@@ -84,7 +121,7 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		Version: pkg.Version,
 	}
 
-	compiledPkgBundle, err := c.packagesBc.Get(compiledPkg)
+	compiledPkgBundle, err = c.packagesBc.Get(compiledPkg)
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Getting bundle for new package")
 	}
@@ -102,11 +139,19 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 	scriptPath := path.Join(compilePath, PackagingScriptName)
 
 	if c.fs.FileExists(scriptPath) {
-		if err := c.runPackagingCommand(compilePath, enablePath, pkg); err != nil {
+		progress(boshtask.Progress{Stage: "Running packaging script"})
+
+		if err := c.runPackagingCommand(compilePath, enablePath, pkg, cancelCh); err != nil {
 			return "", nil, bosherr.WrapError(err, "Running packaging script")
 		}
 	}
 
+	if boshtask.IsCanceled(cancelCh) {
+		return "", nil, errCompilationCanceled
+	}
+
+	progress(boshtask.Progress{Stage: "Compressing compiled package"})
+
 	tmpPackageTar, err := c.compressor.CompressFilesInDir(installPath)
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Compressing compiled package")
@@ -127,11 +172,23 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 		return "", nil, bosherr.WrapError(err, "Calculating compiled package digest")
 	}
 
-	uploadedBlobID, _, err := c.blobstore.Create(tmpPackageTar)
+	uploadProgress := boshtask.Progress{Stage: "Uploading compiled package", Unit: boshtask.ProgressUnitBytes}
+
+	// Size is only informational so failing to determine it does not fail compilation
+	if fileInfo, statErr := file.Stat(); statErr == nil {
+		uploadProgress.Total = fileInfo.Size()
+	}
+
+	progress(uploadProgress)
+
+	uploadedBlobID, _, err := boshagentblob.CreateCancelable(c.blobstore, tmpPackageTar, cancelCh, c.logger)
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Uploading compiled package")
 	}
 
+	uploadProgress.Current = uploadProgress.Total
+	progress(uploadProgress)
+
 	err = compiledPkgBundle.Disable()
 	if err != nil {
 		return "", nil, bosherr.WrapError(err, "Disabling compiled package")
@@ -150,7 +207,38 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (blobI
 	return uploadedBlobID, digest, nil
 }
 
-func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) error {
+func (c *concreteCompiler) Cancel() error {
+	// Cancelling while no package is being compiled is a no-op
+	c.cancel.Cancel()
+	return nil
+}
+
+// rollBack removes compiled package bundle and installed dependencies
+// so that a cancelled compilation does not leave anything behind
+func (c *concreteCompiler) rollBack(compiledPkgBundle boshbc.Bundle, cancelErr error) error {
+	c.logger.Info(concreteCompilerLogTag, "Rolling back cancelled compilation")
+
+	if compiledPkgBundle != nil {
+		err := compiledPkgBundle.Disable()
+		if err != nil {
+			return bosherr.WrapError(err, "Rolling back cancelled compilation: Disabling compiled package")
+		}
+
+		err = compiledPkgBundle.Uninstall()
+		if err != nil {
+			return bosherr.WrapError(err, "Rolling back cancelled compilation: Uninstalling compiled package")
+		}
+	}
+
+	err := c.packageApplier.KeepOnly([]boshmodels.Package{})
+	if err != nil {
+		return bosherr.WrapError(err, "Rolling back cancelled compilation: Removing packages")
+	}
+
+	return cancelErr
+}
+
+func (c *concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) error {
 	if pkg.BlobstoreID == "" {
 		return bosherr.Error(fmt.Sprintf("Blobstore ID for package '%s' is empty", pkg.Name))
 	}
@@ -168,7 +256,7 @@ func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) erro
 	return nil
 }
 
-func (c concreteCompiler) atomicDecompress(archivePath string, finalDir string) error {
+func (c *concreteCompiler) atomicDecompress(archivePath string, finalDir string) error {
 	tmpInstallPath := finalDir + "-bosh-agent-unpack"
 
 	{
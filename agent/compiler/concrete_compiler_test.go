@@ -14,9 +14,11 @@ import (
 	fakepackages "github.com/cloudfoundry/bosh-agent/agent/applier/packages/fakes"
 	fakecmdrunner "github.com/cloudfoundry/bosh-agent/agent/cmdrunner/fakes"
 	. "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	fakeblobstore "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 )
@@ -85,6 +87,7 @@ func init() {
 				FakeCompileDirProvider{Dir: "/fake-compile-dir"},
 				packageApplier,
 				packagesBc,
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 
 			fs.MkdirAll("/fake-compile-dir", os.ModePerm)
@@ -115,20 +118,43 @@ func init() {
 			It("returns blob id and sha1 of created compiled package", func() {
 				blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
 
-				blobID, digest, err := compiler.Compile(pkg, pkgDeps)
+				blobID, digest, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(blobID).To(Equal("fake-blob-id"))
 				Expect(digest).To(Equal(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA1, "978ad524a02039f261773fe93d94973ae7de6470")))
 			})
 
+			It("reports progress of each compilation stage", func() {
+				blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
+				compressor.DecompressFileToDirCallBack = func() {
+					fs.WriteFileString("/fake-compile-dir/pkg_name/"+PackagingScriptName, "yay")
+				}
+
+				var reported []boshtask.Progress
+				progress := func(p boshtask.Progress) { reported = append(reported, p) }
+
+				_, _, err := compiler.Compile(pkg, pkgDeps, progress)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(reported).To(Equal([]boshtask.Progress{
+					{Stage: "Installing dependencies", Current: 1, Total: 2},
+					{Stage: "Installing dependencies", Current: 2, Total: 2},
+					{Stage: "Fetching package"},
+					{Stage: "Running packaging script"},
+					{Stage: "Compressing compiled package"},
+					{Stage: "Uploading compiled package", Current: 0, Total: 13, Unit: "bytes"},
+					{Stage: "Uploading compiled package", Current: 13, Total: 13, Unit: "bytes"},
+				}))
+			})
+
 			It("returns blob id and correct sha algo of created compiled package", func() {
 				blobstore.CreateReturns("fake-blob-id", boshcrypto.MultipleDigest{}, nil)
 
 				// Currently algo of source package is used for compilation pkg algo
 				pkg.Sha1 = boshcrypto.MustNewMultipleDigest(boshcrypto.NewDigest(boshcrypto.DigestAlgorithmSHA256, "fakesha"))
 
-				_, digest, err := compiler.Compile(pkg, pkgDeps)
+				_, digest, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 				// echo -n fake-contents|shasum -a 256
 				Expect(digest.String()).To(Equal("sha256:d12d3a3ee8dcdc9e7ea3416fd618298ea50abde2cf434313c6c3edb213f441cd"))
@@ -139,7 +165,7 @@ func init() {
 			})
 
 			It("cleans up all packages before and after applying dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "Apply", "KeepOnly"}))
 				Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
@@ -148,7 +174,7 @@ func init() {
 			It("returns an error if cleaning up packages fails", func() {
 				packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
 			})
@@ -161,7 +187,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -174,7 +200,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -187,7 +213,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -195,7 +221,7 @@ func init() {
 			It("returns an error if creating temporary compile target directory during uncompression fails", func() {
 				fs.RegisterMkdirAllError("/fake-compile-dir/pkg_name-bosh-agent-unpack", errors.New("fake-mkdir-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -203,25 +229,25 @@ func init() {
 			It("returns an error if target directory is empty during uncompression", func() {
 				pkg.BlobstoreID = ""
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("Blobstore ID for package '%s' is empty", pkg.Name))
 			})
 
 			It("installs dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.AppliedPackages).To(Equal(pkgDeps))
 			})
 
 			It("cleans up the compile directory", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(fs.FileExists("/fake-compile-dir/pkg_name")).To(BeFalse())
 			})
 
 			It("installs, enables and later cleans up bundle", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(bundle.ActionsCalled).To(Equal([]string{
 					"InstallWithoutContents",
@@ -243,7 +269,7 @@ func init() {
 					return nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -258,7 +284,7 @@ func init() {
 				})
 
 				It("runs packaging script ", func() {
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 					Expect(err).ToNot(HaveOccurred())
 
 					expectedCmd := boshsys.Command{
@@ -289,20 +315,26 @@ func init() {
 				It("propagates the error from packaging script", func() {
 					runner.RunCommandErr = errors.New("fake-packaging-error")
 
-					_, _, err := compiler.Compile(pkg, pkgDeps)
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
 				})
+
+				It("runs packaging script so that it can be cancelled", func() {
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(runner.RunCommandCancelCh).ToNot(BeNil())
+				})
 			})
 
 			It("does not run packaging script when script does not exist", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(runner.RunCommands).To(BeEmpty())
 			})
 
 			It("compresses compiled package", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 
 				// archive was downloaded from the blobstore and decompress to this temp dir
@@ -321,7 +353,7 @@ func init() {
 			It("uploads compressed package to blobstore", func() {
 				compressor.CompressFilesInDirTarballPath = "/tmp/compressed-compiled-package"
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(blobstore.CreateArgsForCall(0)).To(Equal("/tmp/compressed-compiled-package"))
 			})
@@ -329,11 +361,94 @@ func init() {
 			It("returs error if uploading compressed package fails", func() {
 				blobstore.CreateReturns("", boshcrypto.MultipleDigest{}, errors.New("fake-create-err"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-create-err"))
 			})
 
+			Context("when compilation is cancelled", func() {
+				It("stops before installing remaining dependencies and removes installed ones", func() {
+					packageApplier.ApplyStub = func(boshmodels.Package) error {
+						return compiler.Cancel()
+					}
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Compilation was cancelled by user request"))
+
+					Expect(packageApplier.AppliedPackages).To(Equal([]boshmodels.Package{pkgDeps[0]}))
+					Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "KeepOnly"}))
+					Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
+					Expect(blobstore.CreateCallCount()).To(Equal(0))
+				})
+
+				It("rolls back compiled package bundle when packaging script is cancelled", func() {
+					compressor.DecompressFileToDirCallBack = func() {
+						fs.WriteFileString("/fake-compile-dir/pkg_name/"+PackagingScriptName, "hi")
+						Expect(compiler.Cancel()).To(Succeed())
+					}
+					runner.RunCommandErr = errors.New("Command was cancelled by user request")
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Command was cancelled by user request"))
+
+					Expect(bundle.ActionsCalled).To(Equal([]string{"InstallWithoutContents", "Enable", "Disable", "Uninstall"}))
+					Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
+					Expect(blobstore.CreateCallCount()).To(Equal(0))
+				})
+
+				It("stops waiting for the upload and deletes the blob once it is uploaded", func() {
+					finishUploadCh := make(chan struct{})
+					blobstore.CreateStub = func(string) (string, boshcrypto.MultipleDigest, error) {
+						Expect(compiler.Cancel()).To(Succeed())
+						<-finishUploadCh
+						return "fake-blob-id", boshcrypto.MultipleDigest{}, nil
+					}
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Upload was cancelled by user request"))
+					Expect(bundle.ActionsCalled).To(Equal([]string{"InstallWithoutContents", "Enable", "Disable", "Uninstall"}))
+
+					close(finishUploadCh)
+					Eventually(blobstore.DeleteCallCount).Should(Equal(1))
+					Expect(blobstore.DeleteArgsForCall(0)).To(Equal("fake-blob-id"))
+				})
+
+				It("returns an error if rolling back fails", func() {
+					compressor.DecompressFileToDirCallBack = func() {
+						Expect(compiler.Cancel()).To(Succeed())
+					}
+					bundle.DisableErr = errors.New("fake-disable-err")
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Rolling back cancelled compilation"))
+					Expect(err.Error()).To(ContainSubstring("fake-disable-err"))
+				})
+
+				It("does not affect subsequent compilations", func() {
+					packageApplier.ApplyStub = func(boshmodels.Package) error {
+						packageApplier.ApplyStub = nil
+						return compiler.Cancel()
+					}
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).To(HaveOccurred())
+
+					_, _, err = compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("ignores cancel requests made while no compilation is running", func() {
+					Expect(compiler.Cancel()).To(Succeed())
+
+					_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
+					Expect(err).ToNot(HaveOccurred())
+				})
+			})
+
 			It("cleans up compressed package after uploading it to blobstore", func() {
 				var beforeCleanUpTarballPath, afterCleanUpTarballPath string
 
@@ -342,7 +457,7 @@ func init() {
 					return "my-blob-id", boshcrypto.MultipleDigest{}, nil
 				}
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, err := compiler.Compile(pkg, pkgDeps, boshtask.NoopProgressFunc)
 				Expect(err).ToNot(HaveOccurred())
 
 				// Compressed package is not cleaned up before blobstore upload
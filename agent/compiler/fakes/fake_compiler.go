@@ -3,6 +3,7 @@ package fakes
 import (
 	boshmodels "github.com/cloudfoundry/bosh-agent/agent/applier/models"
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 )
 
@@ -12,6 +13,11 @@ type FakeCompiler struct {
 	CompileBlobID string
 	CompileDigest boshcrypto.Digest
 	CompileErr    error
+
+	CompileProgress []boshtask.Progress
+
+	Canceled  bool
+	CancelErr error
 }
 
 func NewFakeCompiler() (c *FakeCompiler) {
@@ -19,11 +25,19 @@ func NewFakeCompiler() (c *FakeCompiler) {
 	return
 }
 
-func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package) (blobID string, digest boshcrypto.Digest, err error) {
+func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package, progress boshtask.ProgressFunc) (blobID string, digest boshcrypto.Digest, err error) {
 	c.CompilePkg = pkg
 	c.CompileDeps = deps
+	for _, p := range c.CompileProgress {
+		progress(p)
+	}
 	blobID = c.CompileBlobID
 	digest = c.CompileDigest
 	err = c.CompileErr
 	return
 }
+
+func (c *FakeCompiler) Cancel() error {
+	c.Canceled = true
+	return c.CancelErr
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"time"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+)
+
+// DefaultIdempotencyWindow is used when agent config does not specify
+// how long responses to requests with idempotency keys are remembered.
+const DefaultIdempotencyWindow = 10 * time.Minute
+
+type Options struct {
+	// Number of seconds during which a request with an already seen
+	// idempotency key is answered with the previous response
+	// instead of being executed again.
+	IdempotencyWindowSeconds int
+
+	Authorization AuthorizationPolicy
+
+	// Deduplication, rate limiting and flap detection
+	// of alerts sent to health monitor
+	Alerts boshalert.PipelineOptions
+
+	// Disk, memory and swap usage levels at which
+	// the agent alerts health monitor on its own
+	Thresholds boshalert.ThresholdOptions
+}
+
+func (o Options) IdempotencyWindow() time.Duration {
+	if o.IdempotencyWindowSeconds <= 0 {
+		return DefaultIdempotencyWindow
+	}
+	return time.Duration(o.IdempotencyWindowSeconds) * time.Second
+}
+
+// AuthorizationPolicy limits which methods each caller may invoke.
+// All callers may invoke any method when there are no rules.
+type AuthorizationPolicy struct {
+	// Rules are checked in order and the first one matching caller applies;
+	// callers that match no rule are denied
+	Rules []AuthorizationRule
+}
+
+// AuthorizationRule matches callers by every criteria that is set;
+// rule without any criteria matches every caller
+type AuthorizationRule struct {
+	// Prefix of request reply-to (e.g. "director." or "hm.").
+	// Reply-to is chosen by sender so this only identifies callers
+	// when mbus permissions restrict who may use such subjects.
+	ReplyToPrefix string
+
+	// Common name or SAN on TLS client certificate
+	ClientName string
+
+	// Methods caller may invoke; "*" allows any method.
+	// Batches are allowed only when every one of their steps is allowed.
+	Methods []string
+}
+
+// LocalAuthorizationPolicy is applied to requests from tools on the VM
+// which may only inspect the agent and never change it
+func LocalAuthorizationPolicy() AuthorizationPolicy {
+	return AuthorizationPolicy{
+		Rules: []AuthorizationRule{
+			{Methods: []string{"get_state", "list_disk", "info", "ping", "get_task"}},
+		},
+	}
+}
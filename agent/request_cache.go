@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+)
+
+// CachedRequest is what is remembered about a dispatched request
+// so that it can be answered again without re-executing its action.
+type CachedRequest struct {
+	Method string
+
+	// TaskID is set for asynchronous actions so that
+	// current task state can be returned instead of initial one.
+	TaskID string
+
+	Response boshhandler.Response
+}
+
+type requestCacheEntry struct {
+	CachedRequest
+
+	expiresAt time.Time
+	done      chan struct{}
+}
+
+// RequestCache remembers responses to requests with idempotency keys
+// for a limited window of time.
+type RequestCache struct {
+	window      time.Duration
+	timeService clock.Clock
+
+	entries map[string]*requestCacheEntry
+	lock    sync.Mutex
+}
+
+func NewRequestCache(window time.Duration, timeService clock.Clock) *RequestCache {
+	return &RequestCache{
+		window:      window,
+		timeService: timeService,
+		entries:     map[string]*requestCacheEntry{},
+	}
+}
+
+// Begin returns previously recorded request for the key, waiting for it
+// to be dispatched if it is still in flight. If the key has not been seen
+// it is reserved and caller must later call Complete or Release.
+func (c *RequestCache) Begin(key, method string) (CachedRequest, bool) {
+	c.lock.Lock()
+
+	c.removeExpired()
+
+	entry, found := c.entries[key]
+	if !found {
+		c.entries[key] = &requestCacheEntry{
+			CachedRequest: CachedRequest{Method: method},
+			done:          make(chan struct{}),
+		}
+		c.lock.Unlock()
+		return CachedRequest{}, false
+	}
+
+	c.lock.Unlock()
+
+	<-entry.done
+
+	return entry.CachedRequest, true
+}
+
+// Complete records response for the reserved key until window expires.
+func (c *RequestCache) Complete(key, taskID string, resp boshhandler.Response) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, found := c.entries[key]
+	if !found {
+		return
+	}
+
+	entry.TaskID = taskID
+	entry.Response = resp
+	entry.expiresAt = c.timeService.Now().Add(c.window)
+
+	close(entry.done)
+}
+
+// Release forgets the reserved key so that request could be retried
+// (e.g. when action could not be started). Requests with the same key
+// that are already waiting receive given response.
+func (c *RequestCache) Release(key string, resp boshhandler.Response) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, found := c.entries[key]
+	if !found {
+		return
+	}
+
+	entry.Response = resp
+
+	delete(c.entries, key)
+	close(entry.done)
+}
+
+func (c *RequestCache) removeExpired() {
+	now := c.timeService.Now()
+
+	for key, entry := range c.entries {
+		// Requests in flight do not have expiration time yet
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
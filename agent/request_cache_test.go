@@ -0,0 +1,116 @@
+package agent_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/agent"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+)
+
+var _ = Describe("RequestCache", func() {
+	var (
+		timeService *fakeclock.FakeClock
+		cache       *RequestCache
+	)
+
+	BeforeEach(func() {
+		timeService = fakeclock.NewFakeClock(time.Now())
+		cache = NewRequestCache(time.Minute, timeService)
+	})
+
+	Describe("Begin", func() {
+		It("reserves key that has not been seen", func() {
+			_, found := cache.Begin("fake-key", "fake-method")
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns completed request for the key", func() {
+			cache.Begin("fake-key", "fake-method")
+			cache.Complete("fake-key", "fake-task-id", boshhandler.NewValueResponse("fake-value"))
+
+			cached, found := cache.Begin("fake-key", "fake-other-method")
+			Expect(found).To(BeTrue())
+			Expect(cached).To(Equal(CachedRequest{
+				Method:   "fake-method",
+				TaskID:   "fake-task-id",
+				Response: boshhandler.NewValueResponse("fake-value"),
+			}))
+		})
+
+		It("forgets completed request after window expires", func() {
+			cache.Begin("fake-key", "fake-method")
+			cache.Complete("fake-key", "", boshhandler.NewValueResponse("fake-value"))
+
+			timeService.Increment(59 * time.Second)
+			_, found := cache.Begin("fake-key", "fake-method")
+			Expect(found).To(BeTrue())
+
+			timeService.Increment(time.Second)
+			_, found = cache.Begin("fake-key", "fake-method")
+			Expect(found).To(BeFalse())
+		})
+
+		It("waits for request in flight to complete", func() {
+			cache.Begin("fake-key", "fake-method")
+
+			resultCh := make(chan CachedRequest)
+			go func() {
+				defer GinkgoRecover()
+				cached, found := cache.Begin("fake-key", "fake-method")
+				Expect(found).To(BeTrue())
+				resultCh <- cached
+			}()
+
+			Consistently(resultCh).ShouldNot(Receive())
+
+			cache.Complete("fake-key", "", boshhandler.NewValueResponse("fake-value"))
+
+			var cached CachedRequest
+			Eventually(resultCh).Should(Receive(&cached))
+			Expect(cached.Response).To(Equal(boshhandler.NewValueResponse("fake-value")))
+		})
+
+		It("does not expire requests in flight", func() {
+			cache.Begin("fake-key", "fake-method")
+			timeService.Increment(time.Hour)
+			cache.Complete("fake-key", "", boshhandler.NewValueResponse("fake-value"))
+
+			cached, found := cache.Begin("fake-key", "fake-method")
+			Expect(found).To(BeTrue())
+			Expect(cached.Response).To(Equal(boshhandler.NewValueResponse("fake-value")))
+		})
+	})
+
+	Describe("Release", func() {
+		It("forgets reserved key so that request could be retried", func() {
+			cache.Begin("fake-key", "fake-method")
+			cache.Release("fake-key", boshhandler.NewValueResponse("fake-value"))
+
+			_, found := cache.Begin("fake-key", "fake-method")
+			Expect(found).To(BeFalse())
+		})
+
+		It("gives response to requests waiting for the key", func() {
+			cache.Begin("fake-key", "fake-method")
+
+			resultCh := make(chan CachedRequest)
+			go func() {
+				defer GinkgoRecover()
+				cached, _ := cache.Begin("fake-key", "fake-method")
+				resultCh <- cached
+			}()
+
+			Consistently(resultCh).ShouldNot(Receive())
+
+			cache.Release("fake-key", boshhandler.NewValueResponse("fake-value"))
+
+			var cached CachedRequest
+			Eventually(resultCh).Should(Receive(&cached))
+			Expect(cached.Response).To(Equal(boshhandler.NewValueResponse("fake-value")))
+		})
+	})
+})
@@ -1,38 +1,62 @@
 package task
 
 import (
+	"sort"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
-// Access to the currentTasks map should always be performed in the semaphore
+// Access to the currentTasks, busyGroups and queuedTasks should always be performed in the semaphore
 // Use the taskSem channel for that
 
+const (
+	asyncTaskServiceLogTag = "Task Service"
+
+	historyEntryMaxErrorLength = 1024
+)
+
 type asyncTaskService struct {
-	uuidGen boshuuid.Generator
-	logger  boshlog.Logger
+	uuidGen     boshuuid.Generator
+	history     History
+	timeService clock.Clock
+	logger      boshlog.Logger
 
 	currentTasks map[string]Task
-	taskChan     chan Task
 	taskSem      chan func()
+
+	// Concurrency groups held by running tasks
+	busyGroups map[string]bool
+
+	// Tasks waiting for their concurrency groups in the order they were started
+	queuedTasks []Task
 }
 
-func NewAsyncTaskService(uuidGen boshuuid.Generator, logger boshlog.Logger) (service Service) {
-	s := asyncTaskService{
+func NewAsyncTaskService(
+	uuidGen boshuuid.Generator,
+	history History,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) (service Service) {
+	s := &asyncTaskService{
 		uuidGen:      uuidGen,
+		history:      history,
+		timeService:  timeService,
 		logger:       logger,
 		currentTasks: make(map[string]Task),
-		taskChan:     make(chan Task),
 		taskSem:      make(chan func()),
+		busyGroups:   make(map[string]bool),
 	}
 
-	go s.processTasks()
 	go s.processSemFuncs()
 
 	return s
 }
 
-func (service asyncTaskService) CreateTask(
+func (service *asyncTaskService) CreateTask(
 	taskFunc Func,
 	cancelFunc CancelFunc,
 	endFunc EndFunc,
@@ -45,7 +69,7 @@ func (service asyncTaskService) CreateTask(
 	return service.CreateTaskWithID(uuid, taskFunc, cancelFunc, endFunc), nil
 }
 
-func (service asyncTaskService) CreateTaskWithID(
+func (service *asyncTaskService) CreateTaskWithID(
 	id string,
 	taskFunc Func,
 	cancelFunc CancelFunc,
@@ -60,19 +84,42 @@ func (service asyncTaskService) CreateTaskWithID(
 	}
 }
 
-func (service asyncTaskService) StartTask(task Task) {
+func (service *asyncTaskService) StartTask(task Task) {
+	task.StartedAt = service.timeService.Now()
+
+	cancelFunc := task.CancelFunc
+	task.CancelFunc = func(task Task) error {
+		return service.cancelTask(task, cancelFunc)
+	}
+
 	taskChan := make(chan Task)
 
 	service.taskSem <- func() {
+		if service.canStart(task) {
+			service.holdGroups(task)
+			task.State = StateRunning
+		} else {
+			task.State = StateQueued
+			service.queuedTasks = append(service.queuedTasks, task)
+		}
+
 		service.currentTasks[task.ID] = task
 		taskChan <- task
 	}
 
 	recordedTask := <-taskChan
-	service.taskChan <- recordedTask
+
+	service.recordHistoryEntry(recordedTask)
+
+	if recordedTask.State == StateQueued {
+		service.logger.Info(asyncTaskServiceLogTag, "Queued task #%s until tasks in groups %v finish", task.ID, task.ConcurrencyGroups)
+		return
+	}
+
+	go service.runTask(recordedTask)
 }
 
-func (service asyncTaskService) FindTaskWithID(id string) (Task, bool) {
+func (service *asyncTaskService) FindTaskWithID(id string) (Task, bool) {
 	taskChan := make(chan Task)
 	foundChan := make(chan bool)
 
@@ -85,7 +132,61 @@ func (service asyncTaskService) FindTaskWithID(id string) (Task, bool) {
 	return <-taskChan, <-foundChan
 }
 
-func (service asyncTaskService) processSemFuncs() {
+func (service *asyncTaskService) UpdateProgress(id string, progress Progress) {
+	service.taskSem <- func() {
+		task, found := service.currentTasks[id]
+		if found && task.State == StateRunning {
+			task.Progress = &progress
+			service.currentTasks[id] = task
+		}
+	}
+}
+
+func (service *asyncTaskService) ListTasks() ([]HistoryEntry, error) {
+	entries, err := service.history.GetEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	tasksChan := make(chan map[string]Task)
+
+	service.taskSem <- func() {
+		tasks := make(map[string]Task, len(service.currentTasks))
+		for id, task := range service.currentTasks {
+			tasks[id] = task
+		}
+		tasksChan <- tasks
+	}
+
+	tasks := <-tasksChan
+
+	var result []HistoryEntry
+
+	for _, entry := range entries {
+		if task, found := tasks[entry.TaskID]; found {
+			entry = newHistoryEntry(task)
+			delete(tasks, entry.TaskID)
+		} else if entry.State == StateRunning || entry.State == StateQueued {
+			// Task was started by a previous agent process and was not resumed
+			entry.State = StateFailed
+			entry.Error = "Task was interrupted by agent restart"
+		}
+		result = append(result, entry)
+	}
+
+	// Tasks whose history entry could not be recorded
+	for _, task := range tasks {
+		result = append(result, newHistoryEntry(task))
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+
+	return result, nil
+}
+
+func (service *asyncTaskService) processSemFuncs() {
 	defer service.logger.HandlePanic("Task Service Process Sem Funcs")
 
 	for {
@@ -94,33 +195,183 @@ func (service asyncTaskService) processSemFuncs() {
 	}
 }
 
-func (service asyncTaskService) processTasks() {
-	defer service.logger.HandlePanic("Task Service Process Tasks")
+func (service *asyncTaskService) runTask(task Task) {
+	defer service.logger.HandlePanic("Task Service Run Task")
 
-	for {
-		task := <-service.taskChan
+	value, err := task.Func()
+	task.FinishedAt = service.timeService.Now()
+	if err != nil {
+		task.Error = err
+		task.State = StateFailed
+		service.logger.Error(asyncTaskServiceLogTag, "Failed processing task #%s got: %s", task.ID, err.Error())
+	} else {
+		task.Value = value
+		task.State = StateDone
+	}
 
-		value, err := task.Func()
-		if err != nil {
-			task.Error = err
-			task.State = StateFailed
-			service.logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
-		} else {
-			task.Value = value
-			task.State = StateDone
+	task = service.finishTask(task)
+
+	startedTasksChan := make(chan []Task)
+
+	service.taskSem <- func() {
+		service.currentTasks[task.ID] = task
+		service.releaseGroups(task)
+		startedTasksChan <- service.startQueuedTasks()
+	}
+
+	service.runStartedTasks(<-startedTasksChan)
+}
+
+func (service *asyncTaskService) cancelTask(task Task, cancelFunc CancelFunc) error {
+	dequeuedChan := make(chan bool)
+
+	service.taskSem <- func() {
+		for i, queuedTask := range service.queuedTasks {
+			if queuedTask.ID == task.ID {
+				task = queuedTask
+				service.queuedTasks = append(service.queuedTasks[:i], service.queuedTasks[i+1:]...)
+				dequeuedChan <- true
+				return
+			}
 		}
+		dequeuedChan <- false
+	}
 
-		if task.EndFunc != nil {
-			task.EndFunc(task)
+	if !<-dequeuedChan {
+		if cancelFunc != nil {
+			return cancelFunc(task)
 		}
+		return nil
+	}
+
+	// Task that has not started yet does not need to be stopped
+	task.FinishedAt = service.timeService.Now()
+	task.Error = bosherr.Error("Task was cancelled before it started")
+	task.State = StateFailed
+
+	task = service.finishTask(task)
+
+	startedTasksChan := make(chan []Task)
+
+	service.taskSem <- func() {
+		service.currentTasks[task.ID] = task
+		startedTasksChan <- service.startQueuedTasks()
+	}
 
-		// Nil to prevent to memory leaks in case these are closures.
-		task.Func = nil
-		task.CancelFunc = nil
-		task.EndFunc = nil
+	// Tasks queued behind cancelled task might be able to start now
+	service.runStartedTasks(<-startedTasksChan)
 
-		service.taskSem <- func() {
+	return nil
+}
+
+func (service *asyncTaskService) runStartedTasks(tasks []Task) {
+	for _, task := range tasks {
+		service.recordHistoryEntry(task)
+		go service.runTask(task)
+	}
+}
+
+func (service *asyncTaskService) finishTask(task Task) Task {
+	if task.EndFunc != nil {
+		task.EndFunc(task)
+	}
+
+	// Nil to prevent to memory leaks in case these are closures.
+	task.Func = nil
+	task.CancelFunc = nil
+	task.EndFunc = nil
+
+	service.recordHistoryEntry(task)
+
+	return task
+}
+
+// canStart must be called in the semaphore
+func (service *asyncTaskService) canStart(task Task) bool {
+	for _, group := range task.ConcurrencyGroups {
+		if service.busyGroups[group] {
+			return false
+		}
+
+		// Do not let task jump ahead of tasks already waiting for the same group
+		for _, queuedTask := range service.queuedTasks {
+			for _, queuedGroup := range queuedTask.ConcurrencyGroups {
+				if queuedGroup == group {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// holdGroups must be called in the semaphore
+func (service *asyncTaskService) holdGroups(task Task) {
+	for _, group := range task.ConcurrencyGroups {
+		service.busyGroups[group] = true
+	}
+}
+
+// releaseGroups must be called in the semaphore
+func (service *asyncTaskService) releaseGroups(task Task) {
+	for _, group := range task.ConcurrencyGroups {
+		delete(service.busyGroups, group)
+	}
+}
+
+// startQueuedTasks must be called in the semaphore
+func (service *asyncTaskService) startQueuedTasks() []Task {
+	var startedTasks []Task
+
+	queuedTasks := service.queuedTasks
+	service.queuedTasks = nil
+
+	for _, task := range queuedTasks {
+		if service.canStart(task) {
+			service.holdGroups(task)
+			task.State = StateRunning
 			service.currentTasks[task.ID] = task
+			startedTasks = append(startedTasks, task)
+		} else {
+			service.queuedTasks = append(service.queuedTasks, task)
+		}
+	}
+
+	return startedTasks
+}
+
+func (service *asyncTaskService) recordHistoryEntry(task Task) {
+	err := service.history.RecordEntry(newHistoryEntry(task))
+	if err != nil {
+		// History is informational only and must not affect task execution
+		service.logger.Warn(asyncTaskServiceLogTag, "Failed to record history of task #%s: %s", task.ID, err.Error())
+	}
+}
+
+func newHistoryEntry(task Task) HistoryEntry {
+	entry := HistoryEntry{
+		TaskID:    task.ID,
+		Method:    task.Method,
+		State:     task.State,
+		StartedAt: task.StartedAt,
+	}
+
+	if task.State == StateRunning {
+		entry.Progress = task.Progress
+	}
+
+	if !task.FinishedAt.IsZero() {
+		finishedAt := task.FinishedAt
+		entry.FinishedAt = &finishedAt
+	}
+
+	if task.Error != nil {
+		entry.Error = task.Error.Error()
+		if len(entry.Error) > historyEntryMaxErrorLength {
+			entry.Error = entry.Error[:historyEntryMaxErrorLength] + "..."
 		}
 	}
+
+	return entry
 }
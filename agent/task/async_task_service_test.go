@@ -3,12 +3,16 @@ package task_test
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"code.cloudfoundry.org/clock/fakeclock"
+
 	. "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 )
@@ -16,13 +20,17 @@ import (
 func init() {
 	Describe("asyncTaskService", func() {
 		var (
-			uuidGen *fakeuuid.FakeGenerator
-			service Service
+			uuidGen     *fakeuuid.FakeGenerator
+			history     *faketask.FakeHistory
+			timeService *fakeclock.FakeClock
+			service     Service
 		)
 
 		BeforeEach(func() {
 			uuidGen = &fakeuuid.FakeGenerator{}
-			service = NewAsyncTaskService(uuidGen, boshlog.NewLogger(boshlog.LevelNone))
+			history = faketask.NewFakeHistory()
+			timeService = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC))
+			service = NewAsyncTaskService(uuidGen, history, timeService, boshlog.NewLogger(boshlog.LevelNone))
 		})
 
 		Describe("StartTask", func() {
@@ -88,6 +96,42 @@ func init() {
 				Expect(task.EndFunc).To(BeNil())
 			})
 
+			It("records start and finish of a task in history", func() {
+				runFunc := func() (interface{}, error) { return nil, errors.New("fake-error") }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+				task.Method = "fake-method"
+
+				task = startAndWaitForTaskCompletion(task)
+				Expect(task.StartedAt).To(Equal(timeService.Now()))
+				Expect(task.FinishedAt).To(Equal(timeService.Now()))
+
+				Eventually(func() []HistoryEntry {
+					entries, _ := history.GetEntries()
+					return entries
+				}).Should(Equal([]HistoryEntry{
+					{
+						TaskID:     "fake-task-id",
+						Method:     "fake-method",
+						State:      StateFailed,
+						StartedAt:  timeService.Now(),
+						FinishedAt: &task.FinishedAt,
+						Error:      "fake-error",
+					},
+				}))
+			})
+
+			It("runs task even if recording history fails", func() {
+				history.RecordEntryErr = errors.New("fake-record-error")
+				runFunc := func() (interface{}, error) { return 123, nil }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+
+				task = startAndWaitForTaskCompletion(task)
+				Expect(task.State).To(Equal(StateDone))
+				Expect(task.Value).To(Equal(123))
+			})
+
 			Describe("CreateTask", func() {
 				It("can run task created with CreateTask which does not have end func", func() {
 					ranFunc := false
@@ -184,6 +228,253 @@ func init() {
 			})
 		})
 
+		Describe("concurrency groups", func() {
+			var (
+				blockChs map[string]chan struct{}
+			)
+
+			BeforeEach(func() {
+				blockChs = map[string]chan struct{}{}
+			})
+
+			startBlockedTask := func(id string, groups ...string) {
+				blockCh := make(chan struct{})
+				blockChs[id] = blockCh
+
+				task := service.CreateTaskWithID(id, func() (interface{}, error) {
+					<-blockCh
+					return id, nil
+				}, nil, nil)
+				task.ConcurrencyGroups = groups
+
+				service.StartTask(task)
+			}
+
+			taskState := func(id string) func() State {
+				return func() State {
+					task, _ := service.FindTaskWithID(id)
+					return task.State
+				}
+			}
+
+			It("runs tasks in different groups at the same time", func() {
+				startBlockedTask("fake-task-1", "jobs")
+				startBlockedTask("fake-task-2", "disks")
+				startBlockedTask("fake-task-3")
+
+				Expect(taskState("fake-task-1")()).To(Equal(StateRunning))
+				Expect(taskState("fake-task-2")()).To(Equal(StateRunning))
+				Expect(taskState("fake-task-3")()).To(Equal(StateRunning))
+
+				close(blockChs["fake-task-1"])
+				close(blockChs["fake-task-2"])
+				close(blockChs["fake-task-3"])
+			})
+
+			It("queues task until task in the same group finishes", func() {
+				startBlockedTask("fake-task-1", "jobs")
+				startBlockedTask("fake-task-2", "jobs")
+
+				Expect(taskState("fake-task-2")()).To(Equal(StateQueued))
+				Consistently(taskState("fake-task-2")).Should(Equal(StateQueued))
+
+				close(blockChs["fake-task-1"])
+
+				Eventually(taskState("fake-task-1")).Should(Equal(StateDone))
+				Eventually(taskState("fake-task-2")).Should(Equal(StateRunning))
+
+				close(blockChs["fake-task-2"])
+
+				Eventually(taskState("fake-task-2")).Should(Equal(StateDone))
+			})
+
+			It("does not let task start ahead of queued tasks that wait for the same group", func() {
+				startBlockedTask("fake-task-1", "jobs")
+				startBlockedTask("fake-task-2", "jobs", "disks")
+				startBlockedTask("fake-task-3", "disks")
+
+				Expect(taskState("fake-task-2")()).To(Equal(StateQueued))
+				Expect(taskState("fake-task-3")()).To(Equal(StateQueued))
+
+				close(blockChs["fake-task-1"])
+
+				Eventually(taskState("fake-task-2")).Should(Equal(StateRunning))
+				Expect(taskState("fake-task-3")()).To(Equal(StateQueued))
+
+				close(blockChs["fake-task-2"])
+
+				Eventually(taskState("fake-task-3")).Should(Equal(StateRunning))
+
+				close(blockChs["fake-task-3"])
+			})
+
+			It("records queued tasks in history", func() {
+				startBlockedTask("fake-task-1", "jobs")
+				startBlockedTask("fake-task-2", "jobs")
+
+				entries, err := service.ListTasks()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[1].TaskID).To(Equal("fake-task-2"))
+				Expect(entries[1].State).To(Equal(StateQueued))
+
+				close(blockChs["fake-task-1"])
+				close(blockChs["fake-task-2"])
+			})
+
+			Describe("cancelling queued task", func() {
+				It("fails task without running it and calls its end func", func() {
+					startBlockedTask("fake-task-1", "jobs")
+
+					var canceled, ended bool
+					task := service.CreateTaskWithID("fake-task-2", func() (interface{}, error) {
+						Fail("queued task should not run")
+						return nil, nil
+					}, func(_ Task) error {
+						canceled = true
+						return nil
+					}, func(_ Task) {
+						ended = true
+					})
+					task.ConcurrencyGroups = []string{"jobs"}
+					service.StartTask(task)
+
+					queuedTask, _ := service.FindTaskWithID("fake-task-2")
+					Expect(queuedTask.Cancel()).To(Succeed())
+
+					cancelledTask, _ := service.FindTaskWithID("fake-task-2")
+					Expect(cancelledTask.State).To(Equal(StateFailed))
+					Expect(cancelledTask.Error).To(MatchError("Task was cancelled before it started"))
+					Expect(canceled).To(BeFalse())
+					Expect(ended).To(BeTrue())
+
+					close(blockChs["fake-task-1"])
+					Eventually(taskState("fake-task-1")).Should(Equal(StateDone))
+				})
+
+				It("starts tasks that were queued behind cancelled task", func() {
+					startBlockedTask("fake-task-1", "jobs")
+					startBlockedTask("fake-task-2", "jobs", "disks")
+					startBlockedTask("fake-task-3", "disks")
+
+					queuedTask, _ := service.FindTaskWithID("fake-task-2")
+					Expect(queuedTask.Cancel()).To(Succeed())
+
+					Eventually(taskState("fake-task-3")).Should(Equal(StateRunning))
+
+					close(blockChs["fake-task-1"])
+					close(blockChs["fake-task-3"])
+				})
+			})
+
+			It("cancels running task with its cancel func", func() {
+				var canceled bool
+				task := service.CreateTaskWithID("fake-task-id", func() (interface{}, error) {
+					return nil, nil
+				}, func(_ Task) error {
+					canceled = true
+					return errors.New("fake-cancel-error")
+				}, nil)
+				task.ConcurrencyGroups = []string{"jobs"}
+
+				startBlockedTask("fake-task-1", "disks")
+				service.StartTask(task)
+
+				runningTask, _ := service.FindTaskWithID("fake-task-id")
+				Expect(runningTask.Cancel()).To(MatchError("fake-cancel-error"))
+				Expect(canceled).To(BeTrue())
+
+				close(blockChs["fake-task-1"])
+			})
+		})
+
+		Describe("ListTasks", func() {
+			It("lists running and finished tasks most recently started first", func() {
+				finishedFunc := func() (interface{}, error) { return nil, nil }
+				finishedTask := service.CreateTaskWithID("fake-finished-task-id", finishedFunc, nil, nil)
+				finishedTask.Method = "fake-finished-method"
+				service.StartTask(finishedTask)
+
+				Eventually(func() State {
+					task, _ := service.FindTaskWithID("fake-finished-task-id")
+					return task.State
+				}).Should(Equal(StateDone))
+
+				timeService.Increment(time.Minute)
+
+				blockCh := make(chan struct{})
+				defer close(blockCh)
+
+				runningFunc := func() (interface{}, error) { <-blockCh; return nil, nil }
+				runningTask := service.CreateTaskWithID("fake-running-task-id", runningFunc, nil, nil)
+				runningTask.Method = "fake-running-method"
+				service.StartTask(runningTask)
+
+				entries, err := service.ListTasks()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(2))
+
+				Expect(entries[0].TaskID).To(Equal("fake-running-task-id"))
+				Expect(entries[0].Method).To(Equal("fake-running-method"))
+				Expect(entries[0].State).To(Equal(StateRunning))
+				Expect(entries[0].FinishedAt).To(BeNil())
+
+				Expect(entries[1].TaskID).To(Equal("fake-finished-task-id"))
+				Expect(entries[1].Method).To(Equal("fake-finished-method"))
+				Expect(entries[1].State).To(Equal(StateDone))
+				Expect(entries[1].FinishedAt).ToNot(BeNil())
+			})
+
+			It("marks tasks left running by a previous agent process as failed", func() {
+				history.Entries = []HistoryEntry{
+					{
+						TaskID:    "fake-task-id",
+						Method:    "fake-method",
+						State:     StateRunning,
+						StartedAt: timeService.Now(),
+					},
+				}
+
+				entries, err := service.ListTasks()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(Equal([]HistoryEntry{
+					{
+						TaskID:    "fake-task-id",
+						Method:    "fake-method",
+						State:     StateFailed,
+						StartedAt: timeService.Now(),
+						Error:     "Task was interrupted by agent restart",
+					},
+				}))
+			})
+
+			It("truncates long task errors", func() {
+				longErr := errors.New(strings.Repeat("e", 2000))
+				runFunc := func() (interface{}, error) { return nil, longErr }
+
+				task := service.CreateTaskWithID("fake-task-id", runFunc, nil, nil)
+				service.StartTask(task)
+
+				Eventually(func() State {
+					task, _ := service.FindTaskWithID("fake-task-id")
+					return task.State
+				}).Should(Equal(StateFailed))
+
+				entries, err := service.ListTasks()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+				Expect(entries[0].Error).To(Equal(strings.Repeat("e", 1024) + "..."))
+			})
+
+			It("returns error when history cannot be read", func() {
+				history.GetEntriesErr = errors.New("fake-get-entries-error")
+
+				_, err := service.ListTasks()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-get-entries-error"))
+			})
+		})
+
 		Describe("CreateTask", func() {
 			It("creates a task with auto-assigned id", func() {
 				uuidGen.GeneratedUUID = "fake-uuid"
@@ -0,0 +1,109 @@
+package task
+
+import (
+	"encoding/json"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Checkpoint records steps completed by a persistent task so that
+// the task can skip them when it is resumed after agent restart.
+type Checkpoint interface {
+	// Get loads result of a completed step into value (if value is not nil)
+	// and returns false if step was not completed yet
+	Get(step string, value interface{}) (bool, error)
+
+	// Complete records that step completed with value as its result
+	Complete(step string, value interface{}) error
+}
+
+// NoopCheckpoint is given to tasks that are not resumed after agent restart
+var NoopCheckpoint Checkpoint = noopCheckpoint{}
+
+type noopCheckpoint struct{}
+
+func (c noopCheckpoint) Get(_ string, _ interface{}) (bool, error) { return false, nil }
+
+func (c noopCheckpoint) Complete(_ string, _ interface{}) error { return nil }
+
+type managerCheckpoint struct {
+	manager Manager
+	taskID  string
+
+	steps     map[string]json.RawMessage
+	stepsLock sync.Mutex
+}
+
+// NewCheckpoint returns checkpoint that is saved in the task info
+// of given task so that it is available when the task is resumed.
+func NewCheckpoint(manager Manager, taskID string, completedSteps map[string]json.RawMessage) Checkpoint {
+	steps := map[string]json.RawMessage{}
+	for step, result := range completedSteps {
+		steps[step] = result
+	}
+
+	return &managerCheckpoint{
+		manager: manager,
+		taskID:  taskID,
+		steps:   steps,
+	}
+}
+
+func (c *managerCheckpoint) Get(step string, value interface{}) (bool, error) {
+	c.stepsLock.Lock()
+	result, found := c.steps[step]
+	c.stepsLock.Unlock()
+
+	if !found {
+		return false, nil
+	}
+
+	if value != nil {
+		err := json.Unmarshal(result, value)
+		if err != nil {
+			return false, bosherr.WrapErrorf(err, "Unmarshalling result of step '%s'", step)
+		}
+	}
+
+	return true, nil
+}
+
+func (c *managerCheckpoint) Complete(step string, value interface{}) error {
+	result, err := json.Marshal(value)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling result of step '%s'", step)
+	}
+
+	err = c.manager.AddCheckpoint(c.taskID, step, result)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Saving checkpoint for step '%s'", step)
+	}
+
+	c.stepsLock.Lock()
+	c.steps[step] = result
+	c.stepsLock.Unlock()
+
+	return nil
+}
+
+// RunStep runs given step unless checkpoint says it was already completed.
+// Value is recorded as result of the step after it runs successfully,
+// or is loaded from the checkpoint when the step is skipped.
+func RunStep(checkpoint Checkpoint, step string, value interface{}, run func() error) error {
+	completed, err := checkpoint.Get(step, value)
+	if err != nil {
+		return err
+	}
+
+	if completed {
+		return nil
+	}
+
+	err = run()
+	if err != nil {
+		return err
+	}
+
+	return checkpoint.Complete(step, value)
+}
@@ -0,0 +1,140 @@
+package task_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	faketask "github.com/cloudfoundry/bosh-agent/agent/task/fakes"
+)
+
+var _ = Describe("Checkpoint", func() {
+	var (
+		manager    *faketask.FakeManager
+		checkpoint boshtask.Checkpoint
+	)
+
+	BeforeEach(func() {
+		manager = faketask.NewFakeManager()
+		err := manager.AddInfo(boshtask.Info{TaskID: "fake-task-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		checkpoint = boshtask.NewCheckpoint(manager, "fake-task-id", map[string]json.RawMessage{
+			"fake-completed-step": json.RawMessage(`{"blob_id":"fake-blob-id"}`),
+		})
+	})
+
+	Describe("Get", func() {
+		It("loads result of previously completed step", func() {
+			var result struct {
+				BlobID string `json:"blob_id"`
+			}
+
+			completed, err := checkpoint.Get("fake-completed-step", &result)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(completed).To(BeTrue())
+			Expect(result.BlobID).To(Equal("fake-blob-id"))
+		})
+
+		It("returns false for steps that were not completed", func() {
+			completed, err := checkpoint.Get("fake-step", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(completed).To(BeFalse())
+		})
+
+		It("returns an error if result cannot be loaded into value", func() {
+			var result int
+
+			_, err := checkpoint.Get("fake-completed-step", &result)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling result of step 'fake-completed-step'"))
+		})
+	})
+
+	Describe("Complete", func() {
+		It("saves step result with the task info", func() {
+			err := checkpoint.Complete("fake-step", map[string]string{"fake": "result"})
+			Expect(err).ToNot(HaveOccurred())
+
+			taskInfos, err := manager.GetInfos()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(taskInfos[0].Checkpoints["fake-step"])).To(Equal(`{"fake":"result"}`))
+
+			completed, err := checkpoint.Get("fake-step", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(completed).To(BeTrue())
+		})
+
+		It("returns an error and does not mark step as completed when saving fails", func() {
+			manager.AddCheckpointErr = errors.New("fake-add-checkpoint-err")
+
+			err := checkpoint.Complete("fake-step", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-add-checkpoint-err"))
+
+			completed, err := checkpoint.Get("fake-step", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(completed).To(BeFalse())
+		})
+	})
+
+	Describe("RunStep", func() {
+		It("runs step that was not completed and records its result", func() {
+			var result string
+
+			err := boshtask.RunStep(checkpoint, "fake-step", &result, func() error {
+				result = "fake-result"
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			var savedResult string
+			completed, err := checkpoint.Get("fake-step", &savedResult)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(completed).To(BeTrue())
+			Expect(savedResult).To(Equal("fake-result"))
+		})
+
+		It("skips step that was already completed and loads its result", func() {
+			var result struct {
+				BlobID string `json:"blob_id"`
+			}
+
+			err := boshtask.RunStep(checkpoint, "fake-completed-step", &result, func() error {
+				Fail("step should not run")
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.BlobID).To(Equal("fake-blob-id"))
+		})
+
+		It("does not record step that failed", func() {
+			err := boshtask.RunStep(checkpoint, "fake-step", nil, func() error {
+				return errors.New("fake-step-err")
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-step-err"))
+
+			completed, err := checkpoint.Get("fake-step", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(completed).To(BeFalse())
+		})
+	})
+
+	Describe("NoopCheckpoint", func() {
+		It("always runs steps", func() {
+			runs := 0
+			for i := 0; i < 2; i++ {
+				err := boshtask.RunStep(boshtask.NoopCheckpoint, "fake-step", nil, func() error {
+					runs++
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(runs).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,173 @@
+package task
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const historyMaxEntries = 100
+
+type concreteHistoryProvider struct{}
+
+func NewHistoryProvider() HistoryProvider {
+	return concreteHistoryProvider{}
+}
+
+func (provider concreteHistoryProvider) NewHistory(
+	logger boshlog.Logger,
+	fs boshsys.FileSystem,
+	dir string,
+) History {
+	return NewHistory(logger, fs, path.Join(dir, "tasks_history.json"), historyMaxEntries)
+}
+
+type concreteHistory struct {
+	logger boshlog.Logger
+
+	fs          boshsys.FileSystem
+	fsSem       chan func()
+	historyPath string
+	maxEntries  int
+
+	// Access to entries and loaded must be synchronized via fsSem
+	entries []HistoryEntry
+	loaded  bool
+}
+
+func NewHistory(logger boshlog.Logger, fs boshsys.FileSystem, historyPath string, maxEntries int) History {
+	h := &concreteHistory{
+		logger:      logger,
+		fs:          fs,
+		fsSem:       make(chan func()),
+		historyPath: historyPath,
+		maxEntries:  maxEntries,
+	}
+
+	go h.processFsFuncs()
+
+	return h
+}
+
+func (h *concreteHistory) GetEntries() ([]HistoryEntry, error) {
+	entriesChan := make(chan []HistoryEntry)
+	errCh := make(chan error)
+
+	h.fsSem <- func() {
+		err := h.loadEntries()
+		entriesChan <- append([]HistoryEntry{}, h.entries...)
+		errCh <- err
+	}
+
+	entries := <-entriesChan
+	err := <-errCh
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (h *concreteHistory) RecordEntry(entry HistoryEntry) error {
+	errCh := make(chan error)
+
+	h.fsSem <- func() {
+		err := h.loadEntries()
+		if err != nil {
+			errCh <- err
+			return
+		}
+
+		h.entries = h.addEntry(h.entries, entry)
+		errCh <- h.writeEntries(h.entries)
+	}
+
+	return <-errCh
+}
+
+func (h *concreteHistory) addEntry(entries []HistoryEntry, entry HistoryEntry) []HistoryEntry {
+	var updated []HistoryEntry
+
+	for _, existing := range entries {
+		if existing.TaskID != entry.TaskID {
+			updated = append(updated, existing)
+		}
+	}
+
+	updated = append(updated, entry)
+
+	sort.SliceStable(updated, func(i, j int) bool {
+		return updated[i].StartedAt.After(updated[j].StartedAt)
+	})
+
+	if len(updated) > h.maxEntries {
+		updated = updated[:h.maxEntries]
+	}
+
+	return updated
+}
+
+func (h *concreteHistory) processFsFuncs() {
+	defer h.logger.HandlePanic("Task History Process Fs Funcs")
+
+	for {
+		do := <-h.fsSem
+		do()
+	}
+}
+
+func (h *concreteHistory) loadEntries() error {
+	if h.loaded {
+		return nil
+	}
+
+	entries, err := h.readEntries()
+	if err != nil {
+		return err
+	}
+
+	h.entries = entries
+	h.loaded = true
+
+	return nil
+}
+
+func (h *concreteHistory) readEntries() ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+
+	exists := h.fs.FileExists(h.historyPath)
+	if !exists {
+		return entries, nil
+	}
+
+	historyJSON, err := h.fs.ReadFile(h.historyPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading tasks history json")
+	}
+
+	err = json.Unmarshal(historyJSON, &entries)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshaling tasks history json")
+	}
+
+	return entries, nil
+}
+
+func (h *concreteHistory) writeEntries(entries []HistoryEntry) error {
+	newHistoryJSON, err := json.Marshal(entries)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling tasks history json")
+	}
+
+	err = h.fs.WriteFile(h.historyPath, newHistoryJSON)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing tasks history json")
+	}
+
+	return nil
+}
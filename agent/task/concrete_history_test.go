@@ -0,0 +1,181 @@
+package task_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+func init() {
+	Describe("concreteHistoryProvider", func() {
+		Describe("NewHistory", func() {
+			It("returns history with tasks_history.json as its path", func() {
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				fs := fakesys.NewFakeFileSystem()
+
+				entry := boshtask.HistoryEntry{
+					TaskID:    "fake-task-id",
+					Method:    "fake-method",
+					State:     boshtask.StateRunning,
+					StartedAt: time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC),
+				}
+
+				history := boshtask.NewHistoryProvider().NewHistory(logger, fs, "/dir/path")
+				err := history.RecordEntry(entry)
+				Expect(err).ToNot(HaveOccurred())
+
+				// Check expected file location with another history
+				otherHistory := boshtask.NewHistory(logger, fs, "/dir/path/tasks_history.json", 10)
+
+				entries, err := otherHistory.GetEntries()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(Equal([]boshtask.HistoryEntry{entry}))
+			})
+		})
+	})
+
+	Describe("concreteHistory", func() {
+		var (
+			logger    boshlog.Logger
+			fs        *fakesys.FakeFileSystem
+			history   boshtask.History
+			startedAt time.Time
+		)
+
+		BeforeEach(func() {
+			logger = boshlog.NewLogger(boshlog.LevelNone)
+			fs = fakesys.NewFakeFileSystem()
+			history = boshtask.NewHistory(logger, fs, "/dir/path", 3)
+			startedAt = time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+		})
+
+		Describe("GetEntries", func() {
+			It("loads entries recorded by a previous history", func() {
+				err := history.RecordEntry(boshtask.HistoryEntry{
+					TaskID:    "fake-task-id-1",
+					Method:    "fake-method-1",
+					State:     boshtask.StateDone,
+					StartedAt: startedAt,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				// Make sure we are not getting cached copy of entries
+				reloadedHistory := boshtask.NewHistory(logger, fs, "/dir/path", 3)
+
+				entries, err := reloadedHistory.GetEntries()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(Equal([]boshtask.HistoryEntry{
+					{
+						TaskID:    "fake-task-id-1",
+						Method:    "fake-method-1",
+						State:     boshtask.StateDone,
+						StartedAt: startedAt,
+					},
+				}))
+			})
+
+			It("succeeds when there is no history (file is not present)", func() {
+				entries, err := history.GetEntries()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+
+			It("returns an error when failing to load history from the file that exists", func() {
+				err := fs.WriteFileString("/dir/path", "[]")
+				Expect(err).ToNot(HaveOccurred())
+
+				fs.ReadFileError = errors.New("fake-read-error")
+
+				_, err = history.GetEntries()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+			})
+		})
+
+		Describe("RecordEntry", func() {
+			It("replaces entry with the same task id", func() {
+				err := history.RecordEntry(boshtask.HistoryEntry{
+					TaskID:    "fake-task-id",
+					Method:    "fake-method",
+					State:     boshtask.StateRunning,
+					StartedAt: startedAt,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				finishedAt := startedAt.Add(time.Minute)
+
+				err = history.RecordEntry(boshtask.HistoryEntry{
+					TaskID:     "fake-task-id",
+					Method:     "fake-method",
+					State:      boshtask.StateFailed,
+					StartedAt:  startedAt,
+					FinishedAt: &finishedAt,
+					Error:      "fake-error",
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				content, err := fs.ReadFile("/dir/path")
+				Expect(err).ToNot(HaveOccurred())
+
+				var decodedEntries []boshtask.HistoryEntry
+
+				err = json.Unmarshal(content, &decodedEntries)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decodedEntries).To(HaveLen(1))
+				Expect(decodedEntries[0].State).To(Equal(boshtask.StateFailed))
+				Expect(decodedEntries[0].FinishedAt.Equal(finishedAt)).To(BeTrue())
+				Expect(decodedEntries[0].Error).To(Equal("fake-error"))
+			})
+
+			It("keeps only the most recently started entries", func() {
+				for i := 0; i < 5; i++ {
+					err := history.RecordEntry(boshtask.HistoryEntry{
+						TaskID:    fmt.Sprintf("fake-task-id-%d", i),
+						State:     boshtask.StateDone,
+						StartedAt: startedAt.Add(time.Duration(i) * time.Minute),
+					})
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				entries, err := history.GetEntries()
+				Expect(err).ToNot(HaveOccurred())
+
+				var taskIDs []string
+				for _, entry := range entries {
+					taskIDs = append(taskIDs, entry.TaskID)
+				}
+				Expect(taskIDs).To(Equal([]string{"fake-task-id-4", "fake-task-id-3", "fake-task-id-2"}))
+			})
+
+			It("does not lose entries recorded by a previous history", func() {
+				err := history.RecordEntry(boshtask.HistoryEntry{TaskID: "fake-task-id-1", StartedAt: startedAt})
+				Expect(err).ToNot(HaveOccurred())
+
+				reloadedHistory := boshtask.NewHistory(logger, fs, "/dir/path", 3)
+
+				err = reloadedHistory.RecordEntry(boshtask.HistoryEntry{TaskID: "fake-task-id-2", StartedAt: startedAt.Add(time.Minute)})
+				Expect(err).ToNot(HaveOccurred())
+
+				entries, err := reloadedHistory.GetEntries()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(2))
+			})
+
+			It("returns an error when failing to save history", func() {
+				fs.WriteFileError = errors.New("fake-write-error")
+
+				err := history.RecordEntry(boshtask.HistoryEntry{TaskID: "fake-task-id"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+			})
+		})
+	})
+}
@@ -96,6 +96,30 @@ func (m *concreteManager) RemoveInfo(taskID string) error {
 	return <-errCh
 }
 
+func (m *concreteManager) AddCheckpoint(taskID, step string, result json.RawMessage) error {
+	errCh := make(chan error)
+
+	m.fsSem <- func() {
+		taskInfo, found := m.taskInfos[taskID]
+		if !found {
+			errCh <- bosherr.Errorf("Task info for '%s' not found", taskID)
+			return
+		}
+
+		checkpoints := map[string]json.RawMessage{}
+		for completedStep, completedResult := range taskInfo.Checkpoints {
+			checkpoints[completedStep] = completedResult
+		}
+		checkpoints[step] = result
+
+		taskInfo.Checkpoints = checkpoints
+		m.taskInfos[taskID] = taskInfo
+
+		errCh <- m.writeInfos(m.taskInfos)
+	}
+	return <-errCh
+}
+
 func (m *concreteManager) processFsFuncs() {
 	defer m.logger.HandlePanic("Task Manager Process Fs Funcs")
 
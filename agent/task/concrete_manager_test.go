@@ -209,5 +209,47 @@ func init() {
 				Expect(err.Error()).To(ContainSubstring("fake-write-error"))
 			})
 		})
+
+		Describe("AddCheckpoint", func() {
+			BeforeEach(func() {
+				err := manager.AddInfo(boshtask.Info{
+					TaskID:  "fake-task-id-1",
+					Method:  "fake-method-1",
+					Payload: []byte("fake-payload-1"),
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("saves completed steps with the task", func() {
+				err := manager.AddCheckpoint("fake-task-id-1", "fake-step-1", json.RawMessage(`"fake-result-1"`))
+				Expect(err).ToNot(HaveOccurred())
+
+				err = manager.AddCheckpoint("fake-task-id-1", "fake-step-2", json.RawMessage(`{"fake":"result-2"}`))
+				Expect(err).ToNot(HaveOccurred())
+
+				reloadedManager := boshtask.NewManager(logger, fs, "/dir/path")
+
+				taskInfos, err := reloadedManager.GetInfos()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(taskInfos).To(HaveLen(1))
+				Expect(taskInfos[0].Checkpoints).To(HaveLen(2))
+				Expect(string(taskInfos[0].Checkpoints["fake-step-1"])).To(Equal(`"fake-result-1"`))
+				Expect(string(taskInfos[0].Checkpoints["fake-step-2"])).To(Equal(`{"fake":"result-2"}`))
+			})
+
+			It("returns an error when task is not known", func() {
+				err := manager.AddCheckpoint("fake-unknown-task-id", "fake-step", json.RawMessage(`null`))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Task info for 'fake-unknown-task-id' not found"))
+			})
+
+			It("returns an error when failing to save checkpoint", func() {
+				fs.WriteFileError = errors.New("fake-write-error")
+
+				err := manager.AddCheckpoint("fake-task-id-1", "fake-step", json.RawMessage(`null`))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+			})
+		})
 	})
 }
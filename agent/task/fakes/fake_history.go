@@ -0,0 +1,46 @@
+package fakes
+
+import (
+	"sync"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+)
+
+type FakeHistory struct {
+	lock sync.Mutex
+
+	Entries []boshtask.HistoryEntry
+
+	GetEntriesErr  error
+	RecordEntryErr error
+}
+
+func NewFakeHistory() *FakeHistory {
+	return &FakeHistory{}
+}
+
+func (h *FakeHistory) GetEntries() ([]boshtask.HistoryEntry, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return append([]boshtask.HistoryEntry{}, h.Entries...), h.GetEntriesErr
+}
+
+func (h *FakeHistory) RecordEntry(entry boshtask.HistoryEntry) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.RecordEntryErr != nil {
+		return h.RecordEntryErr
+	}
+
+	for i, existing := range h.Entries {
+		if existing.TaskID == entry.TaskID {
+			h.Entries[i] = entry
+			return nil
+		}
+	}
+
+	h.Entries = append(h.Entries, entry)
+	return nil
+}
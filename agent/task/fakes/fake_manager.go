@@ -1,11 +1,17 @@
 package fakes
 
-import boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+import (
+	"encoding/json"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+)
 
 type FakeManager struct {
 	taskIDToTaskInfo map[string]boshtask.Info
 
 	AddInfoErr error
+
+	AddCheckpointErr error
 }
 
 func NewFakeManager() *FakeManager {
@@ -29,3 +35,17 @@ func (m *FakeManager) RemoveInfo(taskID string) error {
 	delete(m.taskIDToTaskInfo, taskID)
 	return nil
 }
+
+func (m *FakeManager) AddCheckpoint(taskID, step string, result json.RawMessage) error {
+	if m.AddCheckpointErr != nil {
+		return m.AddCheckpointErr
+	}
+
+	taskInfo := m.taskIDToTaskInfo[taskID]
+	if taskInfo.Checkpoints == nil {
+		taskInfo.Checkpoints = map[string]json.RawMessage{}
+	}
+	taskInfo.Checkpoints[step] = result
+	m.taskIDToTaskInfo[taskID] = taskInfo
+	return nil
+}
@@ -8,6 +8,9 @@ type FakeService struct {
 	StartedTasks        map[string]boshtask.Task
 	CreateTaskErr       error
 	CreateTaskWithIDErr error
+
+	ListTasksEntries []boshtask.HistoryEntry
+	ListTasksErr     error
 }
 
 func NewFakeService() *FakeService {
@@ -50,3 +53,14 @@ func (s *FakeService) FindTaskWithID(id string) (boshtask.Task, bool) {
 	task, found := s.StartedTasks[id]
 	return task, found
 }
+
+func (s *FakeService) UpdateProgress(id string, progress boshtask.Progress) {
+	if task, found := s.StartedTasks[id]; found {
+		task.Progress = &progress
+		s.StartedTasks[id] = task
+	}
+}
+
+func (s *FakeService) ListTasks() ([]boshtask.HistoryEntry, error) {
+	return s.ListTasksEntries, s.ListTasksErr
+}
@@ -0,0 +1,30 @@
+package task
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type HistoryEntry struct {
+	TaskID     string     `json:"agent_task_id"`
+	Method     string     `json:"method"`
+	State      State      `json:"state"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Progress   *Progress  `json:"progress,omitempty"`
+}
+
+type HistoryProvider interface {
+	NewHistory(boshlog.Logger, boshsys.FileSystem, string) History
+}
+
+type History interface {
+	// Entries are returned most recently started first
+	GetEntries() ([]HistoryEntry, error)
+
+	// Adds entry or replaces existing entry with the same task id
+	RecordEntry(entry HistoryEntry) error
+}
@@ -1,6 +1,8 @@
 package task
 
 import (
+	"encoding/json"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
@@ -9,6 +11,11 @@ type Info struct {
 	TaskID  string
 	Method  string
 	Payload []byte
+
+	ProtocolVersion int `json:",omitempty"`
+
+	// Checkpoints maps steps completed by a task to their results
+	Checkpoints map[string]json.RawMessage `json:",omitempty"`
 }
 
 type ManagerProvider interface {
@@ -19,4 +26,7 @@ type Manager interface {
 	GetInfos() ([]Info, error)
 	AddInfo(taskInfo Info) error
 	RemoveInfo(taskID string) error
+
+	// AddCheckpoint records that given step of a task completed with given result
+	AddCheckpoint(taskID, step string, result json.RawMessage) error
 }
@@ -0,0 +1,23 @@
+package task
+
+import "encoding/json"
+
+type Progress struct {
+	Stage   string `json:"stage"`
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+	Unit    string `json:"unit,omitempty"`
+
+	// Data is action specific output that is available
+	// before task finishes, e.g. log lines being followed
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+const ProgressUnitBytes = "bytes"
+
+// ProgressFunc is given to long running actions so that they
+// can report what they are currently doing, e.g. stage "Installing dependencies"
+// with current 3 of total 7, or stage "Uploading" with 120MB of 400MB bytes
+type ProgressFunc func(progress Progress)
+
+func NoopProgressFunc(_ Progress) {}
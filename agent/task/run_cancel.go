@@ -0,0 +1,50 @@
+package task
+
+import "sync"
+
+// RunCancel lets a cancellable action abort only its run in progress.
+// Cancel requests arriving while nothing runs are ignored
+// so that they do not abort the next run of the same action.
+// Zero value is ready to use.
+type RunCancel struct {
+	ch   chan struct{}
+	lock sync.Mutex
+}
+
+// Start begins a new run and returns channel that is closed when the run is cancelled
+func (c *RunCancel) Start() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ch = make(chan struct{})
+
+	return c.ch
+}
+
+// Finish ends the run started with given channel unless another run has started since
+func (c *RunCancel) Finish(cancelCh <-chan struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.ch != nil && c.ch == cancelCh {
+		c.ch = nil
+	}
+}
+
+func (c *RunCancel) Cancel() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.ch != nil && !IsCanceled(c.ch) {
+		close(c.ch)
+	}
+}
+
+func IsCanceled(cancelCh <-chan struct{}) bool {
+	select {
+	case <-cancelCh:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+package task_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+)
+
+var _ = Describe("RunCancel", func() {
+	var (
+		runCancel *boshtask.RunCancel
+	)
+
+	BeforeEach(func() {
+		runCancel = &boshtask.RunCancel{}
+	})
+
+	It("cancels the run in progress", func() {
+		cancelCh := runCancel.Start()
+		Expect(boshtask.IsCanceled(cancelCh)).To(BeFalse())
+
+		runCancel.Cancel()
+		Expect(boshtask.IsCanceled(cancelCh)).To(BeTrue())
+
+		runCancel.Cancel()
+		Expect(boshtask.IsCanceled(cancelCh)).To(BeTrue())
+	})
+
+	It("ignores cancel requests made while no run is in progress", func() {
+		runCancel.Cancel()
+
+		cancelCh := runCancel.Start()
+		Expect(boshtask.IsCanceled(cancelCh)).To(BeFalse())
+
+		runCancel.Finish(cancelCh)
+		runCancel.Cancel()
+
+		Expect(boshtask.IsCanceled(cancelCh)).To(BeFalse())
+		Expect(boshtask.IsCanceled(runCancel.Start())).To(BeFalse())
+	})
+
+	It("does not end a run that started after the finished one", func() {
+		firstCancelCh := runCancel.Start()
+		secondCancelCh := runCancel.Start()
+
+		runCancel.Finish(firstCancelCh)
+		runCancel.Cancel()
+
+		Expect(boshtask.IsCanceled(firstCancelCh)).To(BeFalse())
+		Expect(boshtask.IsCanceled(secondCancelCh)).To(BeTrue())
+	})
+})
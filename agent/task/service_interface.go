@@ -8,4 +8,10 @@ type Service interface {
 	// Records that task to run later
 	StartTask(Task)
 	FindTaskWithID(string) (Task, bool)
+
+	// Records latest progress reported by a running task
+	UpdateProgress(string, Progress)
+
+	// Lists running and recently finished tasks, most recently started first
+	ListTasks() ([]HistoryEntry, error)
 }
@@ -1,5 +1,7 @@
 package task
 
+import "time"
+
 type Func func() (value interface{}, err error)
 
 type CancelFunc func(task Task) error
@@ -9,16 +11,28 @@ type EndFunc func(task Task)
 type State string
 
 const (
+	// StateQueued is reported for tasks waiting for
+	// tasks in the same concurrency group to finish
+	StateQueued  State = "queued"
 	StateRunning State = "running"
 	StateDone    State = "done"
 	StateFailed  State = "failed"
 )
 
 type Task struct {
-	ID    string
-	State State
-	Value interface{}
-	Error error
+	ID     string
+	Method string
+	State  State
+	Value  interface{}
+	Error  error
+
+	Progress *Progress
+
+	// Tasks that share any concurrency group do not run at the same time
+	ConcurrencyGroups []string
+
+	StartedAt  time.Time
+	FinishedAt time.Time
 
 	Func       Func
 	CancelFunc CancelFunc
@@ -33,6 +47,7 @@ func (t Task) Cancel() error {
 }
 
 type StateValue struct {
-	AgentTaskID string `json:"agent_task_id"`
-	State       State  `json:"state"`
+	AgentTaskID string    `json:"agent_task_id"`
+	State       State     `json:"state"`
+	Progress    *Progress `json:"progress,omitempty"`
 }
@@ -1,6 +1,11 @@
 package agentclient
 
-import "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+)
 
 //go:generate counterfeiter -o fakes/fake_agent_client.go agent_client_interface.go AgentClient
 
@@ -21,6 +26,29 @@ type AgentClient interface {
 	DeleteARPEntries(ips []string) error
 	SyncDNS(blobID, sha1 string, version uint64) (string, error)
 	RunScript(scriptName string, options map[string]interface{}) error
+
+	Info() (AgentInfo, error)
+	Prepare(applyspec.ApplySpec) error
+	RunErrand(errandName string) (ErrandResult, error)
+	SSH(cmd string, params SSHParams) (SSHResult, error)
+	FetchLogs(logType string, filters []string, options FetchLogsOptions) (FetchLogsResult, error)
+	TailLogs(logType string, jobName string, options TailLogsOptions) (TailLogsResult, error)
+	UpdateSettings(settings UpdateSettings) error
+	UploadBlob(blobID string, payload []byte, checksum string) error
+	Shutdown() error
+	Batch(steps []BatchStep, options BatchOptions) ([]BatchStepResult, error)
+
+	// Name is either a job name or a process name as returned by GetState
+	StartJob(name string, options JobControlOptions) error
+	StopJob(name string, options JobControlOptions) error
+	RestartJob(name string, options JobControlOptions) error
+
+	// Helpers for asynchronous actions that callers want to follow or cancel themselves
+	StartTask(method string, arguments []interface{}) (taskID string, err error)
+	GetTask(taskID string) (AgentTask, error)
+	WaitForTask(taskID string, progressFunc func(TaskProgress)) (json.RawMessage, error)
+	CancelTask(taskID string) error
+	ListTasks() ([]TaskHistoryEntry, error)
 }
 
 type AgentState struct {
@@ -38,3 +66,148 @@ type BlobRef struct {
 	BlobstoreID string
 	SHA1        string
 }
+
+type AgentInfo struct {
+	APIVersion int `json:"api_version"`
+}
+
+type ErrandResult struct {
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitStatus int    `json:"exit_code"`
+}
+
+type SSHParams struct {
+	UserRegex string `json:"user_regex"`
+	User      string `json:"user"`
+	PublicKey string `json:"public_key"`
+}
+
+type SSHResult struct {
+	Command       string `json:"command"`
+	Status        string `json:"status"`
+	IP            string `json:"ip,omitempty"`
+	HostPublicKey string `json:"host_public_key,omitempty"`
+}
+
+// FetchLogsOptions are ignored by agents that do not support them
+type FetchLogsOptions struct {
+	ModifiedAfter     time.Time `json:"modified_after"`
+	ModifiedBefore    time.Time `json:"modified_before"`
+	MaxFileBytes      int64     `json:"max_file_bytes"`
+	MaxTotalBytes     int64     `json:"max_total_bytes"`
+	ExcludeCompressed bool      `json:"exclude_compressed"`
+}
+
+type FetchLogsResult struct {
+	BlobstoreID string `json:"blobstore_id"`
+	SHA1        string `json:"sha1"`
+
+	// Not returned by agents that do not support fetch logs options
+	Manifest *FetchLogsManifest `json:"manifest,omitempty"`
+}
+
+type FetchLogsManifest struct {
+	IncludedFiles int                      `json:"included_files"`
+	IncludedBytes int64                    `json:"included_bytes"`
+	Truncated     []FetchLogsManifestEntry `json:"truncated"`
+	Skipped       []FetchLogsManifestEntry `json:"skipped"`
+}
+
+type FetchLogsManifestEntry struct {
+	Path          string `json:"path"`
+	Size          int64  `json:"size"`
+	IncludedBytes int64  `json:"included_bytes,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+type TailLogsOptions struct {
+	Filters       []string `json:"filters"`
+	Lines         int      `json:"lines"`
+	Bytes         int64    `json:"bytes"`
+	FollowSeconds int      `json:"follow_seconds"`
+}
+
+type TailLogsResult struct {
+	Files  []TailedLogFile  `json:"files"`
+	Chunks []TailedLogChunk `json:"chunks,omitempty"`
+}
+
+type TailedLogFile struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+}
+
+type TailedLogChunk struct {
+	Sequence int    `json:"sequence"`
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+}
+
+type UpdateSettings struct {
+	DiskAssociations []DiskAssociation `json:"disk_associations"`
+	TrustedCerts     string            `json:"trusted_certs"`
+}
+
+type DiskAssociation struct {
+	Name    string `json:"name"`
+	DiskCID string `json:"cid"`
+}
+
+type BatchStep struct {
+	Method    string        `json:"method"`
+	Arguments []interface{} `json:"arguments"`
+}
+
+type BatchOptions struct {
+	StopOnFailure bool `json:"stop_on_failure"`
+}
+
+type BatchStepResult struct {
+	Method string          `json:"method"`
+	State  string          `json:"state"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type JobControlOptions struct {
+	Wait           bool `json:"wait"`
+	TimeoutSeconds int  `json:"timeout_seconds"`
+	SkipDrain      bool `json:"skip_drain"`
+}
+
+const (
+	TaskStateQueued  = "queued"
+	TaskStateRunning = "running"
+	TaskStateDone    = "done"
+	TaskStateFailed  = "failed"
+)
+
+// AgentTask is state of asynchronous action.
+// Value is only set once task is done and Error once it failed.
+type AgentTask struct {
+	ID       string
+	State    string
+	Progress *TaskProgress
+	Value    json.RawMessage
+	Error    string
+}
+
+type TaskProgress struct {
+	Stage   string          `json:"stage"`
+	Current int64           `json:"current"`
+	Total   int64           `json:"total"`
+	Unit    string          `json:"unit,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type TaskHistoryEntry struct {
+	TaskID     string        `json:"agent_task_id"`
+	Method     string        `json:"method"`
+	State      string        `json:"state"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Progress   *TaskProgress `json:"progress,omitempty"`
+}
@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	json "encoding/json"
 	sync "sync"
 
 	agentclient "github.com/cloudfoundry/bosh-agent/agentclient"
@@ -32,6 +33,31 @@ type FakeAgentClient struct {
 	applyReturnsOnCall map[int]struct {
 		result1 error
 	}
+	BatchStub        func([]agentclient.BatchStep, agentclient.BatchOptions) ([]agentclient.BatchStepResult, error)
+	batchMutex       sync.RWMutex
+	batchArgsForCall []struct {
+		arg1 []agentclient.BatchStep
+		arg2 agentclient.BatchOptions
+	}
+	batchReturns struct {
+		result1 []agentclient.BatchStepResult
+		result2 error
+	}
+	batchReturnsOnCall map[int]struct {
+		result1 []agentclient.BatchStepResult
+		result2 error
+	}
+	CancelTaskStub        func(string) error
+	cancelTaskMutex       sync.RWMutex
+	cancelTaskArgsForCall []struct {
+		arg1 string
+	}
+	cancelTaskReturns struct {
+		result1 error
+	}
+	cancelTaskReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CompilePackageStub        func(agentclient.BlobRef, []agentclient.BlobRef) (agentclient.BlobRef, error)
 	compilePackageMutex       sync.RWMutex
 	compilePackageArgsForCall []struct {
@@ -70,6 +96,21 @@ type FakeAgentClient struct {
 		result1 int64
 		result2 error
 	}
+	FetchLogsStub        func(string, []string, agentclient.FetchLogsOptions) (agentclient.FetchLogsResult, error)
+	fetchLogsMutex       sync.RWMutex
+	fetchLogsArgsForCall []struct {
+		arg1 string
+		arg2 []string
+		arg3 agentclient.FetchLogsOptions
+	}
+	fetchLogsReturns struct {
+		result1 agentclient.FetchLogsResult
+		result2 error
+	}
+	fetchLogsReturnsOnCall map[int]struct {
+		result1 agentclient.FetchLogsResult
+		result2 error
+	}
 	GetStateStub        func() (agentclient.AgentState, error)
 	getStateMutex       sync.RWMutex
 	getStateArgsForCall []struct {
@@ -82,6 +123,31 @@ type FakeAgentClient struct {
 		result1 agentclient.AgentState
 		result2 error
 	}
+	GetTaskStub        func(string) (agentclient.AgentTask, error)
+	getTaskMutex       sync.RWMutex
+	getTaskArgsForCall []struct {
+		arg1 string
+	}
+	getTaskReturns struct {
+		result1 agentclient.AgentTask
+		result2 error
+	}
+	getTaskReturnsOnCall map[int]struct {
+		result1 agentclient.AgentTask
+		result2 error
+	}
+	InfoStub        func() (agentclient.AgentInfo, error)
+	infoMutex       sync.RWMutex
+	infoArgsForCall []struct {
+	}
+	infoReturns struct {
+		result1 agentclient.AgentInfo
+		result2 error
+	}
+	infoReturnsOnCall map[int]struct {
+		result1 agentclient.AgentInfo
+		result2 error
+	}
 	ListDiskStub        func() ([]string, error)
 	listDiskMutex       sync.RWMutex
 	listDiskArgsForCall []struct {
@@ -94,6 +160,18 @@ type FakeAgentClient struct {
 		result1 []string
 		result2 error
 	}
+	ListTasksStub        func() ([]agentclient.TaskHistoryEntry, error)
+	listTasksMutex       sync.RWMutex
+	listTasksArgsForCall []struct {
+	}
+	listTasksReturns struct {
+		result1 []agentclient.TaskHistoryEntry
+		result2 error
+	}
+	listTasksReturnsOnCall map[int]struct {
+		result1 []agentclient.TaskHistoryEntry
+		result2 error
+	}
 	MigrateDiskStub        func() error
 	migrateDiskMutex       sync.RWMutex
 	migrateDiskArgsForCall []struct {
@@ -127,6 +205,17 @@ type FakeAgentClient struct {
 		result1 string
 		result2 error
 	}
+	PrepareStub        func(applyspec.ApplySpec) error
+	prepareMutex       sync.RWMutex
+	prepareArgsForCall []struct {
+		arg1 applyspec.ApplySpec
+	}
+	prepareReturns struct {
+		result1 error
+	}
+	prepareReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RemovePersistentDiskStub        func(string) error
 	removePersistentDiskMutex       sync.RWMutex
 	removePersistentDiskArgsForCall []struct {
@@ -138,6 +227,31 @@ type FakeAgentClient struct {
 	removePersistentDiskReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RestartJobStub        func(string, agentclient.JobControlOptions) error
+	restartJobMutex       sync.RWMutex
+	restartJobArgsForCall []struct {
+		arg1 string
+		arg2 agentclient.JobControlOptions
+	}
+	restartJobReturns struct {
+		result1 error
+	}
+	restartJobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RunErrandStub        func(string) (agentclient.ErrandResult, error)
+	runErrandMutex       sync.RWMutex
+	runErrandArgsForCall []struct {
+		arg1 string
+	}
+	runErrandReturns struct {
+		result1 agentclient.ErrandResult
+		result2 error
+	}
+	runErrandReturnsOnCall map[int]struct {
+		result1 agentclient.ErrandResult
+		result2 error
+	}
 	RunScriptStub        func(string, map[string]interface{}) error
 	runScriptMutex       sync.RWMutex
 	runScriptArgsForCall []struct {
@@ -150,6 +264,30 @@ type FakeAgentClient struct {
 	runScriptReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SSHStub        func(string, agentclient.SSHParams) (agentclient.SSHResult, error)
+	sSHMutex       sync.RWMutex
+	sSHArgsForCall []struct {
+		arg1 string
+		arg2 agentclient.SSHParams
+	}
+	sSHReturns struct {
+		result1 agentclient.SSHResult
+		result2 error
+	}
+	sSHReturnsOnCall map[int]struct {
+		result1 agentclient.SSHResult
+		result2 error
+	}
+	ShutdownStub        func() error
+	shutdownMutex       sync.RWMutex
+	shutdownArgsForCall []struct {
+	}
+	shutdownReturns struct {
+		result1 error
+	}
+	shutdownReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StartStub        func() error
 	startMutex       sync.RWMutex
 	startArgsForCall []struct {
@@ -160,6 +298,32 @@ type FakeAgentClient struct {
 	startReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StartJobStub        func(string, agentclient.JobControlOptions) error
+	startJobMutex       sync.RWMutex
+	startJobArgsForCall []struct {
+		arg1 string
+		arg2 agentclient.JobControlOptions
+	}
+	startJobReturns struct {
+		result1 error
+	}
+	startJobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StartTaskStub        func(string, []interface{}) (string, error)
+	startTaskMutex       sync.RWMutex
+	startTaskArgsForCall []struct {
+		arg1 string
+		arg2 []interface{}
+	}
+	startTaskReturns struct {
+		result1 string
+		result2 error
+	}
+	startTaskReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	StopStub        func() error
 	stopMutex       sync.RWMutex
 	stopArgsForCall []struct {
@@ -170,6 +334,18 @@ type FakeAgentClient struct {
 	stopReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StopJobStub        func(string, agentclient.JobControlOptions) error
+	stopJobMutex       sync.RWMutex
+	stopJobArgsForCall []struct {
+		arg1 string
+		arg2 agentclient.JobControlOptions
+	}
+	stopJobReturns struct {
+		result1 error
+	}
+	stopJobReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SyncDNSStub        func(string, string, uint64) (string, error)
 	syncDNSMutex       sync.RWMutex
 	syncDNSArgsForCall []struct {
@@ -185,6 +361,21 @@ type FakeAgentClient struct {
 		result1 string
 		result2 error
 	}
+	TailLogsStub        func(string, string, agentclient.TailLogsOptions) (agentclient.TailLogsResult, error)
+	tailLogsMutex       sync.RWMutex
+	tailLogsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 agentclient.TailLogsOptions
+	}
+	tailLogsReturns struct {
+		result1 agentclient.TailLogsResult
+		result2 error
+	}
+	tailLogsReturnsOnCall map[int]struct {
+		result1 agentclient.TailLogsResult
+		result2 error
+	}
 	UnmountDiskStub        func(string) error
 	unmountDiskMutex       sync.RWMutex
 	unmountDiskArgsForCall []struct {
@@ -196,6 +387,44 @@ type FakeAgentClient struct {
 	unmountDiskReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UpdateSettingsStub        func(agentclient.UpdateSettings) error
+	updateSettingsMutex       sync.RWMutex
+	updateSettingsArgsForCall []struct {
+		arg1 agentclient.UpdateSettings
+	}
+	updateSettingsReturns struct {
+		result1 error
+	}
+	updateSettingsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UploadBlobStub        func(string, []byte, string) error
+	uploadBlobMutex       sync.RWMutex
+	uploadBlobArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+		arg3 string
+	}
+	uploadBlobReturns struct {
+		result1 error
+	}
+	uploadBlobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	WaitForTaskStub        func(string, func(agentclient.TaskProgress)) (json.RawMessage, error)
+	waitForTaskMutex       sync.RWMutex
+	waitForTaskArgsForCall []struct {
+		arg1 string
+		arg2 func(agentclient.TaskProgress)
+	}
+	waitForTaskReturns struct {
+		result1 json.RawMessage
+		result2 error
+	}
+	waitForTaskReturnsOnCall map[int]struct {
+		result1 json.RawMessage
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -321,6 +550,135 @@ func (fake *FakeAgentClient) ApplyReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAgentClient) Batch(arg1 []agentclient.BatchStep, arg2 agentclient.BatchOptions) ([]agentclient.BatchStepResult, error) {
+	var arg1Copy []agentclient.BatchStep
+	if arg1 != nil {
+		arg1Copy = make([]agentclient.BatchStep, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.batchMutex.Lock()
+	ret, specificReturn := fake.batchReturnsOnCall[len(fake.batchArgsForCall)]
+	fake.batchArgsForCall = append(fake.batchArgsForCall, struct {
+		arg1 []agentclient.BatchStep
+		arg2 agentclient.BatchOptions
+	}{arg1Copy, arg2})
+	fake.recordInvocation("Batch", []interface{}{arg1Copy, arg2})
+	fake.batchMutex.Unlock()
+	if fake.BatchStub != nil {
+		return fake.BatchStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.batchReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) BatchCallCount() int {
+	fake.batchMutex.RLock()
+	defer fake.batchMutex.RUnlock()
+	return len(fake.batchArgsForCall)
+}
+
+func (fake *FakeAgentClient) BatchCalls(stub func([]agentclient.BatchStep, agentclient.BatchOptions) ([]agentclient.BatchStepResult, error)) {
+	fake.batchMutex.Lock()
+	defer fake.batchMutex.Unlock()
+	fake.BatchStub = stub
+}
+
+func (fake *FakeAgentClient) BatchArgsForCall(i int) ([]agentclient.BatchStep, agentclient.BatchOptions) {
+	fake.batchMutex.RLock()
+	defer fake.batchMutex.RUnlock()
+	argsForCall := fake.batchArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentClient) BatchReturns(result1 []agentclient.BatchStepResult, result2 error) {
+	fake.batchMutex.Lock()
+	defer fake.batchMutex.Unlock()
+	fake.BatchStub = nil
+	fake.batchReturns = struct {
+		result1 []agentclient.BatchStepResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) BatchReturnsOnCall(i int, result1 []agentclient.BatchStepResult, result2 error) {
+	fake.batchMutex.Lock()
+	defer fake.batchMutex.Unlock()
+	fake.BatchStub = nil
+	if fake.batchReturnsOnCall == nil {
+		fake.batchReturnsOnCall = make(map[int]struct {
+			result1 []agentclient.BatchStepResult
+			result2 error
+		})
+	}
+	fake.batchReturnsOnCall[i] = struct {
+		result1 []agentclient.BatchStepResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) CancelTask(arg1 string) error {
+	fake.cancelTaskMutex.Lock()
+	ret, specificReturn := fake.cancelTaskReturnsOnCall[len(fake.cancelTaskArgsForCall)]
+	fake.cancelTaskArgsForCall = append(fake.cancelTaskArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("CancelTask", []interface{}{arg1})
+	fake.cancelTaskMutex.Unlock()
+	if fake.CancelTaskStub != nil {
+		return fake.CancelTaskStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.cancelTaskReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) CancelTaskCallCount() int {
+	fake.cancelTaskMutex.RLock()
+	defer fake.cancelTaskMutex.RUnlock()
+	return len(fake.cancelTaskArgsForCall)
+}
+
+func (fake *FakeAgentClient) CancelTaskCalls(stub func(string) error) {
+	fake.cancelTaskMutex.Lock()
+	defer fake.cancelTaskMutex.Unlock()
+	fake.CancelTaskStub = stub
+}
+
+func (fake *FakeAgentClient) CancelTaskArgsForCall(i int) string {
+	fake.cancelTaskMutex.RLock()
+	defer fake.cancelTaskMutex.RUnlock()
+	argsForCall := fake.cancelTaskArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAgentClient) CancelTaskReturns(result1 error) {
+	fake.cancelTaskMutex.Lock()
+	defer fake.cancelTaskMutex.Unlock()
+	fake.CancelTaskStub = nil
+	fake.cancelTaskReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) CancelTaskReturnsOnCall(i int, result1 error) {
+	fake.cancelTaskMutex.Lock()
+	defer fake.cancelTaskMutex.Unlock()
+	fake.CancelTaskStub = nil
+	if fake.cancelTaskReturnsOnCall == nil {
+		fake.cancelTaskReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cancelTaskReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAgentClient) CompilePackage(arg1 agentclient.BlobRef, arg2 []agentclient.BlobRef) (agentclient.BlobRef, error) {
 	var arg2Copy []agentclient.BlobRef
 	if arg2 != nil {
@@ -518,6 +876,76 @@ func (fake *FakeAgentClient) DrainReturnsOnCall(i int, result1 int64, result2 er
 	}{result1, result2}
 }
 
+func (fake *FakeAgentClient) FetchLogs(arg1 string, arg2 []string, arg3 agentclient.FetchLogsOptions) (agentclient.FetchLogsResult, error) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.fetchLogsMutex.Lock()
+	ret, specificReturn := fake.fetchLogsReturnsOnCall[len(fake.fetchLogsArgsForCall)]
+	fake.fetchLogsArgsForCall = append(fake.fetchLogsArgsForCall, struct {
+		arg1 string
+		arg2 []string
+		arg3 agentclient.FetchLogsOptions
+	}{arg1, arg2Copy, arg3})
+	fake.recordInvocation("FetchLogs", []interface{}{arg1, arg2Copy, arg3})
+	fake.fetchLogsMutex.Unlock()
+	if fake.FetchLogsStub != nil {
+		return fake.FetchLogsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.fetchLogsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) FetchLogsCallCount() int {
+	fake.fetchLogsMutex.RLock()
+	defer fake.fetchLogsMutex.RUnlock()
+	return len(fake.fetchLogsArgsForCall)
+}
+
+func (fake *FakeAgentClient) FetchLogsCalls(stub func(string, []string, agentclient.FetchLogsOptions) (agentclient.FetchLogsResult, error)) {
+	fake.fetchLogsMutex.Lock()
+	defer fake.fetchLogsMutex.Unlock()
+	fake.FetchLogsStub = stub
+}
+
+func (fake *FakeAgentClient) FetchLogsArgsForCall(i int) (string, []string, agentclient.FetchLogsOptions) {
+	fake.fetchLogsMutex.RLock()
+	defer fake.fetchLogsMutex.RUnlock()
+	argsForCall := fake.fetchLogsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeAgentClient) FetchLogsReturns(result1 agentclient.FetchLogsResult, result2 error) {
+	fake.fetchLogsMutex.Lock()
+	defer fake.fetchLogsMutex.Unlock()
+	fake.FetchLogsStub = nil
+	fake.fetchLogsReturns = struct {
+		result1 agentclient.FetchLogsResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) FetchLogsReturnsOnCall(i int, result1 agentclient.FetchLogsResult, result2 error) {
+	fake.fetchLogsMutex.Lock()
+	defer fake.fetchLogsMutex.Unlock()
+	fake.FetchLogsStub = nil
+	if fake.fetchLogsReturnsOnCall == nil {
+		fake.fetchLogsReturnsOnCall = make(map[int]struct {
+			result1 agentclient.FetchLogsResult
+			result2 error
+		})
+	}
+	fake.fetchLogsReturnsOnCall[i] = struct {
+		result1 agentclient.FetchLogsResult
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentClient) GetState() (agentclient.AgentState, error) {
 	fake.getStateMutex.Lock()
 	ret, specificReturn := fake.getStateReturnsOnCall[len(fake.getStateArgsForCall)]
@@ -573,6 +1001,124 @@ func (fake *FakeAgentClient) GetStateReturnsOnCall(i int, result1 agentclient.Ag
 	}{result1, result2}
 }
 
+func (fake *FakeAgentClient) GetTask(arg1 string) (agentclient.AgentTask, error) {
+	fake.getTaskMutex.Lock()
+	ret, specificReturn := fake.getTaskReturnsOnCall[len(fake.getTaskArgsForCall)]
+	fake.getTaskArgsForCall = append(fake.getTaskArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetTask", []interface{}{arg1})
+	fake.getTaskMutex.Unlock()
+	if fake.GetTaskStub != nil {
+		return fake.GetTaskStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getTaskReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) GetTaskCallCount() int {
+	fake.getTaskMutex.RLock()
+	defer fake.getTaskMutex.RUnlock()
+	return len(fake.getTaskArgsForCall)
+}
+
+func (fake *FakeAgentClient) GetTaskCalls(stub func(string) (agentclient.AgentTask, error)) {
+	fake.getTaskMutex.Lock()
+	defer fake.getTaskMutex.Unlock()
+	fake.GetTaskStub = stub
+}
+
+func (fake *FakeAgentClient) GetTaskArgsForCall(i int) string {
+	fake.getTaskMutex.RLock()
+	defer fake.getTaskMutex.RUnlock()
+	argsForCall := fake.getTaskArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAgentClient) GetTaskReturns(result1 agentclient.AgentTask, result2 error) {
+	fake.getTaskMutex.Lock()
+	defer fake.getTaskMutex.Unlock()
+	fake.GetTaskStub = nil
+	fake.getTaskReturns = struct {
+		result1 agentclient.AgentTask
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) GetTaskReturnsOnCall(i int, result1 agentclient.AgentTask, result2 error) {
+	fake.getTaskMutex.Lock()
+	defer fake.getTaskMutex.Unlock()
+	fake.GetTaskStub = nil
+	if fake.getTaskReturnsOnCall == nil {
+		fake.getTaskReturnsOnCall = make(map[int]struct {
+			result1 agentclient.AgentTask
+			result2 error
+		})
+	}
+	fake.getTaskReturnsOnCall[i] = struct {
+		result1 agentclient.AgentTask
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) Info() (agentclient.AgentInfo, error) {
+	fake.infoMutex.Lock()
+	ret, specificReturn := fake.infoReturnsOnCall[len(fake.infoArgsForCall)]
+	fake.infoArgsForCall = append(fake.infoArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Info", []interface{}{})
+	fake.infoMutex.Unlock()
+	if fake.InfoStub != nil {
+		return fake.InfoStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.infoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) InfoCallCount() int {
+	fake.infoMutex.RLock()
+	defer fake.infoMutex.RUnlock()
+	return len(fake.infoArgsForCall)
+}
+
+func (fake *FakeAgentClient) InfoCalls(stub func() (agentclient.AgentInfo, error)) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = stub
+}
+
+func (fake *FakeAgentClient) InfoReturns(result1 agentclient.AgentInfo, result2 error) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = nil
+	fake.infoReturns = struct {
+		result1 agentclient.AgentInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) InfoReturnsOnCall(i int, result1 agentclient.AgentInfo, result2 error) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = nil
+	if fake.infoReturnsOnCall == nil {
+		fake.infoReturnsOnCall = make(map[int]struct {
+			result1 agentclient.AgentInfo
+			result2 error
+		})
+	}
+	fake.infoReturnsOnCall[i] = struct {
+		result1 agentclient.AgentInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentClient) ListDisk() ([]string, error) {
 	fake.listDiskMutex.Lock()
 	ret, specificReturn := fake.listDiskReturnsOnCall[len(fake.listDiskArgsForCall)]
@@ -628,26 +1174,81 @@ func (fake *FakeAgentClient) ListDiskReturnsOnCall(i int, result1 []string, resu
 	}{result1, result2}
 }
 
-func (fake *FakeAgentClient) MigrateDisk() error {
-	fake.migrateDiskMutex.Lock()
-	ret, specificReturn := fake.migrateDiskReturnsOnCall[len(fake.migrateDiskArgsForCall)]
-	fake.migrateDiskArgsForCall = append(fake.migrateDiskArgsForCall, struct {
+func (fake *FakeAgentClient) ListTasks() ([]agentclient.TaskHistoryEntry, error) {
+	fake.listTasksMutex.Lock()
+	ret, specificReturn := fake.listTasksReturnsOnCall[len(fake.listTasksArgsForCall)]
+	fake.listTasksArgsForCall = append(fake.listTasksArgsForCall, struct {
 	}{})
-	fake.recordInvocation("MigrateDisk", []interface{}{})
-	fake.migrateDiskMutex.Unlock()
-	if fake.MigrateDiskStub != nil {
-		return fake.MigrateDiskStub()
+	fake.recordInvocation("ListTasks", []interface{}{})
+	fake.listTasksMutex.Unlock()
+	if fake.ListTasksStub != nil {
+		return fake.ListTasksStub()
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.migrateDiskReturns
-	return fakeReturns.result1
+	fakeReturns := fake.listTasksReturns
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeAgentClient) MigrateDiskCallCount() int {
-	fake.migrateDiskMutex.RLock()
-	defer fake.migrateDiskMutex.RUnlock()
+func (fake *FakeAgentClient) ListTasksCallCount() int {
+	fake.listTasksMutex.RLock()
+	defer fake.listTasksMutex.RUnlock()
+	return len(fake.listTasksArgsForCall)
+}
+
+func (fake *FakeAgentClient) ListTasksCalls(stub func() ([]agentclient.TaskHistoryEntry, error)) {
+	fake.listTasksMutex.Lock()
+	defer fake.listTasksMutex.Unlock()
+	fake.ListTasksStub = stub
+}
+
+func (fake *FakeAgentClient) ListTasksReturns(result1 []agentclient.TaskHistoryEntry, result2 error) {
+	fake.listTasksMutex.Lock()
+	defer fake.listTasksMutex.Unlock()
+	fake.ListTasksStub = nil
+	fake.listTasksReturns = struct {
+		result1 []agentclient.TaskHistoryEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) ListTasksReturnsOnCall(i int, result1 []agentclient.TaskHistoryEntry, result2 error) {
+	fake.listTasksMutex.Lock()
+	defer fake.listTasksMutex.Unlock()
+	fake.ListTasksStub = nil
+	if fake.listTasksReturnsOnCall == nil {
+		fake.listTasksReturnsOnCall = make(map[int]struct {
+			result1 []agentclient.TaskHistoryEntry
+			result2 error
+		})
+	}
+	fake.listTasksReturnsOnCall[i] = struct {
+		result1 []agentclient.TaskHistoryEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) MigrateDisk() error {
+	fake.migrateDiskMutex.Lock()
+	ret, specificReturn := fake.migrateDiskReturnsOnCall[len(fake.migrateDiskArgsForCall)]
+	fake.migrateDiskArgsForCall = append(fake.migrateDiskArgsForCall, struct {
+	}{})
+	fake.recordInvocation("MigrateDisk", []interface{}{})
+	fake.migrateDiskMutex.Unlock()
+	if fake.MigrateDiskStub != nil {
+		return fake.MigrateDiskStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.migrateDiskReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) MigrateDiskCallCount() int {
+	fake.migrateDiskMutex.RLock()
+	defer fake.migrateDiskMutex.RUnlock()
 	return len(fake.migrateDiskArgsForCall)
 }
 
@@ -795,6 +1396,66 @@ func (fake *FakeAgentClient) PingReturnsOnCall(i int, result1 string, result2 er
 	}{result1, result2}
 }
 
+func (fake *FakeAgentClient) Prepare(arg1 applyspec.ApplySpec) error {
+	fake.prepareMutex.Lock()
+	ret, specificReturn := fake.prepareReturnsOnCall[len(fake.prepareArgsForCall)]
+	fake.prepareArgsForCall = append(fake.prepareArgsForCall, struct {
+		arg1 applyspec.ApplySpec
+	}{arg1})
+	fake.recordInvocation("Prepare", []interface{}{arg1})
+	fake.prepareMutex.Unlock()
+	if fake.PrepareStub != nil {
+		return fake.PrepareStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.prepareReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) PrepareCallCount() int {
+	fake.prepareMutex.RLock()
+	defer fake.prepareMutex.RUnlock()
+	return len(fake.prepareArgsForCall)
+}
+
+func (fake *FakeAgentClient) PrepareCalls(stub func(applyspec.ApplySpec) error) {
+	fake.prepareMutex.Lock()
+	defer fake.prepareMutex.Unlock()
+	fake.PrepareStub = stub
+}
+
+func (fake *FakeAgentClient) PrepareArgsForCall(i int) applyspec.ApplySpec {
+	fake.prepareMutex.RLock()
+	defer fake.prepareMutex.RUnlock()
+	argsForCall := fake.prepareArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAgentClient) PrepareReturns(result1 error) {
+	fake.prepareMutex.Lock()
+	defer fake.prepareMutex.Unlock()
+	fake.PrepareStub = nil
+	fake.prepareReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) PrepareReturnsOnCall(i int, result1 error) {
+	fake.prepareMutex.Lock()
+	defer fake.prepareMutex.Unlock()
+	fake.PrepareStub = nil
+	if fake.prepareReturnsOnCall == nil {
+		fake.prepareReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.prepareReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAgentClient) RemovePersistentDisk(arg1 string) error {
 	fake.removePersistentDiskMutex.Lock()
 	ret, specificReturn := fake.removePersistentDiskReturnsOnCall[len(fake.removePersistentDiskArgsForCall)]
@@ -855,6 +1516,130 @@ func (fake *FakeAgentClient) RemovePersistentDiskReturnsOnCall(i int, result1 er
 	}{result1}
 }
 
+func (fake *FakeAgentClient) RestartJob(arg1 string, arg2 agentclient.JobControlOptions) error {
+	fake.restartJobMutex.Lock()
+	ret, specificReturn := fake.restartJobReturnsOnCall[len(fake.restartJobArgsForCall)]
+	fake.restartJobArgsForCall = append(fake.restartJobArgsForCall, struct {
+		arg1 string
+		arg2 agentclient.JobControlOptions
+	}{arg1, arg2})
+	fake.recordInvocation("RestartJob", []interface{}{arg1, arg2})
+	fake.restartJobMutex.Unlock()
+	if fake.RestartJobStub != nil {
+		return fake.RestartJobStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.restartJobReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) RestartJobCallCount() int {
+	fake.restartJobMutex.RLock()
+	defer fake.restartJobMutex.RUnlock()
+	return len(fake.restartJobArgsForCall)
+}
+
+func (fake *FakeAgentClient) RestartJobCalls(stub func(string, agentclient.JobControlOptions) error) {
+	fake.restartJobMutex.Lock()
+	defer fake.restartJobMutex.Unlock()
+	fake.RestartJobStub = stub
+}
+
+func (fake *FakeAgentClient) RestartJobArgsForCall(i int) (string, agentclient.JobControlOptions) {
+	fake.restartJobMutex.RLock()
+	defer fake.restartJobMutex.RUnlock()
+	argsForCall := fake.restartJobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentClient) RestartJobReturns(result1 error) {
+	fake.restartJobMutex.Lock()
+	defer fake.restartJobMutex.Unlock()
+	fake.RestartJobStub = nil
+	fake.restartJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) RestartJobReturnsOnCall(i int, result1 error) {
+	fake.restartJobMutex.Lock()
+	defer fake.restartJobMutex.Unlock()
+	fake.RestartJobStub = nil
+	if fake.restartJobReturnsOnCall == nil {
+		fake.restartJobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restartJobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) RunErrand(arg1 string) (agentclient.ErrandResult, error) {
+	fake.runErrandMutex.Lock()
+	ret, specificReturn := fake.runErrandReturnsOnCall[len(fake.runErrandArgsForCall)]
+	fake.runErrandArgsForCall = append(fake.runErrandArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("RunErrand", []interface{}{arg1})
+	fake.runErrandMutex.Unlock()
+	if fake.RunErrandStub != nil {
+		return fake.RunErrandStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.runErrandReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) RunErrandCallCount() int {
+	fake.runErrandMutex.RLock()
+	defer fake.runErrandMutex.RUnlock()
+	return len(fake.runErrandArgsForCall)
+}
+
+func (fake *FakeAgentClient) RunErrandCalls(stub func(string) (agentclient.ErrandResult, error)) {
+	fake.runErrandMutex.Lock()
+	defer fake.runErrandMutex.Unlock()
+	fake.RunErrandStub = stub
+}
+
+func (fake *FakeAgentClient) RunErrandArgsForCall(i int) string {
+	fake.runErrandMutex.RLock()
+	defer fake.runErrandMutex.RUnlock()
+	argsForCall := fake.runErrandArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAgentClient) RunErrandReturns(result1 agentclient.ErrandResult, result2 error) {
+	fake.runErrandMutex.Lock()
+	defer fake.runErrandMutex.Unlock()
+	fake.RunErrandStub = nil
+	fake.runErrandReturns = struct {
+		result1 agentclient.ErrandResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) RunErrandReturnsOnCall(i int, result1 agentclient.ErrandResult, result2 error) {
+	fake.runErrandMutex.Lock()
+	defer fake.runErrandMutex.Unlock()
+	fake.RunErrandStub = nil
+	if fake.runErrandReturnsOnCall == nil {
+		fake.runErrandReturnsOnCall = make(map[int]struct {
+			result1 agentclient.ErrandResult
+			result2 error
+		})
+	}
+	fake.runErrandReturnsOnCall[i] = struct {
+		result1 agentclient.ErrandResult
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentClient) RunScript(arg1 string, arg2 map[string]interface{}) error {
 	fake.runScriptMutex.Lock()
 	ret, specificReturn := fake.runScriptReturnsOnCall[len(fake.runScriptArgsForCall)]
@@ -916,6 +1701,122 @@ func (fake *FakeAgentClient) RunScriptReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAgentClient) SSH(arg1 string, arg2 agentclient.SSHParams) (agentclient.SSHResult, error) {
+	fake.sSHMutex.Lock()
+	ret, specificReturn := fake.sSHReturnsOnCall[len(fake.sSHArgsForCall)]
+	fake.sSHArgsForCall = append(fake.sSHArgsForCall, struct {
+		arg1 string
+		arg2 agentclient.SSHParams
+	}{arg1, arg2})
+	fake.recordInvocation("SSH", []interface{}{arg1, arg2})
+	fake.sSHMutex.Unlock()
+	if fake.SSHStub != nil {
+		return fake.SSHStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.sSHReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) SSHCallCount() int {
+	fake.sSHMutex.RLock()
+	defer fake.sSHMutex.RUnlock()
+	return len(fake.sSHArgsForCall)
+}
+
+func (fake *FakeAgentClient) SSHCalls(stub func(string, agentclient.SSHParams) (agentclient.SSHResult, error)) {
+	fake.sSHMutex.Lock()
+	defer fake.sSHMutex.Unlock()
+	fake.SSHStub = stub
+}
+
+func (fake *FakeAgentClient) SSHArgsForCall(i int) (string, agentclient.SSHParams) {
+	fake.sSHMutex.RLock()
+	defer fake.sSHMutex.RUnlock()
+	argsForCall := fake.sSHArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentClient) SSHReturns(result1 agentclient.SSHResult, result2 error) {
+	fake.sSHMutex.Lock()
+	defer fake.sSHMutex.Unlock()
+	fake.SSHStub = nil
+	fake.sSHReturns = struct {
+		result1 agentclient.SSHResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) SSHReturnsOnCall(i int, result1 agentclient.SSHResult, result2 error) {
+	fake.sSHMutex.Lock()
+	defer fake.sSHMutex.Unlock()
+	fake.SSHStub = nil
+	if fake.sSHReturnsOnCall == nil {
+		fake.sSHReturnsOnCall = make(map[int]struct {
+			result1 agentclient.SSHResult
+			result2 error
+		})
+	}
+	fake.sSHReturnsOnCall[i] = struct {
+		result1 agentclient.SSHResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) Shutdown() error {
+	fake.shutdownMutex.Lock()
+	ret, specificReturn := fake.shutdownReturnsOnCall[len(fake.shutdownArgsForCall)]
+	fake.shutdownArgsForCall = append(fake.shutdownArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Shutdown", []interface{}{})
+	fake.shutdownMutex.Unlock()
+	if fake.ShutdownStub != nil {
+		return fake.ShutdownStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.shutdownReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) ShutdownCallCount() int {
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	return len(fake.shutdownArgsForCall)
+}
+
+func (fake *FakeAgentClient) ShutdownCalls(stub func() error) {
+	fake.shutdownMutex.Lock()
+	defer fake.shutdownMutex.Unlock()
+	fake.ShutdownStub = stub
+}
+
+func (fake *FakeAgentClient) ShutdownReturns(result1 error) {
+	fake.shutdownMutex.Lock()
+	defer fake.shutdownMutex.Unlock()
+	fake.ShutdownStub = nil
+	fake.shutdownReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) ShutdownReturnsOnCall(i int, result1 error) {
+	fake.shutdownMutex.Lock()
+	defer fake.shutdownMutex.Unlock()
+	fake.ShutdownStub = nil
+	if fake.shutdownReturnsOnCall == nil {
+		fake.shutdownReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.shutdownReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAgentClient) Start() error {
 	fake.startMutex.Lock()
 	ret, specificReturn := fake.startReturnsOnCall[len(fake.startArgsForCall)]
@@ -968,6 +1869,136 @@ func (fake *FakeAgentClient) StartReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAgentClient) StartJob(arg1 string, arg2 agentclient.JobControlOptions) error {
+	fake.startJobMutex.Lock()
+	ret, specificReturn := fake.startJobReturnsOnCall[len(fake.startJobArgsForCall)]
+	fake.startJobArgsForCall = append(fake.startJobArgsForCall, struct {
+		arg1 string
+		arg2 agentclient.JobControlOptions
+	}{arg1, arg2})
+	fake.recordInvocation("StartJob", []interface{}{arg1, arg2})
+	fake.startJobMutex.Unlock()
+	if fake.StartJobStub != nil {
+		return fake.StartJobStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.startJobReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) StartJobCallCount() int {
+	fake.startJobMutex.RLock()
+	defer fake.startJobMutex.RUnlock()
+	return len(fake.startJobArgsForCall)
+}
+
+func (fake *FakeAgentClient) StartJobCalls(stub func(string, agentclient.JobControlOptions) error) {
+	fake.startJobMutex.Lock()
+	defer fake.startJobMutex.Unlock()
+	fake.StartJobStub = stub
+}
+
+func (fake *FakeAgentClient) StartJobArgsForCall(i int) (string, agentclient.JobControlOptions) {
+	fake.startJobMutex.RLock()
+	defer fake.startJobMutex.RUnlock()
+	argsForCall := fake.startJobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentClient) StartJobReturns(result1 error) {
+	fake.startJobMutex.Lock()
+	defer fake.startJobMutex.Unlock()
+	fake.StartJobStub = nil
+	fake.startJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) StartJobReturnsOnCall(i int, result1 error) {
+	fake.startJobMutex.Lock()
+	defer fake.startJobMutex.Unlock()
+	fake.StartJobStub = nil
+	if fake.startJobReturnsOnCall == nil {
+		fake.startJobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.startJobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) StartTask(arg1 string, arg2 []interface{}) (string, error) {
+	var arg2Copy []interface{}
+	if arg2 != nil {
+		arg2Copy = make([]interface{}, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.startTaskMutex.Lock()
+	ret, specificReturn := fake.startTaskReturnsOnCall[len(fake.startTaskArgsForCall)]
+	fake.startTaskArgsForCall = append(fake.startTaskArgsForCall, struct {
+		arg1 string
+		arg2 []interface{}
+	}{arg1, arg2Copy})
+	fake.recordInvocation("StartTask", []interface{}{arg1, arg2Copy})
+	fake.startTaskMutex.Unlock()
+	if fake.StartTaskStub != nil {
+		return fake.StartTaskStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.startTaskReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) StartTaskCallCount() int {
+	fake.startTaskMutex.RLock()
+	defer fake.startTaskMutex.RUnlock()
+	return len(fake.startTaskArgsForCall)
+}
+
+func (fake *FakeAgentClient) StartTaskCalls(stub func(string, []interface{}) (string, error)) {
+	fake.startTaskMutex.Lock()
+	defer fake.startTaskMutex.Unlock()
+	fake.StartTaskStub = stub
+}
+
+func (fake *FakeAgentClient) StartTaskArgsForCall(i int) (string, []interface{}) {
+	fake.startTaskMutex.RLock()
+	defer fake.startTaskMutex.RUnlock()
+	argsForCall := fake.startTaskArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentClient) StartTaskReturns(result1 string, result2 error) {
+	fake.startTaskMutex.Lock()
+	defer fake.startTaskMutex.Unlock()
+	fake.StartTaskStub = nil
+	fake.startTaskReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) StartTaskReturnsOnCall(i int, result1 string, result2 error) {
+	fake.startTaskMutex.Lock()
+	defer fake.startTaskMutex.Unlock()
+	fake.StartTaskStub = nil
+	if fake.startTaskReturnsOnCall == nil {
+		fake.startTaskReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.startTaskReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentClient) Stop() error {
 	fake.stopMutex.Lock()
 	ret, specificReturn := fake.stopReturnsOnCall[len(fake.stopArgsForCall)]
@@ -1020,6 +2051,67 @@ func (fake *FakeAgentClient) StopReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAgentClient) StopJob(arg1 string, arg2 agentclient.JobControlOptions) error {
+	fake.stopJobMutex.Lock()
+	ret, specificReturn := fake.stopJobReturnsOnCall[len(fake.stopJobArgsForCall)]
+	fake.stopJobArgsForCall = append(fake.stopJobArgsForCall, struct {
+		arg1 string
+		arg2 agentclient.JobControlOptions
+	}{arg1, arg2})
+	fake.recordInvocation("StopJob", []interface{}{arg1, arg2})
+	fake.stopJobMutex.Unlock()
+	if fake.StopJobStub != nil {
+		return fake.StopJobStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.stopJobReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) StopJobCallCount() int {
+	fake.stopJobMutex.RLock()
+	defer fake.stopJobMutex.RUnlock()
+	return len(fake.stopJobArgsForCall)
+}
+
+func (fake *FakeAgentClient) StopJobCalls(stub func(string, agentclient.JobControlOptions) error) {
+	fake.stopJobMutex.Lock()
+	defer fake.stopJobMutex.Unlock()
+	fake.StopJobStub = stub
+}
+
+func (fake *FakeAgentClient) StopJobArgsForCall(i int) (string, agentclient.JobControlOptions) {
+	fake.stopJobMutex.RLock()
+	defer fake.stopJobMutex.RUnlock()
+	argsForCall := fake.stopJobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentClient) StopJobReturns(result1 error) {
+	fake.stopJobMutex.Lock()
+	defer fake.stopJobMutex.Unlock()
+	fake.StopJobStub = nil
+	fake.stopJobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) StopJobReturnsOnCall(i int, result1 error) {
+	fake.stopJobMutex.Lock()
+	defer fake.stopJobMutex.Unlock()
+	fake.StopJobStub = nil
+	if fake.stopJobReturnsOnCall == nil {
+		fake.stopJobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.stopJobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeAgentClient) SyncDNS(arg1 string, arg2 string, arg3 uint64) (string, error) {
 	fake.syncDNSMutex.Lock()
 	ret, specificReturn := fake.syncDNSReturnsOnCall[len(fake.syncDNSArgsForCall)]
@@ -1085,6 +2177,71 @@ func (fake *FakeAgentClient) SyncDNSReturnsOnCall(i int, result1 string, result2
 	}{result1, result2}
 }
 
+func (fake *FakeAgentClient) TailLogs(arg1 string, arg2 string, arg3 agentclient.TailLogsOptions) (agentclient.TailLogsResult, error) {
+	fake.tailLogsMutex.Lock()
+	ret, specificReturn := fake.tailLogsReturnsOnCall[len(fake.tailLogsArgsForCall)]
+	fake.tailLogsArgsForCall = append(fake.tailLogsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 agentclient.TailLogsOptions
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("TailLogs", []interface{}{arg1, arg2, arg3})
+	fake.tailLogsMutex.Unlock()
+	if fake.TailLogsStub != nil {
+		return fake.TailLogsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.tailLogsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) TailLogsCallCount() int {
+	fake.tailLogsMutex.RLock()
+	defer fake.tailLogsMutex.RUnlock()
+	return len(fake.tailLogsArgsForCall)
+}
+
+func (fake *FakeAgentClient) TailLogsCalls(stub func(string, string, agentclient.TailLogsOptions) (agentclient.TailLogsResult, error)) {
+	fake.tailLogsMutex.Lock()
+	defer fake.tailLogsMutex.Unlock()
+	fake.TailLogsStub = stub
+}
+
+func (fake *FakeAgentClient) TailLogsArgsForCall(i int) (string, string, agentclient.TailLogsOptions) {
+	fake.tailLogsMutex.RLock()
+	defer fake.tailLogsMutex.RUnlock()
+	argsForCall := fake.tailLogsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeAgentClient) TailLogsReturns(result1 agentclient.TailLogsResult, result2 error) {
+	fake.tailLogsMutex.Lock()
+	defer fake.tailLogsMutex.Unlock()
+	fake.TailLogsStub = nil
+	fake.tailLogsReturns = struct {
+		result1 agentclient.TailLogsResult
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) TailLogsReturnsOnCall(i int, result1 agentclient.TailLogsResult, result2 error) {
+	fake.tailLogsMutex.Lock()
+	defer fake.tailLogsMutex.Unlock()
+	fake.TailLogsStub = nil
+	if fake.tailLogsReturnsOnCall == nil {
+		fake.tailLogsReturnsOnCall = make(map[int]struct {
+			result1 agentclient.TailLogsResult
+			result2 error
+		})
+	}
+	fake.tailLogsReturnsOnCall[i] = struct {
+		result1 agentclient.TailLogsResult
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentClient) UnmountDisk(arg1 string) error {
 	fake.unmountDiskMutex.Lock()
 	ret, specificReturn := fake.unmountDiskReturnsOnCall[len(fake.unmountDiskArgsForCall)]
@@ -1145,6 +2302,197 @@ func (fake *FakeAgentClient) UnmountDiskReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAgentClient) UpdateSettings(arg1 agentclient.UpdateSettings) error {
+	fake.updateSettingsMutex.Lock()
+	ret, specificReturn := fake.updateSettingsReturnsOnCall[len(fake.updateSettingsArgsForCall)]
+	fake.updateSettingsArgsForCall = append(fake.updateSettingsArgsForCall, struct {
+		arg1 agentclient.UpdateSettings
+	}{arg1})
+	fake.recordInvocation("UpdateSettings", []interface{}{arg1})
+	fake.updateSettingsMutex.Unlock()
+	if fake.UpdateSettingsStub != nil {
+		return fake.UpdateSettingsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.updateSettingsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) UpdateSettingsCallCount() int {
+	fake.updateSettingsMutex.RLock()
+	defer fake.updateSettingsMutex.RUnlock()
+	return len(fake.updateSettingsArgsForCall)
+}
+
+func (fake *FakeAgentClient) UpdateSettingsCalls(stub func(agentclient.UpdateSettings) error) {
+	fake.updateSettingsMutex.Lock()
+	defer fake.updateSettingsMutex.Unlock()
+	fake.UpdateSettingsStub = stub
+}
+
+func (fake *FakeAgentClient) UpdateSettingsArgsForCall(i int) agentclient.UpdateSettings {
+	fake.updateSettingsMutex.RLock()
+	defer fake.updateSettingsMutex.RUnlock()
+	argsForCall := fake.updateSettingsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeAgentClient) UpdateSettingsReturns(result1 error) {
+	fake.updateSettingsMutex.Lock()
+	defer fake.updateSettingsMutex.Unlock()
+	fake.UpdateSettingsStub = nil
+	fake.updateSettingsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) UpdateSettingsReturnsOnCall(i int, result1 error) {
+	fake.updateSettingsMutex.Lock()
+	defer fake.updateSettingsMutex.Unlock()
+	fake.UpdateSettingsStub = nil
+	if fake.updateSettingsReturnsOnCall == nil {
+		fake.updateSettingsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateSettingsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) UploadBlob(arg1 string, arg2 []byte, arg3 string) error {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.uploadBlobMutex.Lock()
+	ret, specificReturn := fake.uploadBlobReturnsOnCall[len(fake.uploadBlobArgsForCall)]
+	fake.uploadBlobArgsForCall = append(fake.uploadBlobArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+		arg3 string
+	}{arg1, arg2Copy, arg3})
+	fake.recordInvocation("UploadBlob", []interface{}{arg1, arg2Copy, arg3})
+	fake.uploadBlobMutex.Unlock()
+	if fake.UploadBlobStub != nil {
+		return fake.UploadBlobStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.uploadBlobReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeAgentClient) UploadBlobCallCount() int {
+	fake.uploadBlobMutex.RLock()
+	defer fake.uploadBlobMutex.RUnlock()
+	return len(fake.uploadBlobArgsForCall)
+}
+
+func (fake *FakeAgentClient) UploadBlobCalls(stub func(string, []byte, string) error) {
+	fake.uploadBlobMutex.Lock()
+	defer fake.uploadBlobMutex.Unlock()
+	fake.UploadBlobStub = stub
+}
+
+func (fake *FakeAgentClient) UploadBlobArgsForCall(i int) (string, []byte, string) {
+	fake.uploadBlobMutex.RLock()
+	defer fake.uploadBlobMutex.RUnlock()
+	argsForCall := fake.uploadBlobArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeAgentClient) UploadBlobReturns(result1 error) {
+	fake.uploadBlobMutex.Lock()
+	defer fake.uploadBlobMutex.Unlock()
+	fake.UploadBlobStub = nil
+	fake.uploadBlobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) UploadBlobReturnsOnCall(i int, result1 error) {
+	fake.uploadBlobMutex.Lock()
+	defer fake.uploadBlobMutex.Unlock()
+	fake.UploadBlobStub = nil
+	if fake.uploadBlobReturnsOnCall == nil {
+		fake.uploadBlobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.uploadBlobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAgentClient) WaitForTask(arg1 string, arg2 func(agentclient.TaskProgress)) (json.RawMessage, error) {
+	fake.waitForTaskMutex.Lock()
+	ret, specificReturn := fake.waitForTaskReturnsOnCall[len(fake.waitForTaskArgsForCall)]
+	fake.waitForTaskArgsForCall = append(fake.waitForTaskArgsForCall, struct {
+		arg1 string
+		arg2 func(agentclient.TaskProgress)
+	}{arg1, arg2})
+	fake.recordInvocation("WaitForTask", []interface{}{arg1, arg2})
+	fake.waitForTaskMutex.Unlock()
+	if fake.WaitForTaskStub != nil {
+		return fake.WaitForTaskStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.waitForTaskReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeAgentClient) WaitForTaskCallCount() int {
+	fake.waitForTaskMutex.RLock()
+	defer fake.waitForTaskMutex.RUnlock()
+	return len(fake.waitForTaskArgsForCall)
+}
+
+func (fake *FakeAgentClient) WaitForTaskCalls(stub func(string, func(agentclient.TaskProgress)) (json.RawMessage, error)) {
+	fake.waitForTaskMutex.Lock()
+	defer fake.waitForTaskMutex.Unlock()
+	fake.WaitForTaskStub = stub
+}
+
+func (fake *FakeAgentClient) WaitForTaskArgsForCall(i int) (string, func(agentclient.TaskProgress)) {
+	fake.waitForTaskMutex.RLock()
+	defer fake.waitForTaskMutex.RUnlock()
+	argsForCall := fake.waitForTaskArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeAgentClient) WaitForTaskReturns(result1 json.RawMessage, result2 error) {
+	fake.waitForTaskMutex.Lock()
+	defer fake.waitForTaskMutex.Unlock()
+	fake.WaitForTaskStub = nil
+	fake.waitForTaskReturns = struct {
+		result1 json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgentClient) WaitForTaskReturnsOnCall(i int, result1 json.RawMessage, result2 error) {
+	fake.waitForTaskMutex.Lock()
+	defer fake.waitForTaskMutex.Unlock()
+	fake.WaitForTaskStub = nil
+	if fake.waitForTaskReturnsOnCall == nil {
+		fake.waitForTaskReturnsOnCall = make(map[int]struct {
+			result1 json.RawMessage
+			result2 error
+		})
+	}
+	fake.waitForTaskReturnsOnCall[i] = struct {
+		result1 json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAgentClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -1152,34 +2500,70 @@ func (fake *FakeAgentClient) Invocations() map[string][][]interface{} {
 	defer fake.addPersistentDiskMutex.RUnlock()
 	fake.applyMutex.RLock()
 	defer fake.applyMutex.RUnlock()
+	fake.batchMutex.RLock()
+	defer fake.batchMutex.RUnlock()
+	fake.cancelTaskMutex.RLock()
+	defer fake.cancelTaskMutex.RUnlock()
 	fake.compilePackageMutex.RLock()
 	defer fake.compilePackageMutex.RUnlock()
 	fake.deleteARPEntriesMutex.RLock()
 	defer fake.deleteARPEntriesMutex.RUnlock()
 	fake.drainMutex.RLock()
 	defer fake.drainMutex.RUnlock()
+	fake.fetchLogsMutex.RLock()
+	defer fake.fetchLogsMutex.RUnlock()
 	fake.getStateMutex.RLock()
 	defer fake.getStateMutex.RUnlock()
+	fake.getTaskMutex.RLock()
+	defer fake.getTaskMutex.RUnlock()
+	fake.infoMutex.RLock()
+	defer fake.infoMutex.RUnlock()
 	fake.listDiskMutex.RLock()
 	defer fake.listDiskMutex.RUnlock()
+	fake.listTasksMutex.RLock()
+	defer fake.listTasksMutex.RUnlock()
 	fake.migrateDiskMutex.RLock()
 	defer fake.migrateDiskMutex.RUnlock()
 	fake.mountDiskMutex.RLock()
 	defer fake.mountDiskMutex.RUnlock()
 	fake.pingMutex.RLock()
 	defer fake.pingMutex.RUnlock()
+	fake.prepareMutex.RLock()
+	defer fake.prepareMutex.RUnlock()
 	fake.removePersistentDiskMutex.RLock()
 	defer fake.removePersistentDiskMutex.RUnlock()
+	fake.restartJobMutex.RLock()
+	defer fake.restartJobMutex.RUnlock()
+	fake.runErrandMutex.RLock()
+	defer fake.runErrandMutex.RUnlock()
 	fake.runScriptMutex.RLock()
 	defer fake.runScriptMutex.RUnlock()
+	fake.sSHMutex.RLock()
+	defer fake.sSHMutex.RUnlock()
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
 	fake.startMutex.RLock()
 	defer fake.startMutex.RUnlock()
+	fake.startJobMutex.RLock()
+	defer fake.startJobMutex.RUnlock()
+	fake.startTaskMutex.RLock()
+	defer fake.startTaskMutex.RUnlock()
 	fake.stopMutex.RLock()
 	defer fake.stopMutex.RUnlock()
+	fake.stopJobMutex.RLock()
+	defer fake.stopJobMutex.RUnlock()
 	fake.syncDNSMutex.RLock()
 	defer fake.syncDNSMutex.RUnlock()
+	fake.tailLogsMutex.RLock()
+	defer fake.tailLogsMutex.RUnlock()
 	fake.unmountDiskMutex.RLock()
 	defer fake.unmountDiskMutex.RUnlock()
+	fake.updateSettingsMutex.RLock()
+	defer fake.updateSettingsMutex.RUnlock()
+	fake.uploadBlobMutex.RLock()
+	defer fake.uploadBlobMutex.RUnlock()
+	fake.waitForTaskMutex.RLock()
+	defer fake.waitForTaskMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -13,8 +15,11 @@ import (
 	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
 )
 
+// Agent reports queued state and progress of unfinished tasks from this protocol version
+const getTaskProtocolVersion = 4
+
 type AgentClient struct {
-	AgentRequest        agentRequest
+	AgentRequest        AgentRequest
 	getTaskDelay        time.Duration
 	toleratedErrorCount int
 	logger              boshlog.Logger
@@ -36,12 +41,24 @@ func NewAgentClient(
 		endpoint:   agentEndpoint,
 		httpClient: httpClient,
 	}
+	return NewAgentClientWithRequest(agentRequest, getTaskDelay, toleratedErrorCount, logger, "httpAgentClient")
+}
+
+// NewAgentClientWithRequest creates client sending requests with given agent request,
+// e.g. one publishing them over NATS
+func NewAgentClientWithRequest(
+	agentRequest AgentRequest,
+	getTaskDelay time.Duration,
+	toleratedErrorCount int,
+	logger boshlog.Logger,
+	logTag string,
+) *AgentClient {
 	return &AgentClient{
 		AgentRequest:        agentRequest,
 		getTaskDelay:        getTaskDelay,
 		toleratedErrorCount: toleratedErrorCount,
 		logger:              logger,
-		logTag:              "httpAgentClient",
+		logTag:              logTag,
 	}
 }
 
@@ -84,7 +101,7 @@ func (c *AgentClient) Start() error {
 	}
 
 	if response.Value != "started" {
-		return bosherr.Errorf("Failed to start agent services with response: '%s'", response)
+		return bosherr.Errorf("Failed to start agent services with response: '%s'", response.Value)
 	}
 
 	return nil
@@ -219,22 +236,200 @@ func (c *AgentClient) SyncDNS(blobID, sha1 string, version uint64) (string, erro
 	return response.Value, nil
 }
 
-func (c *AgentClient) SendAsyncTaskMessage(method string, arguments []interface{}) (value interface{}, err error) {
+func (c *AgentClient) Info() (agentclient.AgentInfo, error) {
+	var info agentclient.AgentInfo
+	err := c.sendAndDecode("info", []interface{}{}, &info)
+	return info, err
+}
+
+func (c *AgentClient) Prepare(spec applyspec.ApplySpec) error {
+	_, err := c.SendAsyncTaskMessage("prepare", []interface{}{spec})
+	return err
+}
+
+func (c *AgentClient) RunErrand(errandName string) (agentclient.ErrandResult, error) {
+	// Agent runs errand of the first job when no name is given
+	args := []interface{}{}
+	if errandName != "" {
+		args = append(args, errandName)
+	}
+
+	var result agentclient.ErrandResult
+	err := c.sendAsyncAndDecode("run_errand", args, &result)
+	return result, err
+}
+
+func (c *AgentClient) SSH(cmd string, params agentclient.SSHParams) (agentclient.SSHResult, error) {
+	var result agentclient.SSHResult
+	err := c.sendAndDecode("ssh", []interface{}{cmd, params}, &result)
+	return result, err
+}
+
+func (c *AgentClient) FetchLogs(logType string, filters []string, options agentclient.FetchLogsOptions) (agentclient.FetchLogsResult, error) {
+	var result agentclient.FetchLogsResult
+	err := c.sendAsyncAndDecode("fetch_logs", []interface{}{logType, filters, options}, &result)
+	return result, err
+}
+
+func (c *AgentClient) TailLogs(logType string, jobName string, options agentclient.TailLogsOptions) (agentclient.TailLogsResult, error) {
+	var result agentclient.TailLogsResult
+	err := c.sendAsyncAndDecode("tail_logs", []interface{}{logType, jobName, options}, &result)
+	return result, err
+}
+
+func (c *AgentClient) UpdateSettings(settings agentclient.UpdateSettings) error {
+	_, err := c.SendAsyncTaskMessage("update_settings", []interface{}{settings})
+	return err
+}
+
+func (c *AgentClient) UploadBlob(blobID string, payload []byte, checksum string) error {
+	spec := map[string]string{
+		"blob_id":  blobID,
+		"checksum": checksum,
+		"payload":  base64.StdEncoding.EncodeToString(payload),
+	}
+
+	_, err := c.SendAsyncTaskMessage("upload_blob", []interface{}{spec})
+	return err
+}
+
+func (c *AgentClient) Shutdown() error {
+	var response SimpleTaskResponse
+	err := c.AgentRequest.Send("shutdown", []interface{}{}, &response)
+	if err != nil {
+		return bosherr.WrapError(err, "Sending 'shutdown' to the agent")
+	}
+
+	return nil
+}
+
+func (c *AgentClient) Batch(steps []agentclient.BatchStep, options agentclient.BatchOptions) ([]agentclient.BatchStepResult, error) {
+	var results []agentclient.BatchStepResult
+	err := c.sendAsyncAndDecode("batch", []interface{}{steps, options}, &results)
+	return results, err
+}
+
+func (c *AgentClient) StartJob(name string, options agentclient.JobControlOptions) error {
+	_, err := c.SendAsyncTaskMessage("start_job", []interface{}{name, options})
+	return err
+}
+
+func (c *AgentClient) StopJob(name string, options agentclient.JobControlOptions) error {
+	_, err := c.SendAsyncTaskMessage("stop_job", []interface{}{name, options})
+	return err
+}
+
+func (c *AgentClient) RestartJob(name string, options agentclient.JobControlOptions) error {
+	_, err := c.SendAsyncTaskMessage("restart_job", []interface{}{name, options})
+	return err
+}
+
+func (c *AgentClient) ListTasks() ([]agentclient.TaskHistoryEntry, error) {
+	var entries []agentclient.TaskHistoryEntry
+	err := c.sendAndDecode("list_tasks", []interface{}{}, &entries)
+	return entries, err
+}
+
+func (c *AgentClient) StartTask(method string, arguments []interface{}) (string, error) {
 	var response TaskResponse
-	err = c.AgentRequest.Send(method, arguments, &response)
+	err := c.AgentRequest.Send(method, arguments, &response)
 	if err != nil {
-		return value, bosherr.WrapErrorf(err, "Sending '%s' to the agent", method)
+		return "", bosherr.WrapErrorf(err, "Sending '%s' to the agent", method)
 	}
 
 	agentTaskID, err := response.TaskID()
 	if err != nil {
-		return value, bosherr.WrapError(err, "Getting agent task id")
+		return "", bosherr.WrapError(err, "Getting agent task id")
+	}
+
+	return agentTaskID, nil
+}
+
+// GetTask returns current state of the task without waiting for it.
+// Agent responds with exception for failed tasks as well as for tasks
+// it does not know about so both are returned as failed tasks.
+func (c *AgentClient) GetTask(taskID string) (agentclient.AgentTask, error) {
+	var response TaskResponse
+	err := c.AgentRequest.SendWithProtocol("get_task", []interface{}{taskID}, getTaskProtocolVersion, &response)
+	if err != nil {
+		if response.Exception != nil {
+			return agentclient.AgentTask{
+				ID:    taskID,
+				State: agentclient.TaskStateFailed,
+				Error: response.Exception.Message,
+			}, nil
+		}
+
+		return agentclient.AgentTask{}, bosherr.WrapError(err, "Sending 'get_task' to the agent")
 	}
 
+	taskState, err := response.TaskState()
+	if err != nil {
+		return agentclient.AgentTask{}, bosherr.WrapError(err, "Getting task state")
+	}
+
+	if isUnfinishedTaskState(taskState) {
+		progress, err := response.TaskProgress()
+		if err != nil {
+			return agentclient.AgentTask{}, bosherr.WrapError(err, "Getting task progress")
+		}
+
+		return agentclient.AgentTask{ID: taskID, State: taskState, Progress: progress}, nil
+	}
+
+	value, err := json.Marshal(response.Value)
+	if err != nil {
+		return agentclient.AgentTask{}, bosherr.WrapError(err, "Marshalling task value")
+	}
+
+	return agentclient.AgentTask{ID: taskID, State: agentclient.TaskStateDone, Value: value}, nil
+}
+
+// WaitForTask polls the task until it finishes and returns its value.
+// Progress reported by the agent while task is unfinished is passed to progressFunc if given.
+func (c *AgentClient) WaitForTask(taskID string, progressFunc func(agentclient.TaskProgress)) (json.RawMessage, error) {
+	value, err := c.waitForTask(taskID, taskID, getTaskProtocolVersion, progressFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling task value")
+	}
+
+	return valueBytes, nil
+}
+
+func (c *AgentClient) CancelTask(taskID string) error {
+	var response SimpleTaskResponse
+	err := c.AgentRequest.Send("cancel_task", []interface{}{taskID}, &response)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending 'cancel_task' for task %s to the agent", taskID)
+	}
+
+	return nil
+}
+
+func (c *AgentClient) SendAsyncTaskMessage(method string, arguments []interface{}) (value interface{}, err error) {
+	agentTaskID, err := c.StartTask(method, arguments)
+	if err != nil {
+		return value, err
+	}
+
+	return c.waitForTask(method, agentTaskID, 0, nil)
+}
+
+func (c *AgentClient) waitForTask(
+	description string,
+	agentTaskID string,
+	protocol int,
+	progressFunc func(agentclient.TaskProgress),
+) (value interface{}, err error) {
 	sendErrors := 0
 	getTaskRetryable := boshretry.NewRetryable(func() (bool, error) {
 		var response TaskResponse
-		err = c.AgentRequest.Send("get_task", []interface{}{agentTaskID}, &response)
+		err = c.AgentRequest.SendWithProtocol("get_task", []interface{}{agentTaskID}, protocol, &response)
 		if err != nil {
 			sendErrors++
 			shouldRetry := sendErrors <= c.toleratedErrorCount
@@ -251,12 +446,23 @@ func (c *AgentClient) SendAsyncTaskMessage(method string, arguments []interface{
 			return false, bosherr.WrapError(err, "Getting task state")
 		}
 
-		if taskState != "running" {
+		if !isUnfinishedTaskState(taskState) {
 			value = response.Value
 			return false, nil
 		}
 
-		return true, bosherr.Errorf("Task %s is still running", method)
+		if progressFunc != nil {
+			progress, err := response.TaskProgress()
+			if err != nil {
+				return false, bosherr.WrapError(err, "Getting task progress")
+			}
+
+			if progress != nil {
+				progressFunc(*progress)
+			}
+		}
+
+		return true, bosherr.Errorf("Task %s is still running", description)
 	})
 
 	getTaskRetryStrategy := boshretry.NewUnlimitedRetryStrategy(c.getTaskDelay, getTaskRetryable, c.logger)
@@ -266,6 +472,39 @@ func (c *AgentClient) SendAsyncTaskMessage(method string, arguments []interface{
 	return value, err
 }
 
+func (c *AgentClient) sendAndDecode(method string, arguments []interface{}, result interface{}) error {
+	var response ValueResponse
+	err := c.AgentRequest.Send(method, arguments, &response)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending '%s' to the agent", method)
+	}
+
+	err = json.Unmarshal(response.Value, result)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unable to parse '%s' response from the agent", method)
+	}
+
+	return nil
+}
+
+func (c *AgentClient) sendAsyncAndDecode(method string, arguments []interface{}, result interface{}) error {
+	value, err := c.SendAsyncTaskMessage(method, arguments)
+	if err != nil {
+		return err
+	}
+
+	err = decodeValue(value, result)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unable to parse '%s' response from the agent", method)
+	}
+
+	return nil
+}
+
+func isUnfinishedTaskState(taskState string) bool {
+	return taskState == agentclient.TaskStateRunning || taskState == agentclient.TaskStateQueued
+}
+
 func (c *AgentClient) AddPersistentDisk(diskCID string, diskHints interface{}) error {
 	_, err := c.SendAsyncTaskMessage("add_persistent_disk", []interface{}{diskCID, diskHints})
 	return err
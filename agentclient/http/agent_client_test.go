@@ -1080,4 +1080,522 @@ var _ = Describe("AgentClient", func() {
 			})
 		})
 	})
+
+	Describe("Info", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/agent"),
+				ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+					Method:    "info",
+					Arguments: []interface{}{},
+					ReplyTo:   replyToAddress,
+				}),
+				ghttp.RespondWith(200, `{"value":{"api_version":1}}`),
+			))
+		})
+
+		It("returns agent info", func() {
+			info, err := agentClient.Info()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info).To(Equal(agentclient.AgentInfo{APIVersion: 1}))
+		})
+	})
+
+	Describe("SSH", func() {
+		Context("when agent responds with a value", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method: "ssh",
+						Arguments: []interface{}{"setup", map[string]interface{}{
+							"user_regex": "",
+							"user":       "fake-user",
+							"public_key": "fake-public-key",
+						}},
+						ReplyTo: replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":{"command":"setup","status":"success","ip":"10.0.0.1","host_public_key":"fake-host-key"}}`),
+				))
+			})
+
+			It("returns ssh result", func() {
+				result, err := agentClient.SSH("setup", agentclient.SSHParams{User: "fake-user", PublicKey: "fake-public-key"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(agentclient.SSHResult{
+					Command:       "setup",
+					Status:        "success",
+					IP:            "10.0.0.1",
+					HostPublicKey: "fake-host-key",
+				}))
+			})
+		})
+
+		Context("when agent responds with exception", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(ghttp.RespondWith(200, `{"exception":{"message":"bad request"}}`))
+			})
+
+			It("returns an error", func() {
+				_, err := agentClient.SSH("setup", agentclient.SSHParams{})
+				Expect(err).To(MatchError(ContainSubstring("bad request")))
+			})
+		})
+	})
+
+	Describe("FetchLogs", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method: "fetch_logs",
+						Arguments: []interface{}{"job", []string{"*.log"}, map[string]interface{}{
+							"modified_after":     "0001-01-01T00:00:00Z",
+							"modified_before":    "0001-01-01T00:00:00Z",
+							"max_file_bytes":     0,
+							"max_total_bytes":    100,
+							"exclude_compressed": true,
+						}},
+						ReplyTo: replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":{"blobstore_id":"fake-blob-id","sha1":"fake-sha1","manifest":{"included_files":1,"included_bytes":100,"truncated":[{"path":"job/a.log","size":200,"included_bytes":100}],"skipped":[]}}}`),
+				),
+			)
+		})
+
+		It("waits for the task and returns fetched logs", func() {
+			result, err := agentClient.FetchLogs("job", []string{"*.log"}, agentclient.FetchLogsOptions{
+				MaxTotalBytes:     100,
+				ExcludeCompressed: true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result).To(Equal(agentclient.FetchLogsResult{
+				BlobstoreID: "fake-blob-id",
+				SHA1:        "fake-sha1",
+				Manifest: &agentclient.FetchLogsManifest{
+					IncludedFiles: 1,
+					IncludedBytes: 100,
+					Truncated:     []agentclient.FetchLogsManifestEntry{{Path: "job/a.log", Size: 200, IncludedBytes: 100}},
+					Skipped:       []agentclient.FetchLogsManifestEntry{},
+				},
+			}))
+		})
+	})
+
+	Describe("TailLogs", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method: "tail_logs",
+						Arguments: []interface{}{"job", "fake-job", map[string]interface{}{
+							"filters":        nil,
+							"lines":          10,
+							"bytes":          0,
+							"follow_seconds": 0,
+						}},
+						ReplyTo: replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":{"files":[{"path":"fake-job.log","content":"line\n","truncated":true}]}}`),
+				),
+			)
+		})
+
+		It("waits for the task and returns log tails", func() {
+			result, err := agentClient.TailLogs("job", "fake-job", agentclient.TailLogsOptions{Lines: 10})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(result).To(Equal(agentclient.TailLogsResult{
+				Files: []agentclient.TailedLogFile{{Path: "fake-job.log", Content: "line\n", Truncated: true}},
+			}))
+		})
+	})
+
+	Describe("RunErrand", func() {
+		respondWithErrandResult := func(expectedArguments []interface{}) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method:    "run_errand",
+						Arguments: expectedArguments,
+						ReplyTo:   replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":{"stdout":"fake-stdout","stderr":"fake-stderr","exit_code":1}}`),
+				),
+			)
+		}
+
+		It("runs given errand and returns its result", func() {
+			respondWithErrandResult([]interface{}{"fake-errand"})
+
+			result, err := agentClient.RunErrand("fake-errand")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(agentclient.ErrandResult{Stdout: "fake-stdout", Stderr: "fake-stderr", ExitStatus: 1}))
+		})
+
+		It("does not send errand name when it is not given", func() {
+			respondWithErrandResult([]interface{}{})
+
+			_, err := agentClient.RunErrand("")
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("async actions without result", func() {
+		respondWithTaskDone := func(expectedMethod string, expectedArguments []interface{}) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method:    expectedMethod,
+						Arguments: expectedArguments,
+						ReplyTo:   replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":"fake-value"}`),
+				),
+			)
+		}
+
+		It("sends prepare and waits for the task", func() {
+			spec := applyspec.ApplySpec{Deployment: "fake-deployment"}
+			respondWithTaskDone("prepare", []interface{}{spec})
+
+			Expect(agentClient.Prepare(spec)).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("sends update_settings and waits for the task", func() {
+			settings := agentclient.UpdateSettings{
+				DiskAssociations: []agentclient.DiskAssociation{{Name: "fake-disk", DiskCID: "fake-disk-cid"}},
+				TrustedCerts:     "fake-certs",
+			}
+			respondWithTaskDone("update_settings", []interface{}{settings})
+
+			Expect(agentClient.UpdateSettings(settings)).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("sends base64 encoded payload in upload_blob and waits for the task", func() {
+			respondWithTaskDone("upload_blob", []interface{}{map[string]string{
+				"blob_id":  "fake-blob-id",
+				"checksum": "fake-checksum",
+				"payload":  "ZmFrZS1wYXlsb2Fk",
+			}})
+
+			Expect(agentClient.UploadBlob("fake-blob-id", []byte("fake-payload"), "fake-checksum")).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
+	Describe("Shutdown", func() {
+		It("sends shutdown to the agent", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/agent"),
+				ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+					Method:    "shutdown",
+					Arguments: []interface{}{},
+					ReplyTo:   replyToAddress,
+				}),
+				ghttp.RespondWith(200, `{"value":"shutdown"}`),
+			))
+
+			Expect(agentClient.Shutdown()).To(Succeed())
+		})
+
+		It("returns an error when agent responds with exception", func() {
+			server.AppendHandlers(ghttp.RespondWith(200, `{"exception":{"message":"bad request"}}`))
+
+			err := agentClient.Shutdown()
+			Expect(err).To(MatchError(ContainSubstring("bad request")))
+		})
+	})
+
+	Describe("job control", func() {
+		respondWithJobControlResult := func(method string, value string) {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method: method,
+						Arguments: []interface{}{"fake-job", map[string]interface{}{
+							"wait":            true,
+							"timeout_seconds": 30,
+							"skip_drain":      true,
+						}},
+						ReplyTo: replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":"`+value+`"}`),
+				),
+			)
+		}
+
+		options := agentclient.JobControlOptions{Wait: true, TimeoutSeconds: 30, SkipDrain: true}
+
+		It("sends start_job and waits for the task to be finished", func() {
+			respondWithJobControlResult("start_job", "started")
+
+			err := agentClient.StartJob("fake-job", options)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("sends stop_job and waits for the task to be finished", func() {
+			respondWithJobControlResult("stop_job", "stopped")
+
+			err := agentClient.StopJob("fake-job", options)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("sends restart_job and waits for the task to be finished", func() {
+			respondWithJobControlResult("restart_job", "restarted")
+
+			err := agentClient.RestartJob("fake-job", options)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("returns an error when agent responds with exception", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/agent"),
+				ghttp.RespondWith(200, `{"exception":{"message":"fake-job-control-error"}}`),
+			))
+
+			err := agentClient.StopJob("fake-job", options)
+			Expect(err).To(MatchError(ContainSubstring("fake-job-control-error")))
+		})
+	})
+
+	Describe("Batch", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method: "batch",
+						Arguments: []interface{}{
+							[]agentclient.BatchStep{
+								{Method: "ping", Arguments: []interface{}{}},
+								{Method: "unmount_disk", Arguments: []interface{}{"fake-disk-cid"}},
+							},
+							agentclient.BatchOptions{StopOnFailure: true},
+						},
+						ReplyTo: replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.RespondWith(200, `{"value":[{"method":"ping","state":"done","value":"pong"},{"method":"unmount_disk","state":"failed","error":"fake-error"}]}`),
+				),
+			)
+		})
+
+		It("waits for the task and returns result of each step", func() {
+			results, err := agentClient.Batch([]agentclient.BatchStep{
+				{Method: "ping", Arguments: []interface{}{}},
+				{Method: "unmount_disk", Arguments: []interface{}{"fake-disk-cid"}},
+			}, agentclient.BatchOptions{StopOnFailure: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(results).To(Equal([]agentclient.BatchStepResult{
+				{Method: "ping", State: "done", Value: json.RawMessage(`"pong"`)},
+				{Method: "unmount_disk", State: "failed", Error: "fake-error"},
+			}))
+		})
+	})
+
+	Describe("ListTasks", func() {
+		It("returns task history", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/agent"),
+				ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+					Method:    "list_tasks",
+					Arguments: []interface{}{},
+					ReplyTo:   replyToAddress,
+				}),
+				ghttp.RespondWith(200, `{"value":[{"agent_task_id":"fake-task-id","method":"apply","state":"running","started_at":"2018-01-02T03:04:05Z","progress":{"stage":"Applying","current":1,"total":2}}]}`),
+			))
+
+			entries, err := agentClient.ListTasks()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(entries).To(Equal([]agentclient.TaskHistoryEntry{{
+				TaskID:    "fake-task-id",
+				Method:    "apply",
+				State:     "running",
+				StartedAt: time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+				Progress:  &agentclient.TaskProgress{Stage: "Applying", Current: 1, Total: 2},
+			}}))
+		})
+	})
+
+	Describe("task helpers", func() {
+		It("starts a task and returns its id", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/agent"),
+				ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+					Method:    "tail_logs",
+					Arguments: []interface{}{"agent", ""},
+					ReplyTo:   replyToAddress,
+				}),
+				ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running"}}`),
+			))
+
+			taskID, err := agentClient.StartTask("tail_logs", []interface{}{"agent", ""})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(taskID).To(Equal("fake-agent-task-id"))
+		})
+
+		Describe("GetTask", func() {
+			respondToGetTask := func(response string) {
+				server.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method:    "get_task",
+						Arguments: []interface{}{"fake-agent-task-id"},
+						ReplyTo:   replyToAddress,
+						Protocol:  4,
+					}),
+					ghttp.RespondWith(200, response),
+				))
+			}
+
+			It("returns state and progress of unfinished task", func() {
+				respondToGetTask(`{"value":{"agent_task_id":"fake-agent-task-id","state":"queued","progress":{"stage":"Following logs","current":2,"total":0,"data":[1]}}}`)
+
+				task, err := agentClient.GetTask("fake-agent-task-id")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(task).To(Equal(agentclient.AgentTask{
+					ID:       "fake-agent-task-id",
+					State:    agentclient.TaskStateQueued,
+					Progress: &agentclient.TaskProgress{Stage: "Following logs", Current: 2, Data: json.RawMessage(`[1]`)},
+				}))
+			})
+
+			It("returns value of finished task", func() {
+				respondToGetTask(`{"value":{"blobstore_id":"fake-blob-id"}}`)
+
+				task, err := agentClient.GetTask("fake-agent-task-id")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(task.State).To(Equal(agentclient.TaskStateDone))
+				Expect(task.Value).To(MatchJSON(`{"blobstore_id":"fake-blob-id"}`))
+			})
+
+			It("returns error of failed task", func() {
+				respondToGetTask(`{"exception":{"message":"Task fake-agent-task-id result: fake-error"}}`)
+
+				task, err := agentClient.GetTask("fake-agent-task-id")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(task).To(Equal(agentclient.AgentTask{
+					ID:    "fake-agent-task-id",
+					State: agentclient.TaskStateFailed,
+					Error: "Task fake-agent-task-id result: fake-error",
+				}))
+			})
+
+			It("returns an error when agent cannot be reached", func() {
+				server.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, ""))
+
+				_, err := agentClient.GetTask("fake-agent-task-id")
+				Expect(err).To(MatchError(ContainSubstring("status code: 500")))
+			})
+		})
+
+		Describe("WaitForTask", func() {
+			It("reports progress until task finishes and returns its value", func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/agent"),
+						ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+							Method:    "get_task",
+							Arguments: []interface{}{"fake-agent-task-id"},
+							ReplyTo:   replyToAddress,
+							Protocol:  4,
+						}),
+						ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"queued"}}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/agent"),
+						ghttp.RespondWith(200, `{"value":{"agent_task_id":"fake-agent-task-id","state":"running","progress":{"stage":"Reading logs","current":1,"total":0}}}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/agent"),
+						ghttp.RespondWith(200, `{"value":{"files":[]}}`),
+					),
+				)
+
+				var progresses []agentclient.TaskProgress
+
+				value, err := agentClient.WaitForTask("fake-agent-task-id", func(progress agentclient.TaskProgress) {
+					progresses = append(progresses, progress)
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(MatchJSON(`{"files":[]}`))
+
+				Expect(progresses).To(Equal([]agentclient.TaskProgress{{Stage: "Reading logs", Current: 1}}))
+			})
+
+			It("returns an error when task fails", func() {
+				server.AppendHandlers(
+					ghttp.RespondWith(200, `{"exception":{"message":"fake-error"}}`),
+					ghttp.RespondWith(200, `{"exception":{"message":"fake-error"}}`),
+					ghttp.RespondWith(200, `{"exception":{"message":"fake-error"}}`),
+				)
+
+				_, err := agentClient.WaitForTask("fake-agent-task-id", nil)
+				Expect(err).To(MatchError(ContainSubstring("fake-error")))
+			})
+		})
+
+		Describe("CancelTask", func() {
+			It("sends cancel_task to the agent", func() {
+				server.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/agent"),
+					ghttp.VerifyJSONRepresenting(AgentRequestMessage{
+						Method:    "cancel_task",
+						Arguments: []interface{}{"fake-agent-task-id"},
+						ReplyTo:   replyToAddress,
+					}),
+					ghttp.RespondWith(200, `{"value":"canceling"}`),
+				))
+
+				Expect(agentClient.CancelTask("fake-agent-task-id")).To(Succeed())
+			})
+
+			It("returns an error when task cannot be cancelled", func() {
+				server.AppendHandlers(ghttp.RespondWith(200, `{"exception":{"message":"Task fake-agent-task-id could not be canceled"}}`))
+
+				err := agentClient.CancelTask("fake-agent-task-id")
+				Expect(err).To(MatchError(ContainSubstring("could not be canceled")))
+			})
+		})
+	})
 })
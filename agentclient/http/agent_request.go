@@ -13,6 +13,15 @@ type AgentRequestMessage struct {
 	Method    string        `json:"method"`
 	Arguments []interface{} `json:"arguments"`
 	ReplyTo   string        `json:"reply_to"`
+
+	// Only set for requests relying on behaviour of newer protocol versions
+	Protocol int `json:"protocol,omitempty"`
+}
+
+// AgentRequest sends a single request to the agent and unmarshals its response
+type AgentRequest interface {
+	Send(method string, arguments []interface{}, response Response) error
+	SendWithProtocol(method string, arguments []interface{}, protocol int, response Response) error
 }
 
 type agentRequest struct {
@@ -22,10 +31,15 @@ type agentRequest struct {
 }
 
 func (r agentRequest) Send(method string, arguments []interface{}, response Response) error {
+	return r.SendWithProtocol(method, arguments, 0, response)
+}
+
+func (r agentRequest) SendWithProtocol(method string, arguments []interface{}, protocol int, response Response) error {
 	postBody := AgentRequestMessage{
 		Method:    method,
 		Arguments: arguments,
 		ReplyTo:   r.directorID,
+		Protocol:  protocol,
 	}
 
 	agentRequestJSON, err := json.Marshal(postBody)
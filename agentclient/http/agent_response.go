@@ -66,6 +66,22 @@ func (r *ListResponse) Unmarshal(message []byte) error {
 	return json.Unmarshal(message, r)
 }
 
+type ValueResponse struct {
+	Value     json.RawMessage
+	Exception *exception
+}
+
+func (r *ValueResponse) ServerError() error {
+	if r.Exception != nil {
+		return bosherr.Errorf("Agent responded with error: %s", r.Exception.Message)
+	}
+	return nil
+}
+
+func (r *ValueResponse) Unmarshal(message []byte) error {
+	return json.Unmarshal(message, r)
+}
+
 type BlobRef struct {
 	Name        string `json:"name"`
 	Version     string `json:"version"`
@@ -162,3 +178,36 @@ func (r *TaskResponse) TaskState() (string, error) {
 
 	return "finished", nil
 }
+
+// TaskProgress returns progress of unfinished task if agent reported any.
+// Agent only reports progress to clients using protocol 4 or newer.
+func (r *TaskResponse) TaskProgress() (*agentclient.TaskProgress, error) {
+	complexResponse, ok := r.Value.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	progressRaw, ok := complexResponse["progress"]
+	if !ok || progressRaw == nil {
+		return nil, nil
+	}
+
+	var progress agentclient.TaskProgress
+
+	err := decodeValue(progressRaw, &progress)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Failed to parse task progress from agent response %#v", r.Value)
+	}
+
+	return &progress, nil
+}
+
+// decodeValue converts generic JSON value into given typed result
+func decodeValue(value interface{}, result interface{}) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(valueBytes, result)
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// Event is a message (heartbeat, alert, shutdown notification)
+// sent by an agent on HTTPS mbus
+type Event struct {
+	Target  string          `json:"target"`
+	Topic   string          `json:"topic"`
+	Message json.RawMessage `json:"message"`
+}
+
+// EventsClient picks up events queued by the agent since agent
+// on HTTPS mbus cannot deliver them on its own
+type EventsClient struct {
+	endpoint   string
+	httpClient *httpclient.HTTPClient
+	logger     boshlog.Logger
+	logTag     string
+}
+
+func NewEventsClient(
+	endpoint string,
+	httpClient *httpclient.HTTPClient,
+	logger boshlog.Logger,
+) *EventsClient {
+	return &EventsClient{
+		endpoint:   fmt.Sprintf("%s/events", endpoint),
+		httpClient: httpClient,
+		logger:     logger,
+		logTag:     "httpEventsClient",
+	}
+}
+
+// Poll returns events queued by the agent. If there are none,
+// agent holds the request for up to timeout (rounded down to seconds) waiting for one.
+// Poll returns no events if timeout passes without any being sent.
+func (c *EventsClient) Poll(timeout time.Duration) ([]Event, error) {
+	endpoint := fmt.Sprintf("%s?timeout=%d", c.endpoint, int(timeout/time.Second))
+
+	httpResponse, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Performing request to agent")
+	}
+	defer func() {
+		_ = httpResponse.Body.Close()
+	}()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, bosherr.Errorf("Agent responded with non-successful status code: %d", httpResponse.StatusCode)
+	}
+
+	responseBody, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading agent response")
+	}
+
+	var events []Event
+
+	err = json.Unmarshal(responseBody, &events)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshaling agent events")
+	}
+
+	c.logger.Debug(c.logTag, "Received %d events from the agent", len(events))
+
+	return events, nil
+}
+
+// Consume keeps polling for events and calls eventFunc with each of them in order
+// until stopCh is closed. Polling errors are logged and polling is retried after errorDelay.
+func (c *EventsClient) Consume(timeout, errorDelay time.Duration, eventFunc func(Event), stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		events, err := c.Poll(timeout)
+		if err != nil {
+			c.logger.Warn(c.logTag, "Polling agent for events: %s", err.Error())
+
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(errorDelay):
+			}
+
+			continue
+		}
+
+		for _, event := range events {
+			eventFunc(event)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-agent/agentclient/http"
+
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("EventsClient", func() {
+	var (
+		server       *ghttp.Server
+		eventsClient *EventsClient
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		httpClient := httpclient.NewHTTPClient(httpclient.DefaultClient, logger)
+
+		eventsClient = NewEventsClient(server.URL(), httpClient, logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Poll", func() {
+		It("returns events queued by the agent", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/events", "timeout=30"),
+					ghttp.RespondWith(200, `[
+						{"target":"hm","topic":"heartbeat","message":{"job":"fake-job"}},
+						{"target":"hm","topic":"alert","message":"fake-alert"}
+					]`),
+				),
+			)
+
+			events, err := eventsClient.Poll(30 * time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(events).To(Equal([]Event{
+				{Target: "hm", Topic: "heartbeat", Message: json.RawMessage(`{"job":"fake-job"}`)},
+				{Target: "hm", Topic: "alert", Message: json.RawMessage(`"fake-alert"`)},
+			}))
+		})
+
+		It("returns an error when agent responds with non-successful status code", func() {
+			server.AppendHandlers(ghttp.RespondWith(401, ""))
+
+			_, err := eventsClient.Poll(30 * time.Second)
+			Expect(err).To(MatchError("Agent responded with non-successful status code: 401"))
+		})
+
+		It("returns an error when agent response cannot be unmarshalled", func() {
+			server.AppendHandlers(ghttp.RespondWith(200, "{"))
+
+			_, err := eventsClient.Poll(30 * time.Second)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshaling agent events"))
+		})
+	})
+
+	Describe("Consume", func() {
+		It("calls event func with every event until stopped and keeps polling after errors", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(200, `[{"target":"hm","topic":"alert","message":1}]`),
+				ghttp.RespondWith(500, ""),
+				ghttp.RespondWith(200, `[{"target":"hm","topic":"alert","message":2}]`),
+			)
+			server.SetAllowUnhandledRequests(true)
+			server.SetUnhandledRequestStatusCode(http.StatusOK)
+
+			eventsCh := make(chan Event, 10)
+			stopCh := make(chan struct{})
+			doneCh := make(chan struct{})
+
+			go func() {
+				eventsClient.Consume(0, time.Millisecond, func(event Event) { eventsCh <- event }, stopCh)
+				close(doneCh)
+			}()
+
+			var event Event
+			Eventually(eventsCh).Should(Receive(&event))
+			Expect(event.Message).To(MatchJSON(`1`))
+			Eventually(eventsCh).Should(Receive(&event))
+			Expect(event.Message).To(MatchJSON(`2`))
+
+			close(stopCh)
+			Eventually(doneCh).Should(BeClosed())
+		})
+	})
+})
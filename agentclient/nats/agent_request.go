@@ -0,0 +1,130 @@
+package nats
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/yagnats"
+
+	agentclienthttp "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// signedRequest is the envelope agent expects requests in once mbus signing key is configured.
+// Signature is HMAC-SHA256 of method, timestamp, nonce and payload.
+type signedRequest struct {
+	Method    string `json:"method"`
+	Payload   []byte `json:"payload"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Signature []byte `json:"signature"`
+}
+
+func (r signedRequest) signedContent() []byte {
+	return append([]byte(fmt.Sprintf("%s\n%d\n%s\n", r.Method, r.Timestamp, r.Nonce)), r.Payload...)
+}
+
+type agentRequest struct {
+	natsClient  yagnats.NATSClient
+	agentID     string
+	config      Config
+	timeService clock.Clock
+	uuidGen     boshuuid.Generator
+}
+
+func (r agentRequest) Send(method string, arguments []interface{}, response agentclienthttp.Response) error {
+	return r.SendWithProtocol(method, arguments, 0, response)
+}
+
+func (r agentRequest) SendWithProtocol(method string, arguments []interface{}, protocol int, response agentclienthttp.Response) error {
+	requestID, err := r.uuidGen.Generate()
+	if err != nil {
+		return bosherr.WrapError(err, "Generating request id")
+	}
+
+	// Each request gets its own inbox so responses to concurrent requests are not mixed up
+	replyTo := fmt.Sprintf("director.%s.%s", r.config.DirectorID, requestID)
+
+	payload, err := json.Marshal(agentclienthttp.AgentRequestMessage{
+		Method:    method,
+		Arguments: arguments,
+		ReplyTo:   replyTo,
+		Protocol:  protocol,
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Marshaling agent request")
+	}
+
+	if r.config.SigningKey != "" {
+		payload, err = r.sign(method, payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	responseCh := make(chan []byte, 1)
+
+	subscription, err := r.natsClient.Subscribe(replyTo, func(msg *yagnats.Message) {
+		select {
+		case responseCh <- msg.Payload:
+		default:
+			// Only the first response is expected
+		}
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Subscribing to %s", replyTo)
+	}
+
+	defer func() {
+		_ = r.natsClient.Unsubscribe(subscription)
+	}()
+
+	err = r.natsClient.Publish(fmt.Sprintf("agent.%s", r.agentID), payload)
+	if err != nil {
+		return bosherr.WrapError(err, "Performing request to agent")
+	}
+
+	var responseBody []byte
+
+	select {
+	case responseBody = <-responseCh:
+	case <-r.timeService.After(r.config.requestTimeout()):
+		return bosherr.Errorf("Timed out waiting for agent to respond to '%s'", method)
+	}
+
+	err = response.Unmarshal(responseBody)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshaling agent response")
+	}
+
+	return response.ServerError()
+}
+
+func (r agentRequest) sign(method string, payload []byte) ([]byte, error) {
+	nonce, err := r.uuidGen.Generate()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Generating request nonce")
+	}
+
+	request := signedRequest{
+		Method:    method,
+		Payload:   payload,
+		Timestamp: r.timeService.Now().Unix(),
+		Nonce:     nonce,
+	}
+
+	mac := hmac.New(sha256.New, []byte(r.config.SigningKey))
+	mac.Write(request.signedContent())
+	request.Signature = mac.Sum(nil)
+
+	signedPayload, err := json.Marshal(request)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshaling signed agent request")
+	}
+
+	return signedPayload, nil
+}
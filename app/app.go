@@ -11,6 +11,7 @@ import (
 
 	boshagent "github.com/cloudfoundry/bosh-agent/agent"
 	boshaction "github.com/cloudfoundry/bosh-agent/agent/action"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshapplier "github.com/cloudfoundry/bosh-agent/agent/applier"
 	boshas "github.com/cloudfoundry/bosh-agent/agent/applier/applyspec"
 	boshbc "github.com/cloudfoundry/bosh-agent/agent/applier/bundlecollection"
@@ -22,8 +23,10 @@ import (
 	boshcomp "github.com/cloudfoundry/bosh-agent/agent/compiler"
 	boshscript "github.com/cloudfoundry/bosh-agent/agent/script"
 	boshtask "github.com/cloudfoundry/bosh-agent/agent/task"
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshjobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshhealth "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	boshmbus "github.com/cloudfoundry/bosh-agent/mbus"
 	boshnotif "github.com/cloudfoundry/bosh-agent/notification"
@@ -48,12 +51,19 @@ type App interface {
 type app struct {
 	logger      boshlog.Logger
 	agent       boshagent.Agent
+	localServer localServer
 	platform    boshplatform.Platform
 	fs          boshsys.FileSystem
 	logTag      string
 	dirProvider boshdirs.Provider
 }
 
+// localServer answers read-only requests from tools on the VM
+type localServer struct {
+	handler    boshhandler.Handler
+	dispatcher boshagent.ActionDispatcher
+}
+
 func New(logger boshlog.Logger, fs boshsys.FileSystem) App {
 	return &app{
 		logger: logger,
@@ -156,12 +166,21 @@ func (app *app) Setup(opts Options) error {
 		return bosherr.WrapError(err, "Getting monit client")
 	}
 
+	healthChecker := boshhealth.NewChecker(
+		boshhealth.NewProber(app.platform.GetRunner(), timeService),
+		app.platform.GetFs(),
+		filepath.Join(app.dirProvider.BoshDir(), "health_checks.json"),
+		timeService,
+		app.logger,
+	)
+
 	jobSupervisorProvider := boshjobsuper.NewProvider(
 		app.platform,
 		monitClient,
 		app.logger,
 		app.dirProvider,
 		mbusHandler,
+		healthChecker,
 	)
 
 	jobSupervisor, err := jobSupervisorProvider.Get(opts.JobSupervisor)
@@ -175,13 +194,20 @@ func (app *app) Setup(opts Options) error {
 		app.dirProvider,
 		blobstore,
 		jobSupervisor,
+		healthChecker,
 		settingsService.GetSettings(),
 		timeService,
 	)
 
 	uuidGen := boshuuid.NewGenerator()
 
-	taskService := boshtask.NewAsyncTaskService(uuidGen, app.logger)
+	taskHistory := boshtask.NewHistoryProvider().NewHistory(
+		app.logger,
+		app.platform.GetFs(),
+		app.dirProvider.BoshDir(),
+	)
+
+	taskService := boshtask.NewAsyncTaskService(uuidGen, taskHistory, timeService, app.logger)
 
 	taskManager := boshtask.NewManagerProvider().NewManager(
 		app.logger,
@@ -209,19 +235,38 @@ func (app *app) Setup(opts Options) error {
 		jobSupervisor,
 		specService,
 		jobScriptProvider,
+		timeService,
 		app.logger,
 	)
 
 	actionRunner := boshaction.NewRunner()
 
-	actionDispatcher := boshagent.NewActionDispatcher(
+	concreteActionDispatcher := boshagent.NewActionDispatcher(
 		app.logger,
 		taskService,
 		taskManager,
 		actionFactory,
 		actionRunner,
+		boshagent.NewRequestCache(config.Agent.IdempotencyWindow(), timeService),
+	)
+
+	actionDispatcher := boshagent.NewAuthorizingActionDispatcher(
+		concreteActionDispatcher,
+		config.Agent.Authorization,
+		auditLogger,
+		app.logger,
 	)
 
+	app.localServer = localServer{
+		handler: boshmbus.NewUnixSocketHandler(app.dirProvider.AgentSocketPath(), app.logger),
+		dispatcher: boshagent.NewAuthorizingActionDispatcher(
+			concreteActionDispatcher,
+			boshagent.LocalAuthorizationPolicy(),
+			auditLogger,
+			app.logger,
+		),
+	}
+
 	rebootChecker := bootonce.NewRebootChecker(
 		settingsService,
 		app.platform.GetFs(),
@@ -234,6 +279,8 @@ func (app *app) Setup(opts Options) error {
 		app.platform,
 		actionDispatcher,
 		jobSupervisor,
+		boshalert.NewPipeline(config.Agent.Alerts, timeService),
+		boshalert.NewThresholdEvaluator(config.Agent.Thresholds, settingsService, timeService),
 		specService,
 		time.Second*30,
 		settingsService,
@@ -246,6 +293,13 @@ func (app *app) Setup(opts Options) error {
 }
 
 func (app *app) Run() error {
+	// Agent keeps serving director even if local tools cannot reach it
+	if err := app.localServer.handler.Start(app.localServer.dispatcher.Dispatch); err != nil {
+		app.logger.Error(app.logTag, "Starting local handler: %s", err.Error())
+	} else {
+		defer app.localServer.handler.Stop()
+	}
+
 	if err := app.agent.Run(); err != nil {
 		return bosherr.WrapError(err, "Running agent")
 	}
@@ -260,6 +314,7 @@ func (app *app) buildApplierAndCompiler(
 	dirProvider boshdirs.Provider,
 	blobstore boshblob.DigestBlobstore,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	healthChecker boshhealth.Checker,
 	settings boshsettings.Settings,
 	timeService clock.Clock,
 ) (boshapplier.Applier, boshcomp.Compiler) {
@@ -292,6 +347,8 @@ func (app *app) buildApplierAndCompiler(
 		dirProvider,
 		jobsBc,
 		jobSupervisor,
+		app.platform.GetCgroupManager(),
+		healthChecker,
 		packageApplierProvider,
 		blobstore,
 		boshaj.FixPermissions,
@@ -323,6 +380,7 @@ func (app *app) buildApplierAndCompiler(
 		dirProvider,
 		packageApplierProvider.Root(),
 		packageApplierProvider.RootBundleCollection(),
+		app.logger,
 	)
 
 	return applier, compiler
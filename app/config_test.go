@@ -4,6 +4,8 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshagent "github.com/cloudfoundry/bosh-agent/agent"
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
 	boshinf "github.com/cloudfoundry/bosh-agent/infrastructure"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -63,6 +65,17 @@ var _ = Describe("LoadConfigFromPath", func() {
 				  "UseServerName": true,
 				  "UseRegistry": true
 				}
+			},
+			"Agent": {
+				"IdempotencyWindowSeconds": 300,
+				"Alerts": {"DedupWindowSeconds": 120, "RateLimit": 5, "FlapRestarts": 3},
+				"Thresholds": {"PersistentDisk": {"WarningPercent": 75, "CriticalPercent": 85}, "HysteresisPercent": 3},
+				"Authorization": {
+					"Rules": [
+						{"ReplyToPrefix": "director.", "Methods": ["*"]},
+						{"ClientName": "monitoring", "Methods": ["ping", "get_state"]}
+					]
+				}
 			}
 		}`)
 
@@ -108,6 +121,24 @@ var _ = Describe("LoadConfigFromPath", func() {
 					UseRegistry:   true,
 				},
 			},
+			Agent: boshagent.Options{
+				IdempotencyWindowSeconds: 300,
+				Alerts: boshalert.PipelineOptions{
+					DedupWindowSeconds: 120,
+					RateLimit:          5,
+					FlapRestarts:       3,
+				},
+				Thresholds: boshalert.ThresholdOptions{
+					PersistentDisk:    boshalert.Threshold{WarningPercent: 75, CriticalPercent: 85},
+					HysteresisPercent: 3,
+				},
+				Authorization: boshagent.AuthorizationPolicy{
+					Rules: []boshagent.AuthorizationRule{
+						{ReplyToPrefix: "director.", Methods: []string{"*"}},
+						{ClientName: "monitoring", Methods: []string{"ping", "get_state"}},
+					},
+				},
+			},
 		}))
 	})
 
@@ -72,4 +72,15 @@ var _ = Describe("CommonEventFormat", func() {
 			})
 		})
 	})
+
+	Context("when incoming request is denied", func() {
+		It("should produce CEF string with severity=7 and statusReason", func() {
+			cefLog, err := cef.ProduceDeniedRequestEventLog("hm.agent-monitor", "ssh", "not allowed")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cefLog).To(ContainSubstring("CEF:0|CloudFoundry|BOSH|1|agent_api|ssh|7|duser=hm.agent-monitor"))
+			Expect(cefLog).To(ContainSubstring("shost"))
+			Expect(cefLog).To(ContainSubstring("cs1=not allowed cs1Label=statusReason"))
+		})
+	})
 })
@@ -16,6 +16,14 @@ type Request struct {
 	Method          string
 	Payload         []byte
 	ProtocolVersion ProtocolVersion `json:"protocol"`
+
+	// Optional key set by API consumers on requests that
+	// must not be executed more than once when retried
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// Identity of the sender established by mbus handler
+	// (e.g. name on TLS client certificate); never read from payload
+	ClientName string `json:"-"`
 }
 
 func (r Request) GetPayload() []byte {
@@ -1,19 +1,21 @@
 package integrationagentclient
 
 import (
-	"encoding/json"
 	"time"
 
-	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"code.cloudfoundry.org/clock"
+
+	"github.com/cloudfoundry/bosh-agent/agentclient"
 	"github.com/cloudfoundry/bosh-agent/agentclient/http"
-	"github.com/cloudfoundry/bosh-agent/settings"
+	"github.com/cloudfoundry/bosh-agent/agentclient/nats"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cloudfoundry/bosh-utils/httpclient"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 type IntegrationAgentClient struct {
-	*http.AgentClient
+	agentclient.AgentClient
 }
 
 func NewIntegrationAgentClient(
@@ -25,40 +27,33 @@ func NewIntegrationAgentClient(
 	logger boshlog.Logger,
 ) *IntegrationAgentClient {
 	return &IntegrationAgentClient{
-		AgentClient: http.NewAgentClient(endpoint, directorID, getTaskDelay, toleratedErrorCount, httpClient, logger).(*http.AgentClient),
-	}
-}
-
-type exception struct {
-	Message string
-}
-
-type SSHResponse struct {
-	action.SSHResult
-	Exception *exception
-}
-
-func (r *SSHResponse) ServerError() error {
-	if r.Exception != nil {
-		return bosherr.Errorf("Agent responded with error: %s", r.Exception.Message)
+		AgentClient: http.NewAgentClient(endpoint, directorID, getTaskDelay, toleratedErrorCount, httpClient, logger),
 	}
-	return nil
 }
 
-func (r *SSHResponse) Unmarshal(message []byte) error {
-	return json.Unmarshal(message, r)
-}
-
-func (c *IntegrationAgentClient) SSH(cmd string, params action.SSHParams) error {
-	err := c.AgentRequest.Send("ssh", []interface{}{cmd, params}, &SSHResponse{})
+// NewNATSIntegrationAgentClient talks to agent with given ID over NATS mbus
+func NewNATSIntegrationAgentClient(
+	agentID string,
+	config nats.Config,
+	getTaskDelay time.Duration,
+	toleratedErrorCount int,
+	logger boshlog.Logger,
+) (*IntegrationAgentClient, error) {
+	natsClient, err := nats.Connect(config)
 	if err != nil {
-		return bosherr.WrapError(err, "Sending 'ssh' to the agent")
+		return nil, bosherr.WrapError(err, "Connecting to agent mbus")
 	}
 
-	return nil
-}
-
-func (c *IntegrationAgentClient) UpdateSettings(settings settings.UpdateSettings) error {
-	_, err := c.SendAsyncTaskMessage("update_settings", []interface{}{settings})
-	return err
+	client := nats.NewAgentClient(
+		natsClient,
+		agentID,
+		config,
+		getTaskDelay,
+		toleratedErrorCount,
+		clock.NewClock(),
+		boshuuid.NewGenerator(),
+		logger,
+	)
+
+	return &IntegrationAgentClient{AgentClient: client}, nil
 }
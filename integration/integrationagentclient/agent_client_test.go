@@ -9,7 +9,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
 
-	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/agentclient"
 	agentclienthttp "github.com/cloudfoundry/bosh-agent/agentclient/http"
 	"github.com/cloudfoundry/bosh-agent/integration/integrationagentclient"
 	"github.com/cloudfoundry/bosh-utils/httpclient"
@@ -50,9 +50,8 @@ var _ = Describe("AgentClient", func() {
 	Describe("SSH", func() {
 		Context("when agent successfully executes ssh", func() {
 			BeforeEach(func() {
-				sshSuccess, err := json.Marshal(action.SSHResult{
-					Command: "setup",
-					Status:  "success",
+				sshSuccess, err := json.Marshal(map[string]agentclient.SSHResult{
+					"value": {Command: "setup", Status: "success"},
 				})
 				Expect(err).ToNot(HaveOccurred())
 				server.AppendHandlers(
@@ -61,7 +60,7 @@ var _ = Describe("AgentClient", func() {
 						ghttp.RespondWith(200, string(sshSuccess)),
 						ghttp.VerifyJSONRepresenting(agentclienthttp.AgentRequestMessage{
 							Method:    "ssh",
-							Arguments: []interface{}{"setup", map[string]interface{}{"user_regex": "", "User": "username", "public_key": ""}},
+							Arguments: []interface{}{"setup", map[string]interface{}{"user_regex": "", "user": "username", "public_key": ""}},
 							ReplyTo:   "fake-reply-to-uuid",
 						}),
 					),
@@ -69,13 +68,14 @@ var _ = Describe("AgentClient", func() {
 			})
 
 			It("makes a POST request to the endpoint", func() {
-				params := action.SSHParams{
+				params := agentclient.SSHParams{
 					User: "username",
 				}
 
-				err := agentClient.SSH("setup", params)
+				result, err := agentClient.SSH("setup", params)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(server.ReceivedRequests()).To(HaveLen(10))
+				Expect(result).To(Equal(agentclient.SSHResult{Command: "setup", Status: "success"}))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
 			})
 		})
 
@@ -85,11 +85,11 @@ var _ = Describe("AgentClient", func() {
 			})
 
 			It("returns an error that wraps original error", func() {
-				params := action.SSHParams{
+				params := agentclient.SSHParams{
 					User: "username",
 				}
 
-				err := agentClient.SSH("setup", params)
+				_, err := agentClient.SSH("setup", params)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(MatchError(ContainSubstring("Performing request to agent")))
 				Expect(err).To(MatchError(ContainSubstring("foo error")))
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"github.com/cloudfoundry/bosh-agent/agentclient"
 	"github.com/cloudfoundry/bosh-agent/integration/integrationagentclient"
 	"github.com/cloudfoundry/bosh-agent/settings"
 
@@ -88,7 +89,7 @@ MIIEJDCCaWRnaXRzIFB0eSBMdGQxIjAgBgNVBAMTGWR4MTkwLnRvci5waXZvdGFs
 b20wHhcNMTUwNTEzMTM1NjA2WhcNMjUwNTEwMTM1NjA2WjBpMQswCQYDVQQGEwJD
 QTETMBEGA1U=
 -----END CERTIFICATE-----`
-			settings := settings.UpdateSettings{TrustedCerts: cert}
+			settings := agentclient.UpdateSettings{TrustedCerts: cert}
 
 			err := agentClient.UpdateSettings(settings)
 
@@ -1,7 +1,7 @@
 package integration_test
 
 import (
-	"github.com/cloudfoundry/bosh-agent/agent/action"
+	"github.com/cloudfoundry/bosh-agent/agentclient"
 	"github.com/cloudfoundry/bosh-agent/integration/integrationagentclient"
 	"github.com/cloudfoundry/bosh-agent/settings"
 
@@ -97,7 +97,7 @@ var _ = Describe("Instance Info", func() {
 		})
 
 		It("should contain the correct home directory permissions", func() {
-			err := agentClient.SSH("setup", action.SSHParams{
+			_, err := agentClient.SSH("setup", agentclient.SSHParams{
 				User:      "username",
 				PublicKey: "public-key",
 			})
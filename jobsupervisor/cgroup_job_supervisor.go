@@ -0,0 +1,320 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const cgroupJobSupervisorLogTag = "cgroupJobSupervisor"
+
+// cgroupJobSupervisor keeps processes of jobs with resource limits
+// in their cgroups, reports cgroup usage with processes and alerts
+// when the kernel kills processes for exceeding memory limits.
+//
+// Processes are moved into cgroups periodically since delegate job
+// supervisor (e.g. monit) starts them on its own; all descendants
+// of a process are moved along with it.
+type cgroupJobSupervisor struct {
+	delegate      JobSupervisor
+	cgroupManager boshcgroup.Manager
+	timeService   clock.Clock
+	interval      time.Duration
+	logger        boshlog.Logger
+
+	lock       sync.Mutex
+	cpuSamples map[string]cgroupCPUSample
+	oomKills   map[string]uint64
+	alertCount int
+}
+
+type cgroupCPUSample struct {
+	usage   time.Duration
+	at      time.Time
+	percent float64
+}
+
+func NewCgroupJobSupervisor(
+	delegate JobSupervisor,
+	cgroupManager boshcgroup.Manager,
+	timeService clock.Clock,
+	interval time.Duration,
+	logger boshlog.Logger,
+) JobSupervisor {
+	return &cgroupJobSupervisor{
+		delegate:      delegate,
+		cgroupManager: cgroupManager,
+		timeService:   timeService,
+		interval:      interval,
+		logger:        logger,
+
+		cpuSamples: map[string]cgroupCPUSample{},
+		oomKills:   map[string]uint64{},
+	}
+}
+
+func (s *cgroupJobSupervisor) Reload() error {
+	return s.delegate.Reload()
+}
+
+func (s *cgroupJobSupervisor) Start() error {
+	return s.delegate.Start()
+}
+
+func (s *cgroupJobSupervisor) Stop() error {
+	return s.delegate.Stop()
+}
+
+func (s *cgroupJobSupervisor) StopAndWait() error {
+	return s.delegate.StopAndWait()
+}
+
+func (s *cgroupJobSupervisor) Unmonitor() error {
+	return s.delegate.Unmonitor()
+}
+
+func (s *cgroupJobSupervisor) StartServices(services []string) error {
+	return s.delegate.StartServices(services)
+}
+
+func (s *cgroupJobSupervisor) StopServices(services []string) error {
+	return s.delegate.StopServices(services)
+}
+
+func (s *cgroupJobSupervisor) UnmonitorServices(services []string) error {
+	return s.delegate.UnmonitorServices(services)
+}
+
+func (s *cgroupJobSupervisor) Status() string {
+	return s.delegate.Status()
+}
+
+func (s *cgroupJobSupervisor) Processes() ([]Process, error) {
+	processes, err := s.delegate.Processes()
+	if err != nil {
+		return processes, err
+	}
+
+	// Missing cgroup usage does not make processes less useful
+	jobNames, serviceJobs, err := s.cgroupJobServices()
+	if err != nil {
+		s.logger.Warn(cgroupJobSupervisorLogTag, "Finding processes of jobs with cgroups: %s", err.Error())
+		return processes, nil
+	}
+
+	vitals := map[string]*CgroupVitals{}
+
+	for _, jobName := range jobNames {
+		usage, err := s.cgroupManager.Usage(jobName)
+		if err != nil {
+			s.logger.Warn(cgroupJobSupervisorLogTag, "Getting cgroup usage of job %s: %s", jobName, err.Error())
+			continue
+		}
+
+		vitals[jobName] = s.cgroupVitals(jobName, usage)
+	}
+
+	for i, process := range processes {
+		if jobVitals, found := vitals[serviceJobs[process.Name]]; found {
+			processes[i].Cgroup = jobVitals
+		}
+	}
+
+	return processes, nil
+}
+
+func (s *cgroupJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	return s.delegate.AddJob(jobName, jobIndex, configPath)
+}
+
+func (s *cgroupJobSupervisor) RemoveAllJobs() error {
+	return s.delegate.RemoveAllJobs()
+}
+
+func (s *cgroupJobSupervisor) JobServices(jobName string) ([]string, error) {
+	return s.delegate.JobServices(jobName)
+}
+
+func (s *cgroupJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	go s.enforceLimits(handler)
+
+	return s.delegate.MonitorJobFailures(handler)
+}
+
+func (s *cgroupJobSupervisor) HealthRecorder(status string) {
+	s.delegate.HealthRecorder(status)
+}
+
+func (s *cgroupJobSupervisor) enforceLimits(handler JobFailureHandler) {
+	defer s.logger.HandlePanic("Cgroup Job Supervisor")
+
+	for {
+		s.checkCgroups(handler)
+		s.timeService.Sleep(s.interval)
+	}
+}
+
+func (s *cgroupJobSupervisor) checkCgroups(handler JobFailureHandler) {
+	jobNames, serviceJobs, err := s.cgroupJobServices()
+	if err != nil {
+		s.logger.Warn(cgroupJobSupervisorLogTag, "Finding processes of jobs with cgroups: %s", err.Error())
+		return
+	}
+
+	if len(serviceJobs) > 0 {
+		s.placeProcesses(serviceJobs)
+	}
+
+	for _, jobName := range jobNames {
+		usage, err := s.cgroupManager.Usage(jobName)
+		if err != nil {
+			s.logger.Warn(cgroupJobSupervisorLogTag, "Getting cgroup usage of job %s: %s", jobName, err.Error())
+			continue
+		}
+
+		s.sampleCPU(jobName, usage)
+
+		killed := s.newOOMKills(jobName, usage)
+		if killed > 0 {
+			s.alertOOMKills(handler, jobName, killed, usage)
+		}
+	}
+}
+
+func (s *cgroupJobSupervisor) placeProcesses(serviceJobs map[string]string) {
+	processes, err := s.delegate.Processes()
+	if err != nil {
+		s.logger.Warn(cgroupJobSupervisorLogTag, "Getting processes: %s", err.Error())
+		return
+	}
+
+	tree, err := newProcessTree()
+	if err != nil {
+		s.logger.Warn(cgroupJobSupervisorLogTag, "Listing processes: %s", err.Error())
+		return
+	}
+
+	for _, process := range processes {
+		jobName, found := serviceJobs[process.Name]
+		if !found || process.PID <= 0 {
+			continue
+		}
+
+		for _, pid := range tree.Descendants(process.PID) {
+			// Processes may exit in the meantime
+			err := s.cgroupManager.AddProcess(jobName, pid)
+			if err != nil {
+				s.logger.Debug(cgroupJobSupervisorLogTag, "Adding process %d of %s to cgroup: %s", pid, process.Name, err.Error())
+			}
+		}
+	}
+}
+
+// cgroupJobServices maps services to jobs with cgroups
+func (s *cgroupJobSupervisor) cgroupJobServices() ([]string, map[string]string, error) {
+	jobNames, err := s.cgroupManager.Jobs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceJobs := map[string]string{}
+
+	for _, jobName := range jobNames {
+		services, err := s.delegate.JobServices(jobName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, service := range services {
+			serviceJobs[service] = jobName
+		}
+	}
+
+	return jobNames, serviceJobs, nil
+}
+
+// sampleCPU calculates CPU usage over the last interval as a
+// percentage of a single core, same as process CPU vitals
+func (s *cgroupJobSupervisor) sampleCPU(jobName string, usage boshcgroup.Usage) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sample := cgroupCPUSample{usage: usage.CPUTime, at: s.timeService.Now()}
+
+	if previous, found := s.cpuSamples[jobName]; found {
+		elapsed := sample.at.Sub(previous.at)
+		if elapsed > 0 && sample.usage >= previous.usage {
+			sample.percent = float64(sample.usage-previous.usage) / float64(elapsed) * 100
+		}
+	}
+
+	s.cpuSamples[jobName] = sample
+}
+
+// newOOMKills returns number of kills since last check; kills that
+// happened before the first check (e.g. before agent restart) are ignored
+func (s *cgroupJobSupervisor) newOOMKills(jobName string, usage boshcgroup.Usage) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	previous, found := s.oomKills[jobName]
+	s.oomKills[jobName] = usage.OOMKills
+
+	if !found || usage.OOMKills < previous {
+		return 0
+	}
+
+	return usage.OOMKills - previous
+}
+
+func (s *cgroupJobSupervisor) cgroupVitals(jobName string, usage boshcgroup.Usage) *CgroupVitals {
+	s.lock.Lock()
+	cpuPercent := s.cpuSamples[jobName].percent
+	s.lock.Unlock()
+
+	vitals := &CgroupVitals{
+		Name: jobName,
+		Memory: CgroupMemoryVitals{
+			Kb:      usage.MemoryBytes / 1024,
+			LimitKb: usage.MemoryLimitBytes / 1024,
+		},
+		CPU:      CPUVitals{Total: cpuPercent},
+		OOMKills: usage.OOMKills,
+	}
+
+	if usage.MemoryLimitBytes > 0 {
+		vitals.Memory.Percent = float64(usage.MemoryBytes) / float64(usage.MemoryLimitBytes) * 100
+	}
+
+	return vitals
+}
+
+func (s *cgroupJobSupervisor) alertOOMKills(handler JobFailureHandler, jobName string, killed uint64, usage boshcgroup.Usage) {
+	s.lock.Lock()
+	s.alertCount++
+	alertCount := s.alertCount
+	s.lock.Unlock()
+
+	now := s.timeService.Now()
+
+	err := handler(boshalert.MonitAlert{
+		ID:      fmt.Sprintf("%d.%d@%s", now.Unix(), alertCount, jobName),
+		Service: jobName,
+		Event:   "oom killed",
+		Action:  "alert",
+		Date:    now.Format(time.RFC1123Z),
+		Description: fmt.Sprintf(
+			"%d process(es) of job %s killed for exceeding memory limit of %d MB",
+			killed, jobName, usage.MemoryLimitBytes/1024/1024,
+		),
+	})
+	if err != nil {
+		s.logger.Error(cgroupJobSupervisorLogTag, "Handling OOM kills of %s: %s", jobName, err.Error())
+	}
+}
@@ -0,0 +1,203 @@
+// +build !windows
+
+package jobsupervisor_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	"github.com/cloudfoundry/bosh-agent/platform/cgroup/cgroupfakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("cgroupJobSupervisor", func() {
+	const (
+		// Pid that is not used by any process so that it has no children
+		unusedPid = 99999999
+		interval  = 5 * time.Second
+	)
+
+	var (
+		delegate      *fakejobsuper.FakeJobSupervisor
+		cgroupManager *cgroupfakes.FakeManager
+		timeService   *fakeclock.FakeClock
+		supervisor    JobSupervisor
+
+		alertsLock sync.Mutex
+		alerts     []boshalert.MonitAlert
+	)
+
+	BeforeEach(func() {
+		delegate = fakejobsuper.NewFakeJobSupervisor()
+		cgroupManager = &cgroupfakes.FakeManager{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		supervisor = NewCgroupJobSupervisor(delegate, cgroupManager, timeService, interval, logger)
+
+		delegate.ProcessesStatus = []Process{
+			{Name: "fake-process", State: "running", PID: unusedPid},
+			{Name: "fake-process-extra", State: "running"},
+			{Name: "other-process", State: "running", PID: unusedPid - 1},
+		}
+		delegate.JobServicesByJob = map[string][]string{
+			"fake-job":  {"fake-process", "fake-process-extra"},
+			"other-job": {"other-process"},
+		}
+
+		cgroupManager.JobsReturns([]string{"fake-job"}, nil)
+		cgroupManager.UsageReturns(boshcgroup.Usage{
+			MemoryBytes:      256 * 1024 * 1024,
+			MemoryLimitBytes: 1024 * 1024 * 1024,
+			CPUTime:          10 * time.Second,
+			OOMKills:         1,
+		}, nil)
+
+		alerts = nil
+	})
+
+	receivedAlerts := func() []boshalert.MonitAlert {
+		alertsLock.Lock()
+		defer alertsLock.Unlock()
+		return append([]boshalert.MonitAlert{}, alerts...)
+	}
+
+	monitorJobFailures := func() {
+		err := supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+			alertsLock.Lock()
+			defer alertsLock.Unlock()
+			alerts = append(alerts, alert)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	// nextCheck lets the periodic check run once more
+	nextCheck := func() {
+		calls := cgroupManager.UsageCallCount()
+		Eventually(timeService.WatcherCount).Should(Equal(1))
+		timeService.Increment(interval)
+		Eventually(cgroupManager.UsageCallCount).Should(BeNumerically(">", calls))
+	}
+
+	Describe("Processes", func() {
+		It("reports cgroup usage with processes of jobs with cgroups", func() {
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+
+			vitals := &CgroupVitals{
+				Name: "fake-job",
+				Memory: CgroupMemoryVitals{
+					Kb:      256 * 1024,
+					LimitKb: 1024 * 1024,
+					Percent: 25,
+				},
+				OOMKills: 1,
+			}
+
+			Expect(processes[0].Cgroup).To(Equal(vitals))
+			Expect(processes[1].Cgroup).To(Equal(vitals))
+			Expect(processes[2].Cgroup).To(BeNil())
+			Expect(cgroupManager.UsageArgsForCall(0)).To(Equal("fake-job"))
+		})
+
+		It("reports processes without cgroup usage when it cannot be read", func() {
+			cgroupManager.UsageReturns(boshcgroup.Usage{}, errors.New("fake-usage-err"))
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal(delegate.ProcessesStatus))
+		})
+
+		It("reports processes without cgroup usage when cgroups cannot be listed", func() {
+			cgroupManager.JobsReturns(nil, errors.New("fake-jobs-err"))
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(HaveLen(3))
+			Expect(processes[0].Cgroup).To(BeNil())
+		})
+
+		It("returns error when delegate fails to get processes", func() {
+			delegate.ProcessesError = errors.New("fake-processes-err")
+
+			_, err := supervisor.Processes()
+			Expect(err).To(MatchError("fake-processes-err"))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		It("moves processes of jobs with cgroups into their cgroups", func() {
+			monitorJobFailures()
+
+			Eventually(cgroupManager.AddProcessCallCount).Should(Equal(1))
+
+			jobName, pid := cgroupManager.AddProcessArgsForCall(0)
+			Expect(jobName).To(Equal("fake-job"))
+			Expect(pid).To(Equal(unusedPid))
+		})
+
+		It("keeps moving processes periodically", func() {
+			monitorJobFailures()
+			Eventually(cgroupManager.AddProcessCallCount).Should(Equal(1))
+
+			nextCheck()
+
+			Eventually(cgroupManager.AddProcessCallCount).Should(Equal(2))
+		})
+
+		It("alerts when processes are killed for running out of memory", func() {
+			cgroupManager.UsageReturnsOnCall(1, boshcgroup.Usage{
+				MemoryLimitBytes: 1024 * 1024 * 1024,
+				OOMKills:         3,
+			}, nil)
+
+			monitorJobFailures()
+			Eventually(cgroupManager.UsageCallCount).Should(Equal(1))
+
+			nextCheck()
+
+			Eventually(receivedAlerts).Should(HaveLen(1))
+
+			alert := receivedAlerts()[0]
+			Expect(alert.Service).To(Equal("fake-job"))
+			Expect(alert.Event).To(Equal("oom killed"))
+			Expect(alert.Action).To(Equal("alert"))
+			Expect(alert.Description).To(Equal("2 process(es) of job fake-job killed for exceeding memory limit of 1024 MB"))
+			Expect(alert.ID).To(HaveSuffix("@fake-job"))
+		})
+
+		It("does not alert for processes killed before the first check", func() {
+			monitorJobFailures()
+
+			nextCheck()
+
+			Consistently(receivedAlerts).Should(BeEmpty())
+		})
+
+		It("reports CPU usage over the last check interval", func() {
+			cgroupManager.UsageReturnsOnCall(1, boshcgroup.Usage{CPUTime: 12500 * time.Millisecond}, nil)
+			cgroupManager.UsageReturnsOnCall(2, boshcgroup.Usage{CPUTime: 12500 * time.Millisecond}, nil)
+
+			monitorJobFailures()
+			Eventually(cgroupManager.UsageCallCount).Should(Equal(1))
+
+			nextCheck()
+
+			Eventually(func() float64 {
+				processes, err := supervisor.Processes()
+				Expect(err).ToNot(HaveOccurred())
+				return processes[0].Cgroup.CPU.Total
+			}).Should(Equal(50.0))
+		})
+	})
+})
@@ -33,6 +33,18 @@ func (s *dummyJobSupervisor) Unmonitor() error {
 	return nil
 }
 
+func (s *dummyJobSupervisor) StartServices(services []string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) StopServices(services []string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) UnmonitorServices(services []string) error {
+	return nil
+}
+
 func (s *dummyJobSupervisor) Status() (status string) {
 	return s.status
 }
@@ -49,6 +61,10 @@ func (s *dummyJobSupervisor) RemoveAllJobs() error {
 	return nil
 }
 
+func (s *dummyJobSupervisor) JobServices(jobName string) ([]string, error) {
+	return []string{}, nil
+}
+
 func (s *dummyJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
 	return nil
 }
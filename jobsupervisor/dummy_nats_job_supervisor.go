@@ -99,10 +99,26 @@ func (d *dummyNatsJobSupervisor) Unmonitor() error {
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) StartServices(services []string) error {
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) StopServices(services []string) error {
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) UnmonitorServices(services []string) error {
+	return nil
+}
+
 func (d *dummyNatsJobSupervisor) RemoveAllJobs() error {
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) JobServices(jobName string) ([]string, error) {
+	return []string{}, nil
+}
+
 func (d *dummyNatsJobSupervisor) Status() string {
 	return d.status
 }
@@ -25,6 +25,18 @@ type FakeJobSupervisor struct {
 	Unmonitored  bool
 	UnmonitorErr error
 
+	StartedServices  [][]string
+	StartServicesErr error
+
+	StoppedServices [][]string
+	StopServicesErr error
+
+	UnmonitoredServices  [][]string
+	UnmonitorServicesErr error
+
+	JobServicesByJob map[string][]string
+	JobServicesErr   error
+
 	StatusStatus    string
 	ProcessesStatus []boshjobsuper.Process
 	ProcessesError  error
@@ -90,6 +102,25 @@ func (m *FakeJobSupervisor) Unmonitor() error {
 	return m.UnmonitorErr
 }
 
+func (m *FakeJobSupervisor) StartServices(services []string) error {
+	m.StartedServices = append(m.StartedServices, services)
+	return m.StartServicesErr
+}
+
+func (m *FakeJobSupervisor) StopServices(services []string) error {
+	m.StoppedServices = append(m.StoppedServices, services)
+	return m.StopServicesErr
+}
+
+func (m *FakeJobSupervisor) UnmonitorServices(services []string) error {
+	m.UnmonitoredServices = append(m.UnmonitoredServices, services)
+	return m.UnmonitorServicesErr
+}
+
+func (m *FakeJobSupervisor) JobServices(jobName string) ([]string, error) {
+	return m.JobServicesByJob[jobName], m.JobServicesErr
+}
+
 func (m *FakeJobSupervisor) Status() string {
 	return m.StatusStatus
 }
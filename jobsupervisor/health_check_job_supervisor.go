@@ -0,0 +1,233 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const healthCheckJobSupervisorLogTag = "healthCheckJobSupervisor"
+
+// healthCheckJobSupervisor reports processes of jobs whose health checks
+// fail as failing even though delegate job supervisor sees them running,
+// and alerts when jobs become unhealthy or healthy again.
+//
+// Jobs without running processes (e.g. stopped jobs) are not
+// considered failing regardless of their health checks.
+type healthCheckJobSupervisor struct {
+	delegate    JobSupervisor
+	checker     healthcheck.Checker
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	lock       sync.Mutex
+	alertCount int
+}
+
+func NewHealthCheckJobSupervisor(
+	delegate JobSupervisor,
+	checker healthcheck.Checker,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) JobSupervisor {
+	return &healthCheckJobSupervisor{
+		delegate:    delegate,
+		checker:     checker,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func (s *healthCheckJobSupervisor) Reload() error {
+	return s.delegate.Reload()
+}
+
+func (s *healthCheckJobSupervisor) Start() error {
+	return s.delegate.Start()
+}
+
+func (s *healthCheckJobSupervisor) Stop() error {
+	return s.delegate.Stop()
+}
+
+func (s *healthCheckJobSupervisor) StopAndWait() error {
+	return s.delegate.StopAndWait()
+}
+
+func (s *healthCheckJobSupervisor) Unmonitor() error {
+	return s.delegate.Unmonitor()
+}
+
+func (s *healthCheckJobSupervisor) StartServices(services []string) error {
+	return s.delegate.StartServices(services)
+}
+
+func (s *healthCheckJobSupervisor) StopServices(services []string) error {
+	return s.delegate.StopServices(services)
+}
+
+func (s *healthCheckJobSupervisor) UnmonitorServices(services []string) error {
+	return s.delegate.UnmonitorServices(services)
+}
+
+func (s *healthCheckJobSupervisor) Status() string {
+	status := s.delegate.Status()
+	if status != "running" {
+		return status
+	}
+
+	var unhealthyJobNames []string
+
+	for _, jobName := range s.checker.Jobs() {
+		health, found := s.checker.Health(jobName)
+		if found && !health.Healthy {
+			unhealthyJobNames = append(unhealthyJobNames, jobName)
+		}
+	}
+
+	// Processes are only looked up when there is an unhealthy job
+	// since most of the time all jobs are healthy
+	if len(unhealthyJobNames) == 0 {
+		return status
+	}
+
+	processes, err := s.delegate.Processes()
+	if err != nil {
+		s.logger.Warn(healthCheckJobSupervisorLogTag, "Getting processes: %s", err.Error())
+		return "failing"
+	}
+
+	for _, jobName := range unhealthyJobNames {
+		if s.jobRunning(jobName, processes) {
+			return "failing"
+		}
+	}
+
+	return status
+}
+
+func (s *healthCheckJobSupervisor) Processes() ([]Process, error) {
+	processes, err := s.delegate.Processes()
+	if err != nil {
+		return processes, err
+	}
+
+	for _, jobName := range s.checker.Jobs() {
+		health, found := s.checker.Health(jobName)
+		if !found {
+			continue
+		}
+
+		services, err := s.delegate.JobServices(jobName)
+		if err != nil {
+			s.logger.Warn(healthCheckJobSupervisorLogTag, "Getting services of job %s: %s", jobName, err.Error())
+			continue
+		}
+
+		for i, process := range processes {
+			if !containsService(services, process.Name) {
+				continue
+			}
+
+			processes[i].HealthChecks = health.Checks
+
+			if !health.Healthy && process.State == "running" {
+				processes[i].State = "failing"
+			}
+		}
+	}
+
+	return processes, nil
+}
+
+func (s *healthCheckJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	return s.delegate.AddJob(jobName, jobIndex, configPath)
+}
+
+func (s *healthCheckJobSupervisor) RemoveAllJobs() error {
+	return s.delegate.RemoveAllJobs()
+}
+
+func (s *healthCheckJobSupervisor) JobServices(jobName string) ([]string, error) {
+	return s.delegate.JobServices(jobName)
+}
+
+func (s *healthCheckJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	go s.checker.Run(func(jobName string, health healthcheck.JobHealth) {
+		s.alertTransition(handler, jobName, health)
+	})
+
+	return s.delegate.MonitorJobFailures(handler)
+}
+
+func (s *healthCheckJobSupervisor) HealthRecorder(status string) {
+	s.delegate.HealthRecorder(status)
+}
+
+func (s *healthCheckJobSupervisor) alertTransition(handler JobFailureHandler, jobName string, health healthcheck.JobHealth) {
+	event := "health check recovered"
+	description := fmt.Sprintf("Health checks of job %s are passing again", jobName)
+
+	if !health.Healthy {
+		processes, err := s.delegate.Processes()
+		if err == nil && !s.jobRunning(jobName, processes) {
+			s.logger.Debug(healthCheckJobSupervisorLogTag, "Not alerting about failing health checks of stopped job %s", jobName)
+			return
+		}
+
+		event = "health check failed"
+		description = fmt.Sprintf("Health checks of job %s are failing: %s", jobName, strings.Join(health.FailingChecks(), ", "))
+	}
+
+	s.lock.Lock()
+	s.alertCount++
+	alertCount := s.alertCount
+	s.lock.Unlock()
+
+	now := s.timeService.Now()
+
+	err := handler(boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%d@%s", now.Unix(), alertCount, jobName),
+		Service:     jobName,
+		Event:       event,
+		Action:      "alert",
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	})
+	if err != nil {
+		s.logger.Error(healthCheckJobSupervisorLogTag, "Handling health transition of %s: %s", jobName, err.Error())
+	}
+}
+
+func (s *healthCheckJobSupervisor) jobRunning(jobName string, processes []Process) bool {
+	services, err := s.delegate.JobServices(jobName)
+	if err != nil {
+		s.logger.Warn(healthCheckJobSupervisorLogTag, "Getting services of job %s: %s", jobName, err.Error())
+		return true
+	}
+
+	for _, process := range processes {
+		if process.State == "running" && containsService(services, process.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsService(services []string, name string) bool {
+	for _, service := range services {
+		if service == name {
+			return true
+		}
+	}
+
+	return false
+}
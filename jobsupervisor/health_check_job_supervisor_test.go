@@ -0,0 +1,173 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	fakejobsuper "github.com/cloudfoundry/bosh-agent/jobsupervisor/fakes"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck/healthcheckfakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("healthCheckJobSupervisor", func() {
+	var (
+		delegate      *fakejobsuper.FakeJobSupervisor
+		healthChecker *healthcheckfakes.FakeChecker
+		timeService   *fakeclock.FakeClock
+		supervisor    JobSupervisor
+
+		healthyChecks   []healthcheck.Result
+		unhealthyChecks []healthcheck.Result
+	)
+
+	BeforeEach(func() {
+		delegate = fakejobsuper.NewFakeJobSupervisor()
+		healthChecker = &healthcheckfakes.FakeChecker{}
+		timeService = fakeclock.NewFakeClock(time.Now())
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		supervisor = NewHealthCheckJobSupervisor(delegate, healthChecker, timeService, logger)
+
+		delegate.StatusStatus = "running"
+		delegate.ProcessesStatus = []Process{
+			{Name: "fake-process", State: "running"},
+			{Name: "fake-process-extra", State: "starting"},
+			{Name: "other-process", State: "running"},
+		}
+		delegate.JobServicesByJob = map[string][]string{
+			"fake-job":  {"fake-process", "fake-process-extra"},
+			"other-job": {"other-process"},
+		}
+
+		healthyChecks = []healthcheck.Result{{Name: "tcp:8080", Type: "tcp", State: "passing"}}
+		unhealthyChecks = []healthcheck.Result{
+			{Name: "tcp:8080", Type: "tcp", State: "failing", ConsecutiveFailures: 3, Message: "fake-probe-err"},
+		}
+
+		healthChecker.JobsReturns([]string{"fake-job"})
+		healthChecker.HealthReturns(healthcheck.JobHealth{Healthy: true, Checks: healthyChecks}, true)
+	})
+
+	Describe("Status", func() {
+		It("returns status of delegate when jobs are healthy", func() {
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("returns failing when running job is unhealthy", func() {
+			healthChecker.HealthReturns(healthcheck.JobHealth{Healthy: false, Checks: unhealthyChecks}, true)
+
+			Expect(supervisor.Status()).To(Equal("failing"))
+			Expect(healthChecker.HealthArgsForCall(0)).To(Equal("fake-job"))
+		})
+
+		It("returns status of delegate when unhealthy job has no running processes", func() {
+			healthChecker.HealthReturns(healthcheck.JobHealth{Healthy: false, Checks: unhealthyChecks}, true)
+			delegate.ProcessesStatus[0].State = "stopped"
+
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("returns status of delegate when it is not running", func() {
+			healthChecker.HealthReturns(healthcheck.JobHealth{Healthy: false, Checks: unhealthyChecks}, true)
+			delegate.StatusStatus = "stopped"
+
+			Expect(supervisor.Status()).To(Equal("stopped"))
+		})
+	})
+
+	Describe("Processes", func() {
+		It("reports health check results with processes of jobs with health checks", func() {
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(processes).To(Equal([]Process{
+				{Name: "fake-process", State: "running", HealthChecks: healthyChecks},
+				{Name: "fake-process-extra", State: "starting", HealthChecks: healthyChecks},
+				{Name: "other-process", State: "running"},
+			}))
+		})
+
+		It("reports running processes of unhealthy jobs as failing", func() {
+			healthChecker.HealthReturns(healthcheck.JobHealth{Healthy: false, Checks: unhealthyChecks}, true)
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(processes).To(Equal([]Process{
+				{Name: "fake-process", State: "failing", HealthChecks: unhealthyChecks},
+				{Name: "fake-process-extra", State: "starting", HealthChecks: unhealthyChecks},
+				{Name: "other-process", State: "running"},
+			}))
+		})
+
+		It("returns error when delegate fails to get processes", func() {
+			delegate.ProcessesError = errors.New("fake-processes-err")
+
+			_, err := supervisor.Processes()
+			Expect(err).To(MatchError("fake-processes-err"))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		var (
+			alerts            []boshalert.MonitAlert
+			transitionHandler healthcheck.TransitionHandler
+		)
+
+		BeforeEach(func() {
+			alerts = nil
+
+			err := supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+				alerts = append(alerts, alert)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(healthChecker.RunCallCount).Should(Equal(1))
+			transitionHandler = healthChecker.RunArgsForCall(0)
+		})
+
+		It("alerts when job becomes unhealthy", func() {
+			transitionHandler("fake-job", healthcheck.JobHealth{Healthy: false, Checks: unhealthyChecks})
+
+			Expect(alerts).To(HaveLen(1))
+			Expect(alerts[0].Service).To(Equal("fake-job"))
+			Expect(alerts[0].Event).To(Equal("health check failed"))
+			Expect(alerts[0].Action).To(Equal("alert"))
+			Expect(alerts[0].Description).To(Equal("Health checks of job fake-job are failing: tcp:8080 (fake-probe-err)"))
+			Expect(alerts[0].ID).To(HaveSuffix("@fake-job"))
+			Expect(alerts[0].Date).To(Equal(timeService.Now().Format(time.RFC1123Z)))
+		})
+
+		It("alerts when job becomes healthy again", func() {
+			transitionHandler("fake-job", healthcheck.JobHealth{Healthy: true, Checks: healthyChecks})
+
+			Expect(alerts).To(HaveLen(1))
+			Expect(alerts[0].Event).To(Equal("health check recovered"))
+			Expect(alerts[0].Description).To(Equal("Health checks of job fake-job are passing again"))
+		})
+
+		It("does not alert when health checks of stopped job fail", func() {
+			delegate.ProcessesStatus[0].State = "stopped"
+
+			transitionHandler("fake-job", healthcheck.JobHealth{Healthy: false, Checks: unhealthyChecks})
+
+			Expect(alerts).To(BeEmpty())
+		})
+
+		It("gives each alert a different id", func() {
+			transitionHandler("fake-job", healthcheck.JobHealth{Healthy: false, Checks: unhealthyChecks})
+			transitionHandler("fake-job", healthcheck.JobHealth{Healthy: true, Checks: healthyChecks})
+
+			Expect(alerts).To(HaveLen(2))
+			Expect(alerts[0].ID).ToNot(Equal(alerts[1].ID))
+		})
+	})
+})
@@ -0,0 +1,171 @@
+package healthcheck
+
+import (
+	"fmt"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	TypeTCP  = "tcp"
+	TypeHTTP = "http"
+	TypeExec = "exec"
+
+	defaultHost             = "127.0.0.1"
+	defaultPath             = "/"
+	defaultExpectedStatus   = 200
+	defaultInterval         = 10 * time.Second
+	defaultTimeout          = 5 * time.Second
+	defaultFailureThreshold = 3
+)
+
+// Check is declared per job in apply spec, e.g. an http check
+// {"type": "http", "port": 8080, "path": "/healthz"} passes
+// when GET of the path on 127.0.0.1:8080 responds with 200.
+type Check struct {
+	// Defaults to type and target of the check, e.g. "tcp:8080"
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+
+	// Used by tcp and http checks
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port,omitempty"`
+
+	// Used by http checks
+	Path           string `json:"path,omitempty"`
+	ExpectedStatus int    `json:"expected_status,omitempty"`
+
+	// Used by exec checks; command succeeds when it exits with 0
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
+	TimeoutSeconds  int `json:"timeout_seconds,omitempty"`
+
+	// Number of consecutive failures after which check is failing
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+}
+
+func (c Check) Validate() error {
+	switch c.Type {
+	case TypeTCP, TypeHTTP:
+		if c.Port < 1 || c.Port > 65535 {
+			return bosherr.Errorf("Health check '%s' must have port between 1 and 65535", c.DisplayName())
+		}
+	case TypeExec:
+		if len(c.Command) == 0 {
+			return bosherr.Errorf("Health check '%s' must have command", c.DisplayName())
+		}
+	default:
+		return bosherr.Errorf("Health check '%s' has unknown type '%s'", c.DisplayName(), c.Type)
+	}
+
+	if c.IntervalSeconds < 0 || c.TimeoutSeconds < 0 || c.FailureThreshold < 0 {
+		return bosherr.Errorf("Health check '%s' must not have negative interval, timeout or failure threshold", c.DisplayName())
+	}
+
+	return nil
+}
+
+func (c Check) DisplayName() string {
+	if len(c.Name) > 0 {
+		return c.Name
+	}
+
+	switch c.Type {
+	case TypeTCP:
+		return fmt.Sprintf("tcp:%d", c.Port)
+	case TypeHTTP:
+		return fmt.Sprintf("http:%d%s", c.Port, c.path())
+	case TypeExec:
+		return fmt.Sprintf("exec:%s", c.Command)
+	default:
+		return c.Type
+	}
+}
+
+func (c Check) Interval() time.Duration {
+	return secondsOrDefault(c.IntervalSeconds, defaultInterval)
+}
+
+func (c Check) Timeout() time.Duration {
+	return secondsOrDefault(c.TimeoutSeconds, defaultTimeout)
+}
+
+func (c Check) failureThreshold() int {
+	if c.FailureThreshold > 0 {
+		return c.FailureThreshold
+	}
+
+	return defaultFailureThreshold
+}
+
+func (c Check) host() string {
+	if len(c.Host) > 0 {
+		return c.Host
+	}
+
+	return defaultHost
+}
+
+func (c Check) path() string {
+	if len(c.Path) > 0 {
+		return c.Path
+	}
+
+	return defaultPath
+}
+
+func (c Check) expectedStatus() int {
+	if c.ExpectedStatus > 0 {
+		return c.ExpectedStatus
+	}
+
+	return defaultExpectedStatus
+}
+
+func secondsOrDefault(seconds int, defaultDuration time.Duration) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return defaultDuration
+}
+
+const (
+	// Check has not run or has not failed often enough to be failing yet
+	StatePending = "pending"
+	StatePassing = "passing"
+	StateFailing = "failing"
+)
+
+type Result struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+
+	ConsecutiveFailures int `json:"consecutive_failures,omitempty"`
+
+	// Reason of last failure
+	Message string `json:"message,omitempty"`
+}
+
+type JobHealth struct {
+	// Job is healthy unless one of its checks is failing
+	Healthy bool
+	Checks  []Result
+}
+
+// FailingChecks describes failing checks, e.g. for alerts
+func (h JobHealth) FailingChecks() []string {
+	var failing []string
+
+	for _, result := range h.Checks {
+		if result.State == StateFailing {
+			failing = append(failing, fmt.Sprintf("%s (%s)", result.Name, result.Message))
+		}
+	}
+
+	return failing
+}
@@ -0,0 +1,58 @@
+package healthcheck_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+)
+
+var _ = Describe("Check", func() {
+	Describe("Validate", func() {
+		It("accepts tcp, http and exec checks", func() {
+			Expect(Check{Type: "tcp", Port: 8080}.Validate()).To(Succeed())
+			Expect(Check{Type: "http", Port: 8080, Path: "/healthz"}.Validate()).To(Succeed())
+			Expect(Check{Type: "exec", Command: "/var/vcap/jobs/fake-job/bin/healthy"}.Validate()).To(Succeed())
+		})
+
+		It("returns error when tcp or http check has no valid port", func() {
+			Expect(Check{Type: "tcp"}.Validate()).To(MatchError("Health check 'tcp:0' must have port between 1 and 65535"))
+			Expect(Check{Name: "fake-check", Type: "http", Port: 70000}.Validate()).To(MatchError("Health check 'fake-check' must have port between 1 and 65535"))
+		})
+
+		It("returns error when exec check has no command", func() {
+			Expect(Check{Name: "fake-check", Type: "exec"}.Validate()).To(MatchError("Health check 'fake-check' must have command"))
+		})
+
+		It("returns error when type is unknown", func() {
+			Expect(Check{Name: "fake-check", Type: "udp"}.Validate()).To(MatchError("Health check 'fake-check' has unknown type 'udp'"))
+		})
+
+		It("returns error when interval, timeout or failure threshold is negative", func() {
+			err := Check{Name: "fake-check", Type: "tcp", Port: 80, FailureThreshold: -1}.Validate()
+			Expect(err).To(MatchError("Health check 'fake-check' must not have negative interval, timeout or failure threshold"))
+		})
+	})
+
+	Describe("DisplayName", func() {
+		It("uses name when given", func() {
+			Expect(Check{Name: "fake-check", Type: "tcp", Port: 80}.DisplayName()).To(Equal("fake-check"))
+		})
+
+		It("describes target of check when name is not given", func() {
+			Expect(Check{Type: "tcp", Port: 80}.DisplayName()).To(Equal("tcp:80"))
+			Expect(Check{Type: "http", Port: 80}.DisplayName()).To(Equal("http:80/"))
+			Expect(Check{Type: "http", Port: 80, Path: "/healthz"}.DisplayName()).To(Equal("http:80/healthz"))
+			Expect(Check{Type: "exec", Command: "/bin/healthy"}.DisplayName()).To(Equal("exec:/bin/healthy"))
+		})
+	})
+
+	It("defaults interval and timeout", func() {
+		Expect(Check{}.Interval()).To(Equal(10 * time.Second))
+		Expect(Check{}.Timeout()).To(Equal(5 * time.Second))
+		Expect(Check{IntervalSeconds: 30, TimeoutSeconds: 2}.Interval()).To(Equal(30 * time.Second))
+		Expect(Check{IntervalSeconds: 30, TimeoutSeconds: 2}.Timeout()).To(Equal(2 * time.Second))
+	})
+})
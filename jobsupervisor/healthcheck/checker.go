@@ -0,0 +1,359 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Checks are scheduled with one second precision
+const tickInterval = 1 * time.Second
+
+//go:generate counterfeiter . Checker
+
+// Checker runs health checks of jobs in the agent since job supervisors
+// only know whether processes are running, not whether they are serving.
+type Checker interface {
+	// Configure replaces health checks of the job; results of unchanged
+	// checks are kept so that re-applying a job does not reset its health.
+	Configure(jobName string, checks []Check) error
+
+	// KeepOnly removes health checks of jobs that are not in the list
+	KeepOnly(jobNames []string) error
+
+	// Jobs lists names of jobs that have health checks
+	Jobs() []string
+
+	// Health returns latest results of health checks of the job;
+	// found is false when job has no health checks.
+	Health(jobName string) (health JobHealth, found bool)
+
+	// Run runs each check at its interval and calls handler
+	// whenever a job becomes unhealthy or healthy again
+	Run(handler TransitionHandler)
+}
+
+type TransitionHandler func(jobName string, health JobHealth)
+
+type checker struct {
+	prober      Prober
+	fs          boshsys.FileSystem
+	configPath  string
+	timeService clock.Clock
+
+	logTag string
+	logger boshlog.Logger
+
+	lock   sync.Mutex
+	loaded bool
+	jobs   map[string]*jobChecks
+}
+
+type jobChecks struct {
+	checks  []Check
+	states  []*checkState
+	healthy bool
+}
+
+type checkState struct {
+	result  Result
+	nextRun time.Time
+	running bool
+}
+
+type dueCheck struct {
+	jobName string
+	job     *jobChecks
+	index   int
+}
+
+// NewChecker keeps configured checks in file at configPath
+// so that jobs are checked again after agent restarts.
+func NewChecker(
+	prober Prober,
+	fs boshsys.FileSystem,
+	configPath string,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) Checker {
+	return &checker{
+		prober:      prober,
+		fs:          fs,
+		configPath:  configPath,
+		timeService: timeService,
+
+		logTag: "healthChecker",
+		logger: logger,
+
+		jobs: map[string]*jobChecks{},
+	}
+}
+
+func (c *checker) Configure(jobName string, checks []Check) error {
+	for _, check := range checks {
+		err := check.Validate()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Validating health checks of job '%s'", jobName)
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ensureLoaded()
+
+	existing, found := c.jobs[jobName]
+
+	if len(checks) == 0 {
+		if !found {
+			return nil
+		}
+
+		delete(c.jobs, jobName)
+		return c.save()
+	}
+
+	if found && reflect.DeepEqual(existing.checks, checks) {
+		return nil
+	}
+
+	c.logger.Debug(c.logTag, "Configuring health checks of job %s: %#v", jobName, checks)
+
+	c.jobs[jobName] = newJobChecks(checks)
+
+	return c.save()
+}
+
+func (c *checker) KeepOnly(jobNames []string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ensureLoaded()
+
+	keep := map[string]bool{}
+	for _, jobName := range jobNames {
+		keep[jobName] = true
+	}
+
+	removed := false
+
+	for jobName := range c.jobs {
+		if !keep[jobName] {
+			delete(c.jobs, jobName)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return nil
+	}
+
+	return c.save()
+}
+
+func (c *checker) Jobs() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ensureLoaded()
+
+	jobNames := []string{}
+	for jobName := range c.jobs {
+		jobNames = append(jobNames, jobName)
+	}
+
+	sort.Strings(jobNames)
+
+	return jobNames
+}
+
+func (c *checker) Health(jobName string) (JobHealth, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ensureLoaded()
+
+	job, found := c.jobs[jobName]
+	if !found {
+		return JobHealth{}, false
+	}
+
+	return job.health(), true
+}
+
+func (c *checker) Run(handler TransitionHandler) {
+	defer c.logger.HandlePanic("Health Checker")
+
+	for {
+		for _, due := range c.dueChecks() {
+			go c.probe(due, handler)
+		}
+
+		c.timeService.Sleep(tickInterval)
+	}
+}
+
+func (c *checker) dueChecks() []dueCheck {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ensureLoaded()
+
+	now := c.timeService.Now()
+
+	var due []dueCheck
+
+	for jobName, job := range c.jobs {
+		for i, state := range job.states {
+			// Slow checks are not run again until they finish
+			if state.running || now.Before(state.nextRun) {
+				continue
+			}
+
+			state.running = true
+			state.nextRun = now.Add(job.checks[i].Interval())
+
+			due = append(due, dueCheck{jobName: jobName, job: job, index: i})
+		}
+	}
+
+	return due
+}
+
+func (c *checker) probe(due dueCheck, handler TransitionHandler) {
+	defer c.logger.HandlePanic("Health Check")
+
+	check := due.job.checks[due.index]
+
+	err := c.prober.Probe(check)
+	if err != nil {
+		c.logger.Debug(c.logTag, "Health check %s of job %s failed: %s", check.DisplayName(), due.jobName, err.Error())
+	}
+
+	health, changed := c.record(due, err)
+	if changed {
+		handler(due.jobName, health)
+	}
+}
+
+func (c *checker) record(due dueCheck, probeErr error) (JobHealth, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Job might have been reconfigured while check was running
+	if c.jobs[due.jobName] != due.job {
+		return JobHealth{}, false
+	}
+
+	check := due.job.checks[due.index]
+	state := due.job.states[due.index]
+
+	state.running = false
+
+	if probeErr == nil {
+		state.result.State = StatePassing
+		state.result.ConsecutiveFailures = 0
+		state.result.Message = ""
+	} else {
+		state.result.ConsecutiveFailures++
+		state.result.Message = probeErr.Error()
+
+		if state.result.ConsecutiveFailures >= check.failureThreshold() {
+			state.result.State = StateFailing
+		}
+	}
+
+	health := due.job.health()
+
+	if health.Healthy == due.job.healthy {
+		return health, false
+	}
+
+	due.job.healthy = health.Healthy
+
+	return health, true
+}
+
+func (c *checker) ensureLoaded() {
+	if c.loaded {
+		return
+	}
+
+	c.loaded = true
+
+	if !c.fs.FileExists(c.configPath) {
+		return
+	}
+
+	contents, err := c.fs.ReadFile(c.configPath)
+	if err != nil {
+		c.logger.Error(c.logTag, "Reading health checks: %s", err.Error())
+		return
+	}
+
+	var jobs map[string][]Check
+
+	err = json.Unmarshal(contents, &jobs)
+	if err != nil {
+		c.logger.Error(c.logTag, "Unmarshalling health checks: %s", err.Error())
+		return
+	}
+
+	for jobName, checks := range jobs {
+		c.jobs[jobName] = newJobChecks(checks)
+	}
+}
+
+func (c *checker) save() error {
+	jobs := map[string][]Check{}
+	for jobName, job := range c.jobs {
+		jobs[jobName] = job.checks
+	}
+
+	contents, err := json.Marshal(jobs)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling health checks")
+	}
+
+	err = c.fs.WriteFile(c.configPath, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing health checks")
+	}
+
+	return nil
+}
+
+func newJobChecks(checks []Check) *jobChecks {
+	job := &jobChecks{checks: checks, healthy: true}
+
+	for _, check := range checks {
+		job.states = append(job.states, &checkState{
+			result: Result{
+				Name:  check.DisplayName(),
+				Type:  check.Type,
+				State: StatePending,
+			},
+		})
+	}
+
+	return job
+}
+
+func (j *jobChecks) health() JobHealth {
+	health := JobHealth{Healthy: true}
+
+	for _, state := range j.states {
+		health.Checks = append(health.Checks, state.result)
+		health.Healthy = health.Healthy && state.result.State != StateFailing
+	}
+
+	return health
+}
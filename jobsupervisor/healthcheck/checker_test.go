@@ -0,0 +1,222 @@
+package healthcheck_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck/healthcheckfakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("checker", func() {
+	const configPath = "/var/vcap/bosh/health_checks.json"
+
+	var (
+		prober      *healthcheckfakes.FakeProber
+		fs          *fakesys.FakeFileSystem
+		timeService *fakeclock.FakeClock
+		checker     Checker
+
+		portCheck Check
+		execCheck Check
+
+		transitionsLock sync.Mutex
+		transitions     []JobHealth
+	)
+
+	BeforeEach(func() {
+		prober = &healthcheckfakes.FakeProber{}
+		fs = fakesys.NewFakeFileSystem()
+		timeService = fakeclock.NewFakeClock(time.Now())
+
+		checker = NewChecker(prober, fs, configPath, timeService, boshlog.NewLogger(boshlog.LevelNone))
+
+		portCheck = Check{Type: "tcp", Port: 8080, IntervalSeconds: 5, FailureThreshold: 2}
+		execCheck = Check{Name: "fake-exec", Type: "exec", Command: "/bin/healthy", IntervalSeconds: 30}
+
+		transitionsLock.Lock()
+		transitions = nil
+		transitionsLock.Unlock()
+	})
+
+	receivedTransitions := func() []JobHealth {
+		transitionsLock.Lock()
+		defer transitionsLock.Unlock()
+		return append([]JobHealth{}, transitions...)
+	}
+
+	run := func() {
+		go checker.Run(func(jobName string, health JobHealth) {
+			defer GinkgoRecover()
+			Expect(jobName).To(Equal("fake-job"))
+
+			transitionsLock.Lock()
+			defer transitionsLock.Unlock()
+			transitions = append(transitions, health)
+		})
+	}
+
+	// advance lets given number of seconds pass and waits
+	// until checks that became due have finished
+	advance := func(seconds int, expectedProbes int) {
+		for i := 0; i < seconds; i++ {
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(1 * time.Second)
+		}
+
+		Eventually(prober.ProbeCallCount).Should(Equal(expectedProbes))
+		Eventually(timeService.WatcherCount).Should(Equal(1))
+	}
+
+	checkState := func(jobName string, index int) string {
+		health, found := checker.Health(jobName)
+		Expect(found).To(BeTrue())
+		return health.Checks[index].State
+	}
+
+	Describe("Configure", func() {
+		It("returns error when check is invalid", func() {
+			err := checker.Configure("fake-job", []Check{{Type: "udp"}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating health checks of job 'fake-job'"))
+			Expect(checker.Jobs()).To(BeEmpty())
+		})
+
+		It("reports checks of job as pending until they run", func() {
+			Expect(checker.Configure("fake-job", []Check{portCheck, execCheck})).To(Succeed())
+
+			health, found := checker.Health("fake-job")
+			Expect(found).To(BeTrue())
+			Expect(health).To(Equal(JobHealth{
+				Healthy: true,
+				Checks: []Result{
+					{Name: "tcp:8080", Type: "tcp", State: "pending"},
+					{Name: "fake-exec", Type: "exec", State: "pending"},
+				},
+			}))
+		})
+
+		It("keeps checks so that they are loaded after agent restarts", func() {
+			Expect(checker.Configure("fake-job", []Check{portCheck})).To(Succeed())
+
+			checker = NewChecker(prober, fs, configPath, timeService, boshlog.NewLogger(boshlog.LevelNone))
+			Expect(checker.Jobs()).To(Equal([]string{"fake-job"}))
+		})
+
+		It("returns error when checks cannot be saved", func() {
+			fs.WriteFileError = errors.New("fake-write-err")
+
+			err := checker.Configure("fake-job", []Check{portCheck})
+			Expect(err).To(MatchError("Writing health checks: fake-write-err"))
+		})
+
+		It("removes checks of job when no checks are given", func() {
+			Expect(checker.Configure("fake-job", []Check{portCheck})).To(Succeed())
+			Expect(checker.Configure("fake-job", nil)).To(Succeed())
+
+			_, found := checker.Health("fake-job")
+			Expect(found).To(BeFalse())
+			Expect(fs.ReadFileString(configPath)).To(Equal("{}"))
+		})
+
+		It("keeps results when checks do not change", func() {
+			prober.ProbeReturns(errors.New("fake-probe-err"))
+			Expect(checker.Configure("fake-job", []Check{portCheck})).To(Succeed())
+
+			run()
+			advance(0, 1)
+			advance(5, 2)
+			Expect(checkState("fake-job", 0)).To(Equal("failing"))
+
+			Expect(checker.Configure("fake-job", []Check{portCheck})).To(Succeed())
+			Expect(checkState("fake-job", 0)).To(Equal("failing"))
+
+			portCheck.Port = 8081
+			Expect(checker.Configure("fake-job", []Check{portCheck})).To(Succeed())
+			Expect(checkState("fake-job", 0)).To(Equal("pending"))
+		})
+	})
+
+	Describe("KeepOnly", func() {
+		It("removes checks of other jobs", func() {
+			Expect(checker.Configure("fake-job", []Check{portCheck})).To(Succeed())
+			Expect(checker.Configure("other-job", []Check{execCheck})).To(Succeed())
+
+			Expect(checker.KeepOnly([]string{"fake-job"})).To(Succeed())
+			Expect(checker.Jobs()).To(Equal([]string{"fake-job"}))
+
+			checker = NewChecker(prober, fs, configPath, timeService, boshlog.NewLogger(boshlog.LevelNone))
+			Expect(checker.Jobs()).To(Equal([]string{"fake-job"}))
+		})
+	})
+
+	Describe("Run", func() {
+		BeforeEach(func() {
+			Expect(checker.Configure("fake-job", []Check{portCheck, execCheck})).To(Succeed())
+		})
+
+		It("runs each check at its interval", func() {
+			run()
+			advance(0, 2)
+
+			advance(4, 2)
+			advance(1, 3)
+			Expect(prober.ProbeArgsForCall(2)).To(Equal(portCheck))
+
+			advance(25, 9)
+		})
+
+		It("marks check failing once it fails failure threshold times in a row", func() {
+			prober.ProbeReturns(errors.New("fake-probe-err"))
+
+			run()
+			advance(0, 2)
+
+			health, _ := checker.Health("fake-job")
+			Expect(health.Healthy).To(BeTrue())
+			Expect(health.Checks[0]).To(Equal(Result{
+				Name:                "tcp:8080",
+				Type:                "tcp",
+				State:               "pending",
+				ConsecutiveFailures: 1,
+				Message:             "fake-probe-err",
+			}))
+
+			advance(5, 3)
+
+			health, _ = checker.Health("fake-job")
+			Expect(health.Healthy).To(BeFalse())
+			Expect(health.Checks[0].State).To(Equal("failing"))
+			Expect(health.Checks[0].ConsecutiveFailures).To(Equal(2))
+			Expect(health.FailingChecks()).To(Equal([]string{"tcp:8080 (fake-probe-err)"}))
+		})
+
+		It("notifies about job becoming unhealthy and healthy again", func() {
+			prober.ProbeReturns(errors.New("fake-probe-err"))
+			prober.ProbeReturnsOnCall(4, nil)
+
+			run()
+			advance(0, 2)
+			Expect(receivedTransitions()).To(BeEmpty())
+
+			advance(5, 3)
+			Eventually(receivedTransitions).Should(HaveLen(1))
+			Expect(receivedTransitions()[0].Healthy).To(BeFalse())
+
+			advance(5, 4)
+			Consistently(receivedTransitions).Should(HaveLen(1))
+
+			advance(5, 5)
+			Eventually(receivedTransitions).Should(HaveLen(2))
+			Expect(receivedTransitions()[1].Healthy).To(BeTrue())
+			Expect(checkState("fake-job", 0)).To(Equal("passing"))
+		})
+	})
+})
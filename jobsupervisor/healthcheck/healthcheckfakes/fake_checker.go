@@ -0,0 +1,375 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package healthcheckfakes
+
+import (
+	sync "sync"
+
+	healthcheck "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+)
+
+type FakeChecker struct {
+	ConfigureStub        func(string, []healthcheck.Check) error
+	configureMutex       sync.RWMutex
+	configureArgsForCall []struct {
+		arg1 string
+		arg2 []healthcheck.Check
+	}
+	configureReturns struct {
+		result1 error
+	}
+	configureReturnsOnCall map[int]struct {
+		result1 error
+	}
+	HealthStub        func(string) (healthcheck.JobHealth, bool)
+	healthMutex       sync.RWMutex
+	healthArgsForCall []struct {
+		arg1 string
+	}
+	healthReturns struct {
+		result1 healthcheck.JobHealth
+		result2 bool
+	}
+	healthReturnsOnCall map[int]struct {
+		result1 healthcheck.JobHealth
+		result2 bool
+	}
+	JobsStub        func() []string
+	jobsMutex       sync.RWMutex
+	jobsArgsForCall []struct {
+	}
+	jobsReturns struct {
+		result1 []string
+	}
+	jobsReturnsOnCall map[int]struct {
+		result1 []string
+	}
+	KeepOnlyStub        func([]string) error
+	keepOnlyMutex       sync.RWMutex
+	keepOnlyArgsForCall []struct {
+		arg1 []string
+	}
+	keepOnlyReturns struct {
+		result1 error
+	}
+	keepOnlyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RunStub        func(healthcheck.TransitionHandler)
+	runMutex       sync.RWMutex
+	runArgsForCall []struct {
+		arg1 healthcheck.TransitionHandler
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeChecker) Configure(arg1 string, arg2 []healthcheck.Check) error {
+	var arg2Copy []healthcheck.Check
+	if arg2 != nil {
+		arg2Copy = make([]healthcheck.Check, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.configureMutex.Lock()
+	ret, specificReturn := fake.configureReturnsOnCall[len(fake.configureArgsForCall)]
+	fake.configureArgsForCall = append(fake.configureArgsForCall, struct {
+		arg1 string
+		arg2 []healthcheck.Check
+	}{arg1, arg2Copy})
+	fake.recordInvocation("Configure", []interface{}{arg1, arg2Copy})
+	fake.configureMutex.Unlock()
+	if fake.ConfigureStub != nil {
+		return fake.ConfigureStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.configureReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeChecker) ConfigureCallCount() int {
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	return len(fake.configureArgsForCall)
+}
+
+func (fake *FakeChecker) ConfigureCalls(stub func(string, []healthcheck.Check) error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = stub
+}
+
+func (fake *FakeChecker) ConfigureArgsForCall(i int) (string, []healthcheck.Check) {
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	argsForCall := fake.configureArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeChecker) ConfigureReturns(result1 error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = nil
+	fake.configureReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeChecker) ConfigureReturnsOnCall(i int, result1 error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = nil
+	if fake.configureReturnsOnCall == nil {
+		fake.configureReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.configureReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeChecker) Health(arg1 string) (healthcheck.JobHealth, bool) {
+	fake.healthMutex.Lock()
+	ret, specificReturn := fake.healthReturnsOnCall[len(fake.healthArgsForCall)]
+	fake.healthArgsForCall = append(fake.healthArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Health", []interface{}{arg1})
+	fake.healthMutex.Unlock()
+	if fake.HealthStub != nil {
+		return fake.HealthStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.healthReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeChecker) HealthCallCount() int {
+	fake.healthMutex.RLock()
+	defer fake.healthMutex.RUnlock()
+	return len(fake.healthArgsForCall)
+}
+
+func (fake *FakeChecker) HealthCalls(stub func(string) (healthcheck.JobHealth, bool)) {
+	fake.healthMutex.Lock()
+	defer fake.healthMutex.Unlock()
+	fake.HealthStub = stub
+}
+
+func (fake *FakeChecker) HealthArgsForCall(i int) string {
+	fake.healthMutex.RLock()
+	defer fake.healthMutex.RUnlock()
+	argsForCall := fake.healthArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeChecker) HealthReturns(result1 healthcheck.JobHealth, result2 bool) {
+	fake.healthMutex.Lock()
+	defer fake.healthMutex.Unlock()
+	fake.HealthStub = nil
+	fake.healthReturns = struct {
+		result1 healthcheck.JobHealth
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeChecker) HealthReturnsOnCall(i int, result1 healthcheck.JobHealth, result2 bool) {
+	fake.healthMutex.Lock()
+	defer fake.healthMutex.Unlock()
+	fake.HealthStub = nil
+	if fake.healthReturnsOnCall == nil {
+		fake.healthReturnsOnCall = make(map[int]struct {
+			result1 healthcheck.JobHealth
+			result2 bool
+		})
+	}
+	fake.healthReturnsOnCall[i] = struct {
+		result1 healthcheck.JobHealth
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeChecker) Jobs() []string {
+	fake.jobsMutex.Lock()
+	ret, specificReturn := fake.jobsReturnsOnCall[len(fake.jobsArgsForCall)]
+	fake.jobsArgsForCall = append(fake.jobsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Jobs", []interface{}{})
+	fake.jobsMutex.Unlock()
+	if fake.JobsStub != nil {
+		return fake.JobsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.jobsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeChecker) JobsCallCount() int {
+	fake.jobsMutex.RLock()
+	defer fake.jobsMutex.RUnlock()
+	return len(fake.jobsArgsForCall)
+}
+
+func (fake *FakeChecker) JobsCalls(stub func() []string) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = stub
+}
+
+func (fake *FakeChecker) JobsReturns(result1 []string) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = nil
+	fake.jobsReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeChecker) JobsReturnsOnCall(i int, result1 []string) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = nil
+	if fake.jobsReturnsOnCall == nil {
+		fake.jobsReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.jobsReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeChecker) KeepOnly(arg1 []string) error {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.keepOnlyMutex.Lock()
+	ret, specificReturn := fake.keepOnlyReturnsOnCall[len(fake.keepOnlyArgsForCall)]
+	fake.keepOnlyArgsForCall = append(fake.keepOnlyArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	fake.recordInvocation("KeepOnly", []interface{}{arg1Copy})
+	fake.keepOnlyMutex.Unlock()
+	if fake.KeepOnlyStub != nil {
+		return fake.KeepOnlyStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.keepOnlyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeChecker) KeepOnlyCallCount() int {
+	fake.keepOnlyMutex.RLock()
+	defer fake.keepOnlyMutex.RUnlock()
+	return len(fake.keepOnlyArgsForCall)
+}
+
+func (fake *FakeChecker) KeepOnlyCalls(stub func([]string) error) {
+	fake.keepOnlyMutex.Lock()
+	defer fake.keepOnlyMutex.Unlock()
+	fake.KeepOnlyStub = stub
+}
+
+func (fake *FakeChecker) KeepOnlyArgsForCall(i int) []string {
+	fake.keepOnlyMutex.RLock()
+	defer fake.keepOnlyMutex.RUnlock()
+	argsForCall := fake.keepOnlyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeChecker) KeepOnlyReturns(result1 error) {
+	fake.keepOnlyMutex.Lock()
+	defer fake.keepOnlyMutex.Unlock()
+	fake.KeepOnlyStub = nil
+	fake.keepOnlyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeChecker) KeepOnlyReturnsOnCall(i int, result1 error) {
+	fake.keepOnlyMutex.Lock()
+	defer fake.keepOnlyMutex.Unlock()
+	fake.KeepOnlyStub = nil
+	if fake.keepOnlyReturnsOnCall == nil {
+		fake.keepOnlyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.keepOnlyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeChecker) Run(arg1 healthcheck.TransitionHandler) {
+	fake.runMutex.Lock()
+	fake.runArgsForCall = append(fake.runArgsForCall, struct {
+		arg1 healthcheck.TransitionHandler
+	}{arg1})
+	fake.recordInvocation("Run", []interface{}{arg1})
+	fake.runMutex.Unlock()
+	if fake.RunStub != nil {
+		fake.RunStub(arg1)
+	}
+}
+
+func (fake *FakeChecker) RunCallCount() int {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return len(fake.runArgsForCall)
+}
+
+func (fake *FakeChecker) RunCalls(stub func(healthcheck.TransitionHandler)) {
+	fake.runMutex.Lock()
+	defer fake.runMutex.Unlock()
+	fake.RunStub = stub
+}
+
+func (fake *FakeChecker) RunArgsForCall(i int) healthcheck.TransitionHandler {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	argsForCall := fake.runArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeChecker) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	fake.healthMutex.RLock()
+	defer fake.healthMutex.RUnlock()
+	fake.jobsMutex.RLock()
+	defer fake.jobsMutex.RUnlock()
+	fake.keepOnlyMutex.RLock()
+	defer fake.keepOnlyMutex.RUnlock()
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeChecker) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ healthcheck.Checker = new(FakeChecker)
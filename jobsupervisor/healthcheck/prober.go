@@ -0,0 +1,118 @@
+package healthcheck
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Grace period given to timed out commands before they are killed
+const execKillGracePeriod = 1 * time.Second
+
+//go:generate counterfeiter . Prober
+
+type Prober interface {
+	// Probe runs check once and returns error when it does not succeed
+	Probe(check Check) error
+}
+
+type prober struct {
+	cmdRunner   boshsys.CmdRunner
+	timeService clock.Clock
+}
+
+func NewProber(cmdRunner boshsys.CmdRunner, timeService clock.Clock) Prober {
+	return prober{
+		cmdRunner:   cmdRunner,
+		timeService: timeService,
+	}
+}
+
+func (p prober) Probe(check Check) error {
+	switch check.Type {
+	case TypeTCP:
+		return p.probeTCP(check)
+	case TypeHTTP:
+		return p.probeHTTP(check)
+	case TypeExec:
+		return p.probeExec(check)
+	default:
+		return bosherr.Errorf("Unknown health check type '%s'", check.Type)
+	}
+}
+
+func (p prober) probeTCP(check Check) error {
+	conn, err := net.DialTimeout("tcp", p.address(check), check.Timeout())
+	if err != nil {
+		return bosherr.WrapError(err, "Connecting")
+	}
+
+	return conn.Close()
+}
+
+func (p prober) probeHTTP(check Check) error {
+	client := &http.Client{
+		Timeout: check.Timeout(),
+
+		// Redirects are reported as is so that they can be expected
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	url := fmt.Sprintf("http://%s%s", p.address(check), check.path())
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Requesting %s", url)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != check.expectedStatus() {
+		return bosherr.Errorf("Expected status %d but got %d", check.expectedStatus(), resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (p prober) probeExec(check Check) error {
+	process, err := p.cmdRunner.RunComplexCommandAsync(boshsys.Command{
+		Name:  check.Command,
+		Args:  check.Args,
+		Quiet: true,
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running %s", check.Command)
+	}
+
+	resultCh := process.Wait()
+	timer := p.timeService.NewTimer(check.Timeout())
+	defer timer.Stop()
+
+	select {
+	case result := <-resultCh:
+		if result.Error != nil {
+			output := strings.TrimSpace(result.Stdout + result.Stderr)
+			return bosherr.WrapErrorf(result.Error, "Command exited with %d: %s", result.ExitStatus, output)
+		}
+
+		return nil
+
+	case <-timer.C():
+		_ = process.TerminateNicely(execKillGracePeriod)
+		return bosherr.Errorf("Command timed out after %s", check.Timeout())
+	}
+}
+
+func (p prober) address(check Check) string {
+	return net.JoinHostPort(check.host(), strconv.Itoa(check.Port))
+}
@@ -0,0 +1,170 @@
+package healthcheck_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("prober", func() {
+	var (
+		cmdRunner   *fakesys.FakeCmdRunner
+		timeService *fakeclock.FakeClock
+		prober      Prober
+	)
+
+	BeforeEach(func() {
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		timeService = fakeclock.NewFakeClock(time.Now())
+		prober = NewProber(cmdRunner, timeService)
+	})
+
+	Describe("tcp checks", func() {
+		It("succeeds when port accepts connections", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer listener.Close()
+
+			port := listener.Addr().(*net.TCPAddr).Port
+
+			Expect(prober.Probe(Check{Type: "tcp", Port: port})).To(Succeed())
+		})
+
+		It("fails when port does not accept connections", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+
+			port := listener.Addr().(*net.TCPAddr).Port
+			listener.Close()
+
+			err = prober.Probe(Check{Type: "tcp", Port: port})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Connecting"))
+		})
+	})
+
+	Describe("http checks", func() {
+		var (
+			server *httptest.Server
+			host   string
+			port   int
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/healthz":
+					w.WriteHeader(http.StatusOK)
+				case "/moved":
+					http.Redirect(w, r, "/healthz", http.StatusFound)
+				default:
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+
+			serverURL, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			var portStr string
+			host, portStr, err = net.SplitHostPort(serverURL.Host)
+			Expect(err).ToNot(HaveOccurred())
+
+			port, err = strconv.Atoi(portStr)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("succeeds when path responds with 200 by default", func() {
+			Expect(prober.Probe(Check{Type: "http", Host: host, Port: port, Path: "/healthz"})).To(Succeed())
+		})
+
+		It("fails when path responds with other status", func() {
+			err := prober.Probe(Check{Type: "http", Host: host, Port: port, Path: "/unavailable"})
+			Expect(err).To(MatchError("Expected status 200 but got 503"))
+		})
+
+		It("succeeds when path responds with expected status", func() {
+			check := Check{Type: "http", Host: host, Port: port, Path: "/moved", ExpectedStatus: 302}
+			Expect(prober.Probe(check)).To(Succeed())
+		})
+
+		It("fails when server cannot be reached", func() {
+			server.Close()
+
+			err := prober.Probe(Check{Type: "http", Host: host, Port: port})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Requesting http://"))
+		})
+	})
+
+	Describe("exec checks", func() {
+		var check Check
+
+		BeforeEach(func() {
+			check = Check{Type: "exec", Command: "/bin/healthy", Args: []string{"--fast"}, TimeoutSeconds: 2}
+		})
+
+		It("succeeds when command exits with 0", func() {
+			cmdRunner.AddProcess("/bin/healthy --fast", &fakesys.FakeProcess{})
+
+			Expect(prober.Probe(check)).To(Succeed())
+			Expect(cmdRunner.RunComplexCommands).To(Equal([]boshsys.Command{
+				{Name: "/bin/healthy", Args: []string{"--fast"}, Quiet: true},
+			}))
+		})
+
+		It("fails with output when command exits with non-zero status", func() {
+			cmdRunner.AddProcess("/bin/healthy --fast", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{
+					Stdout:     "not ready\n",
+					ExitStatus: 1,
+					Error:      errors.New("fake-exit-err"),
+				},
+			})
+
+			err := prober.Probe(check)
+			Expect(err).To(MatchError("Command exited with 1: not ready: fake-exit-err"))
+		})
+
+		It("fails when command cannot be started", func() {
+			cmdRunner.AddProcess("/bin/healthy --fast", &fakesys.FakeProcess{StartErr: errors.New("fake-start-err")})
+
+			err := prober.Probe(check)
+			Expect(err).To(MatchError("Running /bin/healthy: fake-start-err"))
+		})
+
+		It("terminates command and fails when it does not exit within timeout", func() {
+			process := &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{Error: errors.New("fake-terminated-err")}
+				},
+			}
+			cmdRunner.AddProcess("/bin/healthy --fast", process)
+
+			errCh := make(chan error)
+			go func() { errCh <- prober.Probe(check) }()
+
+			Eventually(timeService.WatcherCount).Should(Equal(1))
+			timeService.Increment(2 * time.Second)
+
+			Eventually(errCh).Should(Receive(MatchError("Command timed out after 2s")))
+			Expect(process.TerminatedNicely).To(BeTrue())
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(1 * time.Second))
+		})
+	})
+})
@@ -2,14 +2,23 @@ package jobsupervisor
 
 import (
 	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
 )
 
 type Process struct {
 	Name   string       `json:"name"`
 	State  string       `json:"state"`
+	PID    int          `json:"pid,omitempty"`
 	Uptime UptimeVitals `json:"uptime,omitempty"`
 	Memory MemoryVitals `json:"mem,omitempty"`
 	CPU    CPUVitals    `json:"cpu,omitempty"`
+
+	// Set for processes of jobs with resource limits
+	Cgroup *CgroupVitals `json:"cgroup,omitempty"`
+
+	// Set for processes of jobs with health checks;
+	// running processes of unhealthy jobs are failing
+	HealthChecks []healthcheck.Result `json:"health_checks,omitempty"`
 }
 
 type UptimeVitals struct {
@@ -25,6 +34,22 @@ type CPUVitals struct {
 	Total float64 `json:"total"`
 }
 
+// CgroupVitals are shared by all processes of a job
+type CgroupVitals struct {
+	Name     string             `json:"name"`
+	Memory   CgroupMemoryVitals `json:"mem"`
+	CPU      CPUVitals          `json:"cpu"`
+	OOMKills uint64             `json:"oom_kills"`
+}
+
+type CgroupMemoryVitals struct {
+	Kb      uint64 `json:"kb"`
+	LimitKb uint64 `json:"limit_kb,omitempty"`
+
+	// Percentage of memory limit; zero when memory is not limited
+	Percent float64 `json:"percent"`
+}
+
 type JobFailureHandler func(boshalert.MonitAlert) error
 
 type JobSupervisor interface {
@@ -41,12 +66,22 @@ type JobSupervisor interface {
 	// (Monit complies to above requirements.)
 	Unmonitor() error
 
+	// Actions taken on individual services (monit checks or processes).
+	// Stopped services are no longer monitored until they are started.
+	StartServices(services []string) error
+	StopServices(services []string) error
+	UnmonitorServices(services []string) error
+
 	Status() string
 	Processes() ([]Process, error)
 	// Job management
 	AddJob(jobName string, jobIndex int, configPath string) error
 	RemoveAllJobs() error
 
+	// JobServices returns services of job given to AddJob
+	// including services from its additional monit files
+	JobServices(jobName string) ([]string, error)
+
 	MonitorJobFailures(handler JobFailureHandler) error
 	HealthRecorder(status string)
 }
@@ -28,6 +28,7 @@ type serviceTag struct {
 	StatusMessage string    `xml:"status_message"`
 	Monitor       int       `xml:"monitor"`
 	Uptime        int       `xml:"uptime"`
+	Pid           int       `xml:"pid"`
 	Children      int       `xml:"children"`
 	Memory        memoryTag `xml:"memory"`
 	CPU           cpuTag    `xml:"cpu"`
@@ -110,6 +111,7 @@ func (status status) ServicesInGroup(name string) (services []Service) {
 				StatusMessage:        serviceTag.StatusMessage,
 				Monitored:            serviceTag.Monitor > 0,
 				Uptime:               serviceTag.Uptime,
+				Pid:                  serviceTag.Pid,
 				MemoryPercentTotal:   serviceTag.Memory.PercentTotal,
 				MemoryKilobytesTotal: serviceTag.Memory.KilobyteTotal,
 				CPUPercentTotal:      serviceTag.CPU.PercentTotal,
@@ -20,6 +20,7 @@ type Service struct {
 	Status               string
 	StatusMessage        string
 	Uptime               int
+	Pid                  int
 	MemoryPercentTotal   float64
 	MemoryKilobytesTotal int
 	CPUPercentTotal      float64
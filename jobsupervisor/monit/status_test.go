@@ -99,6 +99,7 @@ var _ = Describe("status", func() {
 					Status:               "running",
 					StatusMessage:        "",
 					Uptime:               880183,
+					Pid:                  1,
 					MemoryPercentTotal:   0,
 					MemoryKilobytesTotal: 4004,
 					CPUPercentTotal:      0,
@@ -0,0 +1,140 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type monitToken struct {
+	value  string
+	quoted bool
+}
+
+// monitCheckNames returns names of all checks (services) in monit file
+func monitCheckNames(contents string) []string {
+	names := []string{}
+
+	tokens := tokenizeMonitConfig(contents)
+
+	for i := 0; i+2 < len(tokens); i++ {
+		if !tokens[i].quoted && strings.ToLower(tokens[i].value) == "check" {
+			names = append(names, tokens[i+2].value)
+			i += 2
+		}
+	}
+
+	return names
+}
+
+func tokenizeMonitConfig(contents string) []monitToken {
+	var tokens []monitToken
+
+	for _, line := range strings.Split(contents, "\n") {
+		var current []rune
+		inQuotes, inToken, inComment := false, false, false
+
+		flush := func(quoted bool) {
+			if inToken || quoted {
+				tokens = append(tokens, monitToken{value: string(current), quoted: quoted})
+			}
+			current = nil
+			inToken = false
+		}
+
+		for _, r := range line {
+			if inComment {
+				break
+			}
+
+			switch {
+			case inQuotes && r == '"':
+				flush(true)
+				inQuotes = false
+			case inQuotes:
+				current = append(current, r)
+			case r == '"':
+				flush(false)
+				inQuotes = true
+			case r == '#':
+				flush(false)
+				inComment = true
+			case r == '=':
+				flush(false)
+				tokens = append(tokens, monitToken{value: "="})
+			case r == ' ' || r == '\t' || r == '\r':
+				flush(false)
+			default:
+				current = append(current, r)
+				inToken = true
+			}
+		}
+
+		flush(false)
+	}
+
+	return tokens
+}
+
+// addedJob is a job config given to AddJob. Additional monit files
+// of a job are added as <job>_<label> with the same index as the job.
+type addedJob struct {
+	name     string
+	index    int
+	services []string
+}
+
+// jobConfigFileName is the name job config is saved under in monit jobs dir
+func jobConfigFileName(jobName string, jobIndex int, extension string) string {
+	return fmt.Sprintf("%04d_%s%s", jobIndex, jobName, extension)
+}
+
+func parseJobConfigFileName(configPath string) (string, int, bool) {
+	fileName := filepath.Base(configPath)
+	fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+
+	parts := strings.SplitN(fileName, "_", 2)
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+
+	index, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return parts[1], index, true
+}
+
+// servicesOfJob returns services of job and of its additional monit files.
+// Additional monit files are only recognized next to the job's own monit file
+// since otherwise they cannot be told apart from jobs whose name has job name
+// as a prefix; additional monit files of jobs without their own monit file
+// are addressed as jobs named <job>_<label>.
+func servicesOfJob(jobName string, jobs []addedJob) []string {
+	services := []string{}
+
+	index, found := 0, false
+	for _, job := range jobs {
+		if job.name == jobName {
+			index, found = job.index, true
+		}
+	}
+
+	if !found {
+		return services
+	}
+
+	for _, job := range jobs {
+		// Job names are matched by prefix only when job index agrees so
+		// that services of job 'a_b' are not taken for services of job 'a'
+		additional := strings.HasPrefix(job.name, jobName+"_") && job.index == index
+
+		if job.name == jobName || additional {
+			services = append(services, job.services...)
+		}
+	}
+
+	return services
+}
@@ -267,6 +267,42 @@ func (m monitJobSupervisor) Unmonitor() error {
 	return nil
 }
 
+func (m monitJobSupervisor) StartServices(services []string) error {
+	for _, service := range services {
+		m.logger.Debug(monitJobSupervisorLogTag, "Starting service %s", service)
+		err := m.client.StartService(service)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Starting service %s", service)
+		}
+	}
+
+	return nil
+}
+
+func (m monitJobSupervisor) StopServices(services []string) error {
+	for _, service := range services {
+		m.logger.Debug(monitJobSupervisorLogTag, "Stopping service %s", service)
+		err := m.client.StopService(service)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Stopping service %s", service)
+		}
+	}
+
+	return nil
+}
+
+func (m monitJobSupervisor) UnmonitorServices(services []string) error {
+	for _, service := range services {
+		m.logger.Debug(monitJobSupervisorLogTag, "Unmonitoring service %s", service)
+		err := m.client.UnmonitorService(service)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Unmonitoring service %s", service)
+		}
+	}
+
+	return nil
+}
+
 func (m monitJobSupervisor) Status() (status string) {
 	status = "running"
 
@@ -307,6 +343,7 @@ func (m monitJobSupervisor) Processes() (processes []Process, err error) {
 		process := Process{
 			Name:  service.Name,
 			State: service.Status,
+			PID:   service.Pid,
 			Uptime: UptimeVitals{
 				Secs: service.Uptime,
 			},
@@ -334,7 +371,7 @@ func (m monitJobSupervisor) getIncarnation() (int, error) {
 }
 
 func (m monitJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
-	targetFilename := fmt.Sprintf("%04d_%s.monitrc", jobIndex, jobName)
+	targetFilename := jobConfigFileName(jobName, jobIndex, ".monitrc")
 	targetConfigPath := path.Join(m.dirProvider.MonitJobsDir(), targetFilename)
 
 	configContent, err := m.fs.ReadFile(configPath)
@@ -354,6 +391,31 @@ func (m monitJobSupervisor) RemoveAllJobs() error {
 	return m.fs.RemoveAll(m.dirProvider.MonitJobsDir())
 }
 
+func (m monitJobSupervisor) JobServices(jobName string) ([]string, error) {
+	configPaths, err := m.fs.Glob(path.Join(m.dirProvider.MonitJobsDir(), "*.monitrc"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding job configs")
+	}
+
+	var jobs []addedJob
+
+	for _, configPath := range configPaths {
+		name, index, ok := parseJobConfigFileName(configPath)
+		if !ok {
+			continue
+		}
+
+		configContent, err := m.fs.ReadFileString(configPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading job config '%s'", configPath)
+		}
+
+		jobs = append(jobs, addedJob{name: name, index: index, services: monitCheckNames(configContent)})
+	}
+
+	return servicesOfJob(jobName, jobs), nil
+}
+
 func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err error) {
 	alertHandler := func(smtpd.Connection, smtpd.MailAddress) (env smtpd.Envelope, err error) {
 		env = &alertEnvelope{
@@ -665,6 +665,7 @@ var _ = Describe("monitJobSupervisor", func() {
 						Monitored:            true,
 						Status:               "running",
 						Uptime:               1234,
+						Pid:                  4321,
 						MemoryPercentTotal:   0.4,
 						MemoryKilobytesTotal: 100,
 						CPUPercentTotal:      0.5,
@@ -687,6 +688,7 @@ var _ = Describe("monitJobSupervisor", func() {
 				Process{
 					Name:  "fake-service-1",
 					State: "running",
+					PID:   4321,
 					Uptime: UptimeVitals{
 						Secs: 1234,
 					},
@@ -811,6 +813,110 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("StartServices", func() {
+		It("starts each service with monit", func() {
+			err := monit.StartServices([]string{"fake-service-1", "fake-service-2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.StartServiceNames).To(Equal([]string{"fake-service-1", "fake-service-2"}))
+		})
+
+		It("returns error when starting service fails", func() {
+			client.StartServiceErr = errors.New("fake-start-error")
+
+			err := monit.StartServices([]string{"fake-service-1", "fake-service-2"})
+			Expect(err).To(MatchError(ContainSubstring("fake-start-error")))
+			Expect(client.StartServiceNames).To(Equal([]string{"fake-service-1"}))
+		})
+	})
+
+	Describe("StopServices", func() {
+		It("stops each service with monit", func() {
+			err := monit.StopServices([]string{"fake-service-1", "fake-service-2"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.StopServiceNames).To(Equal([]string{"fake-service-1", "fake-service-2"}))
+		})
+	})
+
+	Describe("UnmonitorServices", func() {
+		It("unmonitors each service with monit", func() {
+			client.UnmonitorServiceErrs = []error{nil, errors.New("fake-unmonitor-error")}
+
+			err := monit.UnmonitorServices([]string{"fake-service-1", "fake-service-2"})
+			Expect(err).To(MatchError(ContainSubstring("fake-unmonitor-error")))
+			Expect(client.UnmonitorServiceNames).To(Equal([]string{"fake-service-1", "fake-service-2"}))
+		})
+	})
+
+	Describe("JobServices", func() {
+		BeforeEach(func() {
+			jobsDir := dirProvider.MonitJobsDir()
+
+			fs.SetGlob(jobsDir+"/*.monitrc", []string{
+				jobsDir + "/0000_router.monitrc",
+				jobsDir + "/0000_router_extra.monitrc",
+				jobsDir + "/0001_router_api.monitrc",
+				jobsDir + "/0001_nats.monitrc",
+			})
+
+			fs.WriteFileString(jobsDir+"/0000_router.monitrc", `
+check process router with pidfile /var/vcap/sys/run/router.pid
+  start program "/var/vcap/jobs/router/bin/ctl start"
+`)
+			fs.WriteFileString(jobsDir+"/0000_router_extra.monitrc", `
+check process router_metrics with pidfile /var/vcap/sys/run/metrics.pid
+  start program "/var/vcap/jobs/router/bin/metrics"
+check file router_log with path /var/vcap/sys/log/router.log
+`)
+			fs.WriteFileString(jobsDir+"/0001_router_api.monitrc", `
+check process router_api with pidfile /var/vcap/sys/run/router_api.pid
+`)
+			fs.WriteFileString(jobsDir+"/0001_nats.monitrc", `
+check process nats with pidfile /var/vcap/sys/run/nats.pid
+`)
+		})
+
+		It("returns checks from job config and its additional monit files", func() {
+			services, err := monit.JobServices("router")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(Equal([]string{"router", "router_metrics", "router_log"}))
+		})
+
+		It("does not take services of other jobs with job name as a prefix", func() {
+			services, err := monit.JobServices("router_api")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(Equal([]string{"router_api"}))
+		})
+
+		It("does not take services of other jobs for job without its own monit file", func() {
+			fs.WriteFileString(dirProvider.MonitJobsDir()+"/0002_metrics_agent.monitrc", `
+check process metrics_agent with pidfile /var/vcap/sys/run/metrics_agent.pid
+`)
+
+			services, err := monit.JobServices("metrics")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(BeEmpty())
+		})
+
+		It("returns services of additional monit file when it is addressed by its full name", func() {
+			services, err := monit.JobServices("router_extra")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(Equal([]string{"router_metrics", "router_log"}))
+		})
+
+		It("returns no services for unknown job", func() {
+			services, err := monit.JobServices("unknown")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(services).To(BeEmpty())
+		})
+
+		It("returns error when finding job configs fails", func() {
+			fs.GlobErr = errors.New("fake-glob-error")
+
+			_, err := monit.JobServices("router")
+			Expect(err).To(MatchError(ContainSubstring("fake-glob-error")))
+		})
+	})
+
 	Describe("RemoveAllJobs", func() {
 		Context("when jobs directory removal succeeds", func() {
 			It("does not return error because all jobs are removed from monit", func() {
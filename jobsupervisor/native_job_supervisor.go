@@ -0,0 +1,735 @@
+// +build !windows
+
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const nativeJobSupervisorLogTag = "nativeJobSupervisor"
+
+const (
+	nativeStateRunning   = "running"
+	nativeStateStarting  = "starting"
+	nativeStateFailing   = "failing"
+	nativeStateStopped   = "stopped"
+	nativeStateCompleted = "completed"
+	nativeStateUnknown   = "unknown"
+)
+
+type NativeJobSupervisorOptions struct {
+	// How long processes have to exit after being asked to stop
+	// before they are killed; also limits start and stop programs
+	// unless process spec sets its own timeout
+	StopTimeout time.Duration
+
+	// Delay before restarting exited process; doubled on every
+	// consecutive restart up to MaxRestartDelay and reset once
+	// process stays up for MaxRestartDelay
+	RestartDelay    time.Duration
+	MaxRestartDelay time.Duration
+
+	// How often processes that are not children of the agent
+	// (daemonized or left from previous agent run) are checked
+	PollInterval time.Duration
+}
+
+// nativeJob is what AddJob saves for Reload to pick up
+type nativeJob struct {
+	Name      string          `json:"name"`
+	Index     int             `json:"index"`
+	Processes []NativeProcess `json:"processes"`
+}
+
+type nativeJobSupervisor struct {
+	fs          boshsys.FileSystem
+	logger      boshlog.Logger
+	dirProvider boshdir.Provider
+	timeService clock.Clock
+	options     NativeJobSupervisorOptions
+
+	processesLock sync.Mutex
+	processes     []*nativeProcess
+
+	handlerLock sync.RWMutex
+	handler     JobFailureHandler
+
+	// 1 while failed processes are alerted on and restarted
+	monitored int32
+
+	alertCount uint64
+
+	cpuSamplesLock sync.Mutex
+	cpuSamples     map[string]nativeCPUSample
+}
+
+// NewNativeJobSupervisor runs job processes directly as agent children
+// instead of delegating to monit. Processes are restarted according to
+// their restart policy and failures are reported as monit alerts.
+func NewNativeJobSupervisor(
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+	dirProvider boshdir.Provider,
+	timeService clock.Clock,
+	options NativeJobSupervisorOptions,
+) JobSupervisor {
+	return &nativeJobSupervisor{
+		fs:          fs,
+		logger:      logger,
+		dirProvider: dirProvider,
+		timeService: timeService,
+		options:     options,
+		monitored:   1,
+		cpuSamples:  map[string]nativeCPUSample{},
+	}
+}
+
+func (n *nativeJobSupervisor) Reload() error {
+	jobs, err := n.loadJobs()
+	if err != nil {
+		return err
+	}
+
+	n.processesLock.Lock()
+
+	existing := map[string]*nativeProcess{}
+	for _, process := range n.processes {
+		existing[process.Name()] = process
+	}
+
+	var processes []*nativeProcess
+
+	for _, job := range jobs {
+		for _, spec := range job.Processes {
+			process, found := existing[spec.Name]
+			if found {
+				// Running process picks up new spec when it is restarted
+				process.Update(job.Name, job.Index, spec)
+				delete(existing, spec.Name)
+			} else {
+				process = newNativeProcess(job.Name, job.Index, spec)
+			}
+			processes = append(processes, process)
+		}
+	}
+
+	n.processes = processes
+
+	n.processesLock.Unlock()
+
+	var removed []*nativeProcess
+	for _, process := range existing {
+		n.logger.Debug(nativeJobSupervisorLogTag, "Stopping removed process %s", process.Name())
+		removed = append(removed, process)
+	}
+
+	n.stopAll(removed)
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) Start() error {
+	atomic.StoreInt32(&n.monitored, 1)
+
+	for _, process := range n.currentProcesses() {
+		n.logger.Debug(nativeJobSupervisorLogTag, "Starting process %s", process.Name())
+		process.SetMonitored(true)
+		n.start(process)
+	}
+
+	err := n.fs.RemoveAll(n.stoppedFilePath())
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped File")
+	}
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) Stop() error {
+	n.stopAll(n.currentProcesses())
+
+	err := n.fs.WriteFileString(n.stoppedFilePath(), "")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stopped File")
+	}
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) StopAndWait() error {
+	// Stop already waits for processes to exit
+	return n.Stop()
+}
+
+func (n *nativeJobSupervisor) Unmonitor() error {
+	atomic.StoreInt32(&n.monitored, 0)
+	return nil
+}
+
+func (n *nativeJobSupervisor) StartServices(services []string) error {
+	processes, err := n.findProcesses(services)
+	if err != nil {
+		return err
+	}
+
+	for _, process := range processes {
+		n.logger.Debug(nativeJobSupervisorLogTag, "Starting process %s", process.Name())
+		process.SetMonitored(true)
+		n.start(process)
+	}
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) StopServices(services []string) error {
+	processes, err := n.findProcesses(services)
+	if err != nil {
+		return err
+	}
+
+	n.stopAll(processes)
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) UnmonitorServices(services []string) error {
+	processes, err := n.findProcesses(services)
+	if err != nil {
+		return err
+	}
+
+	for _, process := range processes {
+		n.logger.Debug(nativeJobSupervisorLogTag, "Unmonitoring process %s", process.Name())
+		process.SetMonitored(false)
+	}
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) JobServices(jobName string) ([]string, error) {
+	var jobs []addedJob
+
+	for _, process := range n.currentProcesses() {
+		name, index := process.Job()
+		if len(jobs) == 0 || jobs[len(jobs)-1].name != name {
+			jobs = append(jobs, addedJob{name: name, index: index})
+		}
+		jobs[len(jobs)-1].services = append(jobs[len(jobs)-1].services, process.Name())
+	}
+
+	return servicesOfJob(jobName, jobs), nil
+}
+
+func (n *nativeJobSupervisor) Status() string {
+	if n.fs.FileExists(n.stoppedFilePath()) {
+		return "stopped"
+	}
+
+	processes := n.currentProcesses()
+	if len(processes) > 0 && !n.isMonitored() {
+		return "failing"
+	}
+
+	status := "running"
+
+	for _, process := range processes {
+		switch process.State() {
+		case nativeStateStarting:
+			return "starting"
+		case nativeStateRunning, nativeStateCompleted:
+		default:
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (n *nativeJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	var tree processTree
+	var treeErr error
+
+	for _, process := range n.currentProcesses() {
+		snapshot := process.Snapshot()
+
+		result := Process{
+			Name:  snapshot.Name,
+			State: snapshot.State,
+		}
+
+		if snapshot.State == nativeStateRunning && snapshot.PID > 0 {
+			if tree == nil && treeErr == nil {
+				tree, treeErr = newProcessTree()
+				if treeErr != nil {
+					n.logger.Warn(nativeJobSupervisorLogTag, "Listing processes: %s", treeErr.Error())
+				}
+			}
+
+			result.PID = snapshot.PID
+			result.Uptime.Secs = int(n.timeService.Since(snapshot.StartedAt).Seconds())
+			result.Memory, result.CPU = n.vitals(snapshot, tree)
+		}
+
+		processes = append(processes, result)
+	}
+
+	return processes, nil
+}
+
+func (n *nativeJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	configContent, err := n.fs.ReadFile(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job config from file")
+	}
+
+	config, err := ParseNativeProcessConfig(configContent)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Parsing job config for '%s'", jobName)
+	}
+
+	for _, check := range config.SkippedChecks {
+		n.logger.Warn(nativeJobSupervisorLogTag, "Skipping unsupported check '%s' of job '%s'", check, jobName)
+	}
+
+	if len(config.Processes) == 0 {
+		n.logger.Debug(nativeJobSupervisorLogTag, "Skipping job configuration for %q, no processes in %q", jobName, configPath)
+		return nil
+	}
+
+	jobContent, err := json.Marshal(nativeJob{Name: jobName, Index: jobIndex, Processes: config.Processes})
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling job processes")
+	}
+
+	targetFilename := jobConfigFileName(jobName, jobIndex, ".json")
+
+	err = n.fs.WriteFile(path.Join(n.dirProvider.MonitJobsDir(), targetFilename), jobContent)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing to job config file")
+	}
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) RemoveAllJobs() error {
+	return n.fs.RemoveAll(n.dirProvider.MonitJobsDir())
+}
+
+// MonitorJobFailures starts sending failures to handler. Since there is
+// no monit to bring jobs back after agent restarts, previously configured
+// jobs are started again here unless they were stopped on purpose;
+// processes that are still running are adopted through their pid files.
+func (n *nativeJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	n.handlerLock.Lock()
+	n.handler = handler
+	n.handlerLock.Unlock()
+
+	err := n.Reload()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading configured jobs")
+	}
+
+	if !n.fs.FileExists(n.stoppedFilePath()) {
+		err = n.Start()
+		if err != nil {
+			return bosherr.WrapError(err, "Starting configured jobs")
+		}
+	}
+
+	return nil
+}
+
+func (n *nativeJobSupervisor) HealthRecorder(status string) {
+}
+
+func (n *nativeJobSupervisor) loadJobs() ([]nativeJob, error) {
+	jobPaths, err := n.fs.Glob(path.Join(n.dirProvider.MonitJobsDir(), "*.json"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding job configs")
+	}
+
+	// File names start with job index, same as monit job configs
+	sort.Strings(jobPaths)
+
+	var jobs []nativeJob
+
+	for _, jobPath := range jobPaths {
+		jobContent, err := n.fs.ReadFile(jobPath)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading job config '%s'", jobPath)
+		}
+
+		var job nativeJob
+
+		err = json.Unmarshal(jobContent, &job)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling job config '%s'", jobPath)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (n *nativeJobSupervisor) currentProcesses() []*nativeProcess {
+	n.processesLock.Lock()
+	defer n.processesLock.Unlock()
+
+	return append([]*nativeProcess{}, n.processes...)
+}
+
+func (n *nativeJobSupervisor) findProcesses(names []string) ([]*nativeProcess, error) {
+	byName := map[string]*nativeProcess{}
+	for _, process := range n.currentProcesses() {
+		byName[process.Name()] = process
+	}
+
+	var processes []*nativeProcess
+
+	for _, name := range names {
+		process, found := byName[name]
+		if !found {
+			return nil, bosherr.Errorf("Service '%s' could not be found", name)
+		}
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+func (n *nativeJobSupervisor) start(process *nativeProcess) {
+	stopCh, doneCh, started := process.Begin()
+	if started {
+		go n.supervise(process, stopCh, doneCh)
+	}
+}
+
+func (n *nativeJobSupervisor) stopAll(processes []*nativeProcess) {
+	wg := &sync.WaitGroup{}
+
+	for _, process := range processes {
+		wg.Add(1)
+
+		go func(process *nativeProcess) {
+			defer wg.Done()
+			n.logger.Debug(nativeJobSupervisorLogTag, "Stopping process %s", process.Name())
+			process.End()
+		}(process)
+	}
+
+	wg.Wait()
+}
+
+// supervise keeps process running until it is stopped
+// or exits in a way that its restart policy does not cover
+func (n *nativeJobSupervisor) supervise(process *nativeProcess, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+	defer n.logger.HandlePanic("Native Job Supervisor")
+
+	restartDelay := n.options.RestartDelay
+
+	for {
+		jobName, spec := process.Spec()
+
+		instance, err := n.launch(jobName, spec)
+		if err != nil {
+			n.logger.Error(nativeJobSupervisorLogTag, "Starting process %s: %s", spec.Name, err.Error())
+
+			if !n.isMonitoring(process) {
+				process.SetState(nativeStateUnknown)
+				return
+			}
+
+			n.alert(spec.Name, "execution failed", "Start", err.Error())
+
+			if spec.RestartPolicy() == RestartNever {
+				process.SetState(nativeStateFailing)
+				return
+			}
+		} else {
+			process.SetRunning(instance.pid, instance.startedAt)
+
+			select {
+			case <-stopCh:
+				n.terminate(spec, instance)
+				process.SetState(nativeStateStopped)
+				return
+
+			case exitStatus := <-instance.exited:
+				n.logger.Debug(nativeJobSupervisorLogTag, "Process %s %s", spec.Name, exitStatus.Description())
+
+				if !n.isMonitoring(process) {
+					process.SetState(nativeStateUnknown)
+					return
+				}
+
+				restart := spec.RestartPolicy() == RestartAlways ||
+					(spec.RestartPolicy() == RestartOnFailure && !exitStatus.Succeeded())
+
+				if !restart {
+					if exitStatus.Succeeded() {
+						process.SetState(nativeStateCompleted)
+					} else {
+						n.alert(spec.Name, "pid failed", "Alert", exitStatus.Description())
+						process.SetState(nativeStateFailing)
+					}
+					return
+				}
+
+				n.alert(spec.Name, "pid failed", "Restart", exitStatus.Description())
+
+				if n.timeService.Since(instance.startedAt) >= n.options.MaxRestartDelay {
+					restartDelay = n.options.RestartDelay
+				}
+			}
+		}
+
+		process.SetState(nativeStateFailing)
+
+		select {
+		case <-stopCh:
+			process.SetState(nativeStateStopped)
+			return
+		case <-n.timeService.After(restartDelay):
+		}
+
+		restartDelay *= 2
+		if restartDelay > n.options.MaxRestartDelay {
+			restartDelay = n.options.MaxRestartDelay
+		}
+	}
+}
+
+func (n *nativeJobSupervisor) alert(service, event, action, description string) {
+	n.handlerLock.RLock()
+	handler := n.handler
+	n.handlerLock.RUnlock()
+
+	if handler == nil {
+		return
+	}
+
+	now := n.timeService.Now()
+
+	// Health monitor ignores alerts with already seen IDs
+	alertID := fmt.Sprintf("%d.%d@%s", now.Unix(), atomic.AddUint64(&n.alertCount, 1), service)
+
+	err := handler(boshalert.MonitAlert{
+		ID:          alertID,
+		Service:     service,
+		Event:       event,
+		Action:      action,
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	})
+	if err != nil {
+		n.logger.Error(nativeJobSupervisorLogTag, "Handling failure of %s: %s", service, err.Error())
+	}
+}
+
+func (n *nativeJobSupervisor) isMonitored() bool {
+	return atomic.LoadInt32(&n.monitored) == 1
+}
+
+func (n *nativeJobSupervisor) isMonitoring(process *nativeProcess) bool {
+	return n.isMonitored() && process.Monitored()
+}
+
+func (n *nativeJobSupervisor) stoppedFilePath() string {
+	return path.Join(n.dirProvider.MonitDir(), "stopped")
+}
+
+// pidFilePath is where pids of processes that run in foreground are kept
+// so that they can be adopted after agent restarts
+func (n *nativeJobSupervisor) pidFilePath(spec NativeProcess) string {
+	if spec.Daemonizes() {
+		return spec.PidFile
+	}
+	return path.Join(n.dirProvider.MonitDir(), "native", spec.Name+".pid")
+}
+
+func (n *nativeJobSupervisor) logFilePath(jobName, processName, stream string) string {
+	return path.Join(n.dirProvider.LogsDir(), jobName, fmt.Sprintf("%s.%s.log", processName, stream))
+}
+
+type nativeProcessSnapshot struct {
+	Name      string
+	State     string
+	PID       int
+	StartedAt time.Time
+}
+
+// nativeProcess holds supervision state of a single process;
+// at most one supervise loop runs for it at a time
+type nativeProcess struct {
+	lock sync.Mutex
+
+	jobName  string
+	jobIndex int
+	spec     NativeProcess
+
+	// Failures of unmonitored processes are neither reported nor restarted
+	monitored bool
+
+	state     string
+	pid       int
+	startedAt time.Time
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func newNativeProcess(jobName string, jobIndex int, spec NativeProcess) *nativeProcess {
+	return &nativeProcess{
+		jobName:   jobName,
+		jobIndex:  jobIndex,
+		spec:      spec,
+		monitored: true,
+		state:     nativeStateUnknown,
+	}
+}
+
+func (p *nativeProcess) Name() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.spec.Name
+}
+
+func (p *nativeProcess) Spec() (string, NativeProcess) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.jobName, p.spec
+}
+
+func (p *nativeProcess) Job() (string, int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.jobName, p.jobIndex
+}
+
+func (p *nativeProcess) Update(jobName string, jobIndex int, spec NativeProcess) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.jobName = jobName
+	p.jobIndex = jobIndex
+	p.spec = spec
+}
+
+func (p *nativeProcess) Monitored() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.monitored
+}
+
+func (p *nativeProcess) SetMonitored(monitored bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.monitored = monitored
+}
+
+func (p *nativeProcess) State() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.state
+}
+
+func (p *nativeProcess) SetState(state string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.state = state
+	p.pid = 0
+}
+
+func (p *nativeProcess) SetRunning(pid int, startedAt time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.state = nativeStateRunning
+	p.pid = pid
+	p.startedAt = startedAt
+}
+
+func (p *nativeProcess) Snapshot() nativeProcessSnapshot {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return nativeProcessSnapshot{
+		Name:      p.spec.Name,
+		State:     p.state,
+		PID:       p.pid,
+		StartedAt: p.startedAt,
+	}
+}
+
+// Begin returns channels for a new supervise loop
+// unless the previous one is still running
+func (p *nativeProcess) Begin() (chan struct{}, chan struct{}, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.doneCh != nil && !isClosed(p.doneCh) {
+		return nil, nil, false
+	}
+
+	p.stopCh = make(chan struct{})
+	p.doneCh = make(chan struct{})
+	p.state = nativeStateStarting
+
+	return p.stopCh, p.doneCh, true
+}
+
+// End stops supervise loop and waits for it to return
+func (p *nativeProcess) End() {
+	p.lock.Lock()
+
+	if p.doneCh == nil {
+		p.state = nativeStateStopped
+		p.lock.Unlock()
+		return
+	}
+
+	doneCh := p.doneCh
+	if !isClosed(p.stopCh) {
+		close(p.stopCh)
+	}
+
+	p.lock.Unlock()
+
+	<-doneCh
+
+	p.SetState(nativeStateStopped)
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,433 @@
+// +build !windows
+
+package jobsupervisor_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	sigar "github.com/cloudfoundry/gosigar"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "github.com/cloudfoundry/bosh-agent/agent/alert"
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+var _ = Describe("nativeJobSupervisor", func() {
+	var (
+		baseDir     string
+		fs          boshsys.FileSystem
+		logger      boshlog.Logger
+		dirProvider boshdir.Provider
+		options     NativeJobSupervisorOptions
+		supervisor  JobSupervisor
+
+		alertsLock sync.Mutex
+		alerts     []boshalert.MonitAlert
+	)
+
+	BeforeEach(func() {
+		var err error
+		baseDir, err = ioutil.TempDir("", "native-job-supervisor")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		dirProvider = boshdir.NewProvider(baseDir)
+		options = NativeJobSupervisorOptions{
+			StopTimeout:     2 * time.Second,
+			RestartDelay:    10 * time.Millisecond,
+			MaxRestartDelay: 100 * time.Millisecond,
+			PollInterval:    10 * time.Millisecond,
+		}
+
+		alerts = nil
+	})
+
+	newSupervisor := func() JobSupervisor {
+		return NewNativeJobSupervisor(fs, logger, dirProvider, clock.NewClock(), options)
+	}
+
+	JustBeforeEach(func() {
+		supervisor = newSupervisor()
+
+		err := supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+			alertsLock.Lock()
+			defer alertsLock.Unlock()
+			alerts = append(alerts, alert)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(supervisor.Stop()).To(Succeed())
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	receivedAlerts := func() []boshalert.MonitAlert {
+		alertsLock.Lock()
+		defer alertsLock.Unlock()
+		return append([]boshalert.MonitAlert{}, alerts...)
+	}
+
+	addJob := func(jobName string, jobIndex int, config string) {
+		configPath := filepath.Join(baseDir, jobName+".monit")
+		Expect(ioutil.WriteFile(configPath, []byte(config), 0644)).To(Succeed())
+		Expect(supervisor.AddJob(jobName, jobIndex, configPath)).To(Succeed())
+	}
+
+	shellJob := func(processName, script, restart string) string {
+		config, err := json.Marshal(NativeProcessConfig{
+			Processes: []NativeProcess{{
+				Name:       processName,
+				Executable: "/bin/sh",
+				Args:       []string{"-c", script},
+				Restart:    RestartPolicy(restart),
+			}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return string(config)
+	}
+
+	processNamed := func(name string) func() Process {
+		return func() Process {
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			for _, process := range processes {
+				if process.Name == name {
+					return process
+				}
+			}
+			return Process{}
+		}
+	}
+
+	stateOf := func(name string) func() string {
+		return func() string {
+			return processNamed(name)().State
+		}
+	}
+
+	// Zombies are not alive; they may wait for init to reap them
+	isAlive := func(pid int) bool {
+		var procState sigar.ProcState
+		return syscall.Kill(pid, 0) == nil && procState.Get(pid) == nil && procState.State != sigar.RunStateZombie
+	}
+
+	Describe("AddJob", func() {
+		It("saves parsed job processes in monit jobs dir", func() {
+			addJob("fake-job", 1, shellJob("fake-process", "exec sleep 100", ""))
+
+			jobConfig, err := ioutil.ReadFile(filepath.Join(dirProvider.MonitJobsDir(), "0001_fake-job.json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(jobConfig)).To(ContainSubstring(`"name":"fake-process"`))
+		})
+
+		It("skips jobs without processes", func() {
+			addJob("fake-job", 1, "")
+			Expect(filepath.Join(dirProvider.MonitJobsDir(), "0001_fake-job.json")).ToNot(BeAnExistingFile())
+		})
+
+		It("returns an error when job config cannot be parsed", func() {
+			configPath := filepath.Join(baseDir, "fake-job.monit")
+			Expect(ioutil.WriteFile(configPath, []byte(`{"processes":[{"name":"fake-process"}]}`), 0644)).To(Succeed())
+
+			err := supervisor.AddJob("fake-job", 1, configPath)
+			Expect(err).To(MatchError(ContainSubstring("Parsing job config for 'fake-job'")))
+		})
+	})
+
+	Describe("Start", func() {
+		It("runs job processes and reports their pid and vitals", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "echo fake-output; exec sleep 100", ""))
+			Expect(supervisor.Reload()).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			Expect(processNamed("fake-process")().PID).To(BeNumerically(">", 0))
+			Expect(processNamed("fake-process")().Memory.Kb).To(BeNumerically(">", 0))
+			Expect(supervisor.Status()).To(Equal("running"))
+
+			Eventually(func() string {
+				output, _ := ioutil.ReadFile(filepath.Join(dirProvider.LogsDir(), "fake-job", "fake-process.stdout.log"))
+				return string(output)
+			}).Should(Equal("fake-output\n"))
+		})
+
+		It("restarts processes that exit and reports their failures", func() {
+			marker := filepath.Join(baseDir, "started-once")
+			addJob("fake-job", 0, shellJob("fake-process",
+				fmt.Sprintf("if [ -f %[1]s ]; then exec sleep 100; fi; touch %[1]s; exit 3", marker), ""))
+			Expect(supervisor.Reload()).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(receivedAlerts).Should(HaveLen(1))
+			alert := receivedAlerts()[0]
+			Expect(alert.ID).ToNot(BeEmpty())
+			Expect(alert.Service).To(Equal("fake-process"))
+			Expect(alert.Event).To(Equal("pid failed"))
+			Expect(alert.Action).To(Equal("Restart"))
+			Expect(alert.Description).To(Equal("exited with code 3"))
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			Consistently(receivedAlerts, 100*time.Millisecond).Should(HaveLen(1))
+		})
+
+		It("leaves processes that exit successfully when they restart only on failure", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exit 0", "on-failure"))
+			Expect(supervisor.Reload()).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("completed"))
+			Expect(supervisor.Status()).To(Equal("running"))
+			Expect(receivedAlerts()).To(BeEmpty())
+		})
+
+		It("reports processes that are never restarted as failing", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exit 1", "never"))
+			Expect(supervisor.Reload()).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("failing"))
+			Expect(supervisor.Status()).To(Equal("failing"))
+			Expect(receivedAlerts()).To(HaveLen(1))
+			Expect(receivedAlerts()[0].Action).To(Equal("Alert"))
+		})
+
+		It("reports processes that cannot be started", func() {
+			config := `{"processes":[{"name":"fake-process","executable":"/does/not/exist","restart":"never"}]}`
+			addJob("fake-job", 0, config)
+			Expect(supervisor.Reload()).To(Succeed())
+
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(receivedAlerts).Should(HaveLen(1))
+			Expect(receivedAlerts()[0].Event).To(Equal("execution failed"))
+			Eventually(stateOf("fake-process")).Should(Equal("failing"))
+		})
+
+		It("removes stopped file", func() {
+			Expect(supervisor.Stop()).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("stopped"))
+
+			Expect(supervisor.Start()).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("terminates processes", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exec sleep 100", ""))
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			pid := processNamed("fake-process")().PID
+
+			Expect(supervisor.Stop()).To(Succeed())
+
+			Expect(isAlive(pid)).To(BeFalse())
+			Expect(processNamed("fake-process")()).To(Equal(Process{Name: "fake-process", State: "stopped"}))
+			Expect(supervisor.Status()).To(Equal("stopped"))
+			Expect(receivedAlerts()).To(BeEmpty())
+		})
+	})
+
+	Describe("Unmonitor", func() {
+		It("neither restarts nor reports processes that exit", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exec sleep 100", ""))
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			pid := processNamed("fake-process")().PID
+
+			Expect(supervisor.Unmonitor()).To(Succeed())
+			Expect(supervisor.Status()).To(Equal("failing"))
+
+			Expect(syscall.Kill(pid, syscall.SIGKILL)).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("unknown"))
+			Expect(receivedAlerts()).To(BeEmpty())
+		})
+	})
+
+	Describe("services", func() {
+		JustBeforeEach(func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exec sleep 100", ""))
+			addJob("fake-job_extra", 0, shellJob("extra-process", "exec sleep 100", ""))
+			addJob("other-job", 1, shellJob("other-process", "exec sleep 100", ""))
+
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			Eventually(stateOf("other-process")).Should(Equal("running"))
+		})
+
+		It("lists processes of job and of its additional monit files", func() {
+			Expect(supervisor.JobServices("fake-job")).To(Equal([]string{"fake-process", "extra-process"}))
+			Expect(supervisor.JobServices("other-job")).To(Equal([]string{"other-process"}))
+			Expect(supervisor.JobServices("unknown")).To(BeEmpty())
+		})
+
+		It("stops and starts only given processes", func() {
+			pid := processNamed("fake-process")().PID
+
+			Expect(supervisor.StopServices([]string{"fake-process"})).To(Succeed())
+
+			Expect(isAlive(pid)).To(BeFalse())
+			Expect(stateOf("fake-process")()).To(Equal("stopped"))
+			Expect(stateOf("other-process")()).To(Equal("running"))
+
+			Expect(supervisor.StartServices([]string{"fake-process"})).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			Expect(receivedAlerts()).To(BeEmpty())
+		})
+
+		It("neither restarts nor reports unmonitored processes that exit", func() {
+			pid := processNamed("fake-process")().PID
+
+			Expect(supervisor.UnmonitorServices([]string{"fake-process"})).To(Succeed())
+			Expect(syscall.Kill(pid, syscall.SIGKILL)).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("unknown"))
+			Expect(receivedAlerts()).To(BeEmpty())
+		})
+
+		It("returns an error for unknown processes", func() {
+			err := supervisor.StopServices([]string{"fake-process", "unknown"})
+			Expect(err).To(MatchError("Service 'unknown' could not be found"))
+			Expect(stateOf("fake-process")()).To(Equal("running"))
+		})
+	})
+
+	Describe("Reload", func() {
+		It("stops processes of removed jobs", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exec sleep 100", ""))
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			pid := processNamed("fake-process")().PID
+
+			Expect(supervisor.RemoveAllJobs()).To(Succeed())
+			Expect(supervisor.Reload()).To(Succeed())
+
+			Expect(isAlive(pid)).To(BeFalse())
+			Expect(supervisor.Processes()).To(BeEmpty())
+		})
+	})
+
+	Context("when processes daemonize themselves", func() {
+		var pidFile string
+
+		BeforeEach(func() {
+			pidFile = filepath.Join(baseDir, "fake-process.pid")
+		})
+
+		JustBeforeEach(func() {
+			ctlPath := filepath.Join(baseDir, "ctl")
+			ctl := fmt.Sprintf(`
+case $1 in
+  start) sleep 100 & echo $! > %[1]s ;;
+  stop) kill $(cat %[1]s) ;;
+esac
+`, pidFile)
+			Expect(ioutil.WriteFile(ctlPath, []byte(ctl), 0755)).To(Succeed())
+
+			addJob("fake-job", 0, fmt.Sprintf(`
+check process fake-process
+  with pidfile %[1]s
+  start program "/bin/sh %[2]s start"
+  stop program "/bin/sh %[2]s stop"
+  group vcap
+`, pidFile, ctlPath))
+			Expect(supervisor.Reload()).To(Succeed())
+		})
+
+		It("follows them through their pid files", func() {
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+
+			pidContent, err := ioutil.ReadFile(pidFile)
+			Expect(err).ToNot(HaveOccurred())
+
+			pid := processNamed("fake-process")().PID
+			Expect(fmt.Sprintf("%d\n", pid)).To(Equal(string(pidContent)))
+
+			Expect(supervisor.Stop()).To(Succeed())
+			Eventually(func() bool { return isAlive(pid) }).Should(BeFalse())
+		})
+
+		It("restarts them once they go away", func() {
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			pid := processNamed("fake-process")().PID
+
+			Expect(syscall.Kill(pid, syscall.SIGKILL)).To(Succeed())
+
+			Eventually(receivedAlerts).Should(HaveLen(1))
+			Expect(receivedAlerts()[0].Description).To(Equal("is not running"))
+
+			Eventually(func() int { return processNamed("fake-process")().PID }).Should(And(
+				BeNumerically(">", 0),
+				Not(Equal(pid)),
+			))
+		})
+	})
+
+	Context("when agent restarts", func() {
+		It("adopts processes that are still running", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exec sleep 100", ""))
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Start()).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			pid := processNamed("fake-process")().PID
+
+			// Previous agent no longer looks after its processes
+			Expect(supervisor.Unmonitor()).To(Succeed())
+
+			supervisor = newSupervisor()
+			Expect(supervisor.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })).To(Succeed())
+
+			Eventually(stateOf("fake-process")).Should(Equal("running"))
+			Expect(processNamed("fake-process")().PID).To(Equal(pid))
+
+			Expect(supervisor.Stop()).To(Succeed())
+			Eventually(func() bool { return isAlive(pid) }).Should(BeFalse())
+		})
+
+		It("does not start jobs that were stopped", func() {
+			addJob("fake-job", 0, shellJob("fake-process", "exec sleep 100", ""))
+			Expect(supervisor.Reload()).To(Succeed())
+			Expect(supervisor.Stop()).To(Succeed())
+
+			supervisor = newSupervisor()
+			Expect(supervisor.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })).To(Succeed())
+
+			Consistently(stateOf("fake-process"), 50*time.Millisecond).Should(Equal("unknown"))
+		})
+	})
+})
@@ -0,0 +1,385 @@
+// +build !windows
+
+package jobsupervisor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	sigar "github.com/cloudfoundry/gosigar"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Same environment monit gives to programs it runs
+const nativeDefaultPath = "/usr/sbin:/usr/bin:/sbin:/bin"
+
+type nativeExitStatus struct {
+	code   int
+	signal syscall.Signal
+
+	// Exit status is unknown for processes that are not agent children
+	unknown bool
+}
+
+func (s nativeExitStatus) Succeeded() bool {
+	return !s.unknown && s.signal == 0 && s.code == 0
+}
+
+func (s nativeExitStatus) Description() string {
+	switch {
+	case s.unknown:
+		return "is not running"
+	case s.signal != 0:
+		return fmt.Sprintf("was killed by signal %s", s.signal)
+	default:
+		return fmt.Sprintf("exited with code %d", s.code)
+	}
+}
+
+// nativeInstance is a single run of a process
+type nativeInstance struct {
+	pid       int
+	startedAt time.Time
+
+	exited chan nativeExitStatus
+
+	// Closing stops polling of processes that are not agent children
+	stopPolling chan struct{}
+}
+
+func (i *nativeInstance) stopWatching() {
+	if i.stopPolling != nil && !isClosed(i.stopPolling) {
+		close(i.stopPolling)
+	}
+}
+
+// launch adopts process that is still running from previous agent run
+// or starts a new one
+func (n *nativeJobSupervisor) launch(jobName string, spec NativeProcess) (*nativeInstance, error) {
+	pidFilePath := n.pidFilePath(spec)
+
+	if pid, found := n.runningPid(pidFilePath); found {
+		n.logger.Info(nativeJobSupervisorLogTag, "Adopting already running process %s with pid %d", spec.Name, pid)
+		return n.watch(pid), nil
+	}
+
+	if spec.Daemonizes() {
+		return n.launchDaemon(jobName, spec)
+	}
+
+	return n.launchForeground(jobName, spec)
+}
+
+func (n *nativeJobSupervisor) launchForeground(jobName string, spec NativeProcess) (*nativeInstance, error) {
+	err := n.fs.MkdirAll(path.Join(n.dirProvider.LogsDir(), jobName), os.FileMode(0750))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating log directory for process '%s'", spec.Name)
+	}
+
+	// Files are handed to the process directly so that
+	// its output does not depend on the agent copying it
+	stdout, err := os.OpenFile(n.logFilePath(jobName, spec.Name, "stdout"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.FileMode(0640))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Opening stdout log for process '%s'", spec.Name)
+	}
+	defer stdout.Close()
+
+	stderr, err := os.OpenFile(n.logFilePath(jobName, spec.Name, "stderr"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.FileMode(0640))
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Opening stderr log for process '%s'", spec.Name)
+	}
+	defer stderr.Close()
+
+	cmd := n.command(spec, spec.Executable, spec.Args)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	n.logger.Debug(nativeJobSupervisorLogTag, "Running process %s: %s", spec.Name, strings.Join(cmd.Args, " "))
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Running '%s'", spec.Executable)
+	}
+
+	instance := &nativeInstance{
+		pid:       cmd.Process.Pid,
+		startedAt: n.timeService.Now(),
+		exited:    make(chan nativeExitStatus, 1),
+	}
+
+	go func() {
+		_ = cmd.Wait()
+		instance.exited <- exitStatusOf(cmd.ProcessState)
+	}()
+
+	err = n.fs.MkdirAll(path.Dir(n.pidFilePath(spec)), os.FileMode(0750))
+	if err == nil {
+		err = n.fs.WriteFileString(n.pidFilePath(spec), strconv.Itoa(instance.pid))
+	}
+	if err != nil {
+		n.logger.Warn(nativeJobSupervisorLogTag, "Writing pid file for process %s: %s", spec.Name, err.Error())
+	}
+
+	return instance, nil
+}
+
+func (n *nativeJobSupervisor) launchDaemon(jobName string, spec NativeProcess) (*nativeInstance, error) {
+	timeout := spec.timeout(n.options.StopTimeout)
+
+	err := n.runProgram(spec, spec.Executable, spec.Args, timeout)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Running start program for process '%s'", spec.Name)
+	}
+
+	timer := n.timeService.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		if pid, found := n.runningPid(spec.PidFile); found {
+			return n.watch(pid), nil
+		}
+
+		select {
+		case <-timer.C():
+			return nil, bosherr.Errorf("Timed out waiting for process '%s' to write pid file '%s'", spec.Name, spec.PidFile)
+		case <-n.timeService.After(n.options.PollInterval):
+		}
+	}
+}
+
+// runProgram runs start or stop program and waits for it to finish
+func (n *nativeJobSupervisor) runProgram(spec NativeProcess, executable string, args []string, timeout time.Duration) error {
+	cmd := n.command(spec, executable, args)
+
+	n.logger.Debug(nativeJobSupervisorLogTag, "Running program for process %s: %s", spec.Name, strings.Join(cmd.Args, " "))
+
+	err := cmd.Start()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running '%s'", executable)
+	}
+
+	waitCh := make(chan error, 1)
+	go func() { waitCh <- cmd.Wait() }()
+
+	select {
+	case err = <-waitCh:
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Running '%s'", executable)
+		}
+		return nil
+
+	case <-n.timeService.After(timeout):
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-waitCh
+		return bosherr.Errorf("Timed out running '%s' after %s", executable, timeout)
+	}
+}
+
+func (n *nativeJobSupervisor) command(spec NativeProcess, executable string, args []string) *exec.Cmd {
+	cmd := exec.Command(executable, args...)
+
+	cmd.Dir = spec.WorkingDir
+	if cmd.Dir == "" {
+		cmd.Dir = "/"
+	}
+
+	cmd.Env = []string{"PATH=" + nativeDefaultPath}
+	for name, value := range spec.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	// Own process group lets the whole process tree be signalled
+	// and keeps processes running if the agent restarts
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	return cmd
+}
+
+// watch polls process that is not an agent child until it goes away
+func (n *nativeJobSupervisor) watch(pid int) *nativeInstance {
+	instance := &nativeInstance{
+		pid:         pid,
+		startedAt:   n.timeService.Now(),
+		exited:      make(chan nativeExitStatus, 1),
+		stopPolling: make(chan struct{}),
+	}
+
+	var procTime sigar.ProcTime
+	if procTime.Get(pid) == nil {
+		instance.startedAt = time.Unix(0, int64(procTime.StartTime)*int64(time.Millisecond))
+	}
+
+	go func() {
+		for {
+			select {
+			case <-instance.stopPolling:
+				return
+			case <-n.timeService.After(n.options.PollInterval):
+			}
+
+			if !isProcessAlive(pid) {
+				instance.exited <- nativeExitStatus{unknown: true}
+				return
+			}
+		}
+	}()
+
+	return instance
+}
+
+// terminate stops process with its stop program or SIGTERM
+// and kills it if it does not exit within stop timeout
+func (n *nativeJobSupervisor) terminate(spec NativeProcess, instance *nativeInstance) {
+	timeout := spec.timeout(n.options.StopTimeout)
+
+	if spec.Stop != nil {
+		err := n.runProgram(spec, spec.Stop.Executable, spec.Stop.Args, timeout)
+		if err != nil {
+			n.logger.Error(nativeJobSupervisorLogTag, "Running stop program for process %s: %s", spec.Name, err.Error())
+		}
+	} else {
+		n.signal(instance.pid, syscall.SIGTERM)
+	}
+
+	select {
+	case <-instance.exited:
+	case <-n.timeService.After(timeout):
+		n.logger.Warn(nativeJobSupervisorLogTag, "Killing process %s that did not stop after %s", spec.Name, timeout)
+		n.signal(instance.pid, syscall.SIGKILL)
+
+		select {
+		case <-instance.exited:
+		case <-n.timeService.After(timeout):
+			n.logger.Error(nativeJobSupervisorLogTag, "Process %s did not exit after being killed", spec.Name)
+		}
+	}
+
+	instance.stopWatching()
+
+	if !spec.Daemonizes() {
+		_ = n.fs.RemoveAll(n.pidFilePath(spec))
+	}
+}
+
+// signal sends signal to process group, falling back
+// to the process itself if it is not a group leader
+func (n *nativeJobSupervisor) signal(pid int, sig syscall.Signal) {
+	err := syscall.Kill(-pid, sig)
+	if err != nil {
+		err = syscall.Kill(pid, sig)
+	}
+	if err != nil && err != syscall.ESRCH {
+		n.logger.Warn(nativeJobSupervisorLogTag, "Sending %s to pid %d: %s", sig, pid, err.Error())
+	}
+}
+
+// runningPid returns pid from pid file if that process is alive
+// and has been started before pid file was written. The latter
+// guards against pids reused after reboot.
+func (n *nativeJobSupervisor) runningPid(pidFilePath string) (int, bool) {
+	pidFileInfo, err := n.fs.Stat(pidFilePath)
+	if err != nil {
+		return 0, false
+	}
+
+	pidContent, err := n.fs.ReadFileString(pidFilePath)
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(pidContent))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	if !isProcessAlive(pid) {
+		return 0, false
+	}
+
+	var procTime sigar.ProcTime
+	if procTime.Get(pid) == nil {
+		startedAt := time.Unix(0, int64(procTime.StartTime)*int64(time.Millisecond))
+		if startedAt.After(pidFileInfo.ModTime().Add(time.Second)) {
+			return 0, false
+		}
+	}
+
+	return pid, true
+}
+
+func isProcessAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	if err != nil && err != syscall.EPERM {
+		return false
+	}
+
+	var procState sigar.ProcState
+	if procState.Get(pid) == nil && procState.State == sigar.RunStateZombie {
+		return false
+	}
+
+	return true
+}
+
+func exitStatusOf(state *os.ProcessState) nativeExitStatus {
+	if waitStatus, ok := state.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+		return nativeExitStatus{signal: waitStatus.Signal()}
+	}
+	return nativeExitStatus{code: state.ExitCode()}
+}
+
+type nativeCPUSample struct {
+	pid   int
+	total uint64
+	at    time.Time
+}
+
+// vitals sums memory and CPU of the process and all its
+// descendants, same as monit totals do
+func (n *nativeJobSupervisor) vitals(snapshot nativeProcessSnapshot, tree processTree) (MemoryVitals, CPUVitals) {
+	var residentBytes, cpuTotal uint64
+
+	for _, pid := range tree.Descendants(snapshot.PID) {
+		var procMem sigar.ProcMem
+		if procMem.Get(pid) == nil {
+			residentBytes += procMem.Resident
+		}
+
+		var procTime sigar.ProcTime
+		if procTime.Get(pid) == nil {
+			cpuTotal += procTime.Total
+		}
+	}
+
+	memory := MemoryVitals{Kb: int(residentBytes / 1024)}
+
+	var mem sigar.Mem
+	if mem.Get() == nil && mem.Total > 0 {
+		memory.Percent = float64(residentBytes) / float64(mem.Total) * 100
+	}
+
+	now := n.timeService.Now()
+
+	n.cpuSamplesLock.Lock()
+	previous, found := n.cpuSamples[snapshot.Name]
+	n.cpuSamples[snapshot.Name] = nativeCPUSample{pid: snapshot.PID, total: cpuTotal, at: now}
+	n.cpuSamplesLock.Unlock()
+
+	var cpu CPUVitals
+
+	// CPU usage is relative to the previous sample of the same process;
+	// ProcTime totals are in milliseconds
+	elapsed := now.Sub(previous.at)
+	if found && previous.pid == snapshot.PID && elapsed > 0 && cpuTotal >= previous.total {
+		cpu.Total = float64(cpuTotal-previous.total) / float64(elapsed/time.Millisecond) * 100
+	}
+
+	return memory, cpu
+}
@@ -0,0 +1,270 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type RestartPolicy string
+
+const (
+	// RestartAlways restarts process whenever it exits (same as monit does)
+	RestartAlways RestartPolicy = "always"
+
+	// RestartOnFailure restarts process only if it exits with non-zero code or is killed
+	RestartOnFailure RestartPolicy = "on-failure"
+
+	// RestartNever leaves process exited
+	RestartNever RestartPolicy = "never"
+)
+
+type NativeCommand struct {
+	Executable string   `json:"executable"`
+	Args       []string `json:"args,omitempty"`
+}
+
+// NativeProcess describes a single process run by native job supervisor.
+// Process either runs in foreground (supervisor follows it as its child)
+// or daemonizes itself and writes its pid into PidFile.
+type NativeProcess struct {
+	Name       string            `json:"name"`
+	Executable string            `json:"executable"`
+	Args       []string          `json:"args,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+
+	// Defaults to RestartAlways
+	Restart RestartPolicy `json:"restart,omitempty"`
+
+	// Set for processes that daemonize themselves (e.g. monit start programs)
+	PidFile string `json:"pidfile,omitempty"`
+
+	// Optional; process is sent SIGTERM when not given
+	Stop *NativeCommand `json:"stop,omitempty"`
+
+	// How long start and stop programs may run, in seconds
+	Timeout int `json:"timeout,omitempty"`
+}
+
+func (p NativeProcess) RestartPolicy() RestartPolicy {
+	if p.Restart == "" {
+		return RestartAlways
+	}
+	return p.Restart
+}
+
+func (p NativeProcess) Daemonizes() bool {
+	return p.PidFile != ""
+}
+
+func (p NativeProcess) timeout(defaultTimeout time.Duration) time.Duration {
+	if p.Timeout > 0 {
+		return time.Duration(p.Timeout) * time.Second
+	}
+	return defaultTimeout
+}
+
+// NativeProcessConfig is a per-job process spec. It uses the same
+// format as WindowsProcessConfig with restart policy and pid file added.
+type NativeProcessConfig struct {
+	Processes []NativeProcess `json:"processes"`
+
+	// Names of monit checks other than process checks (files, hosts, etc.)
+	// that native job supervisor does not run
+	SkippedChecks []string `json:"-"`
+}
+
+// ParseNativeProcessConfig accepts either JSON process spec or job monit file.
+// Only `check process` statements with pid file and start program are
+// taken from monit files; resource tests and other check types are skipped.
+func ParseNativeProcessConfig(contents []byte) (NativeProcessConfig, error) {
+	var config NativeProcessConfig
+
+	trimmedContents := strings.TrimSpace(string(contents))
+	if trimmedContents == "" {
+		return config, nil
+	}
+
+	var err error
+
+	if strings.HasPrefix(trimmedContents, "{") {
+		err = json.Unmarshal([]byte(trimmedContents), &config)
+		if err != nil {
+			return config, bosherr.WrapError(err, "Unmarshalling process spec")
+		}
+	} else {
+		config, err = parseMonitProcessConfig(trimmedContents)
+		if err != nil {
+			return config, bosherr.WrapError(err, "Parsing monit file")
+		}
+	}
+
+	names := map[string]bool{}
+
+	for _, process := range config.Processes {
+		if process.Name == "" {
+			return config, bosherr.Error("Process name must be specified")
+		}
+
+		if names[process.Name] {
+			return config, bosherr.Errorf("Process '%s' is specified more than once", process.Name)
+		}
+		names[process.Name] = true
+
+		if process.Executable == "" {
+			return config, bosherr.Errorf("Executable for process '%s' must be specified", process.Name)
+		}
+
+		switch process.Restart {
+		case "", RestartAlways, RestartOnFailure, RestartNever:
+		default:
+			return config, bosherr.Errorf("Unknown restart policy '%s' for process '%s'", process.Restart, process.Name)
+		}
+	}
+
+	return config, nil
+}
+
+func parseMonitProcessConfig(contents string) (NativeProcessConfig, error) {
+	var config NativeProcessConfig
+	var current *NativeProcess
+
+	tokens := tokenizeMonitConfig(contents)
+
+	finishCurrent := func() error {
+		if current == nil {
+			return nil
+		}
+		if current.PidFile == "" {
+			return bosherr.Errorf("Process '%s' must be checked with pidfile", current.Name)
+		}
+		if current.Executable == "" {
+			return bosherr.Errorf("Process '%s' must have start program", current.Name)
+		}
+		config.Processes = append(config.Processes, *current)
+		current = nil
+		return nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		keyword := strings.ToLower(token.value)
+
+		if token.quoted {
+			continue
+		}
+
+		switch keyword {
+		case "check":
+			err := finishCurrent()
+			if err != nil {
+				return config, err
+			}
+
+			if i+2 >= len(tokens) {
+				return config, bosherr.Error("Incomplete check statement")
+			}
+
+			checkType, name := strings.ToLower(tokens[i+1].value), tokens[i+2].value
+			i += 2
+
+			if checkType == "process" {
+				current = &NativeProcess{Name: name}
+			} else {
+				config.SkippedChecks = append(config.SkippedChecks, name)
+			}
+
+		case "matching":
+			if current != nil {
+				return config, bosherr.Errorf("Process '%s' is checked by matching its name which is not supported", current.Name)
+			}
+
+		case "pidfile":
+			if current != nil && i+1 < len(tokens) {
+				i++
+				current.PidFile = tokens[i].value
+			}
+
+		case "start", "stop":
+			if current == nil {
+				continue
+			}
+
+			// Accept `start program = "..."` as well as `start "..."`;
+			// `then stop` in resource tests is not followed by a program
+			j := i + 1
+			if j < len(tokens) && !tokens[j].quoted && strings.ToLower(tokens[j].value) == "program" {
+				j++
+			}
+			if j < len(tokens) && !tokens[j].quoted && tokens[j].value == "=" {
+				j++
+			}
+			if j >= len(tokens) || !tokens[j].quoted {
+				continue
+			}
+			i = j
+
+			// Monit does not run programs through shell, it splits them on whitespace
+			args := strings.Fields(tokens[j].value)
+			if len(args) == 0 {
+				return config, bosherr.Errorf("Empty %s program for process '%s'", keyword, current.Name)
+			}
+
+			if keyword == "start" {
+				current.Executable = args[0]
+				current.Args = args[1:]
+			} else {
+				current.Stop = &NativeCommand{Executable: args[0], Args: args[1:]}
+			}
+
+			timeout, consumed := parseMonitTimeout(tokens[j+1:])
+			if timeout > current.Timeout {
+				current.Timeout = timeout
+			}
+			i += consumed
+		}
+	}
+
+	err := finishCurrent()
+	if err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// parseMonitTimeout parses optional `with timeout N seconds` following a program
+func parseMonitTimeout(tokens []monitToken) (int, int) {
+	i := 0
+
+	if i < len(tokens) && strings.ToLower(tokens[i].value) == "with" {
+		i++
+	}
+	if i >= len(tokens) || strings.ToLower(tokens[i].value) != "timeout" {
+		return 0, 0
+	}
+	i++
+
+	if i >= len(tokens) {
+		return 0, 0
+	}
+
+	timeout, err := strconv.Atoi(tokens[i].value)
+	if err != nil {
+		return 0, 0
+	}
+	i++
+
+	if i < len(tokens) {
+		unit := strings.ToLower(tokens[i].value)
+		if unit == "seconds" || unit == "second" {
+			i++
+		}
+	}
+
+	return timeout, i
+}
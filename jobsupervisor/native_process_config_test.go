@@ -0,0 +1,119 @@
+package jobsupervisor_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/jobsupervisor"
+)
+
+var _ = Describe("ParseNativeProcessConfig", func() {
+	It("returns no processes for empty config", func() {
+		config, err := ParseNativeProcessConfig([]byte(" \n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Processes).To(BeEmpty())
+	})
+
+	It("parses JSON process spec", func() {
+		config, err := ParseNativeProcessConfig([]byte(`{
+			"processes": [{
+				"name": "fake-process",
+				"executable": "/var/vcap/packages/fake/bin/fake",
+				"args": ["--port", "8080"],
+				"env": {"FAKE_ENV": "fake-value"},
+				"restart": "on-failure"
+			}]
+		}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Processes).To(Equal([]NativeProcess{{
+			Name:       "fake-process",
+			Executable: "/var/vcap/packages/fake/bin/fake",
+			Args:       []string{"--port", "8080"},
+			Env:        map[string]string{"FAKE_ENV": "fake-value"},
+			Restart:    RestartOnFailure,
+		}}))
+		Expect(config.Processes[0].RestartPolicy()).To(Equal(RestartOnFailure))
+	})
+
+	It("restarts processes always by default", func() {
+		Expect(NativeProcess{}.RestartPolicy()).To(Equal(RestartAlways))
+	})
+
+	It("parses process checks from monit file", func() {
+		config, err := ParseNativeProcessConfig([]byte(`
+check process fake-process
+  with pidfile /var/vcap/sys/run/fake/fake.pid
+  start program "/var/vcap/jobs/fake/bin/ctl start" with timeout 60 seconds
+  stop program = "/var/vcap/jobs/fake/bin/ctl stop"
+  group vcap
+  if totalmem > 100 Mb for 5 cycles then stop # comments are ignored
+
+check file fake-file with path /var/vcap/sys/log/fake.log
+  if does not exist then alert
+
+check process other-process
+  with pidfile "/var/vcap/sys/run/other.pid"
+  start program "/var/vcap/jobs/other/bin/ctl"
+  depends on fake-process
+  group vcap
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Processes).To(Equal([]NativeProcess{
+			{
+				Name:       "fake-process",
+				Executable: "/var/vcap/jobs/fake/bin/ctl",
+				Args:       []string{"start"},
+				PidFile:    "/var/vcap/sys/run/fake/fake.pid",
+				Stop:       &NativeCommand{Executable: "/var/vcap/jobs/fake/bin/ctl", Args: []string{"stop"}},
+				Timeout:    60,
+			},
+			{
+				Name:       "other-process",
+				Executable: "/var/vcap/jobs/other/bin/ctl",
+				Args:       []string{},
+				PidFile:    "/var/vcap/sys/run/other.pid",
+			},
+		}))
+		Expect(config.Processes[0].Daemonizes()).To(BeTrue())
+		Expect(config.SkippedChecks).To(Equal([]string{"fake-file"}))
+	})
+
+	It("returns an error when monit process is checked by matching", func() {
+		_, err := ParseNativeProcessConfig([]byte(`
+check process fake-process matching "fake"
+  start program "/bin/fake"
+`))
+		Expect(err).To(MatchError(ContainSubstring("checked by matching")))
+	})
+
+	It("returns an error when monit process has no start program", func() {
+		_, err := ParseNativeProcessConfig([]byte(`
+check process fake-process
+  with pidfile /var/vcap/sys/run/fake.pid
+`))
+		Expect(err).To(MatchError(ContainSubstring("Process 'fake-process' must have start program")))
+	})
+
+	It("returns an error when process has no executable", func() {
+		_, err := ParseNativeProcessConfig([]byte(`{"processes":[{"name":"fake-process"}]}`))
+		Expect(err).To(MatchError("Executable for process 'fake-process' must be specified"))
+	})
+
+	It("returns an error when process is specified more than once", func() {
+		_, err := ParseNativeProcessConfig([]byte(`{"processes":[
+			{"name":"fake-process","executable":"/bin/true"},
+			{"name":"fake-process","executable":"/bin/true"}
+		]}`))
+		Expect(err).To(MatchError("Process 'fake-process' is specified more than once"))
+	})
+
+	It("returns an error for unknown restart policy", func() {
+		_, err := ParseNativeProcessConfig([]byte(`{"processes":[{"name":"fake-process","executable":"/bin/true","restart":"sometimes"}]}`))
+		Expect(err).To(MatchError("Unknown restart policy 'sometimes' for process 'fake-process'"))
+	})
+
+	It("returns an error for invalid JSON", func() {
+		_, err := ParseNativeProcessConfig([]byte(`{"processes":`))
+		Expect(err).To(MatchError(ContainSubstring("Unmarshalling process spec")))
+	})
+})
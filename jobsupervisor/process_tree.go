@@ -0,0 +1,41 @@
+// +build !windows
+
+package jobsupervisor
+
+import (
+	sigar "github.com/cloudfoundry/gosigar"
+)
+
+// processTree maps pids to their children
+type processTree map[int][]int
+
+func newProcessTree() (processTree, error) {
+	var procList sigar.ProcList
+
+	err := procList.Get()
+	if err != nil {
+		return processTree{}, err
+	}
+
+	tree := processTree{}
+
+	for _, pid := range procList.List {
+		var procState sigar.ProcState
+		if procState.Get(pid) == nil {
+			tree[procState.Ppid] = append(tree[procState.Ppid], pid)
+		}
+	}
+
+	return tree, nil
+}
+
+// Descendants includes pid itself
+func (t processTree) Descendants(pid int) []int {
+	pids := []int{pid}
+
+	for i := 0; i < len(pids); i++ {
+		pids = append(pids, t[pids[i]]...)
+	}
+
+	return pids
+}
@@ -0,0 +1,17 @@
+package jobsupervisor
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// processTree maps pids to their children
+type processTree map[int][]int
+
+func newProcessTree() (processTree, error) {
+	return processTree{}, bosherr.Error("Listing process tree is not supported on Windows")
+}
+
+// Descendants includes pid itself
+func (t processTree) Descendants(pid int) []int {
+	return []int{pid}
+}
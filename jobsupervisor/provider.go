@@ -8,6 +8,7 @@ import (
 	"code.cloudfoundry.org/clock"
 
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -27,6 +28,7 @@ func NewProvider(
 	logger boshlog.Logger,
 	dirProvider boshdir.Provider,
 	handler boshhandler.Handler,
+	healthChecker healthcheck.Checker,
 ) (p Provider) {
 	timeService := clock.NewClock()
 	fs := platform.GetFs()
@@ -46,8 +48,30 @@ func NewProvider(
 		timeService,
 	)
 
+	nativeJobSupervisor := NewNativeJobSupervisor(
+		fs,
+		logger,
+		dirProvider,
+		timeService,
+		NativeJobSupervisorOptions{
+			StopTimeout:     30 * time.Second,
+			RestartDelay:    1 * time.Second,
+			MaxRestartDelay: 1 * time.Minute,
+			PollInterval:    1 * time.Second,
+		},
+	)
+
+	cgroupManager := platform.GetCgroupManager()
+
+	decoratedJobSupervisor := func(delegate JobSupervisor) JobSupervisor {
+		delegate = NewCgroupJobSupervisor(delegate, cgroupManager, timeService, 5*time.Second, logger)
+		delegate = NewHealthCheckJobSupervisor(delegate, healthChecker, timeService, logger)
+		return NewWrapperJobSupervisor(delegate, fs, dirProvider, logger)
+	}
+
 	p.supervisors = map[string]JobSupervisor{
-		"monit":      NewWrapperJobSupervisor(monitJobSupervisor, fs, dirProvider, logger),
+		"monit":      decoratedJobSupervisor(monitJobSupervisor),
+		"native":     decoratedJobSupervisor(nativeJobSupervisor),
 		"dummy":      NewDummyJobSupervisor(),
 		"dummy-nats": NewDummyNatsJobSupervisor(handler),
 	}
@@ -9,6 +9,8 @@ import (
 	. "github.com/onsi/gomega"
 
 	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck/healthcheckfakes"
+	"github.com/cloudfoundry/bosh-agent/platform/cgroup/cgroupfakes"
 	"github.com/cloudfoundry/bosh-agent/platform/platformfakes"
 
 	fakemonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit/fakes"
@@ -34,6 +36,8 @@ func init() {
 			provider              Provider
 			timeService           clock.Clock
 			jobSupervisorName     string
+			cgroupManager         *cgroupfakes.FakeManager
+			healthChecker         *healthcheckfakes.FakeChecker
 		)
 
 		BeforeEach(func() {
@@ -46,9 +50,12 @@ func init() {
 			jobFailuresServerPort = 2825
 			handler = &fakembus.FakeHandler{}
 			timeService = clock.NewClock()
+			cgroupManager = &cgroupfakes.FakeManager{}
+			healthChecker = &healthcheckfakes.FakeChecker{}
 
 			platform.GetFsReturns(fileSystem)
 			platform.GetRunnerReturns(cmdRunner)
+			platform.GetCgroupManagerReturns(cgroupManager)
 
 			provider = NewProvider(
 				platform,
@@ -56,6 +63,7 @@ func init() {
 				logger,
 				dirProvider,
 				handler,
+				healthChecker,
 			)
 			if runtime.GOOS == "windows" {
 				jobSupervisorName = "windows"
@@ -85,8 +93,23 @@ func init() {
 					timeService,
 				)
 
-				expectedSupervisor := NewWrapperJobSupervisor(
+				limitedSupervisor := NewCgroupJobSupervisor(
 					delegateSupervisor,
+					cgroupManager,
+					timeService,
+					5*time.Second,
+					logger,
+				)
+
+				checkedSupervisor := NewHealthCheckJobSupervisor(
+					limitedSupervisor,
+					healthChecker,
+					timeService,
+					logger,
+				)
+
+				expectedSupervisor := NewWrapperJobSupervisor(
+					checkedSupervisor,
 					fileSystem,
 					dirProvider,
 					logger,
@@ -96,6 +119,16 @@ func init() {
 			}
 		})
 
+		It("provides a native job supervisor", func() {
+			if runtime.GOOS == "windows" {
+				Skip("Native job supervisor is not available on Windows")
+			}
+
+			actualSupervisor, err := provider.Get("native")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualSupervisor).ToNot(BeNil())
+		})
+
 		It("provides a dummy job supervisor", func() {
 			actualSupervisor, err := provider.Get("dummy")
 			Expect(err).ToNot(HaveOccurred())
@@ -5,7 +5,10 @@ package jobsupervisor
 import (
 	"os"
 
+	"code.cloudfoundry.org/clock"
+
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/jobsupervisor/healthcheck"
 	boshmonit "github.com/cloudfoundry/bosh-agent/jobsupervisor/monit"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshdir "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -25,7 +28,9 @@ func NewProvider(
 	logger boshlog.Logger,
 	dirProvider boshdir.Provider,
 	handler boshhandler.Handler,
+	healthChecker healthcheck.Checker,
 ) (p Provider) {
+	timeService := clock.NewClock()
 	fs := platform.GetFs()
 	runner := platform.GetRunner()
 
@@ -38,11 +43,17 @@ func NewProvider(
 		machineIP = network.IP
 	}
 
+	windowsJobSupervisor := func() JobSupervisor {
+		delegate := NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP)
+		delegate = NewHealthCheckJobSupervisor(delegate, healthChecker, timeService, logger)
+		return NewWrapperJobSupervisor(delegate, fs, dirProvider, logger)
+	}
+
 	p.supervisors = map[string]JobSupervisor{
-		"monit":      NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger),
+		"monit":      windowsJobSupervisor(),
 		"dummy":      NewDummyJobSupervisor(),
 		"dummy-nats": NewDummyNatsJobSupervisor(handler),
-		"windows":    NewWrapperJobSupervisor(NewWindowsJobSupervisor(runner, dirProvider, fs, logger, jobSupervisorListenPort, make(chan bool), machineIP), fs, dirProvider, logger),
+		"windows":    windowsJobSupervisor(),
 	}
 
 	return
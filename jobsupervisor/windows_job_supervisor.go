@@ -272,6 +272,18 @@ func (w *windowsJobSupervisor) Unmonitor() error {
 	return w.mgr.Unmonitor()
 }
 
+func (w *windowsJobSupervisor) StartServices(services []string) error {
+	return bosherr.Error("Starting individual services is not supported on Windows")
+}
+
+func (w *windowsJobSupervisor) StopServices(services []string) error {
+	return bosherr.Error("Stopping individual services is not supported on Windows")
+}
+
+func (w *windowsJobSupervisor) UnmonitorServices(services []string) error {
+	return bosherr.Error("Unmonitoring individual services is not supported on Windows")
+}
+
 func (w *windowsJobSupervisor) Status() (status string) {
 	if w.fs.FileExists(w.stoppedFilePath()) {
 		return "stopped"
@@ -411,6 +423,10 @@ func (w *windowsJobSupervisor) RemoveAllJobs() error {
 	return w.mgr.Delete()
 }
 
+func (w *windowsJobSupervisor) JobServices(jobName string) ([]string, error) {
+	return nil, bosherr.Error("Listing services of individual jobs is not supported on Windows")
+}
+
 type windowsServiceEvent struct {
 	Event       string `json:"event"`
 	ProcessName string `json:"processName"`
@@ -61,6 +61,21 @@ func (w *wrapperJobSupervisor) Unmonitor() error {
 	w.HealthRecorder(w.delegate.Status())
 	return err
 }
+func (w *wrapperJobSupervisor) StartServices(services []string) error {
+	err := w.delegate.StartServices(services)
+	w.HealthRecorder(w.delegate.Status())
+
+	return err
+}
+func (w *wrapperJobSupervisor) StopServices(services []string) error {
+	err := w.delegate.StopServices(services)
+	w.HealthRecorder(w.delegate.Status())
+
+	return err
+}
+func (w *wrapperJobSupervisor) UnmonitorServices(services []string) error {
+	return w.delegate.UnmonitorServices(services)
+}
 func (w *wrapperJobSupervisor) Status() string {
 	return w.delegate.Status()
 }
@@ -73,6 +88,9 @@ func (w *wrapperJobSupervisor) AddJob(jobName string, jobIndex int, configPath s
 func (w *wrapperJobSupervisor) RemoveAllJobs() error {
 	return w.delegate.RemoveAllJobs()
 }
+func (w *wrapperJobSupervisor) JobServices(jobName string) ([]string, error) {
+	return w.delegate.JobServices(jobName)
+}
 func (w *wrapperJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
 	return w.delegate.MonitorJobFailures(handler)
 }
@@ -75,6 +75,11 @@ func startAgent(logger logger.Logger) error {
 }
 
 func main() {
+	// Talking to a running agent does not need agent logging or signal handling
+	if len(os.Args) > 1 && os.Args[1] == localCommandName {
+		os.Exit(runLocalCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	asyncLog := boshlog.NewAsyncWriterLogger(boshlog.LevelDebug, os.Stderr)
 	logger := newSignalableLogger(asyncLog)
 
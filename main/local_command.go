@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"time"
+
+	boshdirs "github.com/cloudfoundry/bosh-agent/settings/directories"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	localCommandName    = "local"
+	localCommandTimeout = time.Minute
+)
+
+// runLocalCommand sends a single request to the agent over its local socket
+// and prints the response, e.g. `bosh-agent local get_state full`
+func runLocalCommand(args []string, stdout, stderr io.Writer) int {
+	flagSet := flag.NewFlagSet("bosh-agent-local", flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+
+	var baseDirectory string
+	flagSet.StringVar(&baseDirectory, "b", "/var/vcap", "Set Base Directory")
+
+	flagSet.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: bosh-agent local [-b base-dir] <get_state|list_disk|info|ping|get_task> [arguments...]")
+	}
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if flagSet.NArg() < 1 {
+		flagSet.Usage()
+		return 2
+	}
+
+	socketPath := boshdirs.NewProvider(baseDirectory).AgentSocketPath()
+
+	resp, err := sendLocalRequest(socketPath, flagSet.Arg(0), flagSet.Args()[1:])
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	var prettyResp bytes.Buffer
+	if json.Indent(&prettyResp, resp, "", "  ") != nil {
+		prettyResp.Reset()
+		prettyResp.Write(resp)
+	}
+
+	fmt.Fprintln(stdout, prettyResp.String())
+
+	var exceptionResp struct {
+		Exception *struct {
+			Message string `json:"message"`
+		} `json:"exception"`
+	}
+
+	if json.Unmarshal(resp, &exceptionResp) == nil && exceptionResp.Exception != nil {
+		return 1
+	}
+
+	return 0
+}
+
+func sendLocalRequest(socketPath string, method string, arguments []string) ([]byte, error) {
+	// Arguments that are not JSON (e.g. "full") are sent as strings
+	requestArgs := []interface{}{}
+	for _, arg := range arguments {
+		var value interface{}
+		if json.Unmarshal([]byte(arg), &value) != nil {
+			value = arg
+		}
+		requestArgs = append(requestArgs, value)
+	}
+
+	reqBytes, err := json.Marshal(map[string]interface{}{
+		"method":    method,
+		"arguments": requestArgs,
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling request")
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, localCommandTimeout)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Connecting to agent at %s", socketPath)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	err = conn.SetDeadline(time.Now().Add(localCommandTimeout))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Setting connection deadline")
+	}
+
+	_, err = conn.Write(append(reqBytes, '\n'))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Sending request")
+	}
+
+	resp, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading response")
+	}
+
+	if len(resp) == 0 {
+		return nil, bosherr.Error("Agent closed connection without responding")
+	}
+
+	return resp, nil
+}
@@ -41,19 +41,32 @@ func (p HandlerProvider) Get(
 		return
 	}
 
-	mbusURL, err := url.Parse(p.settingsService.GetSettings().GetMbusURL())
-	if err != nil {
-		err = bosherr.WrapError(err, "Parsing handler URL")
-		return
+	var mbusURL *url.URL
+
+	// NATS handler fails over between all URLs so they must be of the same kind
+	for _, rawURL := range p.settingsService.GetSettings().GetMbusURLs() {
+		parsedURL, parseErr := url.Parse(rawURL)
+		if parseErr != nil {
+			err = bosherr.WrapError(parseErr, "Parsing handler URL")
+			return
+		}
+
+		if mbusURL == nil {
+			mbusURL = parsedURL
+		} else if parsedURL.Scheme != mbusURL.Scheme {
+			err = bosherr.Errorf("Message Bus URLs must use the same scheme, found %s and %s", mbusURL.Scheme, parsedURL.Scheme)
+			return
+		}
 	}
 
 	switch mbusURL.Scheme {
 	case "nats":
-		natsClient := NewTimeoutNatsClient(yagnats.NewClient(), clock.NewClock())
-		handler = NewNatsHandler(p.settingsService, natsClient, p.logger, platform)
+		timeService := clock.NewClock()
+		natsClient := NewTimeoutNatsClient(yagnats.NewClient(), timeService)
+		handler = NewNatsHandler(p.settingsService, natsClient, p.logger, platform, timeService)
 	case "https":
-		mbusKeyPair := p.settingsService.GetSettings().Env.Bosh.Mbus.Cert
-		handler = NewHTTPSHandler(mbusURL, mbusKeyPair, blobManager, p.logger, p.auditLogger)
+		mbusSettings := p.settingsService.GetSettings().Env.Bosh.Mbus
+		handler = NewHTTPSHandler(mbusURL, mbusSettings, blobManager, p.logger, p.auditLogger)
 	default:
 		err = bosherr.Errorf("Message Bus Handler with scheme %s could not be found", mbusURL.Scheme)
 	}
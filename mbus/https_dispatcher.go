@@ -3,7 +3,9 @@ package mbus
 import (
 	"crypto/subtle"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -20,15 +22,17 @@ type HTTPSDispatcher struct {
 	httpServer                  *http.Server
 	mux                         *http.ServeMux
 	keyPair                     settings.CertKeyPair
+	httpsSettings               settings.HTTPSMBus
 	listener                    net.Listener
 	logger                      boshlog.Logger
 	baseURL                     *url.URL
 	expectedAuthorizationHeader string
+	requireBasicAuth            bool
 }
 
 type HTTPHandlerFunc func(writer http.ResponseWriter, request *http.Request)
 
-func NewHTTPSDispatcher(baseURL *url.URL, keyPair settings.CertKeyPair, logger boshlog.Logger) *HTTPSDispatcher {
+func NewHTTPSDispatcher(baseURL *url.URL, mbusSettings settings.MBus, logger boshlog.Logger) *HTTPSDispatcher {
 	tlsConfig := &tls.Config{
 		// SSLv3 is insecure due to BEAST and POODLE attacks
 		MinVersion: tls.VersionTLS10,
@@ -46,6 +50,20 @@ func NewHTTPSDispatcher(baseURL *url.URL, keyPair settings.CertKeyPair, logger b
 		},
 		PreferServerCipherSuites: true,
 	}
+
+	if mbusSettings.HTTPS.TLSProfile == settings.HTTPSMBusTLSProfileModern {
+		// Only forward secret AEAD ciphers; TLS 1.3 suites are not configurable and always allowed
+		tlsConfig.MinVersion = tls.VersionTLS12
+		tlsConfig.CipherSuites = []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		}
+	}
+
 	httpServer := &http.Server{
 		TLSConfig: tlsConfig,
 	}
@@ -61,10 +79,13 @@ func NewHTTPSDispatcher(baseURL *url.URL, keyPair settings.CertKeyPair, logger b
 	return &HTTPSDispatcher{
 		httpServer:                  httpServer,
 		mux:                         mux,
-		keyPair:                     keyPair,
+		keyPair:                     mbusSettings.Cert,
+		httpsSettings:               mbusSettings.HTTPS,
 		logger:                      logger,
 		baseURL:                     baseURL,
 		expectedAuthorizationHeader: expectedAuthorizationHeader,
+		// Client certificate replaces shared password unless URL still includes one
+		requireBasicAuth: !mbusSettings.HTTPS.MutualTLS || baseURL.User != nil,
 	}
 }
 
@@ -93,6 +114,22 @@ func (h *HTTPSDispatcher) Start() error {
 	config.NextProtos = []string{"http/1.1"}
 	config.Certificates = []tls.Certificate{cert}
 
+	if h.httpsSettings.MutualTLS {
+		if h.keyPair.CA == "" {
+			_ = tcpListener.Close()
+			return bosherr.Error("Mutual TLS requires mbus CA certificate")
+		}
+
+		config.ClientCAs = x509.NewCertPool()
+		if ok := config.ClientCAs.AppendCertsFromPEM([]byte(h.keyPair.CA)); !ok {
+			_ = tcpListener.Close()
+			return bosherr.Error("Failed to load mbus CA cert")
+		}
+
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.VerifyPeerCertificate = h.verifyClientCertificate
+	}
+
 	tlsListener := tls.NewListener(tcpListener, config)
 
 	return h.httpServer.Serve(tlsListener)
@@ -105,7 +142,42 @@ func (h *HTTPSDispatcher) Stop() {
 	}
 }
 
+// verifyClientCertificate is only called after client certificate was verified against CA
+func (h *HTTPSDispatcher) verifyClientCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(h.httpsSettings.AllowedNames) == 0 {
+		return nil
+	}
+
+	for _, chain := range verifiedChains {
+		if len(chain) == 0 {
+			continue
+		}
+
+		cert := chain[0]
+
+		for _, allowedName := range h.httpsSettings.AllowedNames {
+			if cert.Subject.CommonName == allowedName {
+				return nil
+			}
+
+			for _, dnsName := range cert.DNSNames {
+				if dnsName == allowedName {
+					return nil
+				}
+			}
+		}
+	}
+
+	h.logger.Error(httpsDispatcherLogTag, "Rejected client certificate that does not match any of allowed names")
+
+	return errors.New("Client certificate does not match any of allowed names")
+}
+
 func (h *HTTPSDispatcher) requestNotAuthorized(request *http.Request) bool {
+	if !h.requireBasicAuth {
+		return false
+	}
+
 	return h.constantTimeEquals(h.expectedAuthorizationHeader, request.Header.Get("Authorization"))
 }
 
@@ -0,0 +1,106 @@
+package mbus
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+)
+
+const httpsEventsMaxLength = 100
+
+// HTTPSEvent is a message agent sends to director or health monitor
+// (heartbeats, alerts, shutdown notifications) when running on HTTPS mbus.
+// Since agent cannot reach its client, events are queued until client polls for them.
+type HTTPSEvent struct {
+	Target  boshhandler.Target `json:"target"`
+	Topic   boshhandler.Topic  `json:"topic"`
+	Message json.RawMessage    `json:"message"`
+}
+
+type httpsEventQueue struct {
+	events  []HTTPSEvent
+	waiters []chan struct{}
+	lock    sync.Mutex
+}
+
+// Push drops the oldest event when queue is full
+// so that a client that stopped polling does not grow it forever
+func (q *httpsEventQueue) Push(event HTTPSEvent) (dropped bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.events) >= httpsEventsMaxLength {
+		q.events = q.events[1:]
+		dropped = true
+	}
+
+	q.events = append(q.events, event)
+
+	for _, waiter := range q.waiters {
+		close(waiter)
+	}
+	q.waiters = nil
+
+	return dropped
+}
+
+// Drain returns all queued events. If there are none
+// it waits for up to timeout for events to arrive.
+// It returns false without taking any events if done is closed while waiting.
+func (q *httpsEventQueue) Drain(timeout time.Duration, done <-chan struct{}) ([]HTTPSEvent, bool) {
+	q.lock.Lock()
+
+	if len(q.events) > 0 || timeout <= 0 {
+		events := q.take()
+		q.lock.Unlock()
+		return events, true
+	}
+
+	waiter := make(chan struct{})
+	q.waiters = append(q.waiters, waiter)
+	q.lock.Unlock()
+
+	canceled := false
+
+	select {
+	case <-waiter:
+	case <-done:
+		canceled = true
+	case <-time.After(timeout):
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.removeWaiter(waiter)
+
+	if canceled {
+		return nil, false
+	}
+
+	return q.take(), true
+}
+
+// take must be called with the lock held
+func (q *httpsEventQueue) take() []HTTPSEvent {
+	events := q.events
+	q.events = nil
+
+	if events == nil {
+		return []HTTPSEvent{}
+	}
+
+	return events
+}
+
+// removeWaiter must be called with the lock held
+func (q *httpsEventQueue) removeWaiter(waiter chan struct{}) {
+	for i, w := range q.waiters {
+		if w == waiter {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			return
+		}
+	}
+}
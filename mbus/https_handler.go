@@ -2,11 +2,14 @@ package mbus
 
 import (
 	"bufio"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"time"
 
 	"github.com/cloudfoundry/bosh-agent/platform"
 	"github.com/cloudfoundry/bosh-agent/settings"
@@ -17,7 +20,12 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
-const httpsHandlerLogTag = "https_handler"
+const (
+	httpsHandlerLogTag = "https_handler"
+
+	httpsEventsDefaultTimeout = 30 * time.Second
+	httpsEventsMaxTimeout     = 60 * time.Second
+)
 
 type HTTPSHandler struct {
 	parsedURL   *url.URL
@@ -25,11 +33,12 @@ type HTTPSHandler struct {
 	logger      boshlog.Logger
 	dispatcher  *HTTPSDispatcher
 	auditLogger platform.AuditLogger
+	events      *httpsEventQueue
 }
 
 func NewHTTPSHandler(
 	parsedURL *url.URL,
-	keyPair settings.CertKeyPair,
+	mbusSettings settings.MBus,
 	blobManager boshagentblobstore.BlobManagerInterface,
 	logger boshlog.Logger,
 	auditLogger platform.AuditLogger,
@@ -38,8 +47,9 @@ func NewHTTPSHandler(
 		parsedURL:   parsedURL,
 		logger:      logger,
 		blobManager: blobManager,
-		dispatcher:  NewHTTPSDispatcher(parsedURL, keyPair, logger),
+		dispatcher:  NewHTTPSDispatcher(parsedURL, mbusSettings, logger),
 		auditLogger: auditLogger,
+		events:      &httpsEventQueue{},
 	}
 }
 
@@ -54,6 +64,7 @@ func (h HTTPSHandler) Run(handlerFunc boshhandler.Func) error {
 func (h HTTPSHandler) Start(handlerFunc boshhandler.Func) error {
 	h.dispatcher.AddRoute("/agent", h.agentHandler(handlerFunc))
 	h.dispatcher.AddRoute("/blobs/", h.blobsHandler())
+	h.dispatcher.AddRoute("/events", h.eventsHandler())
 	return h.dispatcher.Start()
 }
 
@@ -65,7 +76,22 @@ func (h HTTPSHandler) RegisterAdditionalFunc(handlerFunc boshhandler.Func) {
 	panic("HTTPSHandler does not support registering additional handler funcs")
 }
 
+// Send queues message until client picks it up from /events
+// since agent on HTTPS mbus cannot reach director or health monitor
 func (h HTTPSHandler) Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error {
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Marshalling message (target=%s, topic=%s): %#v", target, topic, message)
+	}
+
+	h.logger.Info(httpsHandlerLogTag, "Queueing %s message '%s'", target, topic)
+	h.logger.DebugWithDetails(httpsHandlerLogTag, "Message Payload", string(bytes))
+
+	dropped := h.events.Push(HTTPSEvent{Target: target, Topic: topic, Message: bytes})
+	if dropped {
+		h.logger.Warn(httpsHandlerLogTag, "Dropped oldest queued message since no client picked it up")
+	}
+
 	return nil
 }
 
@@ -88,9 +114,16 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.Respo
 			return
 		}
 
+		// Authorization policy identifies caller by its client certificate
+		clientName := h.clientName(r)
+		identifiedHandlerFunc := func(req boshhandler.Request) boshhandler.Response {
+			req.ClientName = clientName
+			return handlerFunc(req)
+		}
+
 		respBytes, _, err := boshhandler.PerformHandlerWithJSON(
 			rawJSONPayload,
-			handlerFunc,
+			identifiedHandlerFunc,
 			boshhandler.UnlimitedResponseLength,
 			h.logger,
 		)
@@ -113,6 +146,78 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.Func) func(http.Respo
 	}
 }
 
+// eventsHandler responds with all queued events.
+// If there are none it waits up to 'timeout' seconds for one to be sent (long polling).
+func (h HTTPSHandler) eventsHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(404)
+			h.generateCEFLog(r, 404, "")
+			return
+		}
+
+		timeout := httpsEventsDefaultTimeout
+
+		if rawTimeout := r.URL.Query().Get("timeout"); rawTimeout != "" {
+			seconds, err := strconv.Atoi(rawTimeout)
+			if err != nil || seconds < 0 {
+				w.WriteHeader(400)
+				h.generateCEFLog(r, 400, "")
+				return
+			}
+
+			timeout = time.Duration(seconds) * time.Second
+			if timeout > httpsEventsMaxTimeout {
+				timeout = httpsEventsMaxTimeout
+			}
+		}
+
+		// Events stay queued if client goes away while waiting
+		events, ok := h.events.Drain(timeout, r.Context().Done())
+		if !ok {
+			return
+		}
+
+		respBytes, err := json.Marshal(events)
+		if err != nil {
+			err = bosherr.WrapError(err, "Marshalling events")
+			h.logger.Error(httpsHandlerLogTag, err.Error())
+			w.WriteHeader(500)
+			h.generateCEFLog(r, 500, "")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		_, err = w.Write(respBytes)
+		if err != nil {
+			err = bosherr.WrapError(err, "Writing response")
+			h.logger.Error(httpsHandlerLogTag, err.Error())
+		}
+		h.generateCEFLog(r, 200, "")
+	}
+}
+
+// clientName returns common name of verified client certificate or its first SAN;
+// empty if client did not present a certificate
+func (h HTTPSHandler) clientName(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+
+	return ""
+}
+
 func (h HTTPSHandler) blobsHandler() (blobsHandler func(http.ResponseWriter, *http.Request)) {
 	blobsHandler = func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -3,6 +3,7 @@ package mbus_test
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -58,14 +59,16 @@ var _ = Describe("HTTPSHandler", func() {
 			configPrivateKey, err := ioutil.ReadFile("test_assets/custom_key.pem")
 			Expect(err).NotTo(HaveOccurred())
 
-			mbusKeyPair := settings.CertKeyPair{
-				Certificate: string(configCert),
-				PrivateKey:  string(configPrivateKey),
+			mbusSettings := settings.MBus{
+				Cert: settings.CertKeyPair{
+					Certificate: string(configCert),
+					PrivateKey:  string(configPrivateKey),
+				},
 			}
 
 			mbusURL, _ := url.Parse(serverURL)
 			logger := boshlog.NewWriterLogger(boshlog.LevelDebug, GinkgoWriter)
-			handler = NewHTTPSHandler(mbusURL, mbusKeyPair, blobManager, logger, fakes.NewFakeAuditLogger())
+			handler = NewHTTPSHandler(mbusURL, mbusSettings, blobManager, logger, fakes.NewFakeAuditLogger())
 
 			go handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
 				receivedRequest = req
@@ -104,11 +107,61 @@ var _ = Describe("HTTPSHandler", func() {
 		})
 	})
 
+	Context("when the agent requires client certificates", func() {
+		BeforeEach(func() {
+			ca := generateTestCertificate("fake-ca", nil, nil)
+			serverCert := generateTestCertificate("127.0.0.1", nil, ca)
+			clientCert := generateTestCertificate("fake-director", nil, ca)
+
+			mbusSettings := settings.MBus{
+				Cert: settings.CertKeyPair{
+					CA:          ca.certPEM,
+					Certificate: serverCert.certPEM,
+					PrivateKey:  serverCert.keyPEM,
+				},
+				HTTPS: settings.HTTPSMBus{MutualTLS: true},
+			}
+
+			serverURL = "https://127.0.0.1:6900"
+			mbusURL, _ := url.Parse(serverURL)
+			logger := boshlog.NewWriterLogger(boshlog.LevelDebug, GinkgoWriter)
+			handler = NewHTTPSHandler(mbusURL, mbusSettings, blobManager, logger, fakes.NewFakeAuditLogger())
+
+			go handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+				receivedRequest = req
+				return boshhandler.NewValueResponse("expected value")
+			})
+
+			authority := x509.NewCertPool()
+			authority.AddCert(ca.cert)
+
+			httpTransport := &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      authority,
+				Certificates: []tls.Certificate{clientCert.tlsCertificate()},
+			}}
+			httpClient = http.Client{Transport: httpTransport}
+
+			waitForServerToStart(serverURL, httpClient)
+		})
+
+		It("identifies caller by its client certificate", func() {
+			postBody := `{"method":"ping","arguments":[],"reply_to":"reply to me!"}`
+
+			httpResponse, err := httpClient.Post(serverURL+"/agent", "application/json", strings.NewReader(postBody))
+			Expect(err).ToNot(HaveOccurred())
+
+			defer httpResponse.Body.Close()
+
+			Expect(httpResponse.StatusCode).To(Equal(200))
+			Expect(receivedRequest.ClientName).To(Equal("fake-director"))
+		})
+	})
+
 	Context("when the agent is not configured with custom TLS", func() {
 		BeforeEach(func() {
 			mbusURL, _ := url.Parse(serverURL)
 			logger := boshlog.NewWriterLogger(boshlog.LevelDebug, GinkgoWriter)
-			handler = NewHTTPSHandler(mbusURL, settings.CertKeyPair{}, blobManager, logger, fakes.NewFakeAuditLogger())
+			handler = NewHTTPSHandler(mbusURL, settings.MBus{}, blobManager, logger, fakes.NewFakeAuditLogger())
 
 			go handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
 				receivedRequest = req
@@ -240,6 +293,114 @@ var _ = Describe("HTTPSHandler", func() {
 			})
 		})
 
+		Describe("GET /events", func() {
+			It("returns no events when none were sent", func() {
+				httpResponse, err := httpClient.Get(serverURL + "/events?timeout=0")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				Expect(httpResponse.StatusCode).To(Equal(200))
+
+				httpBody, readErr := ioutil.ReadAll(httpResponse.Body)
+				Expect(readErr).ToNot(HaveOccurred())
+				Expect(httpBody).To(MatchJSON(`[]`))
+			})
+
+			It("returns sent messages in order and only once", func() {
+				err := handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, map[string]string{"job": "fake-job"})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, "fake-alert")
+				Expect(err).ToNot(HaveOccurred())
+
+				httpResponse, err := httpClient.Get(serverURL + "/events?timeout=0")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				httpBody, readErr := ioutil.ReadAll(httpResponse.Body)
+				Expect(readErr).ToNot(HaveOccurred())
+				Expect(httpBody).To(MatchJSON(`[
+					{"target":"hm","topic":"heartbeat","message":{"job":"fake-job"}},
+					{"target":"hm","topic":"alert","message":"fake-alert"}
+				]`))
+
+				secondResponse, err := httpClient.Get(serverURL + "/events?timeout=0")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer secondResponse.Body.Close()
+
+				secondBody, readErr := ioutil.ReadAll(secondResponse.Body)
+				Expect(readErr).ToNot(HaveOccurred())
+				Expect(secondBody).To(MatchJSON(`[]`))
+			})
+
+			It("waits for a message to be sent", func() {
+				bodyCh := make(chan []byte, 1)
+
+				go func() {
+					defer GinkgoRecover()
+
+					httpResponse, err := httpClient.Get(serverURL + "/events?timeout=10")
+					Expect(err).ToNot(HaveOccurred())
+
+					defer httpResponse.Body.Close()
+
+					httpBody, readErr := ioutil.ReadAll(httpResponse.Body)
+					Expect(readErr).ToNot(HaveOccurred())
+					bodyCh <- httpBody
+				}()
+
+				Consistently(bodyCh, 200*time.Millisecond).ShouldNot(Receive())
+
+				err := handler.Send(boshhandler.HealthMonitor, boshhandler.Alert, "fake-alert")
+				Expect(err).ToNot(HaveOccurred())
+
+				var httpBody []byte
+				Eventually(bodyCh, 5*time.Second).Should(Receive(&httpBody))
+				Expect(httpBody).To(MatchJSON(`[{"target":"hm","topic":"alert","message":"fake-alert"}]`))
+			})
+
+			It("keeps only the most recent messages when nobody picks them up", func() {
+				for i := 0; i < 150; i++ {
+					err := handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, i)
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				httpResponse, err := httpClient.Get(serverURL + "/events?timeout=0")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				var events []HTTPSEvent
+				Expect(json.NewDecoder(httpResponse.Body).Decode(&events)).To(Succeed())
+				Expect(events).To(HaveLen(100))
+				Expect(events[0].Message).To(MatchJSON(`50`))
+				Expect(events[99].Message).To(MatchJSON(`149`))
+			})
+
+			It("returns a 400 when timeout is invalid", func() {
+				httpResponse, err := httpClient.Get(serverURL + "/events?timeout=forever")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer httpResponse.Body.Close()
+
+				Expect(httpResponse.StatusCode).To(Equal(400))
+			})
+
+			Context("when incorrect http method is used", func() {
+				It("returns a 404", func() {
+					httpResponse, err := httpClient.Post(serverURL+"/events", "application/json", strings.NewReader(""))
+					Expect(err).ToNot(HaveOccurred())
+
+					defer httpResponse.Body.Close()
+
+					Expect(httpResponse.StatusCode).To(Equal(404))
+				})
+			})
+		})
+
 		Describe("routing and auth", func() {
 			Context("when an incorrect uri is specificed", func() {
 				It("returns a 404", func() {
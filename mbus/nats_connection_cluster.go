@@ -0,0 +1,81 @@
+package mbus
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/yagnats"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const natsConnectionClusterLogTag = "NATS Connection Cluster"
+
+// NatsConnectionCluster provides connection to the first reachable NATS server.
+// Servers are tried in turn starting with the one agent was last connected to
+// so that agent only fails over when that server becomes unavailable.
+type NatsConnectionCluster struct {
+	Members []*yagnats.ConnectionInfo
+
+	active int
+	lock   sync.Mutex
+	logger boshlog.Logger
+}
+
+func NewNatsConnectionCluster(members []*yagnats.ConnectionInfo, logger boshlog.Logger) *NatsConnectionCluster {
+	return &NatsConnectionCluster{
+		Members: members,
+		logger:  logger,
+	}
+}
+
+func (c *NatsConnectionCluster) ProvideConnection() (*yagnats.Connection, error) {
+	if len(c.Members) == 0 {
+		return nil, bosherr.Error("No NATS servers configured")
+	}
+
+	c.lock.Lock()
+	start := c.active
+	c.lock.Unlock()
+
+	var lastErr error
+
+	for i := 0; i < len(c.Members); i++ {
+		index := (start + i) % len(c.Members)
+		member := c.Members[index]
+
+		conn, err := member.ProvideConnection()
+		if err != nil {
+			c.logger.Warn(natsConnectionClusterLogTag, "Connecting to NATS server %s failed: %s", member.Addr, err.Error())
+			lastErr = err
+			continue
+		}
+
+		c.lock.Lock()
+		c.active = index
+		c.lock.Unlock()
+
+		if index != start {
+			c.logger.Warn(natsConnectionClusterLogTag, "Failed over to NATS server %s", member.Addr)
+		}
+
+		c.logger.Info(natsConnectionClusterLogTag, "Connected to NATS server %s", member.Addr)
+
+		return conn, nil
+	}
+
+	return nil, bosherr.WrapErrorf(lastErr, "Connecting to any of %d NATS servers", len(c.Members))
+}
+
+// ActiveMember returns the server agent is connected to,
+// or the one it will try first if it is not connected
+func (c *NatsConnectionCluster) ActiveMember() *yagnats.ConnectionInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.Members) == 0 {
+		return nil
+	}
+
+	return c.Members[c.active]
+}
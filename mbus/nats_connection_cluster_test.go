@@ -0,0 +1,106 @@
+package mbus_test
+
+import (
+	"bufio"
+	"errors"
+	"net"
+
+	. "github.com/cloudfoundry/bosh-agent/mbus"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry/yagnats"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("NatsConnectionCluster", func() {
+	var (
+		reachable map[string]bool
+		dialed    []string
+		members   []*yagnats.ConnectionInfo
+		cluster   *NatsConnectionCluster
+	)
+
+	// dial pretends to be a NATS server that accepts CONNECT
+	dial := func(addr string) func(string, string) (net.Conn, error) {
+		return func(_, _ string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+
+			if !reachable[addr] {
+				return nil, errors.New("fake-dial-error")
+			}
+
+			clientConn, serverConn := net.Pipe()
+
+			go func() {
+				_, _ = bufio.NewReader(serverConn).ReadString('\n')
+				_, _ = serverConn.Write([]byte("+OK\r\n"))
+			}()
+
+			return clientConn, nil
+		}
+	}
+
+	BeforeEach(func() {
+		reachable = map[string]bool{}
+		dialed = nil
+
+		members = []*yagnats.ConnectionInfo{
+			{Addr: "127.0.0.1:4222"},
+			{Addr: "127.0.0.2:4222"},
+		}
+		for _, member := range members {
+			member.Dial = dial(member.Addr)
+		}
+
+		cluster = NewNatsConnectionCluster(members, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("connects to the first server when it is reachable", func() {
+		reachable["127.0.0.1:4222"] = true
+		reachable["127.0.0.2:4222"] = true
+
+		conn, err := cluster.ProvideConnection()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(conn).ToNot(BeNil())
+
+		Expect(dialed).To(Equal([]string{"127.0.0.1:4222"}))
+		Expect(cluster.ActiveMember()).To(BeIdenticalTo(members[0]))
+	})
+
+	It("fails over to the next server when first one is unreachable", func() {
+		reachable["127.0.0.2:4222"] = true
+
+		_, err := cluster.ProvideConnection()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(dialed).To(Equal([]string{"127.0.0.1:4222", "127.0.0.2:4222"}))
+		Expect(cluster.ActiveMember()).To(BeIdenticalTo(members[1]))
+	})
+
+	It("starts with the active server when reconnecting and wraps around", func() {
+		reachable["127.0.0.2:4222"] = true
+
+		_, err := cluster.ProvideConnection()
+		Expect(err).ToNot(HaveOccurred())
+
+		reachable["127.0.0.1:4222"] = true
+		reachable["127.0.0.2:4222"] = false
+		dialed = nil
+
+		_, err = cluster.ProvideConnection()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(dialed).To(Equal([]string{"127.0.0.2:4222", "127.0.0.1:4222"}))
+		Expect(cluster.ActiveMember()).To(BeIdenticalTo(members[0]))
+	})
+
+	It("returns an error when no server is reachable", func() {
+		_, err := cluster.ProvideConnection()
+		Expect(err).To(MatchError("Connecting to any of 2 NATS servers: fake-dial-error"))
+
+		Expect(dialed).To(Equal([]string{"127.0.0.1:4222", "127.0.0.2:4222"}))
+		Expect(cluster.ActiveMember()).To(BeIdenticalTo(members[0]))
+	})
+})
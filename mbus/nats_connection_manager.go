@@ -0,0 +1,237 @@
+package mbus
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/cloudfoundry/yagnats"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	natsConnectionManagerLogTag = "NATS Connection Manager"
+
+	natsReconnectInitialDelay  = time.Second
+	natsReconnectMaxDelay      = 2 * time.Minute
+	natsReconnectCheckInterval = time.Second
+
+	natsPendingMessagesMaxLength = 100
+)
+
+type natsConnectionState int
+
+const (
+	natsDisconnected natsConnectionState = iota
+	natsConnected
+	natsReconnecting
+)
+
+type natsPendingMessage struct {
+	subject string
+	payload []byte
+}
+
+// natsConnectionManager keeps NATS client connected for as long as agent runs.
+// NATS client reconnects on its own after losing connection and replays
+// its subscriptions (e.g. agent.<id>); the manager spaces out reconnect attempts
+// and holds on to messages published until connection is back.
+type natsConnectionManager struct {
+	client      yagnats.NATSClient
+	timeService clock.Clock
+	logger      boshlog.Logger
+
+	state             natsConnectionState
+	reconnectAttempts int
+	pendingMessages   []natsPendingMessage
+	flushing          bool
+	stopCh            chan struct{}
+	stopOnce          sync.Once
+	lock              sync.Mutex
+}
+
+func newNatsConnectionManager(
+	client yagnats.NATSClient,
+	timeService clock.Clock,
+	logger boshlog.Logger,
+) *natsConnectionManager {
+	return &natsConnectionManager{
+		client:      client,
+		timeService: timeService,
+		logger:      logger,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// Connect keeps trying to connect until it succeeds or manager is stopped.
+// beforeConnect is called before every connection attempt including reconnects.
+func (m *natsConnectionManager) Connect(connProvider yagnats.ConnectionProvider, beforeConnect func()) error {
+	m.client.BeforeConnectCallback(func() {
+		m.waitBeforeReconnect()
+		beforeConnect()
+	})
+
+	for attempt := 0; ; attempt++ {
+		err := m.client.Connect(connProvider)
+		if err == nil {
+			break
+		}
+
+		delay := m.backoff(attempt)
+		m.logger.Warn(natsConnectionManagerLogTag, "Connecting to NATS failed, retrying in %s: %s", delay, err.Error())
+
+		select {
+		case <-m.stopCh:
+			return bosherr.WrapError(err, "Connecting to NATS")
+		case <-m.timeService.After(delay):
+		}
+	}
+
+	m.lock.Lock()
+	m.state = natsConnected
+	m.lock.Unlock()
+
+	m.flushPendingMessages()
+
+	return nil
+}
+
+// Publish queues messages while client is not connected
+// so that they are sent in order once connection is back.
+func (m *natsConnectionManager) Publish(subject string, payload []byte) error {
+	m.lock.Lock()
+
+	// Messages must not overtake ones that are still being flushed
+	if m.state != natsConnected || m.flushing {
+		m.queueMessage(natsPendingMessage{subject: subject, payload: payload})
+		m.lock.Unlock()
+		return nil
+	}
+
+	m.lock.Unlock()
+
+	return m.client.Publish(subject, payload)
+}
+
+func (m *natsConnectionManager) Stop() {
+	m.stopOnce.Do(func() { close(m.stopCh) })
+
+	m.lock.Lock()
+	m.state = natsDisconnected
+	m.lock.Unlock()
+
+	m.client.Disconnect()
+}
+
+// waitBeforeReconnect is called by the client before each connection attempt
+func (m *natsConnectionManager) waitBeforeReconnect() {
+	m.lock.Lock()
+
+	switch m.state {
+	case natsConnected:
+		// Client lost its connection and starts reconnecting right away
+		m.state = natsReconnecting
+		m.reconnectAttempts = 0
+		m.lock.Unlock()
+
+		m.logger.Warn(natsConnectionManagerLogTag, "Lost connection to NATS, reconnecting")
+
+		go m.waitForReconnect()
+
+	case natsReconnecting:
+		delay := m.backoff(m.reconnectAttempts)
+		m.reconnectAttempts++
+		m.lock.Unlock()
+
+		m.logger.Debug(natsConnectionManagerLogTag, "Waiting %s before reconnecting to NATS", delay)
+
+		select {
+		case <-m.stopCh:
+		case <-m.timeService.After(delay):
+		}
+
+	default:
+		m.lock.Unlock()
+	}
+}
+
+func (m *natsConnectionManager) waitForReconnect() {
+	defer m.logger.HandlePanic("NATS Connection Manager Wait For Reconnect")
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-m.timeService.After(natsReconnectCheckInterval):
+		}
+
+		// Ping only succeeds once client has a connection
+		if !m.client.Ping() {
+			continue
+		}
+
+		m.lock.Lock()
+		if m.state != natsReconnecting {
+			m.lock.Unlock()
+			return
+		}
+		m.state = natsConnected
+		m.lock.Unlock()
+
+		m.logger.Info(natsConnectionManagerLogTag, "Reconnected to NATS")
+
+		m.flushPendingMessages()
+		return
+	}
+}
+
+func (m *natsConnectionManager) flushPendingMessages() {
+	m.lock.Lock()
+	m.flushing = true
+	m.lock.Unlock()
+
+	for {
+		m.lock.Lock()
+		if m.state != natsConnected || len(m.pendingMessages) == 0 {
+			m.flushing = false
+			m.lock.Unlock()
+			return
+		}
+		msg := m.pendingMessages[0]
+		m.pendingMessages = m.pendingMessages[1:]
+		m.lock.Unlock()
+
+		err := m.client.Publish(msg.subject, msg.payload)
+		if err != nil {
+			// Dropping message keeps newer messages from being queued forever
+			m.logger.Error(natsConnectionManagerLogTag, "Publishing queued message to %s: %s", msg.subject, err.Error())
+		}
+	}
+}
+
+// queueMessage must be called with the lock held
+func (m *natsConnectionManager) queueMessage(msg natsPendingMessage) {
+	if len(m.pendingMessages) >= natsPendingMessagesMaxLength {
+		m.logger.Warn(natsConnectionManagerLogTag, "Dropping oldest queued message to %s", m.pendingMessages[0].subject)
+		m.pendingMessages = m.pendingMessages[1:]
+	}
+
+	m.pendingMessages = append(m.pendingMessages, msg)
+}
+
+// backoff doubles delay with every attempt up to a maximum.
+// Jitter spreads out reconnects of many agents after NATS restarts.
+func (m *natsConnectionManager) backoff(attempt int) time.Duration {
+	delay := natsReconnectMaxDelay
+	if attempt < 16 {
+		delay = natsReconnectInitialDelay << uint(attempt)
+		if delay > natsReconnectMaxDelay {
+			delay = natsReconnectMaxDelay
+		}
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -2,35 +2,30 @@ package mbus
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net"
-	"net/url"
 	"os"
 	"os/signal"
 	"strings"
 	"sync"
 	"syscall"
 
+	"code.cloudfoundry.org/clock"
 	"github.com/cloudfoundry/yagnats"
 
 	"crypto/x509"
-	"time"
 
-	"crypto/tls"
 	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	"github.com/cloudfoundry/bosh-agent/mbus/natsconn"
 	boshplatform "github.com/cloudfoundry/bosh-agent/platform"
 	boshsettings "github.com/cloudfoundry/bosh-agent/settings"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
-	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
-	"regexp"
 )
 
 const (
-	responseMaxLength        = 1024 * 1024
-	natsHandlerLogTag        = "NATS Handler"
-	natsConnectionMaxRetries = 4
+	responseMaxLength = 1024 * 1024
+	natsHandlerLogTag = "NATS Handler"
 )
 
 type Handler interface {
@@ -46,6 +41,11 @@ type natsHandler struct {
 	client          yagnats.NATSClient
 	platform        boshplatform.Platform
 
+	connectionManager *natsConnectionManager
+	connCluster       *NatsConnectionCluster
+	requestVerifier   *natsRequestVerifier
+	timeService       clock.Clock
+
 	handlerFuncs     []boshhandler.Func
 	handlerFuncsLock sync.Mutex
 
@@ -59,12 +59,16 @@ func NewNatsHandler(
 	client yagnats.NATSClient,
 	logger boshlog.Logger,
 	platform boshplatform.Platform,
+	timeService clock.Clock,
 ) Handler {
 	return &natsHandler{
 		settingsService: settingsService,
 		client:          client,
 		platform:        platform,
 
+		connectionManager: newNatsConnectionManager(client, timeService, logger),
+		timeService:       timeService,
+
 		logger:      logger,
 		logTag:      natsHandlerLogTag,
 		auditLogger: platform.GetAuditLogger(),
@@ -87,53 +91,63 @@ func (h *natsHandler) Run(handlerFunc boshhandler.Func) error {
 func (h *natsHandler) Start(handlerFunc boshhandler.Func) error {
 	h.RegisterAdditionalFunc(handlerFunc)
 
-	connProvider, err := h.getConnectionInfo()
+	connCluster, err := h.getConnectionCluster()
 	if err != nil {
 		return bosherr.WrapError(err, "Getting connection info")
 	}
 
-	h.client.BeforeConnectCallback(func() {
-		hostSplit := strings.Split(connProvider.Addr, ":")
-		ip := hostSplit[0]
+	h.connCluster = connCluster
 
-		if net.ParseIP(ip) == nil {
-			return
-		}
+	// Connection is retried with increasing delays until it succeeds.
+	// Each attempt tries every NATS server in turn.
+	err = h.connectionManager.Connect(connCluster, func() {
+		for _, member := range connCluster.Members {
+			hostSplit := strings.Split(member.Addr, ":")
+			ip := hostSplit[0]
 
-		err = h.platform.DeleteARPEntryWithIP(ip)
-		if err != nil {
-			h.logger.Error(h.logTag, "Cleaning ip-mac address cache for: %s", ip)
-		}
-	})
+			if net.ParseIP(ip) == nil {
+				continue
+			}
 
-	natsRetryable := boshretry.NewRetryable(func() (bool, error) {
-		err := h.client.Connect(connProvider)
-		if err != nil {
-			return true, bosherr.WrapError(err, "Connecting to NATS")
+			err := h.platform.DeleteARPEntryWithIP(ip)
+			if err != nil {
+				h.logger.Error(h.logTag, "Cleaning ip-mac address cache for: %s", ip)
+			}
 		}
-		return false, nil
 	})
-
-	attemptRetryStrategy := boshretry.NewAttemptRetryStrategy(natsConnectionMaxRetries, time.Second, natsRetryable, h.logger)
-	err = attemptRetryStrategy.Try()
 	if err != nil {
 		return bosherr.WrapError(err, "Connecting")
 	}
 
+	h.logger.Info(h.logTag, "Active NATS server is %s", connCluster.ActiveMember().Addr)
+
 	settings := h.settingsService.GetSettings()
 
+	h.requestVerifier = newNatsRequestVerifier(settings.Env.Bosh.Mbus.SigningKey, h.timeService)
+
 	subject := fmt.Sprintf("agent.%s", settings.AgentID)
 
 	h.logger.Info(h.logTag, "Subscribing to %s", subject)
 
 	_, err = h.client.Subscribe(subject, func(natsMsg *yagnats.Message) {
+		// Verified once since nonce of signed request can only be used once
+		payload, err := h.requestVerifier.Verify(natsMsg.Payload)
+		if err != nil {
+			h.logger.Error(h.logTag, "Verifying request: %s", err)
+			h.generateCEFLog(natsMsg, 7, err.Error())
+			return
+		}
+
+		verifiedMsg := *natsMsg
+		verifiedMsg.Payload = payload
+
 		// Do not lock handler funcs around possible network calls!
 		h.handlerFuncsLock.Lock()
 		handlerFuncs := h.handlerFuncs
 		h.handlerFuncsLock.Unlock()
 
 		for _, handlerFunc := range handlerFuncs {
-			h.handleNatsMsg(natsMsg, handlerFunc)
+			h.handleNatsMsg(&verifiedMsg, handlerFunc)
 		}
 	})
 	if err != nil {
@@ -163,25 +177,17 @@ func (h *natsHandler) Send(target boshhandler.Target, topic boshhandler.Topic, m
 	settings := h.settingsService.GetSettings()
 
 	subject := fmt.Sprintf("%s.agent.%s.%s", target, topic, settings.AgentID)
-	return h.client.Publish(subject, bytes)
+
+	// Messages sent while disconnected are delivered after reconnecting
+	return h.connectionManager.Publish(subject, bytes)
 }
 
 func (h *natsHandler) Stop() {
-	h.client.Disconnect()
+	h.connectionManager.Stop()
 }
 
 func (h *natsHandler) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-	for _, chain := range verifiedChains {
-		if len(chain) == 0 {
-			continue
-		}
-		commonName := chain[0].Subject.CommonName
-		match, _ := regexp.MatchString("^[a-zA-Z0-9*\\-]*.nats.bosh-internal$", commonName)
-		if match {
-			return nil
-		}
-	}
-	return errors.New("Server Certificate CommonName does not match *.nats.bosh-internal")
+	return natsconn.VerifyServerCertificate(rawCerts, verifiedChains)
 }
 
 func (h *natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshhandler.Func) {
@@ -211,7 +217,7 @@ func (h *natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshha
 }
 
 func (h *natsHandler) runUntilInterrupted() {
-	defer h.client.Disconnect()
+	defer h.connectionManager.Stop()
 
 	keepRunning := true
 
@@ -226,68 +232,46 @@ func (h *natsHandler) runUntilInterrupted() {
 	}
 }
 
-func (h *natsHandler) getConnectionInfo() (*yagnats.ConnectionInfo, error) {
+func (h *natsHandler) getConnectionCluster() (*NatsConnectionCluster, error) {
 	settings := h.settingsService.GetSettings()
 
-	natsURL, err := url.Parse(settings.GetMbusURL())
-	if err != nil {
-		return nil, bosherr.WrapError(err, "Parsing Nats URL")
-	}
-
-	connInfo := new(yagnats.ConnectionInfo)
-	connInfo.Addr = natsURL.Host
+	var tlsInfo *yagnats.ConnectionTLSInfo
 
 	if settings.Env.IsNATSMutualTLSEnabled() {
-		connInfo.TLSInfo = &yagnats.ConnectionTLSInfo{}
+		var err error
 
-		caCert := settings.Env.Bosh.Mbus.Cert.CA
-		if caCert != "" {
-			connInfo.TLSInfo.CertPool = x509.NewCertPool()
-			if ok := connInfo.TLSInfo.CertPool.AppendCertsFromPEM([]byte(caCert)); !ok {
-				return nil, bosherr.Error("Failed to load Mbus CA cert")
-			}
+		mbusCert := settings.Env.Bosh.Mbus.Cert
+		tlsInfo, err = natsconn.TLSInfo(mbusCert.CA, mbusCert.Certificate, mbusCert.PrivateKey)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		connInfo.TLSInfo.VerifyPeerCertificate = h.VerifyPeerCertificate
+	var members []*yagnats.ConnectionInfo
 
-		clientCertificate, err := tls.X509KeyPair([]byte(settings.Env.Bosh.Mbus.Cert.Certificate), []byte(settings.Env.Bosh.Mbus.Cert.PrivateKey))
+	for _, mbusURL := range settings.GetMbusURLs() {
+		connInfo, err := natsconn.ConnectionInfo(mbusURL, tlsInfo)
 		if err != nil {
-			return nil, bosherr.WrapError(err, "Parsing certificate and private key")
+			return nil, err
 		}
-		connInfo.TLSInfo.ClientCert = &clientCertificate
-	}
 
-	user := natsURL.User
-	if user != nil {
-		password, passwordIsSet := user.Password()
-		if !passwordIsSet {
-			return nil, errors.New("No password set for connection")
-		}
-		connInfo.Password = password
-		connInfo.Username = user.Username()
+		members = append(members, connInfo)
 	}
 
-	return connInfo, nil
+	return NewNatsConnectionCluster(members, h.logger), nil
 }
 
 func (h *natsHandler) generateCEFLog(natsMsg *yagnats.Message, severity int, statusReason string) {
 	cef := boshhandler.NewCommonEventFormat()
 
-	settings := h.settingsService.GetSettings()
-
-	natsURL, err := url.Parse(settings.GetMbusURL())
-	if err != nil {
-		h.logger.Error(natsHandlerLogTag, err.Error())
-		return
-	}
-
-	hostSplit := strings.Split(natsURL.Host, ":")
+	// Message was received from the server agent is currently connected to
+	hostSplit := strings.Split(h.connCluster.ActiveMember().Addr, ":")
 	ip := hostSplit[0]
 	payload := struct {
 		Method  string `json:"method"`
 		ReplyTo string `json:"reply_to"`
 	}{}
-	err = json.Unmarshal(natsMsg.Payload, &payload)
+	err := json.Unmarshal(natsMsg.Payload, &payload)
 	if err != nil {
 		h.logger.Error(natsHandlerLogTag, err.Error())
 	}
@@ -0,0 +1,134 @@
+package mbus
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Requests signed longer ago (or further in the future) are rejected
+// so nonces only need to be remembered for this long
+const natsSignedRequestMaxAge = 5 * time.Minute
+
+// natsSignedRequest wraps agent request signed by director.
+// Signature is HMAC-SHA256 of method, timestamp, nonce and payload
+// (see natsSignedRequest.signedContent) using key from mbus settings.
+type natsSignedRequest struct {
+	Method    string `json:"method"`
+	Payload   []byte `json:"payload"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Signature []byte `json:"signature"`
+}
+
+func (r natsSignedRequest) isSigned() bool {
+	return r.Signature != nil || r.Payload != nil
+}
+
+func (r natsSignedRequest) signedContent() []byte {
+	return append([]byte(fmt.Sprintf("%s\n%d\n%s\n", r.Method, r.Timestamp, r.Nonce)), r.Payload...)
+}
+
+// natsRequestVerifier unwraps signed requests and rejects
+// forged, stale and replayed ones
+type natsRequestVerifier struct {
+	key         []byte
+	timeService clock.Clock
+
+	nonces map[string]time.Time
+	lock   sync.Mutex
+}
+
+func newNatsRequestVerifier(key string, timeService clock.Clock) *natsRequestVerifier {
+	return &natsRequestVerifier{
+		key:         []byte(key),
+		timeService: timeService,
+		nonces:      map[string]time.Time{},
+	}
+}
+
+// Verify returns request payload to handle. Requests must be signed once signing key is configured.
+func (v *natsRequestVerifier) Verify(rawPayload []byte) ([]byte, error) {
+	var signedRequest natsSignedRequest
+
+	err := json.Unmarshal(rawPayload, &signedRequest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling JSON payload")
+	}
+
+	if !signedRequest.isSigned() {
+		if len(v.key) > 0 {
+			return nil, bosherr.Error("Request is not signed")
+		}
+		return rawPayload, nil
+	}
+
+	if len(v.key) == 0 {
+		return nil, bosherr.Error("Request is signed but no signing key is configured")
+	}
+
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write(signedRequest.signedContent())
+
+	if !hmac.Equal(mac.Sum(nil), signedRequest.Signature) {
+		return nil, bosherr.Error("Request signature is invalid")
+	}
+
+	var request struct {
+		Method string `json:"method"`
+	}
+
+	err = json.Unmarshal(signedRequest.Payload, &request)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling signed payload")
+	}
+
+	if request.Method != signedRequest.Method {
+		return nil, bosherr.Errorf("Signed method %s does not match requested method %s", signedRequest.Method, request.Method)
+	}
+
+	err = v.checkFresh(signedRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return signedRequest.Payload, nil
+}
+
+func (v *natsRequestVerifier) checkFresh(signedRequest natsSignedRequest) error {
+	now := v.timeService.Now()
+	signedAt := time.Unix(signedRequest.Timestamp, 0)
+
+	if now.Sub(signedAt) > natsSignedRequestMaxAge || signedAt.Sub(now) > natsSignedRequestMaxAge {
+		return bosherr.Errorf("Request timestamp %d is outside of allowed window", signedRequest.Timestamp)
+	}
+
+	if signedRequest.Nonce == "" {
+		return bosherr.Error("Request nonce is missing")
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	// Requests with older timestamps are rejected anyway
+	for nonce, expiresAt := range v.nonces {
+		if now.After(expiresAt) {
+			delete(v.nonces, nonce)
+		}
+	}
+
+	if _, found := v.nonces[signedRequest.Nonce]; found {
+		return bosherr.Errorf("Request nonce '%s' was already used", signedRequest.Nonce)
+	}
+
+	v.nonces[signedRequest.Nonce] = signedAt.Add(natsSignedRequestMaxAge)
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package natsconn_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestNatsconn(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NATS Connection Suite")
+}
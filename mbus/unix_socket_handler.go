@@ -0,0 +1,204 @@
+package mbus
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	unixSocketHandlerLogTag = "unix_socket_handler"
+
+	// UnixSocketClientName identifies requests made over local socket in authorization policy
+	UnixSocketClientName = "local"
+
+	unixSocketRequestTimeout = 30 * time.Second
+)
+
+// UnixSocketHandler serves agent requests to tools running on the VM.
+// Each connection carries a single JSON request followed by a single JSON response.
+// Socket is only accessible by root.
+type UnixSocketHandler struct {
+	socketPath string
+	logger     boshlog.Logger
+
+	listener net.Listener
+	lock     sync.Mutex
+	wg       sync.WaitGroup
+}
+
+func NewUnixSocketHandler(socketPath string, logger boshlog.Logger) *UnixSocketHandler {
+	return &UnixSocketHandler{
+		socketPath: socketPath,
+		logger:     logger,
+	}
+}
+
+func (h *UnixSocketHandler) Run(handlerFunc boshhandler.Func) error {
+	err := h.Start(handlerFunc)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting unix socket handler")
+	}
+
+	h.wg.Wait()
+
+	return nil
+}
+
+func (h *UnixSocketHandler) Start(handlerFunc boshhandler.Func) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	listener, err := h.listen()
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(unixSocketHandlerLogTag, "Listening on %s", h.socketPath)
+
+	h.listener = listener
+
+	h.wg.Add(1)
+	go h.acceptConnections(listener, handlerFunc)
+
+	return nil
+}
+
+func (h *UnixSocketHandler) Stop() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.listener == nil {
+		return
+	}
+
+	err := h.listener.Close()
+	if err != nil {
+		h.logger.Error(unixSocketHandlerLogTag, "Closing listener: %s", err.Error())
+	}
+
+	err = os.Remove(h.socketPath)
+	if err != nil && !os.IsNotExist(err) {
+		h.logger.Error(unixSocketHandlerLogTag, "Removing socket: %s", err.Error())
+	}
+
+	h.listener = nil
+}
+
+// RegisterAdditionalFunc is ignored since only tools on the VM connect to socket
+// and nothing they send needs to reach handler funcs other than the main one
+func (h *UnixSocketHandler) RegisterAdditionalFunc(handlerFunc boshhandler.Func) {
+	h.logger.Error(unixSocketHandlerLogTag, "Ignoring additional handler func since it is not supported")
+}
+
+// listen creates socket in a directory only accessible by root
+// and then moves it into place so that it is never accessible by others,
+// replacing socket left behind when agent was not stopped cleanly
+func (h *UnixSocketHandler) listen() (net.Listener, error) {
+	tmpDir, err := ioutil.TempDir(filepath.Dir(h.socketPath), ".agent-socket")
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating private directory for %s", h.socketPath)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	tmpSocketPath := filepath.Join(tmpDir, filepath.Base(h.socketPath))
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpSocketPath, Net: "unix"})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Listening on %s", h.socketPath)
+	}
+
+	// Socket is removed by Stop since it is no longer at the path it was created at
+	listener.SetUnlinkOnClose(false)
+
+	err = os.Chmod(tmpSocketPath, os.FileMode(0600))
+	if err != nil {
+		_ = listener.Close()
+		return nil, bosherr.WrapErrorf(err, "Restricting permissions of %s", h.socketPath)
+	}
+
+	err = os.Rename(tmpSocketPath, h.socketPath)
+	if err != nil {
+		_ = listener.Close()
+		return nil, bosherr.WrapErrorf(err, "Moving socket to %s", h.socketPath)
+	}
+
+	return listener, nil
+}
+
+func (h *UnixSocketHandler) Send(target boshhandler.Target, topic boshhandler.Topic, message interface{}) error {
+	return bosherr.Errorf("UnixSocketHandler does not support sending %s message '%s'", target, topic)
+}
+
+func (h *UnixSocketHandler) acceptConnections(listener net.Listener, handlerFunc boshhandler.Func) {
+	defer h.wg.Done()
+	defer h.logger.HandlePanic("Unix Socket Handler")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			h.logger.Debug(unixSocketHandlerLogTag, "Stopped accepting connections: %s", err.Error())
+			return
+		}
+
+		go h.handleConnection(conn, handlerFunc)
+	}
+}
+
+func (h *UnixSocketHandler) handleConnection(conn net.Conn, handlerFunc boshhandler.Func) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	err := conn.SetDeadline(time.Now().Add(unixSocketRequestTimeout))
+	if err != nil {
+		h.logger.Error(unixSocketHandlerLogTag, "Setting connection deadline: %s", err.Error())
+		return
+	}
+
+	var rawJSONPayload json.RawMessage
+
+	err = json.NewDecoder(conn).Decode(&rawJSONPayload)
+	if err != nil {
+		h.logger.Error(unixSocketHandlerLogTag, "Reading request: %s", err.Error())
+		return
+	}
+
+	identifiedHandlerFunc := func(req boshhandler.Request) boshhandler.Response {
+		req.ClientName = UnixSocketClientName
+		return handlerFunc(req)
+	}
+
+	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
+		rawJSONPayload,
+		identifiedHandlerFunc,
+		boshhandler.UnlimitedResponseLength,
+		h.logger,
+	)
+	if err != nil {
+		h.logger.Error(unixSocketHandlerLogTag, "Running handler: %s", err.Error())
+
+		respBytes, err = boshhandler.BuildErrorWithJSON(err.Error(), h.logger)
+		if err != nil {
+			return
+		}
+	}
+
+	h.logger.Info(unixSocketHandlerLogTag, "Responded to local '%s' request", req.Method)
+
+	_, err = conn.Write(respBytes)
+	if err != nil {
+		h.logger.Error(unixSocketHandlerLogTag, "Writing response: %s", err.Error())
+	}
+}
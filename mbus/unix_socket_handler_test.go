@@ -0,0 +1,124 @@
+package mbus_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshhandler "github.com/cloudfoundry/bosh-agent/handler"
+	. "github.com/cloudfoundry/bosh-agent/mbus"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("UnixSocketHandler", func() {
+	var (
+		tmpDir     string
+		socketPath string
+		handler    *UnixSocketHandler
+
+		receivedRequest boshhandler.Request
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "unix-socket-handler")
+		Expect(err).ToNot(HaveOccurred())
+
+		socketPath = filepath.Join(tmpDir, "agent.sock")
+		handler = NewUnixSocketHandler(socketPath, boshlog.NewLogger(boshlog.LevelNone))
+
+		err = handler.Start(func(req boshhandler.Request) boshhandler.Response {
+			receivedRequest = req
+			return boshhandler.NewValueResponse("pong")
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		handler.Stop()
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	request := func(payload string) string {
+		conn, err := net.Dial("unix", socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(payload))
+		Expect(err).ToNot(HaveOccurred())
+
+		resp, err := ioutil.ReadAll(conn)
+		Expect(err).ToNot(HaveOccurred())
+
+		return string(resp)
+	}
+
+	It("responds to request sent over socket", func() {
+		resp := request(`{"method":"ping","arguments":[]}` + "\n")
+		Expect(resp).To(MatchJSON(`{"value":"pong"}`))
+
+		Expect(receivedRequest.Method).To(Equal("ping"))
+		Expect(receivedRequest.ClientName).To(Equal(UnixSocketClientName))
+	})
+
+	It("responds with exception when request is not valid JSON", func() {
+		resp := request(`"not a request"`)
+		Expect(resp).To(ContainSubstring("Unmarshalling JSON payload"))
+	})
+
+	It("only allows root to connect to socket", func() {
+		info, err := os.Stat(socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode() & os.ModePerm).To(Equal(os.FileMode(0600)))
+	})
+
+	It("creates socket in a private directory and only leaves socket behind", func() {
+		entries, err := ioutil.ReadDir(tmpDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Name()).To(Equal("agent.sock"))
+	})
+
+	It("replaces socket left behind by previous agent", func() {
+		handler.Stop()
+
+		err := ioutil.WriteFile(socketPath, []byte{}, 0600)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = handler.Start(func(req boshhandler.Request) boshhandler.Response {
+			return boshhandler.NewValueResponse("pong")
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(request(`{"method":"ping","arguments":[]}`)).To(MatchJSON(`{"value":"pong"}`))
+	})
+
+	It("stops accepting connections and removes socket when stopped", func() {
+		handler.Stop()
+
+		_, err := net.Dial("unix", socketPath)
+		Expect(err).To(HaveOccurred())
+
+		_, err = os.Stat(socketPath)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("ignores additional handler funcs", func() {
+		Expect(func() {
+			handler.RegisterAdditionalFunc(func(req boshhandler.Request) boshhandler.Response {
+				return boshhandler.NewValueResponse("other")
+			})
+		}).ToNot(Panic())
+
+		Expect(request(`{"method":"ping","arguments":[]}`)).To(MatchJSON(`{"value":"pong"}`))
+	})
+
+	It("does not support sending messages", func() {
+		err := handler.Send(boshhandler.HealthMonitor, boshhandler.Heartbeat, "fake-message")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,412 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package cgroupfakes
+
+import (
+	sync "sync"
+
+	cgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+)
+
+type FakeManager struct {
+	AddProcessStub        func(string, int) error
+	addProcessMutex       sync.RWMutex
+	addProcessArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	addProcessReturns struct {
+		result1 error
+	}
+	addProcessReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ConfigureStub        func(string, cgroup.Limits) error
+	configureMutex       sync.RWMutex
+	configureArgsForCall []struct {
+		arg1 string
+		arg2 cgroup.Limits
+	}
+	configureReturns struct {
+		result1 error
+	}
+	configureReturnsOnCall map[int]struct {
+		result1 error
+	}
+	JobsStub        func() ([]string, error)
+	jobsMutex       sync.RWMutex
+	jobsArgsForCall []struct {
+	}
+	jobsReturns struct {
+		result1 []string
+		result2 error
+	}
+	jobsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	KeepOnlyStub        func([]string) error
+	keepOnlyMutex       sync.RWMutex
+	keepOnlyArgsForCall []struct {
+		arg1 []string
+	}
+	keepOnlyReturns struct {
+		result1 error
+	}
+	keepOnlyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UsageStub        func(string) (cgroup.Usage, error)
+	usageMutex       sync.RWMutex
+	usageArgsForCall []struct {
+		arg1 string
+	}
+	usageReturns struct {
+		result1 cgroup.Usage
+		result2 error
+	}
+	usageReturnsOnCall map[int]struct {
+		result1 cgroup.Usage
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeManager) AddProcess(arg1 string, arg2 int) error {
+	fake.addProcessMutex.Lock()
+	ret, specificReturn := fake.addProcessReturnsOnCall[len(fake.addProcessArgsForCall)]
+	fake.addProcessArgsForCall = append(fake.addProcessArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	fake.recordInvocation("AddProcess", []interface{}{arg1, arg2})
+	fake.addProcessMutex.Unlock()
+	if fake.AddProcessStub != nil {
+		return fake.AddProcessStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addProcessReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) AddProcessCallCount() int {
+	fake.addProcessMutex.RLock()
+	defer fake.addProcessMutex.RUnlock()
+	return len(fake.addProcessArgsForCall)
+}
+
+func (fake *FakeManager) AddProcessCalls(stub func(string, int) error) {
+	fake.addProcessMutex.Lock()
+	defer fake.addProcessMutex.Unlock()
+	fake.AddProcessStub = stub
+}
+
+func (fake *FakeManager) AddProcessArgsForCall(i int) (string, int) {
+	fake.addProcessMutex.RLock()
+	defer fake.addProcessMutex.RUnlock()
+	argsForCall := fake.addProcessArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) AddProcessReturns(result1 error) {
+	fake.addProcessMutex.Lock()
+	defer fake.addProcessMutex.Unlock()
+	fake.AddProcessStub = nil
+	fake.addProcessReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) AddProcessReturnsOnCall(i int, result1 error) {
+	fake.addProcessMutex.Lock()
+	defer fake.addProcessMutex.Unlock()
+	fake.AddProcessStub = nil
+	if fake.addProcessReturnsOnCall == nil {
+		fake.addProcessReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addProcessReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) Configure(arg1 string, arg2 cgroup.Limits) error {
+	fake.configureMutex.Lock()
+	ret, specificReturn := fake.configureReturnsOnCall[len(fake.configureArgsForCall)]
+	fake.configureArgsForCall = append(fake.configureArgsForCall, struct {
+		arg1 string
+		arg2 cgroup.Limits
+	}{arg1, arg2})
+	fake.recordInvocation("Configure", []interface{}{arg1, arg2})
+	fake.configureMutex.Unlock()
+	if fake.ConfigureStub != nil {
+		return fake.ConfigureStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.configureReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) ConfigureCallCount() int {
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	return len(fake.configureArgsForCall)
+}
+
+func (fake *FakeManager) ConfigureCalls(stub func(string, cgroup.Limits) error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = stub
+}
+
+func (fake *FakeManager) ConfigureArgsForCall(i int) (string, cgroup.Limits) {
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	argsForCall := fake.configureArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) ConfigureReturns(result1 error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = nil
+	fake.configureReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) ConfigureReturnsOnCall(i int, result1 error) {
+	fake.configureMutex.Lock()
+	defer fake.configureMutex.Unlock()
+	fake.ConfigureStub = nil
+	if fake.configureReturnsOnCall == nil {
+		fake.configureReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.configureReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) Jobs() ([]string, error) {
+	fake.jobsMutex.Lock()
+	ret, specificReturn := fake.jobsReturnsOnCall[len(fake.jobsArgsForCall)]
+	fake.jobsArgsForCall = append(fake.jobsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Jobs", []interface{}{})
+	fake.jobsMutex.Unlock()
+	if fake.JobsStub != nil {
+		return fake.JobsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.jobsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) JobsCallCount() int {
+	fake.jobsMutex.RLock()
+	defer fake.jobsMutex.RUnlock()
+	return len(fake.jobsArgsForCall)
+}
+
+func (fake *FakeManager) JobsCalls(stub func() ([]string, error)) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = stub
+}
+
+func (fake *FakeManager) JobsReturns(result1 []string, result2 error) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = nil
+	fake.jobsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) JobsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = nil
+	if fake.jobsReturnsOnCall == nil {
+		fake.jobsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.jobsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) KeepOnly(arg1 []string) error {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.keepOnlyMutex.Lock()
+	ret, specificReturn := fake.keepOnlyReturnsOnCall[len(fake.keepOnlyArgsForCall)]
+	fake.keepOnlyArgsForCall = append(fake.keepOnlyArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	fake.recordInvocation("KeepOnly", []interface{}{arg1Copy})
+	fake.keepOnlyMutex.Unlock()
+	if fake.KeepOnlyStub != nil {
+		return fake.KeepOnlyStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.keepOnlyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) KeepOnlyCallCount() int {
+	fake.keepOnlyMutex.RLock()
+	defer fake.keepOnlyMutex.RUnlock()
+	return len(fake.keepOnlyArgsForCall)
+}
+
+func (fake *FakeManager) KeepOnlyCalls(stub func([]string) error) {
+	fake.keepOnlyMutex.Lock()
+	defer fake.keepOnlyMutex.Unlock()
+	fake.KeepOnlyStub = stub
+}
+
+func (fake *FakeManager) KeepOnlyArgsForCall(i int) []string {
+	fake.keepOnlyMutex.RLock()
+	defer fake.keepOnlyMutex.RUnlock()
+	argsForCall := fake.keepOnlyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) KeepOnlyReturns(result1 error) {
+	fake.keepOnlyMutex.Lock()
+	defer fake.keepOnlyMutex.Unlock()
+	fake.KeepOnlyStub = nil
+	fake.keepOnlyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) KeepOnlyReturnsOnCall(i int, result1 error) {
+	fake.keepOnlyMutex.Lock()
+	defer fake.keepOnlyMutex.Unlock()
+	fake.KeepOnlyStub = nil
+	if fake.keepOnlyReturnsOnCall == nil {
+		fake.keepOnlyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.keepOnlyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) Usage(arg1 string) (cgroup.Usage, error) {
+	fake.usageMutex.Lock()
+	ret, specificReturn := fake.usageReturnsOnCall[len(fake.usageArgsForCall)]
+	fake.usageArgsForCall = append(fake.usageArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Usage", []interface{}{arg1})
+	fake.usageMutex.Unlock()
+	if fake.UsageStub != nil {
+		return fake.UsageStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.usageReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) UsageCallCount() int {
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	return len(fake.usageArgsForCall)
+}
+
+func (fake *FakeManager) UsageCalls(stub func(string) (cgroup.Usage, error)) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = stub
+}
+
+func (fake *FakeManager) UsageArgsForCall(i int) string {
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	argsForCall := fake.usageArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) UsageReturns(result1 cgroup.Usage, result2 error) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = nil
+	fake.usageReturns = struct {
+		result1 cgroup.Usage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) UsageReturnsOnCall(i int, result1 cgroup.Usage, result2 error) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = nil
+	if fake.usageReturnsOnCall == nil {
+		fake.usageReturnsOnCall = make(map[int]struct {
+			result1 cgroup.Usage
+			result2 error
+		})
+	}
+	fake.usageReturnsOnCall[i] = struct {
+		result1 cgroup.Usage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.addProcessMutex.RLock()
+	defer fake.addProcessMutex.RUnlock()
+	fake.configureMutex.RLock()
+	defer fake.configureMutex.RUnlock()
+	fake.jobsMutex.RLock()
+	defer fake.jobsMutex.RUnlock()
+	fake.keepOnlyMutex.RLock()
+	defer fake.keepOnlyMutex.RUnlock()
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ cgroup.Manager = new(FakeManager)
@@ -0,0 +1,366 @@
+package cgroup
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	cpuPeriodMicroseconds = 100000
+
+	// cgroup v1 reports unlimited memory as the largest
+	// page aligned int64 instead of a special value
+	v1UnlimitedMemory = uint64(1) << 62
+)
+
+// Controllers that job cgroups are created in when
+// each controller has its own hierarchy (cgroup v1);
+// cpu and cpuacct are usually the same hierarchy.
+var v1Controllers = []string{"memory", "cpu", "cpuacct", "pids"}
+
+type linuxManager struct {
+	fs        boshsys.FileSystem
+	mountPath string
+	parent    string
+
+	logTag string
+	logger boshlog.Logger
+}
+
+// NewLinuxManager creates job cgroups under parent cgroup in cgroup
+// file system mounted at mountPath. Both unified hierarchy (cgroup v2)
+// and per-controller hierarchies (cgroup v1) are supported.
+func NewLinuxManager(fs boshsys.FileSystem, mountPath, parent string, logger boshlog.Logger) Manager {
+	return linuxManager{
+		fs:        fs,
+		mountPath: mountPath,
+		parent:    parent,
+
+		logTag: "cgroupManager",
+		logger: logger,
+	}
+}
+
+func (m linuxManager) Configure(jobName string, limits Limits) error {
+	m.logger.Debug(m.logTag, "Configuring cgroup of job %s with limits %#v", jobName, limits)
+
+	if m.unified() {
+		return m.configureUnified(jobName, limits)
+	}
+
+	return m.configureV1(jobName, limits)
+}
+
+func (m linuxManager) configureUnified(jobName string, limits Limits) error {
+	parentPath := path.Join(m.mountPath, m.parent)
+
+	err := m.fs.MkdirAll(parentPath, os.FileMode(0755))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating parent cgroup")
+	}
+
+	// Controllers are only available to children of cgroups
+	// that enable them in their subtree
+	for _, dir := range []string{m.mountPath, parentPath} {
+		err = m.write(path.Join(dir, "cgroup.subtree_control"), "+memory +cpu +pids")
+		if err != nil {
+			return bosherr.WrapError(err, "Enabling cgroup controllers")
+		}
+	}
+
+	groupPath := path.Join(parentPath, jobName)
+
+	err = m.fs.MkdirAll(groupPath, os.FileMode(0755))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating cgroup of job '%s'", jobName)
+	}
+
+	memoryMax, cpuMax, pidsMax := "max", "max", "max"
+
+	if limits.MemoryMB > 0 {
+		memoryMax = strconv.FormatUint(limits.MemoryMB*1024*1024, 10)
+	}
+
+	if limits.CPUPercent > 0 {
+		cpuMax = strconv.FormatUint(limits.CPUPercent*cpuPeriodMicroseconds/100, 10)
+	}
+
+	if limits.Pids > 0 {
+		pidsMax = strconv.FormatUint(limits.Pids, 10)
+	}
+
+	values := [][2]string{
+		{"memory.max", memoryMax},
+		{"cpu.max", cpuMax + " " + strconv.Itoa(cpuPeriodMicroseconds)},
+		{"pids.max", pidsMax},
+	}
+
+	for _, value := range values {
+		err = m.write(path.Join(groupPath, value[0]), value[1])
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Limiting cgroup of job '%s'", jobName)
+		}
+	}
+
+	return nil
+}
+
+func (m linuxManager) configureV1(jobName string, limits Limits) error {
+	for _, controller := range v1Controllers {
+		err := m.fs.MkdirAll(m.v1GroupPath(controller, jobName), os.FileMode(0755))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating %s cgroup of job '%s'", controller, jobName)
+		}
+	}
+
+	memoryLimit, cpuQuota, pidsMax := "-1", "-1", "max"
+
+	if limits.MemoryMB > 0 {
+		memoryLimit = strconv.FormatUint(limits.MemoryMB*1024*1024, 10)
+	}
+
+	if limits.CPUPercent > 0 {
+		cpuQuota = strconv.FormatUint(limits.CPUPercent*cpuPeriodMicroseconds/100, 10)
+	}
+
+	if limits.Pids > 0 {
+		pidsMax = strconv.FormatUint(limits.Pids, 10)
+	}
+
+	values := [][3]string{
+		{"memory", "memory.limit_in_bytes", memoryLimit},
+		{"cpu", "cpu.cfs_period_us", strconv.Itoa(cpuPeriodMicroseconds)},
+		{"cpu", "cpu.cfs_quota_us", cpuQuota},
+		{"pids", "pids.max", pidsMax},
+	}
+
+	for _, value := range values {
+		err := m.write(path.Join(m.v1GroupPath(value[0], jobName), value[1]), value[2])
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Limiting cgroup of job '%s'", jobName)
+		}
+	}
+
+	return nil
+}
+
+func (m linuxManager) KeepOnly(jobNames []string) error {
+	existingJobNames, err := m.Jobs()
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, jobName := range jobNames {
+		keep[jobName] = true
+	}
+
+	for _, jobName := range existingJobNames {
+		if keep[jobName] {
+			continue
+		}
+
+		m.logger.Debug(m.logTag, "Removing cgroup of job %s", jobName)
+
+		for _, groupPath := range m.groupPaths(jobName) {
+			// Cgroup directories can only be removed as a whole
+			// since control files in them cannot be deleted
+			err = m.fs.RemoveAll(groupPath)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Removing cgroup of job '%s'", jobName)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m linuxManager) Jobs() ([]string, error) {
+	parentPath := path.Join(m.mountPath, m.parent)
+	if !m.unified() {
+		parentPath = path.Join(m.mountPath, "memory", m.parent)
+	}
+
+	matches, err := m.fs.Glob(path.Join(parentPath, "*"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing job cgroups")
+	}
+
+	jobNames := []string{}
+
+	for _, match := range matches {
+		info, err := m.fs.Stat(match)
+		if err == nil && info.IsDir() {
+			jobNames = append(jobNames, path.Base(match))
+		}
+	}
+
+	return jobNames, nil
+}
+
+func (m linuxManager) AddProcess(jobName string, pid int) error {
+	for _, groupPath := range m.groupPaths(jobName) {
+		if !m.fs.FileExists(groupPath) {
+			return bosherr.Errorf("Cgroup of job '%s' does not exist", jobName)
+		}
+
+		err := m.fs.WriteFileQuietly(path.Join(groupPath, "cgroup.procs"), []byte(strconv.Itoa(pid)))
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Adding process %d to cgroup of job '%s'", pid, jobName)
+		}
+	}
+
+	return nil
+}
+
+func (m linuxManager) Usage(jobName string) (Usage, error) {
+	if m.unified() {
+		return m.unifiedUsage(jobName)
+	}
+
+	return m.v1Usage(jobName)
+}
+
+func (m linuxManager) unifiedUsage(jobName string) (Usage, error) {
+	var usage Usage
+
+	groupPath := path.Join(m.mountPath, m.parent, jobName)
+
+	memoryCurrent, err := m.readUint(path.Join(groupPath, "memory.current"))
+	if err != nil {
+		return usage, err
+	}
+
+	usage.MemoryBytes = memoryCurrent
+
+	// memory.max is "max" when memory is not limited
+	memoryMax, err := m.readUint(path.Join(groupPath, "memory.max"))
+	if err == nil {
+		usage.MemoryLimitBytes = memoryMax
+	}
+
+	cpuUsage, err := m.readKeyedUint(path.Join(groupPath, "cpu.stat"), "usage_usec")
+	if err != nil {
+		return usage, err
+	}
+
+	usage.CPUTime = time.Duration(cpuUsage) * time.Microsecond
+
+	usage.OOMKills, err = m.readKeyedUint(path.Join(groupPath, "memory.events"), "oom_kill")
+	if err != nil {
+		return usage, err
+	}
+
+	return usage, nil
+}
+
+func (m linuxManager) v1Usage(jobName string) (Usage, error) {
+	var usage Usage
+
+	memoryPath := m.v1GroupPath("memory", jobName)
+
+	memoryUsage, err := m.readUint(path.Join(memoryPath, "memory.usage_in_bytes"))
+	if err != nil {
+		return usage, err
+	}
+
+	usage.MemoryBytes = memoryUsage
+
+	memoryLimit, err := m.readUint(path.Join(memoryPath, "memory.limit_in_bytes"))
+	if err != nil {
+		return usage, err
+	}
+
+	if memoryLimit < v1UnlimitedMemory {
+		usage.MemoryLimitBytes = memoryLimit
+	}
+
+	cpuUsage, err := m.readUint(path.Join(m.v1GroupPath("cpuacct", jobName), "cpuacct.usage"))
+	if err != nil {
+		return usage, err
+	}
+
+	usage.CPUTime = time.Duration(cpuUsage) * time.Nanosecond
+
+	// Kernels older than 4.13 do not count OOM kills
+	oomKills, err := m.readKeyedUint(path.Join(memoryPath, "memory.oom_control"), "oom_kill")
+	if err == nil {
+		usage.OOMKills = oomKills
+	}
+
+	return usage, nil
+}
+
+// unified is true when cgroup v2 is mounted at mount path
+func (m linuxManager) unified() bool {
+	return m.fs.FileExists(path.Join(m.mountPath, "cgroup.controllers"))
+}
+
+func (m linuxManager) groupPaths(jobName string) []string {
+	if m.unified() {
+		return []string{path.Join(m.mountPath, m.parent, jobName)}
+	}
+
+	var groupPaths []string
+	for _, controller := range v1Controllers {
+		groupPaths = append(groupPaths, m.v1GroupPath(controller, jobName))
+	}
+
+	return groupPaths
+}
+
+func (m linuxManager) v1GroupPath(controller, jobName string) string {
+	return path.Join(m.mountPath, controller, m.parent, jobName)
+}
+
+func (m linuxManager) write(filePath, value string) error {
+	err := m.fs.WriteFileString(filePath, value)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing '%s' to %s", value, filePath)
+	}
+
+	return nil
+}
+
+func (m linuxManager) readUint(filePath string) (uint64, error) {
+	content, err := m.fs.ReadFileString(filePath)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Reading %s", filePath)
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(content), 10, 64)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Parsing %s", filePath)
+	}
+
+	return value, nil
+}
+
+// readKeyedUint reads value of key from files with "key value" lines
+func (m linuxManager) readKeyedUint(filePath, key string) (uint64, error) {
+	content, err := m.fs.ReadFileString(filePath)
+	if err != nil {
+		return 0, bosherr.WrapErrorf(err, "Reading %s", filePath)
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, bosherr.WrapErrorf(err, "Parsing %s in %s", key, filePath)
+			}
+
+			return value, nil
+		}
+	}
+
+	return 0, bosherr.Errorf("Finding %s in %s", key, filePath)
+}
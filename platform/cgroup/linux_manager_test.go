@@ -0,0 +1,244 @@
+package cgroup_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-agent/platform/cgroup"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("linuxManager", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		manager Manager
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		manager = NewLinuxManager(fs, "/sys/fs/cgroup", "bosh-jobs", boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	readFile := func(path string) string {
+		content, err := fs.ReadFileString(path)
+		Expect(err).ToNot(HaveOccurred())
+		return content
+	}
+
+	Context("with unified hierarchy", func() {
+		BeforeEach(func() {
+			fs.WriteFileString("/sys/fs/cgroup/cgroup.controllers", "cpuset cpu io memory pids")
+		})
+
+		Describe("Configure", func() {
+			It("creates job cgroup with enabled controllers and limits", func() {
+				err := manager.Configure("fake-job", Limits{MemoryMB: 512, CPUPercent: 150, Pids: 100})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(readFile("/sys/fs/cgroup/cgroup.subtree_control")).To(Equal("+memory +cpu +pids"))
+				Expect(readFile("/sys/fs/cgroup/bosh-jobs/cgroup.subtree_control")).To(Equal("+memory +cpu +pids"))
+
+				Expect(readFile("/sys/fs/cgroup/bosh-jobs/fake-job/memory.max")).To(Equal("536870912"))
+				Expect(readFile("/sys/fs/cgroup/bosh-jobs/fake-job/cpu.max")).To(Equal("150000 100000"))
+				Expect(readFile("/sys/fs/cgroup/bosh-jobs/fake-job/pids.max")).To(Equal("100"))
+			})
+
+			It("lifts limits that are not specified", func() {
+				err := manager.Configure("fake-job", Limits{MemoryMB: 512})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(readFile("/sys/fs/cgroup/bosh-jobs/fake-job/cpu.max")).To(Equal("max 100000"))
+				Expect(readFile("/sys/fs/cgroup/bosh-jobs/fake-job/pids.max")).To(Equal("max"))
+			})
+
+			It("returns error when limit cannot be written", func() {
+				fs.WriteFileErrors["/sys/fs/cgroup/bosh-jobs/fake-job/memory.max"] = errors.New("fake-write-err")
+
+				err := manager.Configure("fake-job", Limits{MemoryMB: 512})
+				Expect(err).To(MatchError(ContainSubstring("fake-write-err")))
+			})
+		})
+
+		Describe("AddProcess", func() {
+			It("adds process to job cgroup", func() {
+				Expect(manager.Configure("fake-job", Limits{Pids: 10})).To(Succeed())
+
+				Expect(manager.AddProcess("fake-job", 1234)).To(Succeed())
+				Expect(readFile("/sys/fs/cgroup/bosh-jobs/fake-job/cgroup.procs")).To(Equal("1234"))
+			})
+
+			It("returns error when job has no cgroup", func() {
+				err := manager.AddProcess("fake-job", 1234)
+				Expect(err).To(MatchError("Cgroup of job 'fake-job' does not exist"))
+			})
+		})
+
+		Describe("Usage", func() {
+			It("reads memory, cpu and oom kills of job cgroup", func() {
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/memory.current", "1048576\n")
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/memory.max", "2097152\n")
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/cpu.stat", "usage_usec 2500000\nuser_usec 2000000\n")
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/memory.events", "low 0\nhigh 0\nmax 3\noom 2\noom_kill 2\n")
+
+				usage, err := manager.Usage("fake-job")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(usage).To(Equal(Usage{
+					MemoryBytes:      1048576,
+					MemoryLimitBytes: 2097152,
+					CPUTime:          2500 * time.Millisecond,
+					OOMKills:         2,
+				}))
+			})
+
+			It("reports no memory limit when memory is not limited", func() {
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/memory.current", "1048576\n")
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/memory.max", "max\n")
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/cpu.stat", "usage_usec 0\n")
+				fs.WriteFileString("/sys/fs/cgroup/bosh-jobs/fake-job/memory.events", "oom_kill 0\n")
+
+				usage, err := manager.Usage("fake-job")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(usage.MemoryLimitBytes).To(BeZero())
+			})
+
+			It("returns error when job has no cgroup", func() {
+				_, err := manager.Usage("fake-job")
+				Expect(err).To(MatchError(ContainSubstring("memory.current")))
+			})
+		})
+
+		Describe("Jobs and KeepOnly", func() {
+			BeforeEach(func() {
+				Expect(manager.Configure("fake-job", Limits{Pids: 10})).To(Succeed())
+				Expect(manager.Configure("other-job", Limits{Pids: 10})).To(Succeed())
+
+				fs.SetGlob("/sys/fs/cgroup/bosh-jobs/*", []string{
+					"/sys/fs/cgroup/bosh-jobs/cgroup.subtree_control",
+					"/sys/fs/cgroup/bosh-jobs/fake-job",
+					"/sys/fs/cgroup/bosh-jobs/other-job",
+				})
+			})
+
+			It("lists jobs with cgroups", func() {
+				Expect(manager.Jobs()).To(Equal([]string{"fake-job", "other-job"}))
+			})
+
+			It("removes cgroups of other jobs", func() {
+				Expect(manager.KeepOnly([]string{"fake-job"})).To(Succeed())
+
+				Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/fake-job")).To(BeTrue())
+				Expect(fs.FileExists("/sys/fs/cgroup/bosh-jobs/other-job")).To(BeFalse())
+			})
+
+			It("returns error when cgroup cannot be removed", func() {
+				fs.RemoveAllStub = func(path string) error {
+					return errors.New("fake-remove-err")
+				}
+
+				err := manager.KeepOnly([]string{"fake-job"})
+				Expect(err).To(MatchError(ContainSubstring("fake-remove-err")))
+			})
+		})
+	})
+
+	Context("with hierarchy per controller", func() {
+		Describe("Configure", func() {
+			It("creates job cgroup in every controller", func() {
+				err := manager.Configure("fake-job", Limits{MemoryMB: 512, CPUPercent: 50, Pids: 100})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(readFile("/sys/fs/cgroup/memory/bosh-jobs/fake-job/memory.limit_in_bytes")).To(Equal("536870912"))
+				Expect(readFile("/sys/fs/cgroup/cpu/bosh-jobs/fake-job/cpu.cfs_period_us")).To(Equal("100000"))
+				Expect(readFile("/sys/fs/cgroup/cpu/bosh-jobs/fake-job/cpu.cfs_quota_us")).To(Equal("50000"))
+				Expect(readFile("/sys/fs/cgroup/pids/bosh-jobs/fake-job/pids.max")).To(Equal("100"))
+				Expect(fs.FileExists("/sys/fs/cgroup/cpuacct/bosh-jobs/fake-job")).To(BeTrue())
+			})
+
+			It("lifts limits that are not specified", func() {
+				err := manager.Configure("fake-job", Limits{Pids: 100})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(readFile("/sys/fs/cgroup/memory/bosh-jobs/fake-job/memory.limit_in_bytes")).To(Equal("-1"))
+				Expect(readFile("/sys/fs/cgroup/cpu/bosh-jobs/fake-job/cpu.cfs_quota_us")).To(Equal("-1"))
+			})
+		})
+
+		Describe("AddProcess", func() {
+			It("adds process to job cgroup of every controller", func() {
+				Expect(manager.Configure("fake-job", Limits{Pids: 10})).To(Succeed())
+
+				Expect(manager.AddProcess("fake-job", 1234)).To(Succeed())
+
+				for _, controller := range []string{"memory", "cpu", "cpuacct", "pids"} {
+					Expect(readFile("/sys/fs/cgroup/" + controller + "/bosh-jobs/fake-job/cgroup.procs")).To(Equal("1234"))
+				}
+			})
+		})
+
+		Describe("Usage", func() {
+			BeforeEach(func() {
+				fs.WriteFileString("/sys/fs/cgroup/memory/bosh-jobs/fake-job/memory.usage_in_bytes", "1048576\n")
+				fs.WriteFileString("/sys/fs/cgroup/memory/bosh-jobs/fake-job/memory.limit_in_bytes", "9223372036854771712\n")
+				fs.WriteFileString("/sys/fs/cgroup/cpuacct/bosh-jobs/fake-job/cpuacct.usage", "1500000000\n")
+			})
+
+			It("reads memory, cpu and oom kills of job cgroup", func() {
+				fs.WriteFileString("/sys/fs/cgroup/memory/bosh-jobs/fake-job/memory.oom_control", "oom_kill_disable 0\nunder_oom 0\noom_kill 1\n")
+
+				usage, err := manager.Usage("fake-job")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(usage).To(Equal(Usage{
+					MemoryBytes: 1048576,
+					CPUTime:     1500 * time.Millisecond,
+					OOMKills:    1,
+				}))
+			})
+
+			It("reports no oom kills on kernels that do not count them", func() {
+				fs.WriteFileString("/sys/fs/cgroup/memory/bosh-jobs/fake-job/memory.oom_control", "oom_kill_disable 0\nunder_oom 0\n")
+
+				usage, err := manager.Usage("fake-job")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(usage.OOMKills).To(BeZero())
+			})
+		})
+
+		Describe("KeepOnly", func() {
+			It("removes cgroups of other jobs from every controller", func() {
+				Expect(manager.Configure("other-job", Limits{Pids: 10})).To(Succeed())
+				fs.SetGlob("/sys/fs/cgroup/memory/bosh-jobs/*", []string{"/sys/fs/cgroup/memory/bosh-jobs/other-job"})
+
+				Expect(manager.KeepOnly([]string{})).To(Succeed())
+
+				for _, controller := range []string{"memory", "cpu", "cpuacct", "pids"} {
+					Expect(fs.FileExists("/sys/fs/cgroup/" + controller + "/bosh-jobs/other-job")).To(BeFalse())
+				}
+			})
+		})
+	})
+})
+
+var _ = Describe("unsupportedManager", func() {
+	var manager Manager
+
+	BeforeEach(func() {
+		manager = NewUnsupportedManager()
+	})
+
+	It("configures jobs without limits", func() {
+		Expect(manager.Configure("fake-job", Limits{})).To(Succeed())
+	})
+
+	It("returns error for jobs with limits", func() {
+		err := manager.Configure("fake-job", Limits{MemoryMB: 10})
+		Expect(err).To(MatchError("Resource limits of job 'fake-job' are not supported on this platform"))
+	})
+
+	It("has no job cgroups", func() {
+		Expect(manager.Jobs()).To(BeEmpty())
+	})
+})
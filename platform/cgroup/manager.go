@@ -0,0 +1,60 @@
+package cgroup
+
+import (
+	"time"
+)
+
+//go:generate counterfeiter . Manager
+
+// Manager keeps processes of each job with resource limits
+// in a separate control group so that one job cannot starve
+// co-located jobs of memory, CPU or pids.
+type Manager interface {
+	// Configure creates cgroup of the job unless it already exists
+	// and applies given limits to it; zero limits lift restrictions.
+	Configure(jobName string, limits Limits) error
+
+	// KeepOnly removes cgroups of jobs that are not in the list.
+	// Cgroups that still have processes cannot be removed.
+	KeepOnly(jobNames []string) error
+
+	// Jobs lists names of jobs that have cgroups
+	Jobs() ([]string, error)
+
+	// AddProcess moves process into cgroup of the job;
+	// processes it starts afterwards stay in the same cgroup.
+	AddProcess(jobName string, pid int) error
+
+	Usage(jobName string) (Usage, error)
+}
+
+// Limits are declared per job in apply spec
+type Limits struct {
+	// Memory that processes of the job may use
+	// before the kernel starts killing them
+	MemoryMB uint64 `json:"memory_mb,omitempty"`
+
+	// CPU time as a percentage of a single core,
+	// e.g. 150 allows processes to use one and a half cores
+	CPUPercent uint64 `json:"cpu_percent,omitempty"`
+
+	// Maximum number of processes and threads
+	Pids uint64 `json:"pids,omitempty"`
+}
+
+func (l Limits) IsEmpty() bool {
+	return l == Limits{}
+}
+
+type Usage struct {
+	MemoryBytes uint64
+
+	// Zero when memory is not limited
+	MemoryLimitBytes uint64
+
+	// CPU time used by all processes since cgroup was created
+	CPUTime time.Duration
+
+	// Number of processes killed for running out of memory
+	OOMKills uint64
+}
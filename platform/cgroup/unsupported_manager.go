@@ -0,0 +1,37 @@
+package cgroup
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+type unsupportedManager struct{}
+
+// NewUnsupportedManager is used on platforms without control groups;
+// jobs can be configured there as long as they do not declare limits.
+func NewUnsupportedManager() Manager {
+	return unsupportedManager{}
+}
+
+func (m unsupportedManager) Configure(jobName string, limits Limits) error {
+	if limits.IsEmpty() {
+		return nil
+	}
+
+	return bosherr.Errorf("Resource limits of job '%s' are not supported on this platform", jobName)
+}
+
+func (m unsupportedManager) KeepOnly(_ []string) error {
+	return nil
+}
+
+func (m unsupportedManager) Jobs() ([]string, error) {
+	return []string{}, nil
+}
+
+func (m unsupportedManager) AddProcess(jobName string, _ int) error {
+	return bosherr.Errorf("Cgroup of job '%s' does not exist", jobName)
+}
+
+func (m unsupportedManager) Usage(jobName string) (Usage, error) {
+	return Usage{}, bosherr.Errorf("Cgroup of job '%s' does not exist", jobName)
+}
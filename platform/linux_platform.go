@@ -14,6 +14,7 @@ import (
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	"github.com/cloudfoundry/bosh-agent/platform/cdrom"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshdisk "github.com/cloudfoundry/bosh-agent/platform/disk"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
@@ -80,6 +81,13 @@ type LinuxOptions struct {
 	// Strategy for resolving ephemeral & persistent disk partitioners;
 	// possible values: parted, "" (default is sfdisk if disk < 2TB, parted otherwise)
 	PartitionerType string
+
+	// Where cgroup file system is mounted (default is /sys/fs/cgroup)
+	CgroupMountPath string
+
+	// Cgroup under which a cgroup is created for every job
+	// that declares resource limits (default is bosh-jobs)
+	JobsCgroupParent string
 }
 
 type linux struct {
@@ -94,6 +102,7 @@ type linux struct {
 	diskManager            boshdisk.Manager
 	netManager             boshnet.Manager
 	certManager            boshcert.Manager
+	cgroupManager          boshcgroup.Manager
 	monitRetryStrategy     boshretry.RetryStrategy
 	devicePathResolver     boshdpresolv.DevicePathResolver
 	options                LinuxOptions
@@ -125,6 +134,16 @@ func NewLinuxPlatform(
 	uuidGenerator boshuuid.Generator,
 	auditLogger AuditLogger,
 ) Platform {
+	cgroupMountPath := options.CgroupMountPath
+	if cgroupMountPath == "" {
+		cgroupMountPath = "/sys/fs/cgroup"
+	}
+
+	jobsCgroupParent := options.JobsCgroupParent
+	if jobsCgroupParent == "" {
+		jobsCgroupParent = "bosh-jobs"
+	}
+
 	return &linux{
 		fs:                     fs,
 		cmdRunner:              cmdRunner,
@@ -137,6 +156,7 @@ func NewLinuxPlatform(
 		diskManager:            diskManager,
 		netManager:             netManager,
 		certManager:            certManager,
+		cgroupManager:          boshcgroup.NewLinuxManager(fs, cgroupMountPath, jobsCgroupParent, logger),
 		monitRetryStrategy:     monitRetryStrategy,
 		devicePathResolver:     devicePathResolver,
 		state:                  state,
@@ -224,6 +244,10 @@ func (p linux) GetCertManager() boshcert.Manager {
 	return p.certManager
 }
 
+func (p linux) GetCgroupManager() boshcgroup.Manager {
+	return p.cgroupManager
+}
+
 func (p linux) GetHostPublicKey() (string, error) {
 	hostPublicKeyPath := "/etc/ssh/ssh_host_rsa_key.pub"
 	hostPublicKey, err := p.fs.ReadFileString(hostPublicKeyPath)
@@ -990,7 +1014,7 @@ func (p linux) remountWithSecurityFlags(mountPt string) error {
 func (p linux) SetupLogDir() error {
 	logDir := "/var/log"
 
-	boshRootLogPath := path.Join(p.dirProvider.DataDir(), "root_log")
+	boshRootLogPath := p.dirProvider.SystemLogsDir()
 
 	err := p.fs.MkdirAll(boshRootLogPath, userRootLogDirPermissions)
 	if err != nil {
@@ -2,6 +2,7 @@ package platform
 
 import (
 	"github.com/cloudfoundry/bosh-agent/platform/cert"
+	"github.com/cloudfoundry/bosh-agent/platform/cgroup"
 
 	"log"
 
@@ -90,6 +91,9 @@ type Platform interface {
 
 	GetCertManager() cert.Manager
 
+	// Resource limits of jobs
+	GetCgroupManager() cgroup.Manager
+
 	GetHostPublicKey() (string, error)
 
 	RemoveDevTools(packageFileListPath string) error
@@ -7,6 +7,7 @@ import (
 	devicepathresolver "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	platform "github.com/cloudfoundry/bosh-agent/platform"
 	cert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	cgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	vitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
 	settings "github.com/cloudfoundry/bosh-agent/settings"
 	directories "github.com/cloudfoundry/bosh-agent/settings/directories"
@@ -93,6 +94,16 @@ type FakePlatform struct {
 	getCertManagerReturnsOnCall map[int]struct {
 		result1 cert.Manager
 	}
+	GetCgroupManagerStub        func() cgroup.Manager
+	getCgroupManagerMutex       sync.RWMutex
+	getCgroupManagerArgsForCall []struct {
+	}
+	getCgroupManagerReturns struct {
+		result1 cgroup.Manager
+	}
+	getCgroupManagerReturnsOnCall map[int]struct {
+		result1 cgroup.Manager
+	}
 	GetCompressorStub        func() fileutil.Compressor
 	getCompressorMutex       sync.RWMutex
 	getCompressorArgsForCall []struct {
@@ -1026,6 +1037,58 @@ func (fake *FakePlatform) GetCertManagerReturnsOnCall(i int, result1 cert.Manage
 	}{result1}
 }
 
+func (fake *FakePlatform) GetCgroupManager() cgroup.Manager {
+	fake.getCgroupManagerMutex.Lock()
+	ret, specificReturn := fake.getCgroupManagerReturnsOnCall[len(fake.getCgroupManagerArgsForCall)]
+	fake.getCgroupManagerArgsForCall = append(fake.getCgroupManagerArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetCgroupManager", []interface{}{})
+	fake.getCgroupManagerMutex.Unlock()
+	if fake.GetCgroupManagerStub != nil {
+		return fake.GetCgroupManagerStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.getCgroupManagerReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakePlatform) GetCgroupManagerCallCount() int {
+	fake.getCgroupManagerMutex.RLock()
+	defer fake.getCgroupManagerMutex.RUnlock()
+	return len(fake.getCgroupManagerArgsForCall)
+}
+
+func (fake *FakePlatform) GetCgroupManagerCalls(stub func() cgroup.Manager) {
+	fake.getCgroupManagerMutex.Lock()
+	defer fake.getCgroupManagerMutex.Unlock()
+	fake.GetCgroupManagerStub = stub
+}
+
+func (fake *FakePlatform) GetCgroupManagerReturns(result1 cgroup.Manager) {
+	fake.getCgroupManagerMutex.Lock()
+	defer fake.getCgroupManagerMutex.Unlock()
+	fake.GetCgroupManagerStub = nil
+	fake.getCgroupManagerReturns = struct {
+		result1 cgroup.Manager
+	}{result1}
+}
+
+func (fake *FakePlatform) GetCgroupManagerReturnsOnCall(i int, result1 cgroup.Manager) {
+	fake.getCgroupManagerMutex.Lock()
+	defer fake.getCgroupManagerMutex.Unlock()
+	fake.GetCgroupManagerStub = nil
+	if fake.getCgroupManagerReturnsOnCall == nil {
+		fake.getCgroupManagerReturnsOnCall = make(map[int]struct {
+			result1 cgroup.Manager
+		})
+	}
+	fake.getCgroupManagerReturnsOnCall[i] = struct {
+		result1 cgroup.Manager
+	}{result1}
+}
+
 func (fake *FakePlatform) GetCompressor() fileutil.Compressor {
 	fake.getCompressorMutex.Lock()
 	ret, specificReturn := fake.getCompressorReturnsOnCall[len(fake.getCompressorArgsForCall)]
@@ -3692,6 +3755,8 @@ func (fake *FakePlatform) Invocations() map[string][][]interface{} {
 	defer fake.getAuditLoggerMutex.RUnlock()
 	fake.getCertManagerMutex.RLock()
 	defer fake.getCertManagerMutex.RUnlock()
+	fake.getCgroupManagerMutex.RLock()
+	defer fake.getCgroupManagerMutex.RUnlock()
 	fake.getCompressorMutex.RLock()
 	defer fake.getCompressorMutex.RUnlock()
 	fake.getConfiguredNetworkInterfacesMutex.RLock()
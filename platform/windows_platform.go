@@ -11,6 +11,7 @@ import (
 
 	boshdpresolv "github.com/cloudfoundry/bosh-agent/infrastructure/devicepathresolver"
 	boshcert "github.com/cloudfoundry/bosh-agent/platform/cert"
+	boshcgroup "github.com/cloudfoundry/bosh-agent/platform/cgroup"
 	boshnet "github.com/cloudfoundry/bosh-agent/platform/net"
 	boshstats "github.com/cloudfoundry/bosh-agent/platform/stats"
 	boshvitals "github.com/cloudfoundry/bosh-agent/platform/vitals"
@@ -288,6 +289,10 @@ func (p WindowsPlatform) GetCertManager() (certManager boshcert.Manager) {
 	return p.certManager
 }
 
+func (p WindowsPlatform) GetCgroupManager() boshcgroup.Manager {
+	return boshcgroup.NewUnsupportedManager()
+}
+
 func (p WindowsPlatform) SetupLogrotate(groupName, basePath, size string) (err error) {
 	return
 }
@@ -94,6 +94,11 @@ func (p Provider) AgentLogsDir() string {
 	return filepath.Join(p.BaseDir(), "bosh", "log")
 }
 
+// SystemLogsDir is bind mounted onto /var/log on Linux
+func (p Provider) SystemLogsDir() string {
+	return filepath.Join(p.DataDir(), "root_log")
+}
+
 func (p Provider) InstanceDir() string {
 	return filepath.Join(p.BaseDir(), "instance")
 }
@@ -113,3 +118,7 @@ func (p Provider) BlobsDir() string {
 func (p Provider) SensitiveBlobsDir() string {
 	return filepath.Join(p.DataDir(), "sensitive_blobs")
 }
+
+func (p Provider) AgentSocketPath() string {
+	return filepath.Join(p.BoshDir(), "agent.sock")
+}
@@ -37,10 +37,12 @@ var _ = Describe("Provider", func() {
 		Entry("TmpDir()", p.TmpDir(), "/some/dir/data/tmp"),
 		Entry("LogsDir()", p.LogsDir(), "/some/dir/sys/log"),
 		Entry("AgentLogsDir()", p.AgentLogsDir(), "/some/dir/bosh/log"),
+		Entry("SystemLogsDir()", p.SystemLogsDir(), "/some/dir/data/root_log"),
 		Entry("InstanceDir()", p.InstanceDir(), "/some/dir/instance"),
 		Entry("DisksDir()", p.DisksDir(), "/some/dir/instance/disks"),
 		Entry("BlobsDir()", p.BlobsDir(), "/some/dir/data/blobs"),
 		Entry("InstanceDNSDir()", p.InstanceDNSDir(), "/some/dir/instance/dns"),
+		Entry("AgentSocketPath()", p.AgentSocketPath(), "/some/dir/bosh/agent.sock"),
 	)
 
 	It("cleans the base dir", func() {
@@ -148,11 +148,16 @@ func (s Settings) RawEphemeralDiskSettings() (devices []DiskSettings) {
 }
 
 func (s Settings) GetMbusURL() string {
+	return s.GetMbusURLs()[0]
+}
+
+// GetMbusURLs returns all configured mbus URLs in the order they should be tried
+func (s Settings) GetMbusURLs() []string {
 	if len(s.Env.Bosh.Mbus.URLs) > 0 {
-		return s.Env.Bosh.Mbus.URLs[0]
+		return s.Env.Bosh.Mbus.URLs
 	}
 
-	return s.Mbus
+	return []string{s.Mbus}
 }
 
 func (s Settings) GetBlobstore() Blobstore {
@@ -283,8 +288,30 @@ type BoshEnv struct {
 }
 
 type MBus struct {
-	Cert CertKeyPair `json:"cert"`
-	URLs []string    `json:"urls"`
+	Cert  CertKeyPair `json:"cert"`
+	URLs  []string    `json:"urls"`
+	HTTPS HTTPSMBus   `json:"https"`
+
+	// Shared key director signs NATS requests with;
+	// agent only accepts signed requests when it is set
+	SigningKey string `json:"signing_key"`
+}
+
+const HTTPSMBusTLSProfileModern = "modern"
+
+// HTTPSMBus configures agent's server when it runs with HTTPS mbus
+type HTTPSMBus struct {
+	// Requires clients to present a certificate signed by MBus.Cert.CA.
+	// Basic auth is then only checked if mbus URL includes credentials.
+	MutualTLS bool `json:"mutual_tls"`
+
+	// Common names or SANs of client certificates that are allowed to connect;
+	// any client certificate signed by CA is allowed when empty
+	AllowedNames []string `json:"allowed_names"`
+
+	// "modern" only allows TLS 1.2+ with AEAD ciphers;
+	// otherwise older clients are still supported
+	TLSProfile string `json:"tls_profile"`
 }
 
 type CertKeyPair struct {
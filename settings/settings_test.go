@@ -927,6 +927,39 @@ var _ = Describe("Settings", func() {
 			Expect(*env.GetSwapSizeInBytes()).To(Equal(uint64(2048 * 1024 * 1024)))
 		})
 
+		It("permits you to require client certificates for https mbus", func() {
+			var env Env
+			envJSON := `{
+  "bosh": {
+    "mbus": {
+      "cert": {
+        "ca": "fake-ca-pem"
+      },
+      "https": {
+        "mutual_tls": true,
+        "allowed_names": ["director.bosh-internal"],
+        "tls_profile": "modern"
+      }
+    }
+  }
+}`
+			err := json.Unmarshal([]byte(envJSON), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Mbus.Cert.CA).To(Equal("fake-ca-pem"))
+			Expect(env.Bosh.Mbus.HTTPS).To(Equal(HTTPSMBus{
+				MutualTLS:    true,
+				AllowedNames: []string{"director.bosh-internal"},
+				TLSProfile:   HTTPSMBusTLSProfileModern,
+			}))
+		})
+
+		It("permits you to specify key used to verify signed nats mbus requests", func() {
+			env := Env{}
+			err := json.Unmarshal([]byte(`{"bosh": {"mbus": {"signing_key": "fake-signing-key"} } }`), &env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env.Bosh.Mbus.SigningKey).To(Equal("fake-signing-key"))
+		})
+
 		It("can enable ipv6", func() {
 			env := Env{}
 			err := json.Unmarshal([]byte(`{"bosh": {} }`), &env)
@@ -1193,6 +1226,36 @@ var _ = Describe("Settings", func() {
 		})
 	})
 
+	Describe("#GetMbusURLs", func() {
+		Context("Env.Bosh.Mbus.URLs is populated", func() {
+			It("should return all Env.Bosh.Mbus.URLs in order", func() {
+				settings = Settings{
+					Env: Env{
+						Bosh: BoshEnv{
+							Mbus: MBus{
+								URLs: []string{"nats://nested:789", "nats://nested-backup:789"},
+							},
+						},
+					},
+					Mbus: "nats://top-level:123",
+				}
+
+				Expect(settings.GetMbusURLs()).To(Equal([]string{"nats://nested:789", "nats://nested-backup:789"}))
+				Expect(settings.GetMbusURL()).To(Equal("nats://nested:789"))
+			})
+		})
+
+		Context("Settings.Env.Bosh.Mbus.URLs is zero length", func() {
+			It("should return Settings.Mbus", func() {
+				settings = Settings{
+					Mbus: "nats://top-level:456",
+				}
+
+				Expect(settings.GetMbusURLs()).To(Equal([]string{"nats://top-level:456"}))
+			})
+		})
+	})
+
 	Describe("HasInterfaceAlias", func() {
 		Context("when networks is empty", func() {
 			It("returns found=false", func() {